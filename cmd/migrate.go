@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/migrations"
+	"golang-sample/pkg/config"
+	"golang-sample/pkg/postgres"
+)
+
+// migrateCmd groups database schema migration commands
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database schema migrations",
+	Long: `Apply or inspect versioned database schema migrations.
+
+Applied migrations are recorded in the schema_migrations table. The readiness
+probe (/readyz) fails while migrations are pending.
+
+Example:
+  $ migrate up
+  $ migrate status`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		log := zap.S()
+
+		db, cleanup, err := postgres.NewGormDB(config.ENV.Postgres.DSN)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		applied, err := migrations.Up(cmd.Context(), db)
+		for _, m := range applied {
+			log.Infof("Applied migration %d: %s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			log.Info("Database schema is up to date")
+		}
+		return nil
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they are applied",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		db, cleanup, err := postgres.NewGormDB(config.ENV.Postgres.DSN)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		applied, err := migrations.Applied(cmd.Context(), db)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations.All() {
+			state := "pending"
+			if applied[m.Version] {
+				state = "applied"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%04d  %-8s  %s\n", m.Version, state, m.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateUpCmd, migrateStatusCmd)
+}
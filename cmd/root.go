@@ -8,6 +8,9 @@ import (
 	"go.uber.org/zap"
 
 	"golang-sample/pkg/config"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/reporting"
+	"golang-sample/pkg/version"
 )
 
 var rootCmd = &cobra.Command{
@@ -17,11 +20,18 @@ var rootCmd = &cobra.Command{
 
 var (
 	cfgFile string
+
+	// logLevel controls the global logger level at runtime
+	logLevel = zap.NewAtomicLevel()
+	// logCleanup flushes and closes the global logger on exit
+	logCleanup = func() {}
 )
 
 // Execute root execute function
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	logCleanup()
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -34,8 +44,12 @@ func init() {
 }
 
 func initDependency() {
-	initLog()
+	// Bootstrap logger used while the config is loading
+	zap.ReplaceGlobals(zap.Must(zap.NewDevelopment()))
+
 	initConfig()
+	initLog()
+	initSentry()
 }
 
 func initConfig() {
@@ -47,12 +61,79 @@ func initConfig() {
 }
 
 func initLog() {
-	logger, _ := zap.NewDevelopment()
-	defer func(logger *zap.Logger) {
-		err := logger.Sync()
-		if err != nil {
-			fmt.Println(err)
+	cfg := config.ENV
+
+	logCfg := logger.Config{
+		Level:       cfg.Log.Level,
+		Format:      cfg.Log.Format,
+		OutputPaths: cfg.Log.OutputPaths,
+		Rotation: logger.RotationConfig{
+			MaxSizeMB:  cfg.Log.Rotation.MaxSizeMB,
+			MaxBackups: cfg.Log.Rotation.MaxBackups,
+			MaxAgeDays: cfg.Log.Rotation.MaxAgeDays,
+			Compress:   cfg.Log.Rotation.Compress,
+		},
+		Development: cfg.App.Debug,
+	}
+	if logCfg.Level == "" {
+		logCfg.Level = "info"
+		if cfg.App.Debug {
+			logCfg.Level = "debug"
 		}
-	}(logger)
-	zap.ReplaceGlobals(logger)
+	}
+	if logCfg.Format == "" {
+		logCfg.Format = logger.FormatConsole
+		if cfg.App.Env == config.EnvProduction {
+			logCfg.Format = logger.FormatJSON
+		}
+	}
+	if cfg.Log.Sampling.Enabled {
+		// Same defaults as zap.NewProductionConfig
+		logCfg.Sampling = &logger.SamplingConfig{Initial: 100, Thereafter: 100}
+		if cfg.Log.Sampling.Initial > 0 {
+			logCfg.Sampling.Initial = cfg.Log.Sampling.Initial
+		}
+		if cfg.Log.Sampling.Thereafter > 0 {
+			logCfg.Sampling.Thereafter = cfg.Log.Sampling.Thereafter
+		}
+	}
+
+	log, level, cleanup, err := logger.New(logCfg)
+	if err != nil {
+		panic(fmt.Sprintf("can't build logger: %v", err))
+	}
+
+	log = log.With(
+		zap.String("app.env", cfg.App.Env),
+		zap.String("app.version", version.Version),
+	)
+	zap.ReplaceGlobals(log)
+
+	logLevel = level
+	logCleanup = cleanup
+}
+
+func initSentry() {
+	cfg := config.ENV
+
+	reportingCfg := reporting.Config{
+		DSN:         cfg.Sentry.DSN,
+		Environment: cfg.Sentry.Environment,
+		Release:     cfg.Sentry.Release,
+		SampleRate:  cfg.Sentry.SampleRate,
+		Debug:       cfg.App.Debug,
+	}
+	if reportingCfg.Environment == "" {
+		reportingCfg.Environment = cfg.App.Env
+	}
+	if reportingCfg.Release == "" {
+		reportingCfg.Release = version.Version
+	}
+
+	if err := reporting.Init(reportingCfg); err != nil {
+		panic(fmt.Sprintf("can't initialize error reporting: %v", err))
+	}
+	if reportingCfg.DSN == "" {
+		zap.S().Info("Sentry DSN not configured, error reporting disabled")
+	}
 }
@@ -12,6 +12,8 @@ import (
 
 	restHandler "golang-sample/internal/handler/rest"
 	"golang-sample/pkg/config"
+	"golang-sample/pkg/tracing"
+	"golang-sample/pkg/version"
 )
 
 // serverCmd represents the server command
@@ -25,15 +27,20 @@ Features:
   - govern/graceful: Graceful shutdown handling (SIGINT/SIGTERM)
   - govern/postgres: Database connection pooling
   - govern/config: Configuration management
+  - OpenTelemetry tracing (exporter set by tracing.exporter)
+  - govern/metrics: Prometheus metrics on /metrics (optionally on --admin_port)
+  - Outbox relay publishing domain events to the sinks in events.sinks
 
 Shutdown Sequence:
-  1. Stop accepting new connections
-  2. Wait for active requests to complete (configurable timeout)
-  3. Close database connections
-  4. Release resources
+  1. Fail /readyz so load balancers stop routing traffic
+  2. Stop accepting new connections
+  3. Wait for active requests to complete (configurable timeout)
+  4. Close database and Redis connections
+  5. Release resources
 
 Example:
-  $ serverd --port 8080 --shutdown_time 30`,
+  $ serverd --port 8080 --shutdown_time 30
+  $ serverd --port 8080 --admin_port 9090`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		log := zap.S()
 
@@ -47,25 +54,55 @@ Example:
 			return err
 		}
 
+		adminPort, err := cmd.Flags().GetInt64("admin_port")
+		if err != nil {
+			return err
+		}
+
 		// Load config at composition root
 		cfg := config.ENV
 
-		handler, cleanup, err := restHandler.New(log, port, cfg)
+		shutdownTracing, err := tracing.Setup(cmd.Context(), tracing.Config{
+			ServiceName:    "golang-sample-api",
+			ServiceVersion: version.Version,
+			Environment:    cfg.App.Env,
+			Exporter:       cfg.Tracing.Exporter,
+			Endpoint:       cfg.Tracing.Endpoint,
+			SampleRatio:    cfg.Tracing.SampleRatio,
+		})
 		if err != nil {
 			return err
 		}
-		defer cleanup()
-
-		// Create signal context for graceful shutdown
+		defer func() {
+			// Flush pending spans after the servers have stopped
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Warnf("Failed to flush traces: %v", err)
+			}
+		}()
+
+		// Create signal context for graceful shutdown; readiness starts
+		// failing as soon as it is done
 		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer stop()
 
+		services, cleanup, err := restHandler.New(ctx, log, port, restHandler.AdminPort(adminPort), cfg, logLevel)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if adminPort > 0 {
+			services = append(services, restHandler.NewAdminServer(log, restHandler.AdminPort(adminPort)))
+		}
+
 		// Run server with govern graceful runner
 		err = govern.Run(
 			ctx,
 			log,
 			time.Duration(shutdownTime)*time.Second,
-			handler,
+			services...,
 		)
 
 		return err
@@ -76,5 +113,6 @@ func init() {
 	rootCmd.AddCommand(serverCmd)
 
 	serverCmd.Flags().Int64("port", 8080, "API server port (default: 8080)")
+	serverCmd.Flags().Int64("admin_port", 0, "Admin port serving /metrics; 0 serves it on the API port (default: 0)")
 	serverCmd.Flags().Int64("shutdown_time", 10, "Graceful shutdown timeout in seconds (default: 10)")
 }
@@ -0,0 +1,214 @@
+// Package errcode defines the catalog of stable, machine-readable error codes
+// returned in the "code" field of every error response. Codes never change
+// meaning once published; add a new code instead of repurposing one.
+//
+//go:generate go run ./gen -o ../../docs/error-codes.md
+package errcode
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	governerrors "github.com/haipham22/govern/errors"
+)
+
+// CategoryUnavailable is the govern error code of temporary overload, which
+// govern doesn't define; errors carrying it are rendered as 503
+const CategoryUnavailable governerrors.ErrorCode = "UNAVAILABLE"
+
+// Code is a stable error code such as USER_EMAIL_TAKEN
+type Code string
+
+// Definition documents a code for client SDK authors
+type Definition struct {
+	Code        Code   `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+var catalog = map[Code]Definition{}
+
+// define adds a code to the catalog; called once per code at package init
+func define(code Code, status int, description string) Code {
+	if _, exists := catalog[code]; exists {
+		panic("error code defined twice: " + string(code))
+	}
+	catalog[code] = Definition{Code: code, Status: status, Description: description}
+	return code
+}
+
+// Generic codes, one per govern error code or HTTP status class
+var (
+	InvalidRequest       = define("INVALID_REQUEST", http.StatusBadRequest, "The request is malformed or its parameters are invalid.")
+	Unauthorized         = define("UNAUTHORIZED", http.StatusUnauthorized, "Authentication is required or the supplied credentials are invalid.")
+	Forbidden            = define("FORBIDDEN", http.StatusForbidden, "The caller is authenticated but not allowed to perform the action.")
+	NotFound             = define("NOT_FOUND", http.StatusNotFound, "The resource or route does not exist.")
+	MethodNotAllowed     = define("METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed, "The route does not support the HTTP method.")
+	AlreadyExists        = define("ALREADY_EXISTS", http.StatusConflict, "The resource already exists.")
+	Conflict             = define("CONFLICT", http.StatusConflict, "The request conflicts with the current state of the resource.")
+	PayloadTooLarge      = define("PAYLOAD_TOO_LARGE", http.StatusRequestEntityTooLarge, "The request body exceeds the size limit.")
+	UnsupportedMediaType = define("UNSUPPORTED_MEDIA_TYPE", http.StatusUnsupportedMediaType, "The request body content type is not supported.")
+	RateLimited          = define("RATE_LIMITED", http.StatusTooManyRequests, "Too many requests from this client; retry later.")
+	Unavailable          = define("UNAVAILABLE", http.StatusServiceUnavailable, "The server is temporarily overloaded; retry after the Retry-After delay.")
+	Internal             = define("INTERNAL", http.StatusInternalServerError, "An unexpected server error occurred. Quote the request_id when reporting it.")
+)
+
+// Domain codes
+var (
+	AuthInvalidCredentials      = define("AUTH_INVALID_CREDENTIALS", http.StatusUnauthorized, "The username or password is wrong.")
+	AuthInvalidAPIKey           = define("AUTH_INVALID_API_KEY", http.StatusUnauthorized, "The API key is unknown, expired or revoked.")
+	AuthExternalEmailUnverified = define("AUTH_EXTERNAL_EMAIL_UNVERIFIED", http.StatusForbidden, "The identity provider has not verified the account's email, so it cannot be linked to or start an account.")
+	AuthOAuthFailed             = define("AUTH_OAUTH_FAILED", http.StatusUnauthorized, "Sign-in with the identity provider failed, was denied or expired; start it again.")
+	AuthExternalAccountExists   = define("AUTH_EXTERNAL_ACCOUNT_EXISTS", http.StatusConflict, "An account with the provider's email exists and has a password; sign in to it and link the provider from there.")
+	AuthIdentityLinked          = define("AUTH_IDENTITY_LINKED", http.StatusConflict, "The identity is already linked to another account.")
+	OAuthProviderNotFound       = define("OAUTH_PROVIDER_NOT_FOUND", http.StatusNotFound, "The identity provider is unknown or not configured.")
+	AuthMagicLinkInvalid        = define("AUTH_MAGIC_LINK_INVALID", http.StatusUnauthorized, "The sign-in link is invalid, expired, already used or was opened in another browser; request a new one.")
+	AuthPasskeyFailed           = define("AUTH_PASSKEY_FAILED", http.StatusUnauthorized, "The passkey sign-in is invalid, expired or from an unknown passkey; start it again.")
+	AuthSessionRevoked          = define("AUTH_SESSION_REVOKED", http.StatusUnauthorized, "The session of the access token was signed out or expired; log in again.")
+	AuthInsufficientScope       = define("AUTH_INSUFFICIENT_SCOPE", http.StatusForbidden, "The API key lacks a scope the request requires.")
+	AuthImpersonationForbidden  = define("AUTH_IMPERSONATION_FORBIDDEN", http.StatusForbidden, "The operation is not allowed with an impersonation token.")
+	APIKeyNotFound              = define("API_KEY_NOT_FOUND", http.StatusNotFound, "The API key does not exist or belongs to another user.")
+	OrgAccessDenied             = define("ORG_ACCESS_DENIED", http.StatusForbidden, "The caller is not a member of the organization, or the token and X-Org-ID header name different organizations.")
+	OrgRequired                 = define("ORG_REQUIRED", http.StatusBadRequest, "The request needs an organization: use an organization token or send the X-Org-ID header.")
+	OrgRoleInsufficient         = define("ORG_ROLE_INSUFFICIENT", http.StatusForbidden, "The caller's role in the organization does not allow the action.")
+	OrgNotFound                 = define("ORG_NOT_FOUND", http.StatusNotFound, "The organization does not exist.")
+	OrgMemberNotFound           = define("ORG_MEMBER_NOT_FOUND", http.StatusNotFound, "The user is not a member of the organization.")
+	OrgLastOwner                = define("ORG_LAST_OWNER", http.StatusConflict, "The change would leave the organization without an owner; make another member owner first.")
+	OrgAlreadyMember            = define("ORG_ALREADY_MEMBER", http.StatusConflict, "The user is a member of the organization already.")
+	OrgInvitationNotFound       = define("ORG_INVITATION_NOT_FOUND", http.StatusNotFound, "The invitation does not exist, was accepted or belongs to another organization.")
+	OrgInvitationInvalid        = define("ORG_INVITATION_INVALID", http.StatusBadRequest, "The invitation is invalid, expired, already accepted or addressed to another email.")
+	PasskeyRegistrationFailed   = define("PASSKEY_REGISTRATION_FAILED", http.StatusBadRequest, "The passkey could not be verified or registered; start the registration again.")
+	PasskeyNotFound             = define("PASSKEY_NOT_FOUND", http.StatusNotFound, "The passkey does not exist or belongs to another user.")
+	SessionNotFound             = define("SESSION_NOT_FOUND", http.StatusNotFound, "The session does not exist, is no longer active or belongs to another user.")
+	UserUsernameTaken           = define("USER_USERNAME_TAKEN", http.StatusConflict, "Registration failed because the username is already in use.")
+	UserEmailTaken              = define("USER_EMAIL_TAKEN", http.StatusConflict, "Registration failed because the email is already in use.")
+	UserNotFound                = define("USER_NOT_FOUND", http.StatusNotFound, "The user does not exist.")
+	UserAccountExists           = define("USER_ACCOUNT_EXISTS", http.StatusConflict, "Registration failed because a concurrent request claimed the username or email.")
+)
+
+// Field codes, set on each entry of a validation problem's "errors"
+var (
+	FieldRequired     = define("FIELD_REQUIRED", http.StatusBadRequest, "The field is missing or empty.")
+	FieldInvalidEmail = define("FIELD_INVALID_EMAIL", http.StatusBadRequest, "The field is not a valid email address.")
+	FieldTooShort     = define("FIELD_TOO_SHORT", http.StatusBadRequest, "The field is shorter, smaller or has fewer items than allowed.")
+	FieldTooLong      = define("FIELD_TOO_LONG", http.StatusBadRequest, "The field is longer, larger or has more items than allowed.")
+	FieldNotAllowed   = define("FIELD_NOT_ALLOWED", http.StatusBadRequest, "The field is not one of the allowed values.")
+	FieldInvalid      = define("FIELD_INVALID", http.StatusBadRequest, "The field failed another validation rule.")
+)
+
+// Password policy codes, reported per field in "errors"
+var (
+	PasswordMissingCharacterClass = define("PASSWORD_MISSING_CHARACTER_CLASS", http.StatusBadRequest, "The password lacks a required lowercase letter, uppercase letter, digit or symbol.")
+	PasswordContainsIdentity      = define("PASSWORD_CONTAINS_IDENTITY", http.StatusBadRequest, "The password contains the username or email.")
+	PasswordTooWeak               = define("PASSWORD_TOO_WEAK", http.StatusBadRequest, "The password is too easy to guess.")
+	PasswordBreached              = define("PASSWORD_BREACHED", http.StatusBadRequest, "The password appears in a known data breach.")
+)
+
+// All returns every defined code sorted by code
+func All() []Definition {
+	all := make([]Definition, 0, len(catalog))
+	for _, d := range catalog {
+		all = append(all, d)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Code < all[j].Code })
+	return all
+}
+
+// Lookup returns the definition of code
+func Lookup(code Code) (Definition, bool) {
+	d, ok := catalog[code]
+	return d, ok
+}
+
+// Error attaches a stable code to an error. It unwraps to the underlying
+// error, so governerrors.GetCode and errors.Is keep working.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// New creates a domain error with a stable code on top of a govern error
+// code, which still decides the HTTP status
+func New(code Code, category governerrors.ErrorCode, message string) error {
+	return &Error{Code: code, Err: governerrors.NewCode(category, message)}
+}
+
+// Wrap attaches code to err
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Of returns the code attached to err, if any
+func Of(err error) (Code, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code, true
+	}
+	return "", false
+}
+
+// ForStatus returns the generic code of an HTTP status without a more specific one
+func ForStatus(status int) Code {
+	switch status {
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusMethodNotAllowed:
+		return MethodNotAllowed
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusRequestEntityTooLarge:
+		return PayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return UnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case http.StatusServiceUnavailable:
+		return Unavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return Internal
+	}
+	return InvalidRequest
+}
+
+// ForValidationTag returns the field code of a failed validation tag
+func ForValidationTag(tag string) Code {
+	switch tag {
+	case "required":
+		return FieldRequired
+	case "email":
+		return FieldInvalidEmail
+	case "min":
+		return FieldTooShort
+	case "max":
+		return FieldTooLong
+	case "oneof":
+		return FieldNotAllowed
+	case "password_lower", "password_upper", "password_digit", "password_symbol":
+		return PasswordMissingCharacterClass
+	case "password_identity":
+		return PasswordContainsIdentity
+	case "password_strength":
+		return PasswordTooWeak
+	case "password_breached":
+		return PasswordBreached
+	default:
+		return FieldInvalid
+	}
+}
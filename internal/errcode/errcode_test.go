@@ -0,0 +1,85 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	err := New(UserEmailTaken, governerrors.CodeConflict, "email already exists")
+
+	assert.Contains(t, err.Error(), "email already exists")
+	category, ok := governerrors.GetCode(err)
+	require.True(t, ok)
+	assert.Equal(t, governerrors.CodeConflict, category)
+
+	code, ok := Of(fmt.Errorf("register: %w", err))
+	require.True(t, ok)
+	assert.Equal(t, UserEmailTaken, code)
+}
+
+func TestWrap(t *testing.T) {
+	assert.NoError(t, Wrap(Internal, nil))
+
+	base := errors.New("boom")
+	err := Wrap(Internal, base)
+	assert.ErrorIs(t, err, base)
+
+	code, ok := Of(err)
+	require.True(t, ok)
+	assert.Equal(t, Internal, code)
+}
+
+func TestOf_NoCode(t *testing.T) {
+	_, ok := Of(errors.New("plain"))
+	assert.False(t, ok)
+}
+
+func TestForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   Code
+	}{
+		{http.StatusBadRequest, InvalidRequest},
+		{http.StatusUnauthorized, Unauthorized},
+		{http.StatusNotFound, NotFound},
+		{http.StatusConflict, Conflict},
+		{http.StatusTooManyRequests, RateLimited},
+		{http.StatusServiceUnavailable, Unavailable},
+		{http.StatusBadGateway, Internal},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ForStatus(tt.status), "status %d", tt.status)
+	}
+}
+
+func TestForValidationTag(t *testing.T) {
+	assert.Equal(t, FieldRequired, ForValidationTag("required"))
+	assert.Equal(t, FieldInvalidEmail, ForValidationTag("email"))
+	assert.Equal(t, FieldInvalid, ForValidationTag("alphanum"))
+}
+
+func TestAll_Sorted(t *testing.T) {
+	all := All()
+	require.NotEmpty(t, all)
+	for i := 1; i < len(all); i++ {
+		assert.Less(t, all[i-1].Code, all[i].Code)
+	}
+
+	d, ok := Lookup(AuthInvalidCredentials)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, d.Status)
+}
+
+func TestMarkdown_UpToDate(t *testing.T) {
+	doc, err := os.ReadFile("../../docs/error-codes.md")
+	require.NoError(t, err)
+	assert.Equal(t, Markdown(), string(doc), "docs/error-codes.md is stale; run make error-codes")
+}
@@ -0,0 +1,19 @@
+// Command gen writes the error code reference generated from the errcode catalog
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"golang-sample/internal/errcode"
+)
+
+func main() {
+	output := flag.String("o", "docs/error-codes.md", "output file")
+	flag.Parse()
+
+	if err := os.WriteFile(*output, []byte(errcode.Markdown()), 0o644); err != nil {
+		log.Fatalf("failed to write %s: %v", *output, err)
+	}
+}
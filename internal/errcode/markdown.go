@@ -0,0 +1,22 @@
+package errcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the catalog as the docs/error-codes.md reference
+func Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Error Codes\n\n")
+	b.WriteString("<!-- Code generated by go generate ./internal/errcode; DO NOT EDIT. -->\n\n")
+	b.WriteString("Every error response carries a stable `code`. Switch on it instead of the HTTP status\n")
+	b.WriteString("or message text, which may be localized. Validation problems also set a `code` on each\n")
+	b.WriteString("entry of `errors`. The same list is served by `GET /api/error-codes`.\n\n")
+	b.WriteString("| Code | Status | Description |\n")
+	b.WriteString("|------|--------|-------------|\n")
+	for _, d := range All() {
+		fmt.Fprintf(&b, "| `%s` | %d | %s |\n", d.Code, d.Status, d.Description)
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
+	schemas "golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	"golang-sample/pkg/logger"
+)
+
+var errStaffSessionRequired = governerrors.NewCode(governerrors.CodeForbidden, "impersonation requires the staff member's own signed-in user")
+
+// Controller handles operational endpoints reserved for administrators.
+type Controller struct {
+	logLevel zap.AtomicLevel
+	auth     authservice.Service
+}
+
+// New creates a new admin HTTP handler.
+func New(logLevel zap.AtomicLevel, auth authservice.Service) *Controller {
+	return &Controller{
+		logLevel: logLevel,
+		auth:     auth,
+	}
+}
+
+// GetLogLevel godoc
+//
+//	@Summary	Get log level
+//	@Description	Return the current minimum log level
+//	@Tags		admin
+//	@Produce	json
+//	@Param		X-Admin-Token	header		string	true	"Admin token"
+//	@Success	200			{object}	schemas.Response[schemas.LogLevel]
+//	@Router		/admin/log/level [get]
+func (h *Controller) GetLogLevel(c echo.Context) error {
+	return c.JSON(
+		http.StatusOK,
+		schemas.NewResponse(schemas.LogLevel{Level: h.logLevel.String()}),
+	)
+}
+
+// PutLogLevel godoc
+//
+//	@Summary	Change log level
+//	@Description	Change the minimum log level at runtime without restarting
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		X-Admin-Token	header		string	true	"Admin token"
+//	@Param		req	body		schemas.LogLevel	true	"New log level"
+//	@Success	200			{object}	schemas.Response[schemas.LogLevel]
+//	@Router		/admin/log/level [put]
+func (h *Controller) PutLogLevel(c echo.Context) error {
+	var req schemas.LogLevel
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil || req.Level == "" {
+		return governerrors.NewCode(governerrors.CodeInvalid, fmt.Sprintf("invalid log level %q", req.Level))
+	}
+
+	previous := h.logLevel.Level()
+	h.logLevel.SetLevel(level)
+
+	logger.FromContext(c.Request().Context(), nil).Infow("Log level changed",
+		"from", previous.String(),
+		"to", level.String(),
+	)
+
+	return c.JSON(
+		http.StatusOK,
+		schemas.NewResponse(schemas.LogLevel{Level: level.String()}),
+	)
+}
+
+// PostImpersonation godoc
+//
+//	@Summary	Impersonate a user
+//	@Description	Issue a short-lived token acting as a user for the signed-in member of staff. The token carries an "act" claim with the staff member's username, cannot change the user's credentials, and every request made with it is audited.
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		X-Admin-Token	header		string	true	"Admin token"
+//	@Param		Authorization	header		string	true	"Bearer token of the staff member"
+//	@Param		req	body		schemas.ImpersonationRequest	true	"Target user and reason"
+//	@Success	200			{object}	schemas.Response[schemas.LoginResponse]
+//	@Router		/admin/impersonations [post]
+func (h *Controller) PostImpersonation(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+	if principal.Method != model.AuthMethodJWT {
+		return errStaffSessionRequired
+	}
+
+	var req schemas.ImpersonationRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	resp, err := h.auth.Impersonate(c.Request().Context(), authservice.ImpersonateRequest{
+		UserID:  req.UserID,
+		ActorID: principal.UserID,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemas.LoginResponse{
+		Token:     resp.Token,
+		User:      modelToSchemaUser(resp.User),
+		ExpiresAt: resp.ExpiresAt,
+	}))
+}
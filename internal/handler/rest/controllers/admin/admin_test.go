@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	authservice "golang-sample/internal/service/auth"
+	apiValidator "golang-sample/internal/validator"
+)
+
+func TestController_GetLogLevel(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/admin/log/level", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := New(zap.NewAtomicLevelAt(zap.WarnLevel), nil)
+
+	err := handler.GetLogLevel(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"level":"warn"`)
+}
+
+func TestController_PutLogLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantLevel zap.AtomicLevel
+		wantErr   bool
+	}{
+		{"changes level", `{"level":"debug"}`, zap.NewAtomicLevelAt(zap.DebugLevel), false},
+		{"uppercase level", `{"level":"ERROR"}`, zap.NewAtomicLevelAt(zap.ErrorLevel), false},
+		{"unknown level", `{"level":"verbose"}`, zap.NewAtomicLevelAt(zap.InfoLevel), true},
+		{"missing level", `{}`, zap.NewAtomicLevelAt(zap.InfoLevel), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/admin/log/level", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			level := zap.NewAtomicLevelAt(zap.InfoLevel)
+			handler := New(level, nil)
+
+			err := handler.PutLogLevel(c)
+
+			if tt.wantErr {
+				assert.True(t, governerrors.IsCode(err, governerrors.CodeInvalid))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+			assert.Equal(t, tt.wantLevel.Level(), level.Level())
+		})
+	}
+}
+
+// staffContext is the context of req signed in as staff member 5
+func staffContext(e *echo.Echo, req *http.Request, rec *httptest.ResponseRecorder) echo.Context {
+	c := e.NewContext(req, rec)
+	c.Set(middlewares.ContextKeyPrincipal, &model.Principal{UserID: 5, Method: model.AuthMethodJWT})
+	return c
+}
+
+func TestController_PostImpersonation(t *testing.T) {
+	t.Run("issues a token for the user", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+		service.EXPECT().Impersonate(mock.Anything, authservice.ImpersonateRequest{
+			UserID:  42,
+			ActorID: 5,
+			Reason:  "ticket 7",
+		}).Return(&authservice.LoginResponse{
+			Token:     "impersonation-token",
+			User:      &model.User{ID: 42, Username: "alice"},
+			ExpiresAt: time.Now().Add(authservice.ImpersonationExpiration),
+		}, nil)
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodPost, "/admin/impersonations",
+			strings.NewReader(`{"user_id":42,"actor":"someone-else","reason":"ticket 7"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, New(zap.NewAtomicLevel(), service).PostImpersonation(staffContext(e, req, rec)))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"token":"impersonation-token"`)
+		assert.Contains(t, rec.Body.String(), `"username":"alice"`)
+	})
+
+	t.Run("requires a reason", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodPost, "/admin/impersonations",
+			strings.NewReader(`{"user_id":42}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		err := New(zap.NewAtomicLevel(), service).PostImpersonation(staffContext(e, req, rec))
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeInvalid))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+		service.EXPECT().Impersonate(mock.Anything, mock.Anything).
+			RunAndReturn(func(context.Context, authservice.ImpersonateRequest) (*authservice.LoginResponse, error) {
+				return nil, authservice.ErrUserNotFound
+			})
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodPost, "/admin/impersonations",
+			strings.NewReader(`{"user_id":9,"reason":"ticket 7"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		err := New(zap.NewAtomicLevel(), service).PostImpersonation(staffContext(e, req, rec))
+
+		assert.ErrorIs(t, err, authservice.ErrUserNotFound)
+	})
+
+	t.Run("requires the staff member's own session", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodPost, "/admin/impersonations",
+			strings.NewReader(`{"user_id":42,"reason":"ticket 7"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middlewares.ContextKeyPrincipal, &model.Principal{UserID: 5, Method: model.AuthMethodAPIKey})
+
+		err := New(zap.NewAtomicLevel(), service).PostImpersonation(c)
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeForbidden))
+	})
+}
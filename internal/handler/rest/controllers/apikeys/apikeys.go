@@ -0,0 +1,128 @@
+package apikeys
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	apikeyservice "golang-sample/internal/service/apikey"
+)
+
+// Controller handles the API keys of the authenticated user.
+type Controller struct {
+	service apikeyservice.Service
+}
+
+// New creates a new API keys HTTP handler.
+func New(service apikeyservice.Service) *Controller {
+	return &Controller{
+		service: service,
+	}
+}
+
+// PostAPIKey godoc
+//
+//	@Summary	Create API key
+//	@Description	Issue an API key to the authenticated user. The key is only returned in this response.
+//	@Tags		api-keys
+//	@Accept		json
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the api_keys:write scope"
+//	@Param		req	body		schemas.CreateAPIKeyRequest	true	"Create API key request"
+//	@Success	201			{object}	schemas.Response[schemas.CreatedAPIKey]
+//	@Router		/api/me/api-keys [post]
+func (h *Controller) PostAPIKey(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	var req schemas.CreateAPIKeyRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	scopes := make([]model.Scope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = model.Scope(scope)
+	}
+
+	created, err := h.service.Create(c.Request().Context(), apikeyservice.CreateRequest{
+		Creator:   principal,
+		Name:      req.Name,
+		Scopes:    scopes,
+		ExpiresIn: time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, schemas.NewResponse(schemas.CreatedAPIKey{
+		APIKey: *modelToSchemaAPIKey(created.APIKey),
+		Key:    created.Key,
+	}))
+}
+
+// GetAPIKeys godoc
+//
+//	@Summary	List API keys
+//	@Description	List the API keys of the authenticated user that are not revoked
+//	@Tags		api-keys
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the api_keys:read scope"
+//	@Success	200			{object}	schemas.Response[[]schemas.APIKey]
+//	@Router		/api/me/api-keys [get]
+func (h *Controller) GetAPIKeys(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	keys, err := h.service.List(c.Request().Context(), principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.APIKey, len(keys))
+	for i, key := range keys {
+		result[i] = *modelToSchemaAPIKey(key)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// DeleteAPIKey godoc
+//
+//	@Summary	Revoke API key
+//	@Description	Revoke an API key of the authenticated user; it stops working immediately
+//	@Tags		api-keys
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the api_keys:write scope"
+//	@Param		id	path		int	true	"API key ID"
+//	@Success	204
+//	@Router		/api/me/api-keys/{id} [delete]
+func (h *Controller) DeleteAPIKey(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return apikeyservice.ErrNotFound
+	}
+
+	if err := h.service.Revoke(c.Request().Context(), principal.UserID, uint(keyID)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package apikeys
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	apikeyservice "golang-sample/internal/service/apikey"
+	apiValidator "golang-sample/internal/validator"
+)
+
+var testPrincipal = &model.Principal{UserID: 42, Method: model.AuthMethodJWT}
+
+func newEchoContext(method, path string, body interface{}) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = apiValidator.NewCustomValidator()
+
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(middlewares.ContextKeyPrincipal, testPrincipal)
+	return c, rec
+}
+
+func TestController_PostAPIKey(t *testing.T) {
+	t.Run("returns the key once", func(t *testing.T) {
+		service := serviceMocks.NewMockAPIKeyService(t)
+		service.EXPECT().Create(mock.Anything, apikeyservice.CreateRequest{
+			Creator:   testPrincipal,
+			Name:      "ci",
+			Scopes:    []model.Scope{model.ScopeAPIKeysRead},
+			ExpiresIn: 30 * 24 * time.Hour,
+		}).Return(&apikeyservice.CreatedKey{
+			APIKey: &model.APIKey{ID: 7, UserID: 42, Name: "ci", Prefix: "sk_01234567", Scopes: []model.Scope{model.ScopeAPIKeysRead}},
+			Key:    "sk_0123456789abcdef",
+		}, nil)
+
+		c, rec := newEchoContext(http.MethodPost, "/api/me/api-keys", schemas.CreateAPIKeyRequest{
+			Name:          "ci",
+			Scopes:        []string{"api_keys:read"},
+			ExpiresInDays: 30,
+		})
+
+		require.NoError(t, New(service).PostAPIKey(c))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var resp schemas.Response[schemas.CreatedAPIKey]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "sk_0123456789abcdef", resp.Data.Key)
+		assert.Equal(t, "sk_01234567", resp.Data.Prefix)
+		assert.Equal(t, []string{"api_keys:read"}, resp.Data.Scopes)
+	})
+
+	t.Run("accepts every scope", func(t *testing.T) {
+		var scopes []string
+		for _, scope := range model.Scopes() {
+			scopes = append(scopes, string(scope))
+		}
+		service := serviceMocks.NewMockAPIKeyService(t)
+		service.EXPECT().Create(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, req apikeyservice.CreateRequest) (*apikeyservice.CreatedKey, error) {
+				assert.Equal(t, model.Scopes(), req.Scopes)
+				return &apikeyservice.CreatedKey{APIKey: &model.APIKey{ID: 7, UserID: 42, Scopes: req.Scopes}, Key: "sk_0123456789abcdef"}, nil
+			})
+
+		c, rec := newEchoContext(http.MethodPost, "/api/me/api-keys", schemas.CreateAPIKeyRequest{Name: "ci", Scopes: scopes})
+
+		require.NoError(t, New(service).PostAPIKey(c))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("rejects unknown scopes", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodPost, "/api/me/api-keys", schemas.CreateAPIKeyRequest{
+			Name:   "ci",
+			Scopes: []string{"admin"},
+		})
+
+		err := New(serviceMocks.NewMockAPIKeyService(t)).PostAPIKey(c)
+
+		var validationErr *apiValidator.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"scopes[0]"}, validationErr.Properties())
+	})
+}
+
+func TestController_GetAPIKeys(t *testing.T) {
+	service := serviceMocks.NewMockAPIKeyService(t)
+	service.EXPECT().List(mock.Anything, uint(42)).Return([]*model.APIKey{
+		{ID: 7, Name: "ci", Prefix: "sk_01234567"},
+	}, nil)
+
+	c, rec := newEchoContext(http.MethodGet, "/api/me/api-keys", nil)
+
+	require.NoError(t, New(service).GetAPIKeys(c))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"key"`, "the secret is never listed")
+	var resp schemas.Response[[]schemas.APIKey]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, uint(7), resp.Data[0].ID)
+}
+
+func TestController_DeleteAPIKey(t *testing.T) {
+	t.Run("revokes", func(t *testing.T) {
+		service := serviceMocks.NewMockAPIKeyService(t)
+		service.EXPECT().Revoke(mock.Anything, uint(42), uint(7)).Return(nil)
+
+		c, rec := newEchoContext(http.MethodDelete, "/api/me/api-keys/7", nil)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		require.NoError(t, New(service).DeleteAPIKey(c))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("non-numeric id", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodDelete, "/api/me/api-keys/abc", nil)
+		c.SetParamNames("id")
+		c.SetParamValues("abc")
+
+		err := New(serviceMocks.NewMockAPIKeyService(t)).DeleteAPIKey(c)
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeNotFound))
+	})
+}
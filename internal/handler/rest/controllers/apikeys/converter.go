@@ -0,0 +1,28 @@
+package apikeys
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// modelToSchemaAPIKey converts domain APIKey to schema APIKey
+func modelToSchemaAPIKey(k *model.APIKey) *schemas.APIKey {
+	if k == nil {
+		return nil
+	}
+
+	scopes := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return &schemas.APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
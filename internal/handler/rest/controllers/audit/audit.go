@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"net/http"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/schemas"
+	auditservice "golang-sample/internal/service/audit"
+)
+
+// Controller serves the audit log to administrators.
+type Controller struct {
+	service auditservice.Service
+}
+
+// New creates a new audit log HTTP handler.
+func New(service auditservice.Service) *Controller {
+	return &Controller{
+		service: service,
+	}
+}
+
+// GetEvents godoc
+//
+//	@Summary	Query the audit log
+//	@Description	List audit events matching the filters, newest first. Page back by passing the id of the last event as before.
+//	@Tags		admin
+//	@Produce	json
+//	@Param		X-Admin-Token	header		string	true	"Admin token"
+//	@Param		action		query		string	false	"Action, e.g. auth.login_failed"
+//	@Param		actor_id	query		int		false	"User who acted"
+//	@Param		actor		query		string	false	"Member of staff who acted"
+//	@Param		target_id	query		int		false	"User acted upon"
+//	@Param		ip			query		string	false	"Client IP"
+//	@Param		request_id	query		string	false	"Request ID"
+//	@Param		since		query		string	false	"Earliest time, RFC 3339"
+//	@Param		until		query		string	false	"Time before which events happened, RFC 3339"
+//	@Param		before		query		int		false	"Only events with a lower id"
+//	@Param		limit		query		int		false	"Page size, at most 200; defaults to 50"
+//	@Success	200			{object}	schemas.Response[[]schemas.AuditEvent]
+//	@Router		/admin/audit-events [get]
+func (h *Controller) GetEvents(c echo.Context) error {
+	var query schemas.AuditEventQuery
+
+	if err := c.Bind(&query); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&query); err != nil {
+		return err
+	}
+
+	events, err := h.service.List(c.Request().Context(), schemaToModelFilter(&query))
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.AuditEvent, len(events))
+	for i, event := range events {
+		result[i] = *modelToSchemaEvent(event)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// GetVerification godoc
+//
+//	@Summary	Verify the audit log
+//	@Description	Walk the audit log's hash chain and report the first event that was altered, removed or inserted
+//	@Tags		admin
+//	@Produce	json
+//	@Param		X-Admin-Token	header		string	true	"Admin token"
+//	@Success	200			{object}	schemas.Response[schemas.AuditVerification]
+//	@Router		/admin/audit-events/verification [get]
+func (h *Controller) GetVerification(c echo.Context) error {
+	result, err := h.service.Verify(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemas.AuditVerification{
+		Checked:  result.Checked,
+		Intact:   result.BrokenAt == 0,
+		BrokenAt: result.BrokenAt,
+	}))
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	auditservice "golang-sample/internal/service/audit"
+	apiValidator "golang-sample/internal/validator"
+)
+
+func TestController_GetEvents(t *testing.T) {
+	t.Run("filters the log", func(t *testing.T) {
+		since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		service := serviceMocks.NewMockAuditService(t)
+		service.EXPECT().List(mock.Anything, model.AuditFilter{
+			Action:   model.AuditLoginFailed,
+			TargetID: 7,
+			IP:       "203.0.113.7",
+			Since:    since,
+			BeforeID: 100,
+			Limit:    20,
+		}).Return([]*model.AuditEvent{{ID: 99, Action: model.AuditLoginFailed, TargetID: 7, Hash: "abc"}}, nil)
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodGet,
+			"/admin/audit-events?action=auth.login_failed&target_id=7&ip=203.0.113.7&since=2026-10-01T00:00:00Z&before=100&limit=20", nil)
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, New(service).GetEvents(e.NewContext(req, rec)))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"id":99`)
+		assert.Contains(t, rec.Body.String(), `"action":"auth.login_failed"`)
+	})
+
+	t.Run("rejects an oversized page", func(t *testing.T) {
+		service := serviceMocks.NewMockAuditService(t)
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodGet, "/admin/audit-events?limit=1000", nil)
+
+		err := New(service).GetEvents(e.NewContext(req, httptest.NewRecorder()))
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeInvalid))
+	})
+}
+
+func TestController_GetVerification(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *auditservice.VerifyResult
+		want   string
+	}{
+		{"intact", &auditservice.VerifyResult{Checked: 3}, `"checked":3,"intact":true}`},
+		{"broken", &auditservice.VerifyResult{Checked: 2, BrokenAt: 2}, `"checked":2,"intact":false,"broken_at":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := serviceMocks.NewMockAuditService(t)
+			service.EXPECT().Verify(mock.Anything).Return(tt.result, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit-events/verification", nil)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, New(service).GetVerification(echo.New().NewContext(req, rec)))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.want)
+		})
+	}
+}
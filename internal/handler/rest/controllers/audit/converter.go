@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// modelToSchemaEvent converts domain AuditEvent to schema AuditEvent
+func modelToSchemaEvent(e *model.AuditEvent) *schemas.AuditEvent {
+	if e == nil {
+		return nil
+	}
+
+	return &schemas.AuditEvent{
+		ID:        e.ID,
+		Action:    string(e.Action),
+		ActorID:   e.ActorID,
+		Actor:     e.Actor,
+		TargetID:  e.TargetID,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		RequestID: e.RequestID,
+		Metadata:  e.Metadata,
+		CreatedAt: e.CreatedAt,
+		PrevHash:  e.PrevHash,
+		Hash:      e.Hash,
+	}
+}
+
+// schemaToModelFilter converts a schema AuditEventQuery to a domain AuditFilter
+func schemaToModelFilter(q *schemas.AuditEventQuery) model.AuditFilter {
+	return model.AuditFilter{
+		Action:    model.AuditAction(q.Action),
+		ActorID:   q.ActorID,
+		Actor:     q.Actor,
+		TargetID:  q.TargetID,
+		IP:        q.IP,
+		RequestID: q.RequestID,
+		Since:     q.Since,
+		Until:     q.Until,
+		BeforeID:  q.Before,
+		Limit:     q.Limit,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	governerrors "github.com/haipham22/govern/errors"
 	"github.com/labstack/echo/v4"
 
+	"golang-sample/internal/handler/rest/middlewares"
 	schemas "golang-sample/internal/schemas"
 	authservice "golang-sample/internal/service/auth"
 )
@@ -31,6 +32,7 @@ func New(service authservice.Service) *Controller {
 //	@Produce	json
 //	@Param		req	body		schemas.UserRegisterRequest	true	"Register request"
 //	@Success	201			{object}	schemas.Response[schemas.User]
+//	@Success	202			"Accepted without details, with auth.non_enumerating_registration"
 //	@Router		/api/register [post]
 func (h *Controller) PostRegister(c echo.Context) error {
 	var req schemas.UserRegisterRequest
@@ -38,6 +40,9 @@ func (h *Controller) PostRegister(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return governerrors.WrapCode(governerrors.CodeInvalid, err)
 	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
 
 	// Call service - returns domain model
 	modelUser, err := h.service.Register(c.Request().Context(), authservice.RegisterRequest{
@@ -45,10 +50,16 @@ func (h *Controller) PostRegister(c echo.Context) error {
 		Email:    req.Email,
 		Password: req.Password,
 		FullName: req.FullName,
+		Locale:   req.Locale,
 	})
 	if err != nil {
 		return err
 	}
+	// Non-enumerating registration answers alike whether or not an account
+	// was created
+	if modelUser == nil {
+		return c.NoContent(http.StatusAccepted)
+	}
 
 	// Convert model → schema
 	schemaUser := modelToSchemaUser(modelUser)
@@ -62,7 +73,7 @@ func (h *Controller) PostRegister(c echo.Context) error {
 // PostLogin godoc
 //
 //	@Summary	Login user
-//	@Description	Authenticate user with username and password
+//	@Description	Authenticate user with username or email and password
 //	@Tags	auth
 //	@Accept		json
 //	@Produce	json
@@ -75,6 +86,9 @@ func (h *Controller) PostLogin(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return governerrors.WrapCode(governerrors.CodeInvalid, err)
 	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
 
 	// Call service - returns domain model
 	modelResp, err := h.service.Login(c.Request().Context(), authservice.LoginRequest{
@@ -94,3 +108,40 @@ func (h *Controller) PostLogin(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, schemas.NewResponse(schemaResp))
 }
+
+// PutPassword godoc
+//
+//	@Summary	Change password
+//	@Description	Replace the password of the authenticated user after verifying the current one
+//	@Tags		auth
+//	@Accept		json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the password:write scope"
+//	@Param		req	body		schemas.ChangePasswordRequest	true	"Change password request"
+//	@Success	204
+//	@Router		/api/me/password [put]
+func (h *Controller) PutPassword(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	var req schemas.ChangePasswordRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	err := h.service.ChangePassword(c.Request().Context(), authservice.ChangePasswordRequest{
+		UserID:          principal.UserID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
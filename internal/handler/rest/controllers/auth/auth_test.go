@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"golang-sample/internal/handler/rest/middlewares"
 	serviceMocks "golang-sample/internal/mocks/service"
 	"golang-sample/internal/model"
 	schemas "golang-sample/internal/schemas"
@@ -93,6 +94,24 @@ func TestHTTPHandler_PostRegister_Success(t *testing.T) {
 	})
 }
 
+// TestHTTPHandler_PostRegister_Accepted tests non-enumerating registration
+func TestHTTPHandler_PostRegister_Accepted(t *testing.T) {
+	mockService := serviceMocks.NewMockService(t)
+	mockService.EXPECT().Register(mock.Anything, mock.AnythingOfType("auth.RegisterRequest")).Return(nil, nil)
+
+	req := &schemas.UserRegisterRequest{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "SecurePassword123!",
+		FullName: "Test User",
+	}
+	c, rec := newEchoContext(http.MethodPost, "/api/register", req)
+
+	require.NoError(t, newTestHandler(mockService).PostRegister(c))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.String(), "the response does not tell whether an account was created")
+}
+
 // TestHTTPHandler_PostRegister_Conflict tests username conflict scenario
 func TestHTTPHandler_PostRegister_Conflict(t *testing.T) {
 	t.Run("returns conflict error when username exists", func(t *testing.T) {
@@ -119,6 +138,28 @@ func TestHTTPHandler_PostRegister_Conflict(t *testing.T) {
 	})
 }
 
+// TestHTTPHandler_PostRegister_Invalid tests that every invalid field is reported
+func TestHTTPHandler_PostRegister_Invalid(t *testing.T) {
+	t.Run("reports every invalid field at once", func(t *testing.T) {
+		handler := newTestHandler(serviceMocks.NewMockService(t))
+
+		req := &schemas.UserRegisterRequest{Email: "not-an-email"}
+		c, _ := newEchoContext(http.MethodPost, "/api/register", req)
+
+		err := handler.PostRegister(c)
+
+		code, ok := governerrors.GetCode(err)
+		assert.True(t, ok)
+		assert.Equal(t, governerrors.CodeInvalid, code)
+
+		var validationErr *apiValidator.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"username", "password", "email", "full_name"}, validationErr.Properties())
+		assert.Equal(t, "required", validationErr.Errors[0].MsgValues[apiValidator.MsgValueTag])
+		assert.Equal(t, "email", validationErr.Errors[2].MsgValues[apiValidator.MsgValueTag])
+	})
+}
+
 // TestHTTPHandler_PostLogin_Success tests successful login via HTTP handler
 func TestHTTPHandler_PostLogin_Success(t *testing.T) {
 	t.Run("successfully logs in user via HTTP", func(t *testing.T) {
@@ -149,6 +190,21 @@ func TestHTTPHandler_PostLogin_Success(t *testing.T) {
 	})
 }
 
+// TestHTTPHandler_PostLogin_Invalid tests that missing credentials are rejected
+func TestHTTPHandler_PostLogin_Invalid(t *testing.T) {
+	t.Run("reports both missing fields", func(t *testing.T) {
+		handler := newTestHandler(serviceMocks.NewMockService(t))
+
+		c, _ := newEchoContext(http.MethodPost, "/api/login", &schemas.LoginRequest{})
+
+		err := handler.PostLogin(c)
+
+		var validationErr *apiValidator.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"username", "password"}, validationErr.Properties())
+	})
+}
+
 // TestHTTPHandler_PostLogin_Unauthorized tests invalid credentials
 func TestHTTPHandler_PostLogin_Unauthorized(t *testing.T) {
 	t.Run("returns unauthorized error for invalid credentials", func(t *testing.T) {
@@ -172,3 +228,53 @@ func TestHTTPHandler_PostLogin_Unauthorized(t *testing.T) {
 		assert.Equal(t, governerrors.CodeUnauthorized, code)
 	})
 }
+
+// TestHTTPHandler_PutPassword tests password change via HTTP handler
+func TestHTTPHandler_PutPassword(t *testing.T) {
+	t.Run("changes the password of the authenticated user", func(t *testing.T) {
+		mockService := serviceMocks.NewMockService(t)
+		mockService.EXPECT().ChangePassword(mock.Anything, authservice.ChangePasswordRequest{
+			UserID:          42,
+			CurrentPassword: "OldPassword123!",
+			NewPassword:     "NewPassword456!",
+		}).Return(nil)
+
+		handler := newTestHandler(mockService)
+
+		req := &schemas.ChangePasswordRequest{
+			CurrentPassword: "OldPassword123!",
+			NewPassword:     "NewPassword456!",
+		}
+		c, rec := newEchoContext(http.MethodPut, "/api/me/password", req)
+		c.Set(middlewares.ContextKeyPrincipal, &model.Principal{UserID: 42, Method: model.AuthMethodJWT})
+
+		err := handler.PutPassword(c)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("rejects a missing new password", func(t *testing.T) {
+		handler := newTestHandler(serviceMocks.NewMockService(t))
+
+		req := &schemas.ChangePasswordRequest{CurrentPassword: "OldPassword123!"}
+		c, _ := newEchoContext(http.MethodPut, "/api/me/password", req)
+		c.Set(middlewares.ContextKeyPrincipal, &model.Principal{UserID: 42, Method: model.AuthMethodJWT})
+
+		err := handler.PutPassword(c)
+
+		var validationErr *apiValidator.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"new_password"}, validationErr.Properties())
+	})
+
+	t.Run("requires authentication", func(t *testing.T) {
+		handler := newTestHandler(serviceMocks.NewMockService(t))
+
+		c, _ := newEchoContext(http.MethodPut, "/api/me/password", &schemas.ChangePasswordRequest{})
+
+		err := handler.PutPassword(c)
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+	})
+}
@@ -0,0 +1,41 @@
+package errcodes
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/errcode"
+	schemas "golang-sample/internal/schemas"
+)
+
+// Controller publishes the error code catalog.
+type Controller struct{}
+
+// New creates a new error codes HTTP handler.
+func New() *Controller {
+	return &Controller{}
+}
+
+// GetErrorCodes godoc
+//
+//	@Summary	List error codes
+//	@Description	List every stable error code returned in the "code" field of error responses
+//	@Tags	errors
+//	@Produce	json
+//	@Success	200	{object}	schemas.Response[[]schemas.ErrorCode]
+//	@Router		/api/error-codes [get]
+func (h *Controller) GetErrorCodes(c echo.Context) error {
+	definitions := errcode.All()
+
+	codes := make([]schemas.ErrorCode, 0, len(definitions))
+	for _, d := range definitions {
+		codes = append(codes, schemas.ErrorCode{
+			Code:        string(d.Code),
+			Status:      d.Status,
+			Description: d.Description,
+		})
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(codes))
+}
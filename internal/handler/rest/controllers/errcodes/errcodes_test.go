@@ -0,0 +1,36 @@
+package errcodes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/errcode"
+	schemas "golang-sample/internal/schemas"
+)
+
+func TestController_GetErrorCodes(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/error-codes", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := New().GetErrorCodes(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body schemas.Response[[]schemas.ErrorCode]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body.Data, len(errcode.All()))
+	assert.Contains(t, body.Data, schemas.ErrorCode{
+		Code:        "USER_EMAIL_TAKEN",
+		Status:      http.StatusConflict,
+		Description: "Registration failed because the email is already in use.",
+	})
+}
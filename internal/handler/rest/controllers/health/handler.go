@@ -1,21 +1,28 @@
 package health
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
+
+	"golang-sample/internal/healthcheck"
 )
 
+// pingTimeout bounds the database ping of the full health check
+const pingTimeout = 2 * time.Second
+
 // Controller handles health check requests
 type Controller struct {
-	db *gorm.DB
+	db      *gorm.DB
+	checker *healthcheck.Checker
 }
 
 // New creates a new health HTTP handler
-func New(db *gorm.DB) *Controller {
-	return &Controller{db: db}
+func New(db *gorm.DB, checker *healthcheck.Checker) *Controller {
+	return &Controller{db: db, checker: checker}
 }
 
 // Check performs full health check including database connectivity
@@ -35,7 +42,9 @@ func (h *Controller) Check(c echo.Context) error {
 		return c.JSON(http.StatusServiceUnavailable, status)
 	}
 
-	if err := sqlDB.Ping(); err != nil {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), pingTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
 		status["database"] = "error"
 		status["status"] = "degraded"
 		status["error"] = "Database connection failed"
@@ -46,11 +55,31 @@ func (h *Controller) Check(c echo.Context) error {
 	return c.JSON(http.StatusOK, status)
 }
 
-// Ready returns readiness status for Kubernetes probes
+// Ready returns readiness status for Kubernetes probes. It fails once graceful
+// shutdown begins or when a critical check fails; non-critical failures are
+// only reported. Add ?verbose for per-check detail.
 func (h *Controller) Ready(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"status": "ready",
-	})
+	if h.checker.Draining() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "draining",
+		})
+	}
+
+	report := h.checker.Run(c.Request().Context())
+
+	code, status := http.StatusOK, "ready"
+	if report.Status == healthcheck.StatusFailing {
+		code, status = http.StatusServiceUnavailable, "not_ready"
+	}
+
+	body := map[string]interface{}{
+		"status": status,
+	}
+	if c.QueryParams().Has("verbose") {
+		body["checks"] = report.Checks
+		body["checked_at"] = report.CheckedAt.Format(time.RFC3339)
+	}
+	return c.JSON(code, body)
 }
 
 // Live returns liveness status for Kubernetes probes
@@ -1,14 +1,21 @@
 package health
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+
+	"golang-sample/internal/healthcheck"
 )
 
 // mockDB creates a test database connection
@@ -20,6 +27,10 @@ func mockDB(t *testing.T) *gorm.DB {
 	return db
 }
 
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("unavailable") }
+
 func TestHTTPHandler_Check(t *testing.T) {
 	t.Run("healthy with valid DB", func(t *testing.T) {
 		// Setup
@@ -48,24 +59,77 @@ func TestHTTPHandler_Check(t *testing.T) {
 }
 
 func TestHTTPHandler_Ready(t *testing.T) {
-	t.Run("always returns ready", func(t *testing.T) {
-		// Setup
+	serve := func(handler *Controller, target string) *httptest.ResponseRecorder {
 		e := echo.New()
-		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		req := httptest.NewRequest(http.MethodGet, target, nil)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		// Create handler (DB doesn't matter for Ready)
-		handler := &Controller{db: nil}
+		assert.NoError(t, handler.Ready(c))
+		return rec
+	}
 
-		// Execute
-		err := handler.Ready(c)
+	t.Run("ready when all checks pass", func(t *testing.T) {
+		checker := healthcheck.NewChecker(0)
+		checker.Register("database", passing, healthcheck.Critical())
+
+		rec := serve(&Controller{checker: checker}, "/readyz")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ready"}`, rec.Body.String())
+	})
+
+	t.Run("ready when only a non-critical check fails", func(t *testing.T) {
+		checker := healthcheck.NewChecker(0)
+		checker.Register("database", passing, healthcheck.Critical())
+		checker.Register("redis", failing)
+
+		rec := serve(&Controller{checker: checker}, "/readyz")
 
-		// Assert
-		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.JSONEq(t, `{"status":"ready"}`, rec.Body.String())
 	})
+
+	t.Run("not ready when a critical check fails", func(t *testing.T) {
+		checker := healthcheck.NewChecker(0)
+		checker.Register("database", failing, healthcheck.Critical())
+
+		rec := serve(&Controller{checker: checker}, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"not_ready"}`, rec.Body.String())
+	})
+
+	t.Run("verbose reports each check", func(t *testing.T) {
+		checker := healthcheck.NewChecker(0)
+		checker.Register("database", passing, healthcheck.Critical())
+		checker.Register("redis", failing)
+
+		rec := serve(&Controller{checker: checker}, "/readyz?verbose")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Status string                        `json:"status"`
+			Checks map[string]healthcheck.Result `json:"checks"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "ready", body.Status)
+		assert.Equal(t, healthcheck.StatusPassing, body.Checks["database"].Status)
+		assert.True(t, body.Checks["database"].Critical)
+		assert.Equal(t, healthcheck.StatusFailing, body.Checks["redis"].Status)
+		assert.Equal(t, "unavailable", body.Checks["redis"].Message)
+	})
+
+	t.Run("draining once shutdown begins", func(t *testing.T) {
+		checker := healthcheck.NewChecker(0)
+		checker.Register("database", passing, healthcheck.Critical())
+		checker.StartDraining()
+
+		rec := serve(&Controller{checker: checker}, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"draining"}`, rec.Body.String())
+	})
 }
 
 func TestHTTPHandler_Live(t *testing.T) {
@@ -92,10 +156,13 @@ func TestHTTPHandler_Live(t *testing.T) {
 func TestNewHTTPHandler(t *testing.T) {
 	db := mockDB(t)
 
-	handler := New(db)
+	checker := healthcheck.NewChecker(0)
+
+	handler := New(db, checker)
 
 	assert.NotNil(t, handler)
 	assert.Equal(t, db, handler.db)
+	assert.Equal(t, checker, handler.checker)
 }
 
 func TestHTTPHandler_WithDatabase(t *testing.T) {
@@ -131,7 +198,9 @@ func BenchmarkHTTPHandler_Check(b *testing.B) {
 }
 
 func BenchmarkHTTPHandler_Ready(b *testing.B) {
-	handler := &Controller{db: nil}
+	checker := healthcheck.NewChecker(time.Second)
+	checker.Register("database", passing, healthcheck.Critical())
+	handler := &Controller{checker: checker}
 	e := echo.New()
 
 	b.ResetTimer()
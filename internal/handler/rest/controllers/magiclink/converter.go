@@ -0,0 +1,22 @@
+package magiclink
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// modelToSchemaUser converts domain User to schema User
+func modelToSchemaUser(u *model.User) *schemas.User {
+	if u == nil {
+		return nil
+	}
+
+	return &schemas.User{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Locale:    u.Locale,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
@@ -0,0 +1,129 @@
+package magiclink
+
+import (
+	"net/http"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	utilstring "golang-sample/pkg/utils/string"
+)
+
+const (
+	// bindingCookie ties a magic link to the browser that requested it
+	bindingCookie = "magic_link"
+	// bindingLength is the number of hex characters of a binding (256 bits)
+	bindingLength = 64
+)
+
+// Config configures magic link login
+type Config struct {
+	// TTL is the lifetime of the links, and so of the binding cookie
+	TTL time.Duration
+	// SecureCookie restricts the binding cookie to HTTPS
+	SecureCookie bool
+}
+
+// Controller handles passwordless login with emailed links.
+type Controller struct {
+	service authservice.Service
+	cfg     Config
+}
+
+// New creates a new magic link HTTP handler.
+func New(service authservice.Service, cfg Config) *Controller {
+	return &Controller{
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// PostMagicLink godoc
+//
+//	@Summary	Request a sign-in link
+//	@Description	Email a single-use sign-in link to the account with the email, if there is one. The link only works in the browser that requested it.
+//	@Tags		auth
+//	@Accept		json
+//	@Param		req	body		schemas.MagicLinkRequest	true	"Magic link request"
+//	@Success	202
+//	@Router		/api/login/magic-link [post]
+func (h *Controller) PostMagicLink(c echo.Context) error {
+	var req schemas.MagicLinkRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	binding, err := utilstring.RandomHexString(bindingLength)
+	if err != nil {
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	err = h.service.RequestMagicLink(c.Request().Context(), authservice.MagicLinkRequest{
+		Email:   req.Email,
+		Binding: binding,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Set for unknown emails too, so the response is the same
+	c.SetCookie(h.bindingCookie(binding, int(h.cfg.TTL.Seconds())))
+	return c.NoContent(http.StatusAccepted)
+}
+
+// GetVerify godoc
+//
+//	@Summary	Log in with a sign-in link
+//	@Description	Exchange the token of an emailed sign-in link for an access token
+//	@Tags		auth
+//	@Produce	json
+//	@Param		token	query		string	true	"Token of the sign-in link"
+//	@Success	200			{object}	schemas.Response[schemas.LoginResponse]
+//	@Router		/api/login/magic-link/verify [get]
+func (h *Controller) GetVerify(c echo.Context) error {
+	// A request without the cookie, such as a mail scanner's, is rejected
+	// by the service before it uses up the link
+	var binding string
+	if cookie, err := c.Cookie(bindingCookie); err == nil {
+		binding = cookie.Value
+	}
+
+	modelResp, err := h.service.MagicLinkLogin(c.Request().Context(), authservice.MagicLinkLoginRequest{
+		Token:   c.QueryParam("token"),
+		Binding: binding,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(h.bindingCookie("", -1))
+
+	schemaResp := &schemas.LoginResponse{
+		Token:     modelResp.Token,
+		User:      modelToSchemaUser(modelResp.User),
+		ExpiresAt: modelResp.ExpiresAt,
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemaResp))
+}
+
+// bindingCookie builds the binding cookie; a negative maxAge deletes it.
+// Lax lets it accompany the navigation from the email.
+func (h *Controller) bindingCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     bindingCookie,
+		Value:    value,
+		Path:     "/api/login/magic-link",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.cfg.SecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,107 @@
+package magiclink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	apiValidator "golang-sample/internal/validator"
+)
+
+func TestController_PostMagicLink(t *testing.T) {
+	t.Run("binds the link to the browser", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+		var binding string
+		service.EXPECT().RequestMagicLink(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, req authservice.MagicLinkRequest) error {
+				assert.Equal(t, "alice@example.com", req.Email)
+				binding = req.Binding
+				return nil
+			})
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodPost, "/api/login/magic-link", strings.NewReader(`{"email":"alice@example.com"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, New(service, Config{TTL: 15 * time.Minute, SecureCookie: true}).PostMagicLink(e.NewContext(req, rec)))
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, bindingCookie, cookies[0].Name)
+		assert.Len(t, binding, bindingLength)
+		assert.Equal(t, binding, cookies[0].Value)
+		assert.Equal(t, 900, cookies[0].MaxAge)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	})
+
+	t.Run("requires an email", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		req := httptest.NewRequest(http.MethodPost, "/api/login/magic-link", strings.NewReader(`{"email":"alice"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		assert.Error(t, New(service, Config{}).PostMagicLink(e.NewContext(req, httptest.NewRecorder())))
+	})
+}
+
+func TestController_GetVerify(t *testing.T) {
+	newContext := func(cookies ...*http.Cookie) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/api/login/magic-link/verify?token=the-token", nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		return echo.New().NewContext(req, rec), rec
+	}
+
+	t.Run("logs in with the link and the binding cookie", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+		service.EXPECT().MagicLinkLogin(mock.Anything, authservice.MagicLinkLoginRequest{Token: "the-token", Binding: "the-binding"}).
+			Return(&authservice.LoginResponse{Token: "jwt", User: &model.User{ID: 7, Username: "alice"}}, nil)
+
+		c, rec := newContext(&http.Cookie{Name: bindingCookie, Value: "the-binding"})
+
+		require.NoError(t, New(service, Config{}).GetVerify(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp schemas.Response[schemas.LoginResponse]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "jwt", resp.Data.Token)
+		assert.Equal(t, "alice", resp.Data.User.Username)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Negative(t, cookies[0].MaxAge, "the binding is cleared")
+	})
+
+	t.Run("passes an empty binding without the cookie", func(t *testing.T) {
+		service := serviceMocks.NewMockService(t)
+		service.EXPECT().MagicLinkLogin(mock.Anything, authservice.MagicLinkLoginRequest{Token: "the-token"}).
+			Return(nil, authservice.ErrMagicLinkInvalid)
+
+		c, rec := newContext()
+
+		assert.ErrorIs(t, New(service, Config{}).GetVerify(c), authservice.ErrMagicLinkInvalid)
+		assert.Empty(t, rec.Result().Cookies(), "the binding is kept for the user's own attempt")
+	})
+}
@@ -0,0 +1,269 @@
+package oauth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/oidc"
+)
+
+// flowCookie carries the state, nonce and PKCE verifier of a sign-in from
+// the authorize redirect to the callback
+const (
+	flowCookie   = "oauth_flow"
+	flowLifetime = 10 * time.Minute
+)
+
+var (
+	// ErrProviderNotFound is returned for a provider that is not configured
+	ErrProviderNotFound = errcode.New(errcode.OAuthProviderNotFound, governerrors.CodeNotFound, "identity provider not found")
+	// ErrFailed is returned when the callback cannot be trusted: a missing,
+	// expired or mismatched flow, a denied consent or a rejected code
+	ErrFailed = errcode.New(errcode.AuthOAuthFailed, governerrors.CodeUnauthorized, "sign-in with the identity provider failed")
+	// errSignInRequired is returned when an API key tries to link an identity
+	errSignInRequired = governerrors.NewCode(governerrors.CodeForbidden, "linking an identity requires a signed-in user")
+)
+
+// Config configures social login
+type Config struct {
+	// Providers are the configured identity providers by name
+	Providers map[string]oidc.Provider
+	// FlowKey signs the flow cookie; it must differ from the access token key
+	FlowKey []byte
+	// SecureCookie restricts the flow cookie to HTTPS
+	SecureCookie bool
+}
+
+// Controller handles sign-in with external identity providers.
+type Controller struct {
+	log     *zap.SugaredLogger
+	service authservice.Service
+	cfg     Config
+}
+
+// New creates a new social login HTTP handler.
+func New(log *zap.SugaredLogger, service authservice.Service, cfg Config) *Controller {
+	return &Controller{
+		log:     log,
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// flowClaims is the signed content of the flow cookie. It is readable by
+// the browser's owner, which already holds the session it protects.
+type flowClaims struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	// LinkUserID is the signed-in user who started the flow to link the
+	// identity to their account; zero for a login
+	LinkUserID uint `json:"link_user_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GetAuthorize godoc
+//
+//	@Summary	Start social login
+//	@Description	Redirect to the identity provider's sign-in page
+//	@Tags		auth
+//	@Param		provider	path		string	true	"Identity provider, e.g. google or github"
+//	@Success	302
+//	@Router		/api/oauth/{provider}/authorize [get]
+func (h *Controller) GetAuthorize(c echo.Context) error {
+	authURL, err := h.startFlow(c, c.Param("provider"), 0)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// PostLink godoc
+//
+//	@Summary	Start linking an identity provider
+//	@Description	Start signing in with the identity provider to link it to the signed-in account; the callback links instead of logging in. Open the returned URL in the browser that made the request.
+//	@Tags		auth
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Param		provider	path		string	true	"Identity provider, e.g. google or github"
+//	@Success	200			{object}	schemas.Response[schemas.LinkIdentityResponse]
+//	@Router		/api/me/identities/{provider} [post]
+func (h *Controller) PostLink(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+	if principal.Method != model.AuthMethodJWT {
+		return errSignInRequired
+	}
+
+	authURL, err := h.startFlow(c, c.Param("provider"), principal.UserID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(&schemas.LinkIdentityResponse{AuthorizationURL: authURL}))
+}
+
+// startFlow sets the flow cookie of a sign-in with the provider name, or of
+// linking it to linkUserID when not zero, and returns the provider's
+// authorization URL
+func (h *Controller) startFlow(c echo.Context, name string, linkUserID uint) (string, error) {
+	provider, ok := h.cfg.Providers[name]
+	if !ok {
+		return "", ErrProviderNotFound
+	}
+
+	flow := flowClaims{
+		Provider:   name,
+		LinkUserID: linkUserID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(flowLifetime)),
+		},
+	}
+	var challenge string
+	var err error
+	if flow.State, err = oidc.RandomString(); err != nil {
+		return "", governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if flow.Nonce, err = oidc.RandomString(); err != nil {
+		return "", governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if flow.Verifier, challenge, err = oidc.NewPKCE(); err != nil {
+		return "", governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request().Context(), flow.State, flow.Nonce, challenge)
+	if err != nil {
+		logger.FromContext(c.Request().Context(), h.log).Errorf("Failed to build authorization URL of %s: %v", name, err)
+		return "", governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, flow).SignedString(h.cfg.FlowKey)
+	if err != nil {
+		return "", governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	c.SetCookie(h.flowCookie(signed, int(flowLifetime.Seconds())))
+
+	return authURL, nil
+}
+
+// GetCallback godoc
+//
+//	@Summary	Finish social login
+//	@Description	Exchange the provider's authorization code and log in the linked, matched or new account, or link the identity for a flow started by PostLink
+//	@Tags		auth
+//	@Produce	json
+//	@Param		provider	path		string	true	"Identity provider, e.g. google or github"
+//	@Param		code	query		string	true	"Authorization code"
+//	@Param		state	query		string	true	"State of the authorize redirect"
+//	@Success	200			{object}	schemas.Response[schemas.LoginResponse]
+//	@Success	204			"Linked, for a flow started by PostLink"
+//	@Router		/api/oauth/{provider}/callback [get]
+func (h *Controller) GetCallback(c echo.Context) error {
+	ctx := c.Request().Context()
+	log := logger.FromContext(ctx, h.log)
+
+	name := c.Param("provider")
+	provider, ok := h.cfg.Providers[name]
+	if !ok {
+		return ErrProviderNotFound
+	}
+
+	// A flow is used once, whatever its outcome
+	c.SetCookie(h.flowCookie("", -1))
+
+	flow, err := h.readFlow(c)
+	if err != nil {
+		log.Warnf("Social login callback without a valid flow: %v", err)
+		return ErrFailed
+	}
+	if flow.Provider != name || subtle.ConstantTimeCompare([]byte(flow.State), []byte(c.QueryParam("state"))) != 1 {
+		log.Warnf("Social login callback with a mismatched provider or state")
+		return ErrFailed
+	}
+	if errParam := c.QueryParam("error"); errParam != "" {
+		log.Infof("Social login with %s ended by the provider: %s", name, errParam)
+		return ErrFailed
+	}
+
+	identity, err := provider.Exchange(ctx, c.QueryParam("code"), flow.Verifier, flow.Nonce)
+	if err != nil {
+		log.Warnf("Social login with %s failed: %v", name, err)
+		return ErrFailed
+	}
+
+	if flow.LinkUserID != 0 {
+		err := h.service.LinkIdentity(ctx, authservice.LinkIdentityRequest{
+			UserID:   flow.LinkUserID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		})
+		if err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	modelResp, err := h.service.ExternalLogin(ctx, authservice.ExternalLoginRequest{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+	})
+	if err != nil {
+		return err
+	}
+
+	schemaResp := &schemas.LoginResponse{
+		Token:     modelResp.Token,
+		User:      modelToSchemaUser(modelResp.User),
+		ExpiresAt: modelResp.ExpiresAt,
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemaResp))
+}
+
+// readFlow verifies the flow cookie
+func (h *Controller) readFlow(c echo.Context) (*flowClaims, error) {
+	cookie, err := c.Cookie(flowCookie)
+	if err != nil {
+		return nil, err
+	}
+
+	var flow flowClaims
+	_, err = jwt.ParseWithClaims(cookie.Value, &flow, func(*jwt.Token) (interface{}, error) {
+		return h.cfg.FlowKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+// flowCookie builds the flow cookie; a negative maxAge deletes it. Lax
+// lets it accompany the top-level redirect back from the provider.
+func (h *Controller) flowCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     flowCookie,
+		Value:    value,
+		Path:     "/api/oauth",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.cfg.SecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
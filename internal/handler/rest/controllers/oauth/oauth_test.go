@@ -0,0 +1,228 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	authservice "golang-sample/internal/service/auth"
+	"golang-sample/pkg/oidc"
+)
+
+// fakeProvider records the flow it was given and answers with identity
+type fakeProvider struct {
+	state, nonce, challenge string
+	identity                *oidc.Identity
+	err                     error
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) AuthCodeURL(_ context.Context, state, nonce, codeChallenge string) (string, error) {
+	p.state, p.nonce, p.challenge = state, nonce, codeChallenge
+	return "https://idp.example/authorize?state=" + url.QueryEscape(state), nil
+}
+
+func (p *fakeProvider) Exchange(_ context.Context, code, codeVerifier, nonce string) (*oidc.Identity, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if code != "the-code" || nonce != p.nonce || oidc.S256Challenge(codeVerifier) != p.challenge {
+		return nil, oidc.ErrVerification
+	}
+	return p.identity, nil
+}
+
+func newTestController(t *testing.T, provider *fakeProvider) (*Controller, *serviceMocks.MockService) {
+	t.Helper()
+	service := serviceMocks.NewMockService(t)
+	return New(zap.NewNop().Sugar(), service, Config{
+		Providers: map[string]oidc.Provider{"fake": provider},
+		FlowKey:   []byte("flow-key"),
+	}), service
+}
+
+func newContext(path, provider string, cookies ...*http.Cookie) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("provider")
+	c.SetParamValues(provider)
+	return c, rec
+}
+
+// authorize starts a flow and returns its cookie
+func authorize(t *testing.T, ctrl *Controller) *http.Cookie {
+	t.Helper()
+	c, rec := newContext("/api/oauth/fake/authorize", "fake")
+	require.NoError(t, ctrl.GetAuthorize(c))
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	return cookies[0]
+}
+
+func TestController_GetAuthorize(t *testing.T) {
+	t.Run("redirects to the provider", func(t *testing.T) {
+		provider := &fakeProvider{}
+		ctrl, _ := newTestController(t, provider)
+
+		c, rec := newContext("/api/oauth/fake/authorize", "fake")
+		require.NoError(t, ctrl.GetAuthorize(c))
+
+		assert.Equal(t, "https://idp.example/authorize?state="+url.QueryEscape(provider.state), rec.Header().Get(echo.HeaderLocation))
+		assert.NotEmpty(t, provider.nonce)
+		assert.NotEmpty(t, provider.challenge)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		ctrl, _ := newTestController(t, &fakeProvider{})
+
+		c, _ := newContext("/api/oauth/other/authorize", "other")
+
+		assert.ErrorIs(t, ctrl.GetAuthorize(c), ErrProviderNotFound)
+	})
+}
+
+func TestController_GetCallback(t *testing.T) {
+	identity := &oidc.Identity{Provider: "fake", Subject: "s-1", Email: "alice@example.com", EmailVerified: true}
+
+	t.Run("logs in", func(t *testing.T) {
+		provider := &fakeProvider{identity: identity}
+		ctrl, service := newTestController(t, provider)
+		service.EXPECT().ExternalLogin(mock.Anything, authservice.ExternalLoginRequest{
+			Provider:      "fake",
+			Subject:       "s-1",
+			Email:         "alice@example.com",
+			EmailVerified: true,
+		}).Return(&authservice.LoginResponse{Token: "jwt", User: &model.User{ID: 7, Username: "alice"}}, nil)
+
+		cookie := authorize(t, ctrl)
+		c, rec := newContext("/api/oauth/fake/callback?code=the-code&state="+url.QueryEscape(provider.state), "fake", cookie)
+
+		require.NoError(t, ctrl.GetCallback(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"token":"jwt"`)
+
+		cleared := rec.Result().Cookies()
+		require.Len(t, cleared, 1)
+		assert.Equal(t, -1, cleared[0].MaxAge, "the flow cookie is used once")
+	})
+
+	tests := []struct {
+		name  string
+		query func(p *fakeProvider) string
+		// cookie replaces the flow cookie when set
+		cookie func(p *fakeProvider) *http.Cookie
+		err    error
+	}{
+		{
+			name:  "state mismatch",
+			query: func(*fakeProvider) string { return "code=the-code&state=forged" },
+		},
+		{
+			name:   "missing flow cookie",
+			query:  func(p *fakeProvider) string { return "code=the-code&state=" + url.QueryEscape(p.state) },
+			cookie: func(*fakeProvider) *http.Cookie { return nil },
+		},
+		{
+			name:  "flow cookie signed with another key",
+			query: func(p *fakeProvider) string { return "code=the-code&state=" + url.QueryEscape(p.state) },
+			cookie: func(p *fakeProvider) *http.Cookie {
+				other, _ := newTestController(t, p)
+				other.cfg.FlowKey = []byte("another-key")
+				return authorize(t, other)
+			},
+		},
+		{
+			name:  "consent denied",
+			query: func(p *fakeProvider) string { return "error=access_denied&state=" + url.QueryEscape(p.state) },
+		},
+		{
+			name:  "rejected code",
+			query: func(p *fakeProvider) string { return "code=stolen-code&state=" + url.QueryEscape(p.state) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{identity: identity}
+			ctrl, _ := newTestController(t, provider)
+
+			cookie := authorize(t, ctrl)
+			if tt.cookie != nil {
+				cookie = tt.cookie(provider)
+			}
+			var cookies []*http.Cookie
+			if cookie != nil {
+				cookies = append(cookies, cookie)
+			}
+			c, _ := newContext("/api/oauth/fake/callback?"+tt.query(provider), "fake", cookies...)
+
+			assert.ErrorIs(t, ctrl.GetCallback(c), ErrFailed)
+		})
+	}
+}
+
+func TestController_PostLink(t *testing.T) {
+	identity := &oidc.Identity{Provider: "fake", Subject: "s-1", Email: "alice@gmail.com", EmailVerified: true}
+
+	t.Run("the callback links instead of logging in", func(t *testing.T) {
+		provider := &fakeProvider{identity: identity}
+		ctrl, service := newTestController(t, provider)
+		service.EXPECT().LinkIdentity(mock.Anything, authservice.LinkIdentityRequest{
+			UserID:   7,
+			Provider: "fake",
+			Subject:  "s-1",
+			Email:    "alice@gmail.com",
+		}).Return(nil)
+
+		c, rec := newContext("/api/me/identities/fake", "fake")
+		c.Set(middlewares.ContextKeyPrincipal, &model.Principal{UserID: 7, Method: model.AuthMethodJWT})
+		require.NoError(t, ctrl.PostLink(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"authorization_url":"https://idp.example/authorize?state=`)
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+
+		c, rec = newContext("/api/oauth/fake/callback?code=the-code&state="+url.QueryEscape(provider.state), "fake", cookies[0])
+
+		require.NoError(t, ctrl.GetCallback(c))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("requires a signed-in user", func(t *testing.T) {
+		ctrl, _ := newTestController(t, &fakeProvider{})
+
+		c, _ := newContext("/api/me/identities/fake", "fake")
+		c.Set(middlewares.ContextKeyPrincipal, &model.Principal{UserID: 7, Method: model.AuthMethodAPIKey})
+
+		assert.ErrorIs(t, ctrl.PostLink(c), errSignInRequired)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		ctrl, _ := newTestController(t, &fakeProvider{})
+
+		c, _ := newContext("/api/me/identities/other", "other")
+		c.Set(middlewares.ContextKeyPrincipal, &model.Principal{UserID: 7, Method: model.AuthMethodJWT})
+
+		assert.ErrorIs(t, ctrl.PostLink(c), ErrProviderNotFound)
+	})
+}
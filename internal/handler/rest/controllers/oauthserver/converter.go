@@ -0,0 +1,23 @@
+package oauthserver
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// modelToSchemaClient converts domain OAuthClient to schema OAuthClient
+func modelToSchemaClient(c *model.OAuthClient) *schemas.OAuthClient {
+	if c == nil {
+		return nil
+	}
+
+	return &schemas.OAuthClient{
+		ClientID:     c.ClientID,
+		Name:         c.Name,
+		RedirectURIs: c.RedirectURIs,
+		GrantTypes:   c.GrantTypes,
+		Scopes:       c.Scopes,
+		Public:       !c.Confidential,
+		CreatedAt:    c.CreatedAt,
+	}
+}
@@ -0,0 +1,429 @@
+package oauthserver
+
+import (
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	oauthservice "golang-sample/internal/service/oauthserver"
+)
+
+// errSessionRequired is returned when an API key tries to grant consent
+var errSessionRequired = governerrors.NewCode(governerrors.CodeForbidden, "authorizing an application requires a signed-in user")
+
+// Config configures the authorization server endpoints
+type Config struct {
+	// LoginURL is the page of the frontend that signs the user in and asks
+	// for consent. It receives the parameters of the authorization request.
+	LoginURL string
+}
+
+// Controller serves the OAuth 2.0 and OpenID Connect endpoints of the
+// authorization server. Protocol endpoints answer in the format of the
+// specifications rather than the API's response envelope.
+type Controller struct {
+	service oauthservice.Service
+	cfg     Config
+}
+
+// New creates a new authorization server HTTP handler.
+func New(service oauthservice.Service, cfg Config) *Controller {
+	return &Controller{
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// GetDiscovery godoc
+//
+//	@Summary	OpenID Provider metadata
+//	@Tags		oauth2
+//	@Produce	json
+//	@Success	200			{object}	schemas.OpenIDConfiguration
+//	@Router		/.well-known/openid-configuration [get]
+func (h *Controller) GetDiscovery(c echo.Context) error {
+	issuer := h.service.Issuer()
+	return c.JSON(http.StatusOK, schemas.OpenIDConfiguration{
+		Issuer:                                     issuer,
+		AuthorizationEndpoint:                      issuer + "/oauth2/authorize",
+		TokenEndpoint:                              issuer + "/oauth2/token",
+		UserinfoEndpoint:                           issuer + "/oauth2/userinfo",
+		JWKSURI:                                    issuer + "/oauth2/jwks",
+		IntrospectionEndpoint:                      issuer + "/oauth2/introspect",
+		RevocationEndpoint:                         issuer + "/oauth2/revoke",
+		ScopesSupported:                            []string{model.OAuthScopeOpenID, model.OAuthScopeProfile, model.OAuthScopeEmail},
+		ResponseTypesSupported:                     []string{"code"},
+		GrantTypesSupported:                        []string{model.GrantAuthorizationCode, model.GrantClientCredentials},
+		SubjectTypesSupported:                      []string{"public"},
+		IDTokenSigningAlgValuesSupported:           []string{"RS256"},
+		TokenEndpointAuthMethodsSupported:          []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:              []string{"S256"},
+		ClaimsSupported:                            []string{"sub", "iss", "aud", "exp", "iat", "nonce", "preferred_username", "email", "locale"},
+		AuthorizationResponseIssParameterSupported: true,
+	})
+}
+
+// GetJWKS godoc
+//
+//	@Summary	ID token signing keys
+//	@Tags		oauth2
+//	@Produce	json
+//	@Success	200			{object}	schemas.JSONWebKeySet
+//	@Router		/oauth2/jwks [get]
+func (h *Controller) GetJWKS(c echo.Context) error {
+	kid, key := h.service.SigningKey()
+	return c.JSON(http.StatusOK, schemas.JSONWebKeySet{
+		Keys: []schemas.JSONWebKey{{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+// GetAuthorize godoc
+//
+//	@Summary	Authorization endpoint
+//	@Description	Check the client and redirect URI, then send the user to the login page, which asks for consent
+//	@Tags		oauth2
+//	@Param		response_type	query		string	true	"Must be code"
+//	@Param		client_id	query		string	true	"Client ID"
+//	@Param		redirect_uri	query		string	true	"Registered redirect URI"
+//	@Param		scope	query		string	false	"Space-separated scopes"
+//	@Param		state	query		string	false	"Returned to the client unchanged"
+//	@Param		nonce	query		string	false	"Copied into the ID token"
+//	@Param		code_challenge	query		string	true	"PKCE challenge"
+//	@Param		code_challenge_method	query		string	true	"Must be S256"
+//	@Success	302
+//	@Failure	400			{object}	schemas.OAuthError
+//	@Router		/oauth2/authorize [get]
+func (h *Controller) GetAuthorize(c echo.Context) error {
+	_, err := h.service.CheckAuthorization(c.Request().Context(), oauthservice.AuthorizationRequest{
+		ClientID:    c.QueryParam("client_id"),
+		RedirectURI: c.QueryParam("redirect_uri"),
+	})
+	if err != nil {
+		// The redirect URI is not trusted, so the error is shown here
+		return oauthError(c, err)
+	}
+
+	return c.Redirect(http.StatusFound, appendRawQuery(h.cfg.LoginURL, c.QueryString()))
+}
+
+// PostAuthorize godoc
+//
+//	@Summary	Approve or deny an application
+//	@Description	Record the signed-in user's decision on an authorization request and return the redirect back to the client
+//	@Tags		oauth2
+//	@Accept		json
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Param		req	body		schemas.OAuthAuthorizeRequest	true	"Authorization request and decision"
+//	@Success	200			{object}	schemas.Response[schemas.OAuthAuthorizeResponse]
+//	@Router		/api/me/oauth2/authorize [post]
+func (h *Controller) PostAuthorize(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+	// Consent is the user's own; an API key cannot give it on their behalf
+	if principal.Method != model.AuthMethodJWT {
+		return errSessionRequired
+	}
+
+	var req schemas.OAuthAuthorizeRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	redirectURI, err := h.service.Authorize(c.Request().Context(), principal.UserID, oauthservice.AuthorizationRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}, req.Approve)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemas.OAuthAuthorizeResponse{RedirectURI: redirectURI}))
+}
+
+// PostToken godoc
+//
+//	@Summary	Token endpoint
+//	@Description	Exchange an authorization code, or client credentials, for an access token
+//	@Tags		oauth2
+//	@Accept		x-www-form-urlencoded
+//	@Produce	json
+//	@Param		grant_type	formData	string	true	"authorization_code or client_credentials"
+//	@Param		code	formData	string	false	"Authorization code"
+//	@Param		redirect_uri	formData	string	false	"Redirect URI of the authorization request"
+//	@Param		code_verifier	formData	string	false	"PKCE verifier"
+//	@Param		scope	formData	string	false	"Space-separated scopes of a client_credentials request"
+//	@Success	200			{object}	schemas.OAuthTokenResponse
+//	@Failure	400			{object}	schemas.OAuthError
+//	@Failure	401			{object}	schemas.OAuthError
+//	@Router		/oauth2/token [post]
+func (h *Controller) PostToken(c echo.Context) error {
+	noStore(c)
+
+	client, err := clientCredentials(c)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	resp, err := h.service.Token(c.Request().Context(), oauthservice.TokenRequest{
+		Client:       client,
+		GrantType:    c.FormValue("grant_type"),
+		Code:         c.FormValue("code"),
+		RedirectURI:  c.FormValue("redirect_uri"),
+		CodeVerifier: c.FormValue("code_verifier"),
+		Scope:        c.FormValue("scope"),
+	})
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, schemas.OAuthTokenResponse{
+		AccessToken: resp.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(resp.ExpiresIn.Seconds()),
+		Scope:       strings.Join(resp.Scopes, " "),
+		IDToken:     resp.IDToken,
+	})
+}
+
+// PostIntrospect godoc
+//
+//	@Summary	Token introspection
+//	@Description	Describe an access token to a confidential client (RFC 7662)
+//	@Tags		oauth2
+//	@Accept		x-www-form-urlencoded
+//	@Produce	json
+//	@Param		token	formData	string	true	"Access token"
+//	@Success	200			{object}	schemas.OAuthIntrospection
+//	@Failure	401			{object}	schemas.OAuthError
+//	@Router		/oauth2/introspect [post]
+func (h *Controller) PostIntrospect(c echo.Context) error {
+	client, err := clientCredentials(c)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	result, err := h.service.Introspect(c.Request().Context(), client, c.FormValue("token"))
+	if err != nil {
+		return oauthError(c, err)
+	}
+	if !result.Active {
+		return c.JSON(http.StatusOK, schemas.OAuthIntrospection{Active: false})
+	}
+
+	return c.JSON(http.StatusOK, schemas.OAuthIntrospection{
+		Active:    true,
+		Scope:     strings.Join(result.Scopes, " "),
+		ClientID:  result.ClientID,
+		Username:  result.Username,
+		TokenType: "Bearer",
+		Exp:       result.ExpiresAt.Unix(),
+		Iat:       result.IssuedAt.Unix(),
+		Sub:       result.Subject,
+		Iss:       h.service.Issuer(),
+	})
+}
+
+// PostRevoke godoc
+//
+//	@Summary	Token revocation
+//	@Description	Revoke an access token of the calling client (RFC 7009). Unknown tokens are ignored.
+//	@Tags		oauth2
+//	@Accept		x-www-form-urlencoded
+//	@Param		token	formData	string	true	"Access token"
+//	@Success	200
+//	@Failure	401			{object}	schemas.OAuthError
+//	@Router		/oauth2/revoke [post]
+func (h *Controller) PostRevoke(c echo.Context) error {
+	client, err := clientCredentials(c)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	if err := h.service.Revoke(c.Request().Context(), client, c.FormValue("token")); err != nil {
+		return oauthError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// GetUserInfo godoc
+//
+//	@Summary	UserInfo endpoint
+//	@Description	Return the claims about the user that the access token's scopes allow
+//	@Tags		oauth2
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer access token with the openid scope"
+//	@Success	200			{object}	schemas.OAuthUserInfo
+//	@Failure	401			{object}	schemas.OAuthError
+//	@Failure	403			{object}	schemas.OAuthError
+//	@Router		/oauth2/userinfo [get]
+func (h *Controller) GetUserInfo(c echo.Context) error {
+	scheme, token, _ := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+		return c.JSON(http.StatusUnauthorized, schemas.OAuthError{Error: oauthservice.ErrorInvalidToken, ErrorDescription: "a bearer access token is required"})
+	}
+
+	info, err := h.service.UserInfo(c.Request().Context(), strings.TrimSpace(token))
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, schemas.OAuthUserInfo{
+		Sub:               info.Subject,
+		PreferredUsername: info.PreferredUsername,
+		Email:             info.Email,
+		Locale:            info.Locale,
+	})
+}
+
+// PostClient godoc
+//
+//	@Summary	Register OAuth client
+//	@Description	Register an application that signs users in with this service. The secret is only returned in this response.
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		X-Admin-Token	header		string	true	"Admin token"
+//	@Param		req	body		schemas.RegisterOAuthClientRequest	true	"Client registration"
+//	@Success	201			{object}	schemas.Response[schemas.RegisteredOAuthClient]
+//	@Router		/admin/oauth2/clients [post]
+func (h *Controller) PostClient(c echo.Context) error {
+	var req schemas.RegisterOAuthClientRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	registered, err := h.service.RegisterClient(c.Request().Context(), oauthservice.RegisterClientRequest{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+		Public:       req.Public,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, schemas.NewResponse(schemas.RegisteredOAuthClient{
+		OAuthClient:  *modelToSchemaClient(registered.Client),
+		ClientSecret: registered.Secret,
+	}))
+}
+
+// GetClients godoc
+//
+//	@Summary	List OAuth clients
+//	@Tags		admin
+//	@Produce	json
+//	@Param		X-Admin-Token	header		string	true	"Admin token"
+//	@Success	200			{object}	schemas.Response[[]schemas.OAuthClient]
+//	@Router		/admin/oauth2/clients [get]
+func (h *Controller) GetClients(c echo.Context) error {
+	clients, err := h.service.ListClients(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.OAuthClient, len(clients))
+	for i, client := range clients {
+		result[i] = *modelToSchemaClient(client)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// clientCredentials reads client authentication from HTTP Basic, whose
+// parts are form-encoded (RFC 6749 section 2.3.1), or from the form body.
+// A client must not use both.
+func clientCredentials(c echo.Context) (oauthservice.ClientCredentials, error) {
+	formID, formSecret := c.FormValue("client_id"), c.FormValue("client_secret")
+
+	user, password, ok := c.Request().BasicAuth()
+	if !ok {
+		return oauthservice.ClientCredentials{ClientID: formID, ClientSecret: formSecret}, nil
+	}
+	if formSecret != "" {
+		return oauthservice.ClientCredentials{}, &oauthservice.Error{Code: oauthservice.ErrorInvalidRequest, Description: "use a single client authentication method"}
+	}
+
+	clientID, err1 := url.QueryUnescape(user)
+	secret, err2 := url.QueryUnescape(password)
+	if err1 != nil || err2 != nil || (formID != "" && formID != clientID) {
+		return oauthservice.ClientCredentials{}, &oauthservice.Error{Code: oauthservice.ErrorInvalidClient, Description: "malformed client credentials"}
+	}
+	return oauthservice.ClientCredentials{ClientID: clientID, ClientSecret: secret}, nil
+}
+
+// oauthError writes an OAuth error in the protocol's format and status;
+// other errors are left to the API's error handler
+func oauthError(c echo.Context, err error) error {
+	var oauthErr *oauthservice.Error
+	if !errors.As(err, &oauthErr) {
+		return err
+	}
+
+	status := http.StatusBadRequest
+	switch oauthErr.Code {
+	case oauthservice.ErrorInvalidClient:
+		status = http.StatusUnauthorized
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth2"`)
+	case oauthservice.ErrorInvalidToken:
+		status = http.StatusUnauthorized
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+	case oauthservice.ErrorInsufficientScope:
+		status = http.StatusForbidden
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="insufficient_scope", scope="openid"`)
+	}
+
+	return c.JSON(status, schemas.OAuthError{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
+
+// noStore forbids caching of responses carrying tokens (RFC 6749 section 5.1)
+func noStore(c echo.Context) {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+}
+
+// appendRawQuery adds an encoded query to uri, which may have one already
+func appendRawQuery(uri, rawQuery string) string {
+	if rawQuery == "" {
+		return uri
+	}
+	if strings.Contains(uri, "?") {
+		return uri + "&" + rawQuery
+	}
+	return uri + "?" + rawQuery
+}
@@ -0,0 +1,344 @@
+package oauthserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	oauthservice "golang-sample/internal/service/oauthserver"
+	apiValidator "golang-sample/internal/validator"
+)
+
+func newFormContext(path string, form url.Values) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) schemas.OAuthError {
+	t.Helper()
+	var resp schemas.OAuthError
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestController_GetAuthorize(t *testing.T) {
+	query := "response_type=code&client_id=app&redirect_uri=https%3A%2F%2Fapp.example%2Fcb&state=xyz"
+
+	t.Run("sends the user to the login page with the request", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().CheckAuthorization(mock.Anything, oauthservice.AuthorizationRequest{
+			ClientID:    "app",
+			RedirectURI: "https://app.example/cb",
+		}).Return(&model.OAuthClient{ClientID: "app"}, nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+query, nil), rec)
+
+		require.NoError(t, New(service, Config{LoginURL: "https://web.example/consent"}).GetAuthorize(c))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://web.example/consent?"+query, rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("shows errors instead of redirecting to an untrusted URI", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().CheckAuthorization(mock.Anything, mock.Anything).
+			Return(nil, &oauthservice.Error{Code: oauthservice.ErrorInvalidRequest, Description: "unknown client_id"})
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+query, nil), rec)
+
+		require.NoError(t, New(service, Config{LoginURL: "https://web.example/consent"}).GetAuthorize(c))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, oauthservice.ErrorInvalidRequest, decodeError(t, rec).Error)
+	})
+}
+
+func TestController_PostAuthorize(t *testing.T) {
+	body := schemas.OAuthAuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "app",
+		RedirectURI:         "https://app.example/cb",
+		Scope:               "openid",
+		CodeChallenge:       "challenge",
+		CodeChallengeMethod: "S256",
+		Approve:             true,
+	}
+	newContext := func(principal *model.Principal) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		reqBody, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/me/oauth2/authorize", bytes.NewReader(reqBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(middlewares.ContextKeyPrincipal, principal)
+		return c, rec
+	}
+
+	t.Run("returns the redirect of the user's decision", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().Authorize(mock.Anything, uint(42), oauthservice.AuthorizationRequest{
+			ResponseType:        "code",
+			ClientID:            "app",
+			RedirectURI:         "https://app.example/cb",
+			Scope:               "openid",
+			CodeChallenge:       "challenge",
+			CodeChallengeMethod: "S256",
+		}, true).Return("https://app.example/cb?code=abc", nil)
+
+		c, rec := newContext(&model.Principal{UserID: 42, Method: model.AuthMethodJWT})
+
+		require.NoError(t, New(service, Config{}).PostAuthorize(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp schemas.Response[schemas.OAuthAuthorizeResponse]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "https://app.example/cb?code=abc", resp.Data.RedirectURI)
+	})
+
+	t.Run("rejects API keys", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+
+		c, _ := newContext(&model.Principal{UserID: 42, Method: model.AuthMethodAPIKey})
+
+		assert.ErrorIs(t, New(service, Config{}).PostAuthorize(c), errSessionRequired)
+	})
+}
+
+func TestController_PostToken(t *testing.T) {
+	t.Run("authenticates the client with HTTP Basic", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().Token(mock.Anything, oauthservice.TokenRequest{
+			Client:       oauthservice.ClientCredentials{ClientID: "app", ClientSecret: "s3cr:t"},
+			GrantType:    model.GrantAuthorizationCode,
+			Code:         "abc",
+			RedirectURI:  "https://app.example/cb",
+			CodeVerifier: "verifier",
+		}).Return(&oauthservice.TokenResponse{
+			AccessToken: "oat_token",
+			ExpiresIn:   time.Hour,
+			Scopes:      []string{"openid", "email"},
+			IDToken:     "id.token.sig",
+		}, nil)
+
+		c, rec := newFormContext("/oauth2/token", url.Values{
+			"grant_type":    {model.GrantAuthorizationCode},
+			"code":          {"abc"},
+			"redirect_uri":  {"https://app.example/cb"},
+			"code_verifier": {"verifier"},
+		})
+		c.Request().SetBasicAuth("app", url.QueryEscape("s3cr:t"))
+
+		require.NoError(t, New(service, Config{}).PostToken(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		var resp schemas.OAuthTokenResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, schemas.OAuthTokenResponse{
+			AccessToken: "oat_token",
+			TokenType:   "Bearer",
+			ExpiresIn:   3600,
+			Scope:       "openid email",
+			IDToken:     "id.token.sig",
+		}, resp)
+	})
+
+	t.Run("rejects two client authentication methods", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+
+		c, rec := newFormContext("/oauth2/token", url.Values{
+			"grant_type":    {model.GrantClientCredentials},
+			"client_secret": {"other"},
+		})
+		c.Request().SetBasicAuth("app", "secret")
+
+		require.NoError(t, New(service, Config{}).PostToken(c))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, oauthservice.ErrorInvalidRequest, decodeError(t, rec).Error)
+	})
+
+	t.Run("answers invalid_client with 401", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().Token(mock.Anything, mock.Anything).
+			Return(nil, &oauthservice.Error{Code: oauthservice.ErrorInvalidClient, Description: "client authentication failed"})
+
+		c, rec := newFormContext("/oauth2/token", url.Values{
+			"grant_type":    {model.GrantClientCredentials},
+			"client_id":     {"app"},
+			"client_secret": {"wrong"},
+		})
+
+		require.NoError(t, New(service, Config{}).PostToken(c))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get(echo.HeaderWWWAuthenticate))
+		assert.Equal(t, oauthservice.ErrorInvalidClient, decodeError(t, rec).Error)
+	})
+}
+
+func TestController_PostIntrospect(t *testing.T) {
+	t.Run("describes an active token", func(t *testing.T) {
+		issuedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().Issuer().Return("https://id.example")
+		service.EXPECT().Introspect(mock.Anything, oauthservice.ClientCredentials{ClientID: "rs", ClientSecret: "secret"}, "oat_token").
+			Return(&oauthservice.Introspection{
+				Active:    true,
+				ClientID:  "app",
+				Scopes:    []string{"openid"},
+				Subject:   "42",
+				Username:  "alice",
+				ExpiresAt: issuedAt.Add(time.Hour),
+				IssuedAt:  issuedAt,
+			}, nil)
+
+		c, rec := newFormContext("/oauth2/introspect", url.Values{"token": {"oat_token"}})
+		c.Request().SetBasicAuth("rs", "secret")
+
+		require.NoError(t, New(service, Config{}).PostIntrospect(c))
+
+		var resp schemas.OAuthIntrospection
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, schemas.OAuthIntrospection{
+			Active:    true,
+			Scope:     "openid",
+			ClientID:  "app",
+			Username:  "alice",
+			TokenType: "Bearer",
+			Exp:       issuedAt.Add(time.Hour).Unix(),
+			Iat:       issuedAt.Unix(),
+			Sub:       "42",
+			Iss:       "https://id.example",
+		}, resp)
+	})
+
+	t.Run("describes an inactive token with active alone", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().Introspect(mock.Anything, mock.Anything, "oat_gone").
+			Return(&oauthservice.Introspection{Active: false}, nil)
+
+		c, rec := newFormContext("/oauth2/introspect", url.Values{"token": {"oat_gone"}})
+		c.Request().SetBasicAuth("rs", "secret")
+
+		require.NoError(t, New(service, Config{}).PostIntrospect(c))
+
+		assert.JSONEq(t, `{"active":false}`, rec.Body.String())
+	})
+}
+
+func TestController_GetUserInfo(t *testing.T) {
+	newContext := func(authorization string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/oauth2/userinfo", nil)
+		if authorization != "" {
+			req.Header.Set(echo.HeaderAuthorization, authorization)
+		}
+		rec := httptest.NewRecorder()
+		return e.NewContext(req, rec), rec
+	}
+
+	t.Run("returns the claims", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().UserInfo(mock.Anything, "oat_token").
+			Return(&oauthservice.UserInfo{Subject: "42", Email: "alice@example.com"}, nil)
+
+		c, rec := newContext("Bearer oat_token")
+
+		require.NoError(t, New(service, Config{}).GetUserInfo(c))
+
+		assert.JSONEq(t, `{"sub":"42","email":"alice@example.com"}`, rec.Body.String())
+	})
+
+	t.Run("requires a bearer token", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+
+		c, rec := newContext("")
+
+		require.NoError(t, New(service, Config{}).GetUserInfo(c))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+	})
+
+	t.Run("answers insufficient_scope with 403", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().UserInfo(mock.Anything, "oat_token").
+			Return(nil, &oauthservice.Error{Code: oauthservice.ErrorInsufficientScope, Description: "the access token lacks the openid scope"})
+
+		c, rec := newContext("Bearer oat_token")
+
+		require.NoError(t, New(service, Config{}).GetUserInfo(c))
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), "insufficient_scope")
+	})
+}
+
+func TestController_PostClient(t *testing.T) {
+	t.Run("returns the secret once", func(t *testing.T) {
+		service := serviceMocks.NewMockOAuthServerService(t)
+		service.EXPECT().RegisterClient(mock.Anything, oauthservice.RegisterClientRequest{
+			Name:         "Wiki",
+			RedirectURIs: []string{"https://wiki.example/cb"},
+			GrantTypes:   []string{model.GrantAuthorizationCode},
+			Scopes:       []string{"openid", "email"},
+		}).Return(&oauthservice.RegisteredClient{
+			Client: &model.OAuthClient{
+				ClientID:     "0123abcd",
+				Name:         "Wiki",
+				RedirectURIs: []string{"https://wiki.example/cb"},
+				GrantTypes:   []string{model.GrantAuthorizationCode},
+				Scopes:       []string{"openid", "email"},
+				Confidential: true,
+			},
+			Secret: "ocs_secret",
+		}, nil)
+
+		e := echo.New()
+		e.Validator = apiValidator.NewCustomValidator()
+		reqBody, err := json.Marshal(schemas.RegisterOAuthClientRequest{
+			Name:         "Wiki",
+			RedirectURIs: []string{"https://wiki.example/cb"},
+			GrantTypes:   []string{model.GrantAuthorizationCode},
+			Scopes:       []string{"openid", "email"},
+		})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/admin/oauth2/clients", bytes.NewReader(reqBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, New(service, Config{}).PostClient(e.NewContext(req, rec)))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var resp schemas.Response[schemas.RegisteredOAuthClient]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "0123abcd", resp.Data.ClientID)
+		assert.Equal(t, "ocs_secret", resp.Data.ClientSecret)
+		assert.False(t, resp.Data.Public)
+	})
+}
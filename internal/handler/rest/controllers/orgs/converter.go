@@ -0,0 +1,68 @@
+package orgs
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// modelToSchemaOrganization converts domain Organization to schema Organization
+func modelToSchemaOrganization(o *model.Organization) *schemas.Organization {
+	if o == nil {
+		return nil
+	}
+
+	return &schemas.Organization{
+		ID:        o.ID,
+		Name:      o.Name,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+// modelToSchemaMembership converts a domain Membership with its
+// Organization to schema Membership
+func modelToSchemaMembership(m *model.Membership) *schemas.Membership {
+	if m == nil {
+		return nil
+	}
+
+	return &schemas.Membership{
+		Organization: modelToSchemaOrganization(m.Organization),
+		Role:         string(m.Role),
+		JoinedAt:     m.CreatedAt,
+	}
+}
+
+// modelToSchemaMember converts a domain Membership with its User to schema
+// Member
+func modelToSchemaMember(m *model.Membership) *schemas.Member {
+	if m == nil {
+		return nil
+	}
+
+	member := &schemas.Member{
+		UserID:   m.UserID,
+		Role:     string(m.Role),
+		JoinedAt: m.CreatedAt,
+	}
+	if m.User != nil {
+		member.Username = m.User.Username
+		member.Email = m.User.Email
+	}
+	return member
+}
+
+// modelToSchemaInvitation converts domain Invitation to schema Invitation
+func modelToSchemaInvitation(i *model.Invitation) *schemas.Invitation {
+	if i == nil {
+		return nil
+	}
+
+	return &schemas.Invitation{
+		ID:        i.ID,
+		Email:     i.Email,
+		Role:      string(i.Role),
+		InvitedBy: i.InvitedBy,
+		ExpiresAt: i.ExpiresAt,
+		CreatedAt: i.CreatedAt,
+	}
+}
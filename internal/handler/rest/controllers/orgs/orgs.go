@@ -0,0 +1,367 @@
+package orgs
+
+import (
+	"net/http"
+	"strconv"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	orgservice "golang-sample/internal/service/organization"
+)
+
+// errTokenRequired is returned when an API key asks for an organization token
+var errTokenRequired = governerrors.NewCode(governerrors.CodeForbidden, "organization tokens require a signed-in user; API keys send X-Org-ID instead")
+
+// Controller handles organizations, their members and invitations. The
+// /api/org routes act in the organization resolved by the Tenant middleware.
+type Controller struct {
+	service orgservice.Service
+	auth    authservice.Service
+}
+
+// New creates a new organizations HTTP handler.
+func New(service orgservice.Service, auth authservice.Service) *Controller {
+	return &Controller{
+		service: service,
+		auth:    auth,
+	}
+}
+
+// PostOrganization godoc
+//
+//	@Summary	Create organization
+//	@Description	Create an organization with the authenticated user as its owner
+//	@Tags		organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the orgs:write scope"
+//	@Param		req	body		schemas.CreateOrganizationRequest	true	"Create organization request"
+//	@Success	201			{object}	schemas.Response[schemas.Organization]
+//	@Router		/api/orgs [post]
+func (h *Controller) PostOrganization(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	var req schemas.CreateOrganizationRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	org, err := h.service.Create(c.Request().Context(), principal, req.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, schemas.NewResponse(modelToSchemaOrganization(org)))
+}
+
+// GetMyOrganizations godoc
+//
+//	@Summary	List own organizations
+//	@Description	List the organizations the authenticated user is a member of, with the user's role
+//	@Tags		organizations
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the orgs:read scope"
+//	@Success	200			{object}	schemas.Response[[]schemas.Membership]
+//	@Router		/api/me/orgs [get]
+func (h *Controller) GetMyOrganizations(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	memberships, err := h.service.ListForUser(c.Request().Context(), principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.Membership, len(memberships))
+	for i, membership := range memberships {
+		result[i] = *modelToSchemaMembership(membership)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// PostOrgToken godoc
+//
+//	@Summary	Issue organization token
+//	@Description	Reissue the caller's token to act in an organization it is a member of. The token keeps the login session and expiry of the one it came from.
+//	@Tags		organizations
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Param		id	path		int	true	"Organization ID"
+//	@Success	200			{object}	schemas.Response[schemas.OrgToken]
+//	@Router		/api/orgs/{id}/token [post]
+func (h *Controller) PostOrgToken(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+	claims, ok := middlewares.Claims(c)
+	if !ok {
+		return errTokenRequired
+	}
+
+	orgID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return orgservice.ErrAccessDenied
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.service.Membership(ctx, uint(orgID), principal.UserID); err != nil {
+		return err
+	}
+	resp, err := h.auth.OrgToken(ctx, claims, uint(orgID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemas.OrgToken{
+		Token:     resp.Token,
+		OrgID:     uint(orgID),
+		ExpiresAt: resp.ExpiresAt,
+	}))
+}
+
+// GetOrganization godoc
+//
+//	@Summary	Get current organization
+//	@Description	Get the organization of the request
+//	@Tags		organizations
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the orgs:read scope"
+//	@Param		X-Org-ID	header		int	false	"Organization ID, unless the token names one"
+//	@Success	200			{object}	schemas.Response[schemas.Organization]
+//	@Router		/api/org [get]
+func (h *Controller) GetOrganization(c echo.Context) error {
+	org, err := h.service.Get(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(modelToSchemaOrganization(org)))
+}
+
+// GetMembers godoc
+//
+//	@Summary	List members
+//	@Description	List the members of the organization of the request
+//	@Tags		organizations
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the orgs:read scope"
+//	@Param		X-Org-ID	header		int	false	"Organization ID, unless the token names one"
+//	@Success	200			{object}	schemas.Response[[]schemas.Member]
+//	@Router		/api/org/members [get]
+func (h *Controller) GetMembers(c echo.Context) error {
+	members, err := h.service.ListMembers(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.Member, len(members))
+	for i, member := range members {
+		result[i] = *modelToSchemaMember(member)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// PatchMember godoc
+//
+//	@Summary	Change member role
+//	@Description	Change the role of a member. Admins manage admins and members; only owners grant or take away ownership, and the last owner stays owner.
+//	@Tags		organizations
+//	@Accept		json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the orgs:write scope"
+//	@Param		X-Org-ID	header		int	false	"Organization ID, unless the token names one"
+//	@Param		user_id	path		int	true	"User ID of the member"
+//	@Param		req	body		schemas.UpdateMemberRequest	true	"New role"
+//	@Success	204
+//	@Router		/api/org/members/{user_id} [patch]
+func (h *Controller) PatchMember(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return orgservice.ErrMemberNotFound
+	}
+
+	var req schemas.UpdateMemberRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	if err := h.service.ChangeRole(c.Request().Context(), principal, uint(userID), model.OrgRole(req.Role)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteMember godoc
+//
+//	@Summary	Remove member
+//	@Description	Remove a member from the organization, or leave it by naming oneself. The last owner cannot leave.
+//	@Tags		organizations
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the orgs:write scope"
+//	@Param		X-Org-ID	header		int	false	"Organization ID, unless the token names one"
+//	@Param		user_id	path		int	true	"User ID of the member"
+//	@Success	204
+//	@Router		/api/org/members/{user_id} [delete]
+func (h *Controller) DeleteMember(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return orgservice.ErrMemberNotFound
+	}
+
+	if err := h.service.RemoveMember(c.Request().Context(), principal, uint(userID)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PostInvitation godoc
+//
+//	@Summary	Invite member
+//	@Description	Email an invitation to join the organization with a role up to the caller's. A new invitation to the same email replaces a pending one.
+//	@Tags		organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token, or API key with the orgs:write scope, of an admin or owner"
+//	@Param		X-Org-ID	header		int	false	"Organization ID, unless the token names one"
+//	@Param		req	body		schemas.CreateInvitationRequest	true	"Invitation"
+//	@Success	201			{object}	schemas.Response[schemas.Invitation]
+//	@Router		/api/org/invitations [post]
+func (h *Controller) PostInvitation(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	var req schemas.CreateInvitationRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	invitation, err := h.service.Invite(c.Request().Context(), orgservice.InviteRequest{
+		Inviter: principal,
+		Email:   req.Email,
+		Role:    model.OrgRole(req.Role),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, schemas.NewResponse(modelToSchemaInvitation(invitation)))
+}
+
+// GetInvitations godoc
+//
+//	@Summary	List invitations
+//	@Description	List the pending invitations of the organization
+//	@Tags		organizations
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token, or API key with the orgs:read scope, of an admin or owner"
+//	@Param		X-Org-ID	header		int	false	"Organization ID, unless the token names one"
+//	@Success	200			{object}	schemas.Response[[]schemas.Invitation]
+//	@Router		/api/org/invitations [get]
+func (h *Controller) GetInvitations(c echo.Context) error {
+	invitations, err := h.service.ListInvitations(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.Invitation, len(invitations))
+	for i, invitation := range invitations {
+		result[i] = *modelToSchemaInvitation(invitation)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// DeleteInvitation godoc
+//
+//	@Summary	Revoke invitation
+//	@Description	Revoke a pending invitation; its token stops working
+//	@Tags		organizations
+//	@Param		Authorization	header		string	true	"Bearer token, or API key with the orgs:write scope, of an admin or owner"
+//	@Param		X-Org-ID	header		int	false	"Organization ID, unless the token names one"
+//	@Param		id	path		int	true	"Invitation ID"
+//	@Success	204
+//	@Router		/api/org/invitations/{id} [delete]
+func (h *Controller) DeleteInvitation(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return orgservice.ErrInvitationNotFound
+	}
+
+	if err := h.service.RevokeInvitation(c.Request().Context(), principal, uint(id)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PostInvitationAcceptance godoc
+//
+//	@Summary	Accept invitation
+//	@Description	Join the organization of an invitation addressed to the authenticated user's email
+//	@Tags		organizations
+//	@Accept		json
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token or API key with the orgs:write scope"
+//	@Param		req	body		schemas.AcceptInvitationRequest	true	"Token from the invitation email"
+//	@Success	201			{object}	schemas.Response[schemas.Membership]
+//	@Router		/api/invitations/accept [post]
+func (h *Controller) PostInvitationAcceptance(c echo.Context) error {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return governerrors.ErrUnauthorized
+	}
+
+	var req schemas.AcceptInvitationRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	membership, err := h.service.AcceptInvitation(c.Request().Context(), principal, req.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, schemas.NewResponse(modelToSchemaMembership(membership)))
+}
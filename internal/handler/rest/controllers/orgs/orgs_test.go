@@ -0,0 +1,117 @@
+package orgs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	orgservice "golang-sample/internal/service/organization"
+	apiValidator "golang-sample/internal/validator"
+)
+
+var testPrincipal = &model.Principal{UserID: 42, Method: model.AuthMethodJWT}
+
+func newEchoContext(method, path string, principal *model.Principal) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set(middlewares.ContextKeyPrincipal, principal)
+	return c, rec
+}
+
+func TestController_PostOrgToken(t *testing.T) {
+	claims := &schemas.JwtClaims{ID: "42", SessionID: "family"}
+
+	t.Run("issues a token for a member", func(t *testing.T) {
+		service := serviceMocks.NewMockOrganizationService(t)
+		service.EXPECT().Membership(mock.Anything, uint(7), uint(42)).Return(&model.Membership{OrgID: 7, UserID: 42}, nil)
+		auth := serviceMocks.NewMockService(t)
+		expiresAt := time.Now().Add(time.Hour).UTC()
+		auth.EXPECT().OrgToken(mock.Anything, claims, uint(7)).Return(&authservice.LoginResponse{Token: "org-token", ExpiresAt: expiresAt}, nil)
+
+		c, rec := newEchoContext(http.MethodPost, "/api/orgs/7/token", testPrincipal)
+		c.Set(middlewares.ContextKeyClaims, claims)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		require.NoError(t, New(service, auth).PostOrgToken(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp schemas.Response[schemas.OrgToken]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "org-token", resp.Data.Token)
+		assert.Equal(t, uint(7), resp.Data.OrgID)
+	})
+
+	t.Run("refuses non-members", func(t *testing.T) {
+		service := serviceMocks.NewMockOrganizationService(t)
+		service.EXPECT().Membership(mock.Anything, uint(8), uint(42)).Return(nil, orgservice.ErrAccessDenied)
+
+		c, _ := newEchoContext(http.MethodPost, "/api/orgs/8/token", testPrincipal)
+		c.Set(middlewares.ContextKeyClaims, claims)
+		c.SetParamNames("id")
+		c.SetParamValues("8")
+
+		err := New(service, serviceMocks.NewMockService(t)).PostOrgToken(c)
+		assert.ErrorIs(t, err, orgservice.ErrAccessDenied)
+	})
+
+	t.Run("API keys use the header instead", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodPost, "/api/orgs/7/token",
+			&model.Principal{UserID: 42, Method: model.AuthMethodAPIKey, Scopes: []model.Scope{}})
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		err := New(serviceMocks.NewMockOrganizationService(t), serviceMocks.NewMockService(t)).PostOrgToken(c)
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeForbidden))
+	})
+}
+
+func TestController_GetMembers(t *testing.T) {
+	service := serviceMocks.NewMockOrganizationService(t)
+	service.EXPECT().ListMembers(mock.Anything).Return([]*model.Membership{
+		{OrgID: 7, UserID: 42, Role: model.OrgRoleOwner, User: &model.User{ID: 42, Username: "alice", Email: "alice@example.com"}},
+	}, nil)
+
+	c, rec := newEchoContext(http.MethodGet, "/api/org/members", testPrincipal)
+
+	require.NoError(t, New(service, serviceMocks.NewMockService(t)).GetMembers(c))
+
+	var resp schemas.Response[[]schemas.Member]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, schemas.Member{UserID: 42, Username: "alice", Email: "alice@example.com", Role: "owner"}, resp.Data[0])
+}
+
+func TestController_PatchMember(t *testing.T) {
+	principal := &model.Principal{UserID: 42, Method: model.AuthMethodJWT, OrgID: 7, OrgRole: model.OrgRoleAdmin}
+	service := serviceMocks.NewMockOrganizationService(t)
+	service.EXPECT().ChangeRole(mock.Anything, principal, uint(43), model.OrgRoleAdmin).Return(nil)
+
+	e := echo.New()
+	e.Validator = apiValidator.NewCustomValidator()
+	req := httptest.NewRequest(http.MethodPatch, "/api/org/members/43", strings.NewReader(`{"role":"admin"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(middlewares.ContextKeyPrincipal, principal)
+	c.SetParamNames("user_id")
+	c.SetParamValues("43")
+
+	require.NoError(t, New(service, serviceMocks.NewMockService(t)).PatchMember(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,42 @@
+package passkeys
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// modelToSchemaPasskey converts domain Passkey to schema Passkey
+func modelToSchemaPasskey(p *model.Passkey) *schemas.Passkey {
+	if p == nil {
+		return nil
+	}
+
+	transports := p.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	return &schemas.Passkey{
+		ID:             p.ID,
+		Name:           p.Name,
+		Transports:     transports,
+		BackupEligible: p.BackupEligible,
+		CreatedAt:      p.CreatedAt,
+		LastUsedAt:     p.LastUsedAt,
+	}
+}
+
+// modelToSchemaUser converts domain User to schema User
+func modelToSchemaUser(u *model.User) *schemas.User {
+	if u == nil {
+		return nil
+	}
+
+	return &schemas.User{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Locale:    u.Locale,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
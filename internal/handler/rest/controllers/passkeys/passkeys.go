@@ -0,0 +1,211 @@
+package passkeys
+
+import (
+	"net/http"
+	"strconv"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	passkeyservice "golang-sample/internal/service/passkey"
+)
+
+// errSessionRequired is returned when an API key tries to manage passkeys,
+// which would let a leaked key add a way in
+var errSessionRequired = governerrors.NewCode(governerrors.CodeForbidden, "managing passkeys requires a signed-in user")
+
+// Controller handles passkey registration, management and login.
+type Controller struct {
+	service passkeyservice.Service
+	auth    authservice.Service
+}
+
+// New creates a new passkeys HTTP handler.
+func New(service passkeyservice.Service, auth authservice.Service) *Controller {
+	return &Controller{
+		service: service,
+		auth:    auth,
+	}
+}
+
+// PostRegisterBegin godoc
+//
+//	@Summary	Start passkey registration
+//	@Description	Issue the options to create a passkey for the signed-in user with navigator.credentials.create
+//	@Tags		passkeys
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Success	200			{object}	schemas.Response[schemas.PasskeyCreationOptions]
+//	@Router		/api/me/passkeys/register/begin [post]
+func (h *Controller) PostRegisterBegin(c echo.Context) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+
+	opts, err := h.service.BeginRegistration(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemas.PasskeyCreationOptions{PublicKey: opts}))
+}
+
+// PostRegisterFinish godoc
+//
+//	@Summary	Finish passkey registration
+//	@Description	Verify and store the passkey created with the options of the registration start
+//	@Tags		passkeys
+//	@Accept		json
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Param		req	body		schemas.FinishPasskeyRegistrationRequest	true	"Created credential"
+//	@Success	201			{object}	schemas.Response[schemas.Passkey]
+//	@Router		/api/me/passkeys/register/finish [post]
+func (h *Controller) PostRegisterFinish(c echo.Context) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req schemas.FinishPasskeyRegistrationRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	created, err := h.service.FinishRegistration(c.Request().Context(), passkeyservice.FinishRegistrationRequest{
+		UserID:   userID,
+		Name:     req.Name,
+		Response: req.Credential,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, schemas.NewResponse(modelToSchemaPasskey(created)))
+}
+
+// GetPasskeys godoc
+//
+//	@Summary	List passkeys
+//	@Description	List the passkeys of the signed-in user
+//	@Tags		passkeys
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Success	200			{object}	schemas.Response[[]schemas.Passkey]
+//	@Router		/api/me/passkeys [get]
+func (h *Controller) GetPasskeys(c echo.Context) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+
+	passkeys, err := h.service.List(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.Passkey, len(passkeys))
+	for i, p := range passkeys {
+		result[i] = *modelToSchemaPasskey(p)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// DeletePasskey godoc
+//
+//	@Summary	Delete passkey
+//	@Description	Delete a passkey of the signed-in user; it can no longer log in
+//	@Tags		passkeys
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Param		id	path		int	true	"Passkey ID"
+//	@Success	204
+//	@Router		/api/me/passkeys/{id} [delete]
+func (h *Controller) DeletePasskey(c echo.Context) error {
+	userID, err := sessionUser(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return passkeyservice.ErrNotFound
+	}
+
+	if err := h.service.Delete(c.Request().Context(), userID, uint(id)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PostLoginBegin godoc
+//
+//	@Summary	Start passkey login
+//	@Description	Issue the options to sign in with any passkey of this site with navigator.credentials.get
+//	@Tags		auth
+//	@Produce	json
+//	@Success	200			{object}	schemas.Response[schemas.PasskeyRequestOptions]
+//	@Router		/api/login/passkey/begin [post]
+func (h *Controller) PostLoginBegin(c echo.Context) error {
+	opts, err := h.service.BeginLogin(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemas.PasskeyRequestOptions{PublicKey: opts}))
+}
+
+// PostLoginFinish godoc
+//
+//	@Summary	Log in with a passkey
+//	@Description	Exchange a passkey assertion made with the options of the login start for an access token
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		req	body		schemas.PasskeyLoginRequest	true	"Signed credential"
+//	@Success	200			{object}	schemas.Response[schemas.LoginResponse]
+//	@Router		/api/login/passkey/finish [post]
+func (h *Controller) PostLoginFinish(c echo.Context) error {
+	var req schemas.PasskeyLoginRequest
+
+	if err := c.Bind(&req); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInvalid, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	modelResp, err := h.auth.PasskeyLogin(c.Request().Context(), req.Credential)
+	if err != nil {
+		return err
+	}
+
+	schemaResp := &schemas.LoginResponse{
+		Token:     modelResp.Token,
+		User:      modelToSchemaUser(modelResp.User),
+		ExpiresAt: modelResp.ExpiresAt,
+	}
+
+	return c.JSON(http.StatusOK, schemas.NewResponse(schemaResp))
+}
+
+// sessionUser returns the ID of the user signed in with a JWT
+func sessionUser(c echo.Context) (uint, error) {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return 0, governerrors.ErrUnauthorized
+	}
+	if principal.Method != model.AuthMethodJWT {
+		return 0, errSessionRequired
+	}
+	return principal.UserID, nil
+}
@@ -0,0 +1,187 @@
+package passkeys
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	passkeyservice "golang-sample/internal/service/passkey"
+	apiValidator "golang-sample/internal/validator"
+	"golang-sample/pkg/webauthn"
+)
+
+var testPrincipal = &model.Principal{UserID: 42, Method: model.AuthMethodJWT}
+
+func newEchoContext(method, path string, body interface{}, principal *model.Principal) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = apiValidator.NewCustomValidator()
+
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if principal != nil {
+		c.Set(middlewares.ContextKeyPrincipal, principal)
+	}
+	return c, rec
+}
+
+func TestController_Registration(t *testing.T) {
+	t.Run("begin returns the options under publicKey", func(t *testing.T) {
+		service := serviceMocks.NewMockPasskeyService(t)
+		service.EXPECT().BeginRegistration(mock.Anything, uint(42)).
+			Return(&webauthn.CreationOptions{Challenge: "Y2hhbGxlbmdl"}, nil)
+
+		c, rec := newEchoContext(http.MethodPost, "/api/me/passkeys/register/begin", nil, testPrincipal)
+
+		require.NoError(t, New(service, nil).PostRegisterBegin(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp schemas.Response[schemas.PasskeyCreationOptions]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "Y2hhbGxlbmdl", resp.Data.PublicKey.Challenge)
+	})
+
+	t.Run("finish stores the credential", func(t *testing.T) {
+		service := serviceMocks.NewMockPasskeyService(t)
+		service.EXPECT().FinishRegistration(mock.Anything, passkeyservice.FinishRegistrationRequest{
+			UserID:   42,
+			Name:     "Laptop",
+			Response: []byte(`{"id":"abc"}`),
+		}).Return(&model.Passkey{ID: 5, Name: "Laptop", Transports: []string{"internal"}}, nil)
+
+		c, rec := newEchoContext(http.MethodPost, "/api/me/passkeys/register/finish", schemas.FinishPasskeyRegistrationRequest{
+			Name:       "Laptop",
+			Credential: json.RawMessage(`{"id":"abc"}`),
+		}, testPrincipal)
+
+		require.NoError(t, New(service, nil).PostRegisterFinish(c))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var resp schemas.Response[schemas.Passkey]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, uint(5), resp.Data.ID)
+		assert.Equal(t, []string{"internal"}, resp.Data.Transports)
+	})
+
+	t.Run("finish requires the credential", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodPost, "/api/me/passkeys/register/finish", map[string]string{"name": "Laptop"}, testPrincipal)
+
+		err := New(serviceMocks.NewMockPasskeyService(t), nil).PostRegisterFinish(c)
+
+		var validationErr *apiValidator.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"credential"}, validationErr.Properties())
+	})
+
+	t.Run("API keys cannot register passkeys", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodPost, "/api/me/passkeys/register/begin", nil,
+			&model.Principal{UserID: 42, Method: model.AuthMethodAPIKey, Scopes: []model.Scope{model.ScopeAPIKeysWrite}})
+
+		err := New(serviceMocks.NewMockPasskeyService(t), nil).PostRegisterBegin(c)
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeForbidden))
+	})
+}
+
+func TestController_GetPasskeys(t *testing.T) {
+	service := serviceMocks.NewMockPasskeyService(t)
+	service.EXPECT().List(mock.Anything, uint(42)).Return([]*model.Passkey{
+		{ID: 5, Name: "Laptop", CredentialID: []byte{1, 2}, PublicKey: []byte{3}},
+	}, nil)
+
+	c, rec := newEchoContext(http.MethodGet, "/api/me/passkeys", nil, testPrincipal)
+
+	require.NoError(t, New(service, nil).GetPasskeys(c))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "public_key")
+	var resp schemas.Response[[]schemas.Passkey]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "Laptop", resp.Data[0].Name)
+	assert.Equal(t, []string{}, resp.Data[0].Transports)
+}
+
+func TestController_DeletePasskey(t *testing.T) {
+	t.Run("deletes", func(t *testing.T) {
+		service := serviceMocks.NewMockPasskeyService(t)
+		service.EXPECT().Delete(mock.Anything, uint(42), uint(5)).Return(nil)
+
+		c, rec := newEchoContext(http.MethodDelete, "/api/me/passkeys/5", nil, testPrincipal)
+		c.SetParamNames("id")
+		c.SetParamValues("5")
+
+		require.NoError(t, New(service, nil).DeletePasskey(c))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("non-numeric id", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodDelete, "/api/me/passkeys/abc", nil, testPrincipal)
+		c.SetParamNames("id")
+		c.SetParamValues("abc")
+
+		err := New(serviceMocks.NewMockPasskeyService(t), nil).DeletePasskey(c)
+
+		assert.ErrorIs(t, err, passkeyservice.ErrNotFound)
+	})
+}
+
+func TestController_Login(t *testing.T) {
+	t.Run("begin returns the options under publicKey", func(t *testing.T) {
+		service := serviceMocks.NewMockPasskeyService(t)
+		service.EXPECT().BeginLogin(mock.Anything).Return(&webauthn.RequestOptions{RPID: "example.com"}, nil)
+
+		c, rec := newEchoContext(http.MethodPost, "/api/login/passkey/begin", nil, nil)
+
+		require.NoError(t, New(service, nil).PostLoginBegin(c))
+
+		var resp schemas.Response[schemas.PasskeyRequestOptions]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "example.com", resp.Data.PublicKey.RPID)
+	})
+
+	t.Run("finish returns an access token", func(t *testing.T) {
+		auth := serviceMocks.NewMockService(t)
+		auth.EXPECT().PasskeyLogin(mock.Anything, []byte(`{"id":"abc"}`)).Return(&authservice.LoginResponse{
+			Token:     "jwt",
+			User:      &model.User{ID: 42, Username: "alice"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}, nil)
+
+		c, rec := newEchoContext(http.MethodPost, "/api/login/passkey/finish", schemas.PasskeyLoginRequest{
+			Credential: json.RawMessage(`{"id":"abc"}`),
+		}, nil)
+
+		require.NoError(t, New(serviceMocks.NewMockPasskeyService(t), auth).PostLoginFinish(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp schemas.Response[schemas.LoginResponse]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "jwt", resp.Data.Token)
+		assert.Equal(t, "alice", resp.Data.User.Username)
+	})
+}
@@ -0,0 +1,24 @@
+package sessions
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// modelToSchemaSession converts domain Session to schema Session; currentID
+// is the session of the request
+func modelToSchemaSession(s *model.Session, currentID uint) *schemas.Session {
+	if s == nil {
+		return nil
+	}
+
+	return &schemas.Session{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    currentID != 0 && s.ID == currentID,
+	}
+}
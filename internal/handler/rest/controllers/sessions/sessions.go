@@ -0,0 +1,95 @@
+package sessions
+
+import (
+	"net/http"
+	"strconv"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	sessionservice "golang-sample/internal/service/session"
+)
+
+// errSessionRequired is returned when an API key tries to manage sessions
+var errSessionRequired = governerrors.NewCode(governerrors.CodeForbidden, "managing sessions requires a signed-in user")
+
+// Controller handles the login sessions of the authenticated user.
+type Controller struct {
+	service sessionservice.Service
+}
+
+// New creates a new sessions HTTP handler.
+func New(service sessionservice.Service) *Controller {
+	return &Controller{
+		service: service,
+	}
+}
+
+// GetSessions godoc
+//
+//	@Summary	List sessions
+//	@Description	List the devices the signed-in user is logged in on
+//	@Tags		sessions
+//	@Produce	json
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Success	200			{object}	schemas.Response[[]schemas.Session]
+//	@Router		/api/me/sessions [get]
+func (h *Controller) GetSessions(c echo.Context) error {
+	principal, err := sessionPrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := h.service.List(c.Request().Context(), principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	result := make([]schemas.Session, len(sessions))
+	for i, session := range sessions {
+		result[i] = *modelToSchemaSession(session, principal.SessionID)
+	}
+	return c.JSON(http.StatusOK, schemas.NewResponse(result))
+}
+
+// DeleteSession godoc
+//
+//	@Summary	Revoke session
+//	@Description	Log a device of the signed-in user out; its tokens stop working immediately
+//	@Tags		sessions
+//	@Param		Authorization	header		string	true	"Bearer token"
+//	@Param		id	path		int	true	"Session ID"
+//	@Success	204
+//	@Router		/api/me/sessions/{id} [delete]
+func (h *Controller) DeleteSession(c echo.Context) error {
+	principal, err := sessionPrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return sessionservice.ErrNotFound
+	}
+
+	if err := h.service.Revoke(c.Request().Context(), principal.UserID, uint(id)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// sessionPrincipal returns the caller signed in with a JWT
+func sessionPrincipal(c echo.Context) (*model.Principal, error) {
+	principal, ok := middlewares.Principal(c)
+	if !ok {
+		return nil, governerrors.ErrUnauthorized
+	}
+	if principal.Method != model.AuthMethodJWT {
+		return nil, errSessionRequired
+	}
+	return principal, nil
+}
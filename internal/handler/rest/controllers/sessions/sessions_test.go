@@ -0,0 +1,86 @@
+package sessions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	serviceMocks "golang-sample/internal/mocks/service"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	sessionservice "golang-sample/internal/service/session"
+)
+
+var testPrincipal = &model.Principal{UserID: 42, Method: model.AuthMethodJWT, SessionID: 9}
+
+func newEchoContext(method, path string, principal *model.Principal) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set(middlewares.ContextKeyPrincipal, principal)
+	return c, rec
+}
+
+func TestController_GetSessions(t *testing.T) {
+	t.Run("lists sessions and marks the current one", func(t *testing.T) {
+		service := serviceMocks.NewMockSessionService(t)
+		service.EXPECT().List(mock.Anything, uint(42)).Return([]*model.Session{
+			{ID: 9, UserAgent: "Firefox", IP: "203.0.113.7", Family: "secret-family"},
+			{ID: 10, UserAgent: "Safari"},
+		}, nil)
+
+		c, rec := newEchoContext(http.MethodGet, "/api/me/sessions", testPrincipal)
+
+		require.NoError(t, New(service).GetSessions(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "secret-family", "the token family is never listed")
+		var resp schemas.Response[[]schemas.Session]
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 2)
+		assert.True(t, resp.Data[0].Current)
+		assert.Equal(t, "Firefox", resp.Data[0].UserAgent)
+		assert.False(t, resp.Data[1].Current)
+	})
+
+	t.Run("API keys cannot list sessions", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodGet, "/api/me/sessions",
+			&model.Principal{UserID: 42, Method: model.AuthMethodAPIKey, Scopes: []model.Scope{}})
+
+		err := New(serviceMocks.NewMockSessionService(t)).GetSessions(c)
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeForbidden))
+	})
+}
+
+func TestController_DeleteSession(t *testing.T) {
+	t.Run("revokes", func(t *testing.T) {
+		service := serviceMocks.NewMockSessionService(t)
+		service.EXPECT().Revoke(mock.Anything, uint(42), uint(10)).Return(nil)
+
+		c, rec := newEchoContext(http.MethodDelete, "/api/me/sessions/10", testPrincipal)
+		c.SetParamNames("id")
+		c.SetParamValues("10")
+
+		require.NoError(t, New(service).DeleteSession(c))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("non-numeric id", func(t *testing.T) {
+		c, _ := newEchoContext(http.MethodDelete, "/api/me/sessions/abc", testPrincipal)
+		c.SetParamNames("id")
+		c.SetParamValues("abc")
+
+		err := New(serviceMocks.NewMockSessionService(t)).DeleteSession(c)
+
+		assert.ErrorIs(t, err, sessionservice.ErrNotFound)
+	})
+}
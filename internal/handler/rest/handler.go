@@ -15,18 +15,61 @@ import (
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 
+	"golang-sample/internal/errcode"
+	adminctrl "golang-sample/internal/handler/rest/controllers/admin"
+	apikeysctrl "golang-sample/internal/handler/rest/controllers/apikeys"
+	auditctrl "golang-sample/internal/handler/rest/controllers/audit"
 	authctrl "golang-sample/internal/handler/rest/controllers/auth"
+	errcodesctrl "golang-sample/internal/handler/rest/controllers/errcodes"
 	healthctrl "golang-sample/internal/handler/rest/controllers/health"
+	magiclinkctrl "golang-sample/internal/handler/rest/controllers/magiclink"
+	oauthctrl "golang-sample/internal/handler/rest/controllers/oauth"
+	oauthserverctrl "golang-sample/internal/handler/rest/controllers/oauthserver"
+	orgsctrl "golang-sample/internal/handler/rest/controllers/orgs"
+	passkeysctrl "golang-sample/internal/handler/rest/controllers/passkeys"
+	sessionsctrl "golang-sample/internal/handler/rest/controllers/sessions"
 	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/handler/rest/problem"
+	"golang-sample/internal/i18n"
+	"golang-sample/internal/metrics"
+	"golang-sample/internal/schemas"
+	apikeyservice "golang-sample/internal/service/apikey"
+	auditservice "golang-sample/internal/service/audit"
+	orgservice "golang-sample/internal/service/organization"
+	sessionservice "golang-sample/internal/service/session"
 	apiValidator "golang-sample/internal/validator"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
 )
 
+// AdminPort is the port of the admin listener serving /metrics.
+// Zero serves /metrics on the public listener instead.
+type AdminPort int64
+
 func NewHandler(
 	log *zap.SugaredLogger,
 	e *echo.Echo,
 	authCtrl *authctrl.Controller,
 	healthCtrl *healthctrl.Controller,
+	adminCtrl *adminctrl.Controller,
+	errcodesCtrl *errcodesctrl.Controller,
+	apiKeysCtrl *apikeysctrl.Controller,
+	oauthCtrl *oauthctrl.Controller,
+	magicLinkCtrl *magiclinkctrl.Controller,
+	oauthServerCtrl *oauthserverctrl.Controller,
+	passkeysCtrl *passkeysctrl.Controller,
+	sessionsCtrl *sessionsctrl.Controller,
+	auditCtrl *auditctrl.Controller,
+	orgsCtrl *orgsctrl.Controller,
+	apiKeys apikeyservice.Service,
+	sessions sessionservice.Service,
+	auditLog auditservice.Service,
+	orgs orgservice.Service,
+	auth authConfig,
+	admin adminConfig,
+	errs errorsConfig,
 	port int64,
+	adminPort AdminPort,
 	debug bool,
 	env string,
 ) governhttp.Server {
@@ -41,8 +84,13 @@ func NewHandler(
 		echomiddleware.RemoveTrailingSlashWithConfig(echomiddleware.TrailingSlashConfig{
 			RedirectCode: http.StatusPermanentRedirect,
 		}),
+		middlewares.Tracing(),
+		middlewares.Metrics(),
 		echomiddleware.Recover(),
 		echomiddleware.RequestID(),
+		middlewares.ClientInfo(),
+		middlewares.RequestLogger(log),
+		middlewares.Sentry(),
 		middlewares.BodyLimit(),
 		middleware.TrimStrings,
 		middlewares.SecurityHeaders(),
@@ -55,12 +103,16 @@ func NewHandler(
 		httpEcho.WithSwaggerPath("/docs/*"),
 	)
 
-	e.HTTPErrorHandler = customHTTPErrorHandler
+	e.HTTPErrorHandler = newHTTPErrorHandler(newProblemRegistry(errs.typeBaseURI), errs.legacyFormat)
 
 	e.IPExtractor = echo.ExtractIPFromRealIPHeader()
 
 	// Create an HTTP server
-	e = initRouter(e, authCtrl, healthCtrl)
+	e = initRouter(e, authCtrl, healthCtrl, adminCtrl, errcodesCtrl, apiKeysCtrl, oauthCtrl, magicLinkCtrl, oauthServerCtrl, passkeysCtrl, sessionsCtrl, auditCtrl, orgsCtrl,
+		middlewares.Authenticate(auth.jwtSecret, apiKeys, sessions, auth.sessionlessCutoff, auditLog), middlewares.Tenant(orgs), admin.token)
+	if adminPort == 0 {
+		e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+	}
 
 	server := governhttp.NewServer(
 		fmt.Sprintf(":%d", port),
@@ -72,10 +124,154 @@ func NewHandler(
 	return server
 }
 
-// customHTTPErrorHandler handles errors with govern error code support
-func customHTTPErrorHandler(err error, c echo.Context) {
+// NewAdminServer creates the admin listener serving /metrics, kept off the public port
+func NewAdminServer(log *zap.SugaredLogger, port AdminPort) governhttp.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	return governhttp.NewServer(
+		fmt.Sprintf(":%d", port),
+		mux,
+		governhttp.WithTimeout(30*time.Second, 60*time.Second, 120*time.Second),
+		governhttp.WithLogger(log),
+	)
+}
+
+// retryAfterSeconds is the Retry-After of 503 responses
+const retryAfterSeconds = "1"
+
+// newHTTPErrorHandler renders errors as application/problem+json, or in the
+// legacy msg/error/path format when legacy is set
+func newHTTPErrorHandler(problems *problem.Registry, legacy bool) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		log := logger.FromContext(c.Request().Context(), nil).Desugar()
+
+		var code int
+		var responseBody interface{}
+		if legacy {
+			code, responseBody = buildLegacyErrorResponse(log, err, c)
+		} else {
+			code, responseBody = buildProblemResponse(log, problems, err, c)
+		}
+
+		if governerrors.IsCode(err, governerrors.CodeInternal) {
+			middlewares.CaptureError(c, err)
+		}
+
+		// Log error (avoid raw conflict errors which may leak info)
+		if governerrors.IsCode(err, governerrors.CodeConflict) {
+			log.Warn("Request error: conflict",
+				zap.String("path", c.Path()),
+				zap.Int("status", code),
+			)
+		} else if governerrors.IsCode(err, errcode.CategoryUnavailable) {
+			// Expected under load; the metrics of the saturated resource tell more
+			log.Warn("Request error: unavailable",
+				zap.String("path", c.Path()),
+				zap.Int("status", code),
+				zap.Error(err),
+			)
+		} else {
+			log.Error("Request error",
+				zap.String("path", c.Path()),
+				zap.Int("status", code),
+				zap.Error(err),
+			)
+		}
+
+		// Overload is transient; tell clients when to retry
+		if code == http.StatusServiceUnavailable {
+			c.Response().Header().Set(echo.HeaderRetryAfter, retryAfterSeconds)
+		}
+
+		// Send response
+		if !c.Response().Committed {
+			if !legacy {
+				c.Response().Header().Set(echo.HeaderContentType, problem.ContentType)
+			}
+			c.JSON(code, responseBody)
+		}
+	}
+}
+
+// buildProblemResponse resolves err to its problem type and builds the RFC 7807 body
+func buildProblemResponse(log *zap.Logger, problems *problem.Registry, err error, c echo.Context) (int, *schemas.Problem) {
+	loc := middlewares.Localizer(c)
+	c.Response().Header().Add(echo.HeaderVary, middlewares.HeaderAcceptLanguage)
+	c.Response().Header().Set(middlewares.HeaderContentLanguage, loc.Locale())
+
+	t, registered := problems.Resolve(err)
+	detail := localizedProblem(loc, t, "detail", t.Detail)
+
+	var he *echo.HTTPError
+	if errCode, ok := governerrors.GetCode(err); ok && !registered {
+		log.Error("Unknown error code in error handler",
+			zap.String("code", string(errCode)),
+			zap.String("path", c.Path()),
+			zap.Error(err))
+	} else if !registered && errors.As(err, &he) {
+		t = problems.ForStatus(he.Code)
+		detail = httpErrorMessage(log, he, c)
+	}
+
+	body := &schemas.Problem{
+		Type:      problems.URI(t),
+		Title:     localizedProblem(loc, t, "title", t.Title),
+		Status:    t.Status,
+		Detail:    detail,
+		Instance:  c.Request().URL.Path,
+		Code:      string(errorCode(err, t.Code)),
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		TraceID:   tracing.TraceID(c.Request().Context()),
+	}
+
+	var validationErr *apiValidator.ValidationError
+	if t.Status == http.StatusBadRequest && errors.As(err, &validationErr) {
+		body.Errors = apiValidator.LocalizeErrors(loc, validationErr.Errors)
+		body.Detail = validationDetail(loc, body.Errors)
+	}
+
+	return t.Status, body
+}
+
+// errorCode returns the stable code attached to err, or def when it has none
+func errorCode(err error, def errcode.Code) errcode.Code {
+	if code, ok := errcode.Of(err); ok {
+		return code
+	}
+	return def
+}
+
+// localizedProblem returns the field ("title" or "detail") of t in the
+// locale of loc, or def when the catalogs don't translate it
+func localizedProblem(loc i18n.Localizer, t problem.Type, field, def string) string {
+	if t.Slug == "" {
+		return def
+	}
+	return loc.T("problem."+t.Slug+"."+field, def)
+}
+
+// httpErrorMessage returns the client-facing message of an echo HTTP error.
+// 5xx messages are logged and replaced so internal details don't leak.
+func httpErrorMessage(log *zap.Logger, he *echo.HTTPError, c echo.Context) string {
+	if he.Code < 500 {
+		return fmt.Sprintf("%v", he.Message)
+	}
+
+	// Log the actual internal error message (safe to log internally)
+	log.Error("HTTPError (5xx)",
+		zap.Int("status", he.Code),
+		zap.String("path", c.Path()),
+		zap.String("internal_message", fmt.Sprintf("%v", he.Message)),
+	)
+	return "Internal Server Error"
+}
+
+// buildLegacyErrorResponse builds the msg/error/path body used before problem
+// details, kept for clients that haven't migrated
+func buildLegacyErrorResponse(log *zap.Logger, err error, c echo.Context) (int, map[string]interface{}) {
 	code := http.StatusInternalServerError
-	var responseBody interface{}
+	var responseBody map[string]interface{}
 
 	// Check govern error codes first
 	if errCode, ok := governerrors.GetCode(err); ok {
@@ -112,6 +308,13 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 				"error": "conflict occurred",
 				"path":  c.Path(),
 			}
+		case errcode.CategoryUnavailable:
+			code = http.StatusServiceUnavailable
+			responseBody = map[string]interface{}{
+				"msg":   "Service temporarily unavailable",
+				"error": "Service temporarily unavailable",
+				"path":  c.Path(),
+			}
 		case governerrors.CodeInternal:
 			code = http.StatusInternalServerError
 			responseBody = map[string]interface{}{
@@ -121,7 +324,7 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 			}
 		default:
 			// Log unknown error codes
-			zap.L().Error("Unknown error code in error handler",
+			log.Error("Unknown error code in error handler",
 				zap.String("code", string(errCode)),
 				zap.String("path", c.Path()),
 				zap.Error(err))
@@ -134,28 +337,13 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 		}
 	} else if he, ok := err.(*echo.HTTPError); ok {
 		code = he.Code
-
-		// Sanitize 5xx error messages to avoid leaking internal details
-		var clientMsg string
-		if code >= 500 {
-			clientMsg = "Internal Server Error"
-			// Log the actual internal error message (safe to log internally)
-			zap.L().Error("HTTPError (5xx)",
-				zap.Int("status", code),
-				zap.String("path", c.Path()),
-				zap.String("internal_message", fmt.Sprintf("%v", he.Message)),
-			)
-		} else {
-			clientMsg = fmt.Sprintf("%v", he.Message)
-		}
-
+		clientMsg := httpErrorMessage(log, he, c)
 		responseBody = map[string]interface{}{
 			"msg":   clientMsg,
 			"error": clientMsg,
 			"path":  c.Path(),
 		}
-	} else if err != nil {
-		code = http.StatusInternalServerError
+	} else {
 		responseBody = map[string]interface{}{
 			"msg":   "Internal Server Error",
 			"error": "Internal Server Error",
@@ -163,41 +351,42 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 		}
 	}
 
-	// Log error (avoid raw conflict errors which may leak info)
-	if errCode, ok := governerrors.GetCode(err); ok && errCode == governerrors.CodeConflict {
-		zap.L().Warn("Request error: conflict",
-			zap.String("path", c.Path()),
-			zap.Int("status", code),
-		)
-	} else {
-		zap.L().Error("Request error",
-			zap.String("path", c.Path()),
-			zap.Int("status", code),
-			zap.Error(err),
-		)
+	responseBody["code"] = errorCode(err, errcode.ForStatus(code))
+
+	// Let clients quote the trace ID when reporting errors
+	if traceID := tracing.TraceID(c.Request().Context()); traceID != "" {
+		responseBody["trace_id"] = traceID
 	}
 
-	// Send response
-	if !c.Response().Committed {
-		c.JSON(code, responseBody)
+	return code, responseBody
+}
+
+// validationDetail summarizes the failed fields for the problem detail
+func validationDetail(loc i18n.Localizer, details []*schemas.ErrorDetail) string {
+	if len(details) == 1 {
+		return details[0].Msg
 	}
+	return loc.InvalidFields(len(details))
 }
 
 // buildValidationErrorResponse builds a detailed validation error response
 func buildValidationErrorResponse(err error, path string) map[string]interface{} {
 	// Try to unwrap and find ValidationError
 	var validationErr *apiValidator.ValidationError
-	if errors.As(err, &validationErr) {
+	if errors.As(err, &validationErr) && len(validationErr.Errors) > 0 {
+		fieldErrors := make([]map[string]interface{}, 0, len(validationErr.Errors))
+		for _, detail := range validationErr.Errors {
+			fieldErrors = append(fieldErrors, map[string]interface{}{
+				"property": detail.Property,
+				"msg":      detail.Msg,
+			})
+		}
+
 		return map[string]interface{}{
-			"msg":   validationErr.Detail.Msg,
-			"error": validationErr.Detail.Msg,
-			"errors": []map[string]interface{}{
-				{
-					"property": validationErr.Detail.Property,
-					"msg":      validationErr.Detail.Msg,
-				},
-			},
-			"path": path,
+			"msg":    validationErr.Errors[0].Msg,
+			"error":  validationErr.Errors[0].Msg,
+			"errors": fieldErrors,
+			"path":   path,
 		}
 	}
 
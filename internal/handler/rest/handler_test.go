@@ -0,0 +1,240 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/schemas"
+	authservice "golang-sample/internal/service/auth"
+	apiValidator "golang-sample/internal/validator"
+	"golang-sample/pkg/reporting"
+	"golang-sample/pkg/reporting/reportingtest"
+)
+
+func TestCustomHTTPErrorHandler_ReportsInternalErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantEvents int
+	}{
+		{"internal error is reported", governerrors.WrapCode(governerrors.CodeInternal, errors.New("db down")), http.StatusInternalServerError, 1},
+		{"conflict is not reported", governerrors.NewCode(governerrors.CodeConflict, "exists"), http.StatusConflict, 0},
+		{"unauthorized is not reported", governerrors.ErrUnauthorized, http.StatusUnauthorized, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &reportingtest.Transport{}
+			require.NoError(t, reporting.Init(reporting.Config{
+				DSN:       "https://public@sentry.example.com/1",
+				Transport: transport,
+			}))
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/register", nil), rec)
+
+			newHTTPErrorHandler(newProblemRegistry(""), false)(tt.err, c)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Len(t, transport.Events(), tt.wantEvents)
+		})
+	}
+}
+
+// serveError renders err through the error handler for a request to target
+func serveError(t *testing.T, legacy bool, err error, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, target, nil), rec)
+	c.SetPath(target)
+	c.Response().Header().Set(echo.HeaderXRequestID, "req-123")
+
+	newHTTPErrorHandler(newProblemRegistry(""), legacy)(err, c)
+	return rec
+}
+
+func TestHTTPErrorHandler_Problem(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantTitle  string
+		wantDetail string
+		wantCode   string
+	}{
+		{"govern code", governerrors.ErrUnauthorized, http.StatusUnauthorized, "/problems/unauthorized", "Unauthorized", "Authentication is required or the credentials are invalid", "UNAUTHORIZED"},
+		{"domain error", authservice.ErrEmailTaken, http.StatusConflict, "/problems/account-exists", "Account already exists", "An account with this username or email already exists", "USER_EMAIL_TAKEN"},
+		{"domain error with generic type", authservice.ErrInvalidCredentials, http.StatusUnauthorized, "/problems/unauthorized", "Unauthorized", "Authentication is required or the credentials are invalid", "AUTH_INVALID_CREDENTIALS"},
+		{"echo http error", echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests"), http.StatusTooManyRequests, "/problems/rate-limited", "Too many requests", "Too many requests", "RATE_LIMITED"},
+		{"unregistered status", echo.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "about:blank", "Method Not Allowed", "Method Not Allowed", "METHOD_NOT_ALLOWED"},
+		{"5xx http error is sanitized", echo.NewHTTPError(http.StatusBadGateway, "upstream 10.0.0.1 refused"), http.StatusBadGateway, "about:blank", "Bad Gateway", "Internal Server Error", "INTERNAL"},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError, "/problems/internal", "Internal Server Error", "Internal Server Error", "INTERNAL"},
+		{"overload", authservice.ErrBusy, http.StatusServiceUnavailable, "/problems/unavailable", "Service Unavailable", "The server is temporarily overloaded. Please try again later.", "UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveError(t, false, tt.err, "/api/register")
+
+			if tt.wantStatus == http.StatusServiceUnavailable {
+				assert.Equal(t, "1", rec.Header().Get(echo.HeaderRetryAfter))
+			} else {
+				assert.Empty(t, rec.Header().Get(echo.HeaderRetryAfter))
+			}
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/problem+json", rec.Header().Get(echo.HeaderContentType))
+
+			var body schemas.Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantType, body.Type)
+			assert.Equal(t, tt.wantTitle, body.Title)
+			assert.Equal(t, tt.wantStatus, body.Status)
+			assert.Equal(t, tt.wantDetail, body.Detail)
+			assert.Equal(t, tt.wantCode, body.Code)
+			assert.Equal(t, "/api/register", body.Instance)
+			assert.Equal(t, "req-123", body.RequestID)
+			assert.Empty(t, body.Errors)
+		})
+	}
+}
+
+func TestHTTPErrorHandler_ProblemFieldErrors(t *testing.T) {
+	t.Run("single field", func(t *testing.T) {
+		err := governerrors.WrapCode(governerrors.CodeInvalid, &apiValidator.ValidationError{
+			Errors: []*schemas.ErrorDetail{{Property: "email", Msg: "email must be a valid email address"}},
+		})
+
+		rec := serveError(t, false, err, "/api/register")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{
+			"type": "/problems/invalid-request",
+			"title": "Invalid request",
+			"status": 400,
+			"detail": "email must be a valid email address",
+			"instance": "/api/register",
+			"request_id": "req-123",
+			"code": "INVALID_REQUEST",
+			"errors": [{"property": "email", "msg": "email must be a valid email address"}]
+		}`, rec.Body.String())
+	})
+
+	t.Run("every failed field is reported", func(t *testing.T) {
+		err := apiValidator.NewCustomValidator().Validate(&schemas.UserRegisterRequest{Email: "not-an-email"})
+
+		rec := serveError(t, false, err, "/api/register")
+
+		var body schemas.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "4 fields are invalid", body.Detail)
+		require.Len(t, body.Errors, 4)
+		assert.Equal(t, "username", body.Errors[0].Property)
+		assert.Equal(t, "username is required", body.Errors[0].Msg)
+		assert.Equal(t, "email", body.Errors[2].Property)
+		assert.Equal(t, "email", body.Errors[2].MsgValues["tag"])
+		assert.Equal(t, "FIELD_REQUIRED", body.Errors[0].Code)
+		assert.Equal(t, "FIELD_INVALID_EMAIL", body.Errors[2].Code)
+	})
+}
+
+func TestHTTPErrorHandler_Legacy(t *testing.T) {
+	t.Run("conflict", func(t *testing.T) {
+		rec := serveError(t, true, authservice.ErrUsernameTaken, "/api/register")
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+		assert.JSONEq(t, `{"msg":"Resource already exists","error":"conflict occurred","path":"/api/register","code":"USER_USERNAME_TAKEN"}`, rec.Body.String())
+	})
+
+	t.Run("validation lists every field", func(t *testing.T) {
+		err := apiValidator.NewCustomValidator().Validate(&schemas.LoginRequest{})
+
+		rec := serveError(t, true, err, "/api/login")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{
+			"msg": "username is required",
+			"error": "username is required",
+			"errors": [
+				{"property": "username", "msg": "username is required"},
+				{"property": "password", "msg": "password is required"}
+			],
+			"path": "/api/login",
+			"code": "INVALID_REQUEST"
+		}`, rec.Body.String())
+	})
+}
+
+func TestHTTPErrorHandler_ProblemLocalized(t *testing.T) {
+	serveLocalized := func(err error, acceptLanguage string) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/register", nil)
+		req.Header.Set(middlewares.HeaderAcceptLanguage, acceptLanguage)
+		rec := httptest.NewRecorder()
+
+		newHTTPErrorHandler(newProblemRegistry(""), false)(err, e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("domain error", func(t *testing.T) {
+		rec := serveLocalized(authservice.ErrEmailTaken, "vi-VN,vi;q=0.9")
+
+		var body schemas.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "vi", rec.Header().Get(middlewares.HeaderContentLanguage))
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), middlewares.HeaderAcceptLanguage)
+		assert.Equal(t, "/problems/account-exists", body.Type)
+		assert.Equal(t, "Tài khoản đã tồn tại", body.Title)
+		assert.Equal(t, "Đã có tài khoản với tên đăng nhập hoặc email này", body.Detail)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		err := apiValidator.NewCustomValidator().Validate(&schemas.LoginRequest{Username: "alice"})
+
+		rec := serveLocalized(err, "vi")
+
+		var body schemas.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "Yêu cầu không hợp lệ", body.Title)
+		assert.Equal(t, "password là bắt buộc", body.Detail)
+		require.Len(t, body.Errors, 1)
+		assert.Equal(t, "password là bắt buộc", body.Errors[0].Msg)
+	})
+
+	t.Run("password policy errors", func(t *testing.T) {
+		err := apiValidator.Invalid(apiValidator.NewErrorDetail("password", "password_breached", "", "string"))
+
+		rec := serveLocalized(err, "vi")
+
+		var body schemas.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusBadRequest, body.Status)
+		require.Len(t, body.Errors, 1)
+		assert.Equal(t, "PASSWORD_BREACHED", body.Errors[0].Code)
+		assert.Equal(t, "password đã xuất hiện trong một vụ rò rỉ dữ liệu; hãy chọn mật khẩu khác", body.Errors[0].Msg)
+	})
+
+	t.Run("unsupported locale falls back to english", func(t *testing.T) {
+		rec := serveLocalized(governerrors.ErrUnauthorized, "fr-FR")
+
+		var body schemas.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "en", rec.Header().Get(middlewares.HeaderContentLanguage))
+		assert.Equal(t, "Unauthorized", body.Title)
+	})
+}
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderAdminToken carries the shared secret for admin endpoints
+const HeaderAdminToken = "X-Admin-Token"
+
+// AdminToken returns a middleware that rejects requests whose X-Admin-Token
+// header does not match token. The comparison runs in constant time.
+func AdminToken(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			provided := c.Request().Header.Get(HeaderAdminToken)
+			if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				return governerrors.ErrUnauthorized
+			}
+			return next(c)
+		}
+	}
+}
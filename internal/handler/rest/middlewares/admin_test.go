@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminToken(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name       string
+		configured string
+		header     string
+		wantErr    bool
+	}{
+		{"valid token", token, token, false},
+		{"wrong token", token, "wrong", true},
+		{"missing header", token, "", true},
+		{"no token configured", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/log/level", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderAdminToken, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := AdminToken(tt.configured)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			err := handler(c)
+
+			if tt.wantErr {
+				assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package middlewares
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	"golang-sample/pkg/logger"
+)
+
+const (
+	// ContextKeyClaims holds the *schemas.JwtClaims of a JWT-authenticated user
+	ContextKeyClaims = "jwt_claims"
+	// ContextKeyPrincipal holds the *model.Principal of the caller, however it authenticated
+	ContextKeyPrincipal = "principal"
+	// ContextKeyUserID holds the caller's user ID as a string, where
+	// httpEcho.GetUserID looks for it
+	ContextKeyUserID = "user_id"
+
+	// HeaderAPIKey carries an API key as an alternative to the Authorization header
+	HeaderAPIKey = "X-API-Key"
+)
+
+var (
+	// errInsufficientScope is returned by RequireScope
+	errInsufficientScope = errcode.New(errcode.AuthInsufficientScope, governerrors.CodeForbidden, "insufficient scope")
+	// errImpersonationForbidden is returned by DenyImpersonation
+	errImpersonationForbidden = errcode.New(errcode.AuthImpersonationForbidden, governerrors.CodeForbidden, "not allowed while impersonating")
+)
+
+// APIKeyAuthenticator resolves an API key to its principal
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*model.Principal, error)
+}
+
+// SessionChecker resolves the token family of a JWT to its active session
+type SessionChecker interface {
+	Check(ctx context.Context, family string) (*model.Session, error)
+}
+
+// AuditRecorder appends events to the audit log
+type AuditRecorder interface {
+	Record(ctx context.Context, event model.AuditEvent)
+}
+
+// JWTAuth returns a middleware that requires an "Authorization: Bearer"
+// token signed with secret
+func JWTAuth(secret string) echo.MiddlewareFunc {
+	return Authenticate(secret, nil, nil, time.Time{}, nil)
+}
+
+// Authenticate returns a middleware that requires an "Authorization: Bearer"
+// JWT signed with secret or, when apiKeys is set, an API key in either
+// "Authorization: Bearer sk_..." or X-API-Key. With sessions, a JWT that
+// names a session is only accepted while the session is active. A JWT that
+// names none and is not an impersonation token is rejected when issued before
+// sessionlessCutoff, unless it is zero. The caller is stored under
+// ContextKeyPrincipal and the user's stored locale is applied to localized
+// messages. Requests made with an impersonation token are recorded in
+// auditLog with both the user and the actor, or in the "audit" logger
+// without one.
+func Authenticate(secret string, apiKeys APIKeyAuthenticator, sessions SessionChecker, sessionlessCutoff time.Time, auditLog AuditRecorder) echo.MiddlewareFunc {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			raw, ok := bearerToken(c)
+			if apiKey := c.Request().Header.Get(HeaderAPIKey); apiKey != "" {
+				raw, ok = apiKey, true
+			}
+			if !ok {
+				return unauthorized(c)
+			}
+
+			var principal *model.Principal
+			if strings.HasPrefix(raw, model.APIKeyPrefix) {
+				if apiKeys == nil {
+					return unauthorized(c)
+				}
+				var err error
+				principal, err = apiKeys.Authenticate(c.Request().Context(), raw)
+				if err != nil {
+					if governerrors.IsCode(err, governerrors.CodeUnauthorized) {
+						c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+					}
+					return err
+				}
+			} else {
+				claims := &schemas.JwtClaims{}
+				if _, err := parser.ParseWithClaims(raw, claims, keyFunc); err != nil {
+					return unauthorized(c)
+				}
+				userID, err := strconv.ParseUint(claims.ID, 10, 64)
+				if err != nil {
+					return unauthorized(c)
+				}
+				principal = &model.Principal{
+					UserID:   uint(userID),
+					Username: claims.Username,
+					Email:    claims.Email,
+					Locale:   claims.Locale,
+					Method:   model.AuthMethodJWT,
+					OrgID:    claims.OrgID,
+				}
+				if claims.Actor != nil {
+					principal.Actor = claims.Actor.Subject
+				}
+				// Tokens issued before sessions were recorded name none, and
+				// neither do impersonation tokens or those of a deployment
+				// without sessions. They are accepted until they expire, unless
+				// issued before the cutoff; tokens of that time carry no issue
+				// time either.
+				if claims.SessionID == "" {
+					if claims.Actor == nil && !sessionlessCutoff.IsZero() && (claims.IssuedAt == nil || claims.IssuedAt.Before(sessionlessCutoff)) {
+						return unauthorized(c)
+					}
+				} else if sessions != nil {
+					session, err := sessions.Check(c.Request().Context(), claims.SessionID)
+					if err != nil {
+						if governerrors.IsCode(err, governerrors.CodeUnauthorized) {
+							c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+						}
+						return err
+					}
+					if session.UserID != principal.UserID {
+						return unauthorized(c)
+					}
+					principal.SessionID = session.ID
+				}
+				c.Set(ContextKeyClaims, claims)
+			}
+
+			c.Set(ContextKeyPrincipal, principal)
+			SetUserLocale(c, principal.Locale)
+			// RequestLogger ran before the caller was known; add the user ID
+			// to the request's logger now
+			userID := strconv.FormatUint(uint64(principal.UserID), 10)
+			c.Set(ContextKeyUserID, userID)
+			c.SetRequest(c.Request().WithContext(logger.With(c.Request().Context(), "user_id", userID)))
+			if !principal.Impersonated() {
+				return next(c)
+			}
+
+			err := next(c)
+			auditImpersonated(c, auditLog, principal, err)
+			return err
+		}
+	}
+}
+
+// auditImpersonated records a request made by a member of staff acting as
+// a user, once the handler has run
+func auditImpersonated(c echo.Context, auditLog AuditRecorder, principal *model.Principal, err error) {
+	metadata := map[string]string{
+		"method": c.Request().Method,
+		"route":  c.Path(),
+	}
+	if err != nil {
+		code, _ := governerrors.GetCode(err)
+		metadata["error_code"] = string(code)
+	} else {
+		metadata["status"] = strconv.Itoa(c.Response().Status)
+	}
+
+	ctx := c.Request().Context()
+	if auditLog == nil {
+		logger.FromContext(ctx, nil).Named("audit").Infow("Impersonated request",
+			"user_id", principal.UserID,
+			"actor", principal.Actor,
+			"metadata", metadata,
+		)
+		return
+	}
+	auditLog.Record(ctx, model.AuditEvent{
+		Action:   model.AuditImpersonatedRequest,
+		Actor:    principal.Actor,
+		TargetID: principal.UserID,
+		Metadata: metadata,
+	})
+}
+
+// DenyImpersonation returns a middleware, placed after Authenticate, that
+// rejects impersonation tokens with 403. Staff may look around as a user
+// but not change how the user signs in.
+func DenyImpersonation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := Principal(c)
+			if !ok {
+				return unauthorized(c)
+			}
+			if principal.Impersonated() {
+				return errImpersonationForbidden
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireScope returns a middleware, placed after Authenticate, that rejects
+// callers without scope with 403
+func RequireScope(scope model.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := Principal(c)
+			if !ok {
+				return unauthorized(c)
+			}
+			if !principal.HasScope(scope) {
+				return errInsufficientScope
+			}
+			return next(c)
+		}
+	}
+}
+
+// Principal returns the caller stored by Authenticate
+func Principal(c echo.Context) (*model.Principal, bool) {
+	principal, ok := c.Get(ContextKeyPrincipal).(*model.Principal)
+	return principal, ok
+}
+
+// Claims returns the claims stored by JWTAuth; API key callers have none
+func Claims(c echo.Context) (*schemas.JwtClaims, bool) {
+	claims, ok := c.Get(ContextKeyClaims).(*schemas.JwtClaims)
+	return claims, ok
+}
+
+func bearerToken(c echo.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func unauthorized(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	return governerrors.ErrUnauthorized
+}
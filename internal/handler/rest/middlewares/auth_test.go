@@ -0,0 +1,431 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	"golang-sample/pkg/logger"
+)
+
+func TestJWTAuth(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	sign := func(t *testing.T, method jwt.SigningMethod, key interface{}, expiresAt time.Time) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(method, schemas.JwtClaims{
+			ID:       "42",
+			Username: "alice",
+			Locale:   "vi",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+		}).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	valid := sign(t, jwt.SigningMethodHS256, []byte(secret), time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{"valid token", "Bearer " + valid, false},
+		{"lowercase scheme", "bearer " + valid, false},
+		{"missing header", "", true},
+		{"basic scheme", "Basic dXNlcjpwYXNz", true},
+		{"wrong secret", "Bearer " + sign(t, jwt.SigningMethodHS256, []byte("other-secret"), time.Now().Add(time.Hour)), true},
+		{"expired", "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(secret), time.Now().Add(-time.Minute)), true},
+		{"other algorithm", "Bearer " + sign(t, jwt.SigningMethodHS512, []byte(secret), time.Now().Add(time.Hour)), true},
+		{"malformed", "Bearer not-a-token", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			var gotClaims *schemas.JwtClaims
+			handler := JWTAuth(secret)(func(c echo.Context) error {
+				gotClaims, _ = Claims(c)
+				return c.NoContent(http.StatusOK)
+			})
+
+			err := handler(c)
+
+			if tt.wantErr {
+				assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+				assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, gotClaims)
+			assert.Equal(t, "42", gotClaims.ID)
+			assert.Equal(t, "vi", c.Get(ContextKeyUserLocale))
+
+			principal, ok := Principal(c)
+			require.True(t, ok)
+			assert.Equal(t, uint(42), principal.UserID)
+			assert.Equal(t, model.AuthMethodJWT, principal.Method)
+			assert.True(t, principal.HasScope(model.ScopePasswordWrite), "sessions have every scope")
+		})
+	}
+}
+
+// fakeAPIKeys accepts a single key
+type fakeAPIKeys struct{ key string }
+
+func (f fakeAPIKeys) Authenticate(_ context.Context, key string) (*model.Principal, error) {
+	if key != f.key {
+		return nil, governerrors.ErrUnauthorized
+	}
+	return &model.Principal{
+		UserID:   42,
+		Locale:   "vi",
+		Method:   model.AuthMethodAPIKey,
+		Scopes:   []model.Scope{model.ScopeAPIKeysRead},
+		APIKeyID: 7,
+	}, nil
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	const key = "sk_0123456789abcdef"
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		apiKeys APIKeyAuthenticator
+		wantErr bool
+	}{
+		{name: "bearer", headers: map[string]string{echo.HeaderAuthorization: "Bearer " + key}, apiKeys: fakeAPIKeys{key}},
+		{name: "X-API-Key", headers: map[string]string{HeaderAPIKey: key}, apiKeys: fakeAPIKeys{key}},
+		{name: "unknown key", headers: map[string]string{HeaderAPIKey: "sk_unknown"}, apiKeys: fakeAPIKeys{key}, wantErr: true},
+		{name: "API keys disabled", headers: map[string]string{HeaderAPIKey: key}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := Authenticate("secret", tt.apiKeys, nil, time.Time{}, nil)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			err := handler(c)
+
+			if tt.wantErr {
+				assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+				assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+				return
+			}
+			require.NoError(t, err)
+			principal, ok := Principal(c)
+			require.True(t, ok)
+			assert.Equal(t, uint(7), principal.APIKeyID)
+			assert.Equal(t, "vi", c.Get(ContextKeyUserLocale))
+			_, ok = Claims(c)
+			assert.False(t, ok, "API key callers have no JWT claims")
+		})
+	}
+}
+
+// fakeSessions knows active sessions by token family
+type fakeSessions map[string]*model.Session
+
+func (f fakeSessions) Check(_ context.Context, family string) (*model.Session, error) {
+	session, ok := f[family]
+	if !ok {
+		return nil, governerrors.ErrUnauthorized
+	}
+	return session, nil
+}
+
+func TestAuthenticate_Session(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	sessions := fakeSessions{
+		"active":  {ID: 9, UserID: 42},
+		"foreign": {ID: 10, UserID: 7},
+	}
+
+	sign := func(t *testing.T, sessionID string) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, schemas.JwtClaims{
+			ID:        "42",
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}).SignedString([]byte(secret))
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name          string
+		sessionID     string
+		wantErr       bool
+		wantSessionID uint
+	}{
+		{name: "active session", sessionID: "active", wantSessionID: 9},
+		{name: "revoked session", sessionID: "revoked", wantErr: true},
+		{name: "session of another user", sessionID: "foreign", wantErr: true},
+		{name: "token without a session", sessionID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+sign(t, tt.sessionID))
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			err := Authenticate(secret, nil, sessions, time.Time{}, nil)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			if tt.wantErr {
+				assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+				assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+				return
+			}
+			require.NoError(t, err)
+			principal, ok := Principal(c)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantSessionID, principal.SessionID)
+		})
+	}
+}
+
+func TestAuthenticate_SessionlessCutoff(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	cutoff := time.Now().Add(-time.Hour)
+
+	sign := func(t *testing.T, sessionID string, issuedAt *jwt.NumericDate) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, schemas.JwtClaims{
+			ID:        "42",
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				IssuedAt:  issuedAt,
+			},
+		}).SignedString([]byte(secret))
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		cutoff  time.Time
+		wantErr bool
+	}{
+		{
+			name:  "token from before sessions without a cutoff",
+			token: func(t *testing.T) string { return sign(t, "", nil) },
+		},
+		{
+			name:    "token from before sessions",
+			token:   func(t *testing.T) string { return sign(t, "", nil) },
+			cutoff:  cutoff,
+			wantErr: true,
+		},
+		{
+			name:    "sessionless token issued before the cutoff",
+			token:   func(t *testing.T) string { return sign(t, "", jwt.NewNumericDate(cutoff.Add(-time.Minute))) },
+			cutoff:  cutoff,
+			wantErr: true,
+		},
+		{
+			name:   "sessionless token issued after the cutoff",
+			token:  func(t *testing.T) string { return sign(t, "", jwt.NewNumericDate(time.Now())) },
+			cutoff: cutoff,
+		},
+		{
+			name:   "token with a session issued before the cutoff",
+			token:  func(t *testing.T) string { return sign(t, "active", jwt.NewNumericDate(cutoff.Add(-time.Minute))) },
+			cutoff: cutoff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token(t))
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			err := Authenticate(secret, nil, fakeSessions{"active": {ID: 9, UserID: 42}}, tt.cutoff, nil)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			if tt.wantErr {
+				assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthenticate_Impersonation(t *testing.T) {
+	t.Run("records the request in the audit log", func(t *testing.T) {
+		testAuthenticateImpersonation(t, true)
+	})
+	t.Run("logs the request without an audit log", func(t *testing.T) {
+		testAuthenticateImpersonation(t, false)
+	})
+}
+
+// auditRecorderFunc adapts a function to AuditRecorder
+type auditRecorderFunc func(ctx context.Context, event model.AuditEvent)
+
+func (f auditRecorderFunc) Record(ctx context.Context, event model.AuditEvent) {
+	f(ctx, event)
+}
+
+func testAuthenticateImpersonation(t *testing.T, withAuditLog bool) {
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, schemas.JwtClaims{
+		ID:    "42",
+		Actor: &schemas.ActorClaim{Subject: "support@example.com"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	core, logs := observer.New(zap.InfoLevel)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/me/api-keys", nil)
+	req = req.WithContext(logger.NewContext(req.Context(), zap.New(core).Sugar()))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/api/me/api-keys")
+
+	var recorded []model.AuditEvent
+	var auditLog AuditRecorder
+	if withAuditLog {
+		auditLog = auditRecorderFunc(func(_ context.Context, event model.AuditEvent) {
+			recorded = append(recorded, event)
+		})
+	}
+
+	var got *model.Principal
+	// Impersonation tokens name no session, so the cutoff does not apply
+	err = Authenticate(secret, nil, nil, time.Now().Add(time.Hour), auditLog)(func(c echo.Context) error {
+		got, _ = Principal(c)
+		return c.NoContent(http.StatusOK)
+	})(c)
+
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, uint(42), got.UserID)
+	assert.Equal(t, "support@example.com", got.Actor)
+
+	wantMetadata := map[string]string{"method": http.MethodGet, "route": "/api/me/api-keys", "status": "200"}
+	if withAuditLog {
+		require.Len(t, recorded, 1)
+		assert.Equal(t, model.AuditImpersonatedRequest, recorded[0].Action)
+		assert.Equal(t, uint(42), recorded[0].TargetID)
+		assert.Equal(t, "support@example.com", recorded[0].Actor)
+		assert.Equal(t, wantMetadata, recorded[0].Metadata)
+		assert.Empty(t, logs.FilterLoggerName("audit").All())
+		return
+	}
+	entries := logs.FilterLoggerName("audit").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, uint64(42), fields["user_id"])
+	assert.Equal(t, "support@example.com", fields["actor"])
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *model.Principal
+		wantCode  governerrors.ErrorCode
+	}{
+		{name: "user", principal: &model.Principal{UserID: 42, Method: model.AuthMethodJWT}},
+		{name: "impersonated", principal: &model.Principal{UserID: 42, Method: model.AuthMethodJWT, Actor: "support@example.com"}, wantCode: governerrors.CodeForbidden},
+		{name: "unauthenticated", wantCode: governerrors.CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPut, "/api/me/password", nil), httptest.NewRecorder())
+			if tt.principal != nil {
+				c.Set(ContextKeyPrincipal, tt.principal)
+			}
+
+			err := DenyImpersonation()(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, governerrors.IsCode(err, tt.wantCode))
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *model.Principal
+		wantCode  governerrors.ErrorCode
+	}{
+		{name: "session", principal: &model.Principal{UserID: 42, Method: model.AuthMethodJWT}},
+		{name: "key with scope", principal: &model.Principal{UserID: 42, Method: model.AuthMethodAPIKey, Scopes: []model.Scope{model.ScopeAPIKeysRead}}},
+		{name: "key without scope", principal: &model.Principal{UserID: 42, Method: model.AuthMethodAPIKey, Scopes: []model.Scope{}}, wantCode: governerrors.CodeForbidden},
+		{name: "unauthenticated", wantCode: governerrors.CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/me/api-keys", nil), httptest.NewRecorder())
+			if tt.principal != nil {
+				c.Set(ContextKeyPrincipal, tt.principal)
+			}
+
+			err := RequireScope(model.ScopeAPIKeysRead)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, governerrors.IsCode(err, tt.wantCode))
+		})
+	}
+}
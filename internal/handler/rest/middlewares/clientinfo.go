@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/pkg/clientinfo"
+)
+
+// userAgentMaxLength bounds the user agent kept for a request
+const userAgentMaxLength = 512
+
+// ClientInfo returns a middleware that stores the client's IP, user agent
+// and request ID in the request context, where services read them with
+// clientinfo.FromContext. It relies on e.IPExtractor for the IP and must
+// run after echomiddleware.RequestID.
+func ClientInfo() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userAgent := c.Request().UserAgent()
+			if len(userAgent) > userAgentMaxLength {
+				userAgent = userAgent[:userAgentMaxLength]
+			}
+
+			req := c.Request()
+			ctx := clientinfo.NewContext(req.Context(), clientinfo.Info{
+				UserAgent: userAgent,
+				IP:        c.RealIP(),
+				RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+			})
+			c.SetRequest(req.WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/pkg/clientinfo"
+)
+
+func TestClientInfo(t *testing.T) {
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+	req.Header.Set("User-Agent", "Firefox/"+strings.Repeat("x", 1000))
+	req.RemoteAddr = "203.0.113.7:51234"
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+
+	var got clientinfo.Info
+	err := ClientInfo()(func(c echo.Context) error {
+		got = clientinfo.FromContext(c.Request().Context())
+		return nil
+	})(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", got.IP)
+	assert.Equal(t, "req-1", got.RequestID)
+	assert.Len(t, got.UserAgent, userAgentMaxLength)
+	assert.True(t, strings.HasPrefix(got.UserAgent, "Firefox/"))
+}
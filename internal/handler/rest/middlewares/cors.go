@@ -44,6 +44,7 @@ func DefaultCORSConfig() CORSConfig {
 			"Authorization",
 			"Content-Type",
 			"X-CSRF-Token",
+			HeaderOrgID,
 			"X-Requested-With",
 		},
 		ExposeHeaders: []string{
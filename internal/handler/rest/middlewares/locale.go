@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/i18n"
+)
+
+// Headers used for content negotiation of localized messages
+const (
+	HeaderAcceptLanguage  = "Accept-Language"
+	HeaderContentLanguage = "Content-Language"
+)
+
+// ContextKeyUserLocale holds the stored locale of the authenticated user
+const ContextKeyUserLocale = "user_locale"
+
+// SetUserLocale records the authenticated user's stored locale, which takes
+// precedence over Accept-Language
+func SetUserLocale(c echo.Context, locale string) {
+	c.Set(ContextKeyUserLocale, locale)
+}
+
+// Localizer returns the localizer of the request: the user's stored locale
+// first, then the Accept-Language preferences, then i18n.DefaultLocale
+func Localizer(c echo.Context) i18n.Localizer {
+	var candidates []string
+	if locale, ok := c.Get(ContextKeyUserLocale).(string); ok && locale != "" {
+		candidates = append(candidates, locale)
+	}
+	candidates = append(candidates, i18n.ParseAcceptLanguage(c.Request().Header.Get(HeaderAcceptLanguage))...)
+
+	return i18n.Default().Localizer(candidates...)
+}
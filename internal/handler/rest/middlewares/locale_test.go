@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"golang-sample/internal/i18n"
+)
+
+func TestLocalizer(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		userLocale     string
+		want           string
+	}{
+		{"no preference uses default", "", "", i18n.DefaultLocale},
+		{"accept-language", "vi-VN,vi;q=0.9,en;q=0.8", "", i18n.LocaleVI},
+		{"accept-language quality order", "en;q=0.5, vi;q=0.9", "", i18n.LocaleVI},
+		{"unsupported accept-language falls back", "fr-FR", "", i18n.DefaultLocale},
+		{"stored user locale wins", "en", "vi", i18n.LocaleVI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set(HeaderAcceptLanguage, tt.acceptLanguage)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			if tt.userLocale != "" {
+				SetUserLocale(c, tt.userLocale)
+			}
+
+			assert.Equal(t, tt.want, Localizer(c).Locale())
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"strings"
+
+	httpEcho "github.com/haipham22/govern/http/echo"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+)
+
+// traceparentHeader is the W3C Trace Context propagation header
+const traceparentHeader = "traceparent"
+
+// RequestLogger returns a middleware that stores a child of log in the request
+// context, enriched with the request ID and trace ID, and the user ID when
+// the caller is already authenticated. It must run after
+// echomiddleware.RequestID and Tracing so the request ID and span are
+// available. As a global middleware it runs before any route's
+// authentication, which adds the user ID itself.
+func RequestLogger(log *zap.SugaredLogger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			fields := make([]interface{}, 0, 6)
+
+			if requestID := c.Response().Header().Get(echo.HeaderXRequestID); requestID != "" {
+				fields = append(fields, "request_id", requestID)
+			}
+			// Prefer the span started by the Tracing middleware, fall back to the raw header
+			traceID := tracing.TraceID(c.Request().Context())
+			if traceID == "" {
+				traceID = traceIDFromHeader(c.Request().Header.Get(traceparentHeader))
+			}
+			if traceID != "" {
+				fields = append(fields, "trace_id", traceID)
+			}
+			if userID, ok := httpEcho.GetUserID(c); ok && userID != "" {
+				fields = append(fields, "user_id", userID)
+			}
+
+			req := c.Request()
+			ctx := logger.NewContext(req.Context(), log.With(fields...))
+			c.SetRequest(req.WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// traceIDFromHeader extracts the trace ID from a W3C traceparent header value
+// ("version-traceid-parentid-flags"). Returns empty string when malformed.
+func traceIDFromHeader(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+
+	traceID := strings.ToLower(parts[1])
+	if strings.Trim(traceID, "0123456789abcdef") != "" || traceID == strings.Repeat("0", 32) {
+		return ""
+	}
+	return traceID
+}
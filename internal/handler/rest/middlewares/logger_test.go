@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"golang-sample/internal/schemas"
+	"golang-sample/pkg/logger"
+)
+
+func TestRequestLogger_EnrichesContextLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	base := zap.New(core).Sugar()
+
+	e := echo.New()
+	e.Use(echomiddleware.RequestID(), RequestLogger(base))
+	e.GET("/api/test", func(c echo.Context) error {
+		c.Set("user_id", "42")
+		logger.FromContext(c.Request().Context(), nil).Info("handled")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-123")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "req-123", fields["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["trace_id"])
+	assert.NotContains(t, fields, "user_id", "user ID is only known once auth has run")
+}
+
+func TestRequestLogger_AddsUserIDWhenAuthenticated(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	base := zap.New(core).Sugar()
+
+	e := echo.New()
+	authenticated := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "42")
+			return next(c)
+		}
+	}
+	e.Use(authenticated, RequestLogger(base))
+	e.GET("/api/test", func(c echo.Context) error {
+		logger.FromContext(c.Request().Context(), nil).Info("handled")
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "42", logs.All()[0].ContextMap()["user_id"])
+}
+
+func TestTraceIDFromHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"uppercase is normalized", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"empty", "", ""},
+		{"wrong part count", "00-4bf92f3577b34da6a3ce929d0e0e4736-01", ""},
+		{"short trace id", "00-4bf92f35-00f067aa0ba902b7-01", ""},
+		{"non-hex trace id", "00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+		{"all-zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, traceIDFromHeader(tt.header))
+		})
+	}
+}
+
+func TestRequestLogger_UserIDFromRouteAuthentication(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	base := zap.New(core).Sugar()
+
+	const secret = "0123456789abcdef0123456789abcdef"
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, schemas.JwtClaims{
+		ID:               "42",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(echomiddleware.RequestID(), RequestLogger(base))
+	e.GET("/api/test", func(c echo.Context) error {
+		logger.FromContext(c.Request().Context(), nil).Info("handled")
+		return c.NoContent(http.StatusOK)
+	}, JWTAuth(secret))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "42", logs.All()[0].ContextMap()["user_id"])
+	assert.NotEmpty(t, logs.All()[0].ContextMap()["request_id"])
+}
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route, keeping label cardinality bounded
+const unmatchedRoute = "unmatched"
+
+// Metrics returns a middleware that records request count and latency by
+// method, route template and status code.
+// Errors are handed to the HTTP error handler here so the recorded status is
+// the one sent to the client; register it before Recover so panics count as 500.
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			labels := []string{c.Request().Method, route, strconv.Itoa(c.Response().Status)}
+
+			metrics.HTTPRequestsTotal.Inc(labels...)
+			metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), labels...)
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/metrics"
+)
+
+// scrapeMetrics returns the Prometheus text exposition of all registered metrics
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMetrics_RecordsRouteTemplateAndStatus(t *testing.T) {
+	e := echo.New()
+	e.Use(Metrics(), echomiddleware.Recover())
+	e.GET("/metrics-test/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/metrics-test/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot)
+	})
+	e.GET("/metrics-test/panic", func(c echo.Context) error {
+		panic(errors.New("boom"))
+	})
+
+	for _, path := range []string{"/metrics-test/users/1", "/metrics-test/users/2", "/metrics-test/fail", "/metrics-test/panic"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrapeMetrics(t)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/metrics-test/users/:id",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/metrics-test/fail",status="418"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/metrics-test/panic",status="500"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/metrics-test/users/:id",status="200"} 2`)
+}
+
+func TestMetrics_ErrorHandledOnce(t *testing.T) {
+	e := echo.New()
+	calls := 0
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		calls++
+		_ = c.NoContent(http.StatusBadRequest)
+	}
+	e.Use(Metrics())
+	e.GET("/metrics-test/once", func(c echo.Context) error {
+		return errors.New("bad")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics-test/once", nil))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRateLimit_CountsRejections(t *testing.T) {
+	e := echo.New()
+	h := RateLimitWithConfig(context.Background(), RateLimiterConfig{
+		RequestsPerMinute: 1,
+		WindowSize:        60,
+	})(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/metrics-test/limited", nil)
+		req.RemoteAddr = "192.168.1.10:1234"
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetPath("/metrics-test/limited")
+		require.NoError(t, h(c))
+	}
+
+	assert.Contains(t, scrapeMetrics(t), `http_rate_limit_rejections_total{route="/metrics-test/limited"} 2`)
+}
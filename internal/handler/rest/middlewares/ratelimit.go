@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/metrics"
 )
 
 // RateLimiterConfig holds configuration for rate limiting
@@ -137,10 +139,10 @@ func RateLimitWithConfig(ctx context.Context, config RateLimiterConfig) echo.Mid
 
 			// Check if request is allowed
 			if !limiter.allow() {
-				return c.JSON(http.StatusTooManyRequests, map[string]string{
-					"error": "Too many requests",
-					"msg":   "Rate limit exceeded. Please try again later.",
-				})
+				metrics.RateLimitRejectionsTotal.Inc(c.Path())
+				// Render through the error handler so the response matches the configured error format
+				c.Error(echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests"))
+				return nil
 			}
 
 			return next(c)
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	httpEcho "github.com/haipham22/govern/http/echo"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/pkg/tracing"
+)
+
+// Sentry returns a middleware that binds a per-request Sentry hub to the
+// request context, scoped with the request, request ID and trace ID.
+// Panics are reported and re-raised so Recover still turns them into a 500.
+// It must run after echomiddleware.RequestID and inside Recover.
+func Sentry() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			hub := sentry.CurrentHub().Clone()
+			scope := hub.Scope()
+			scope.SetRequest(req)
+			if requestID := c.Response().Header().Get(echo.HeaderXRequestID); requestID != "" {
+				scope.SetTag("request_id", requestID)
+			}
+			if traceID := tracing.TraceID(req.Context()); traceID != "" {
+				scope.SetTag("trace_id", traceID)
+			}
+
+			ctx := sentry.SetHubOnContext(req.Context(), hub)
+			c.SetRequest(req.WithContext(ctx))
+
+			defer func() {
+				if r := recover(); r != nil {
+					if r != http.ErrAbortHandler {
+						hub.WithScope(func(scope *sentry.Scope) {
+							enrichScope(c, scope)
+							hub.RecoverWithContext(ctx, r)
+						})
+					}
+					panic(r)
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// CaptureError reports err to the Sentry hub bound to the request, adding the
+// route and the user authenticated by the time the error surfaced.
+func CaptureError(c echo.Context, err error) {
+	hub := sentry.GetHubFromContext(c.Request().Context())
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+		hub.Scope().SetRequest(c.Request())
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		enrichScope(c, scope)
+		hub.CaptureException(err)
+	})
+}
+
+// enrichScope adds request details only known once the handler has run
+func enrichScope(c echo.Context, scope *sentry.Scope) {
+	if route := c.Path(); route != "" {
+		scope.SetTag("http.route", route)
+	}
+	if userID, ok := httpEcho.GetUserID(c); ok && userID != "" {
+		scope.SetUser(sentry.User{ID: userID})
+	}
+}
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/pkg/reporting"
+	"golang-sample/pkg/reporting/reportingtest"
+)
+
+// setupTestReporting points the global Sentry client at a fake transport
+func setupTestReporting(t *testing.T) *reportingtest.Transport {
+	t.Helper()
+	transport := &reportingtest.Transport{}
+	require.NoError(t, reporting.Init(reporting.Config{
+		DSN:       "https://public@sentry.example.com/1",
+		Transport: transport,
+	}))
+	return transport
+}
+
+func TestSentry_ReportsPanicsWithRequestContext(t *testing.T) {
+	transport := setupTestReporting(t)
+
+	e := echo.New()
+	e.Use(echomiddleware.Recover(), echomiddleware.RequestID(), Sentry())
+	e.GET("/sentry-test/panic/:id", func(c echo.Context) error {
+		c.Set("user_id", "42")
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/sentry-test/panic/1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-123")
+	req.Header.Set(HeaderAdminToken, "admin-secret")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, "Recover still answers the request")
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	event := events[0]
+	assert.Equal(t, "req-123", event.Tags["request_id"])
+	assert.Equal(t, "/sentry-test/panic/:id", event.Tags["http.route"])
+	assert.Equal(t, "42", event.User.ID)
+	require.NotNil(t, event.Request)
+	assert.Equal(t, http.MethodGet, event.Request.Method)
+	assert.Equal(t, "[Filtered]", event.Request.Headers[HeaderAdminToken])
+}
+
+func TestCaptureError_UsesRequestHub(t *testing.T) {
+	transport := setupTestReporting(t)
+
+	e := echo.New()
+	e.Use(echomiddleware.RequestID(), Sentry())
+	e.GET("/sentry-test/error", func(c echo.Context) error {
+		c.Set("user_id", "7")
+		CaptureError(c, errors.New("database unavailable"))
+		return c.NoContent(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/sentry-test/error", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-456")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "req-456", events[0].Tags["request_id"])
+	assert.Equal(t, "7", events[0].User.ID)
+	require.NotEmpty(t, events[0].Exception)
+	assert.Equal(t, "database unavailable", events[0].Exception[0].Value)
+}
+
+func TestCaptureError_WithoutMiddleware(t *testing.T) {
+	transport := setupTestReporting(t)
+
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/login", nil), httptest.NewRecorder())
+
+	CaptureError(c, errors.New("boom"))
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, http.MethodPost, events[0].Request.Method)
+}
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"context"
+	"strconv"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/model"
+	"golang-sample/internal/tenant"
+)
+
+// HeaderOrgID selects the organization of a request made without an
+// organization token
+const HeaderOrgID = "X-Org-ID"
+
+var (
+	// errOrgRequired is returned by Tenant when the request names no organization
+	errOrgRequired = errcode.New(errcode.OrgRequired, governerrors.CodeInvalid, "organization required")
+	// errOrgMismatch is returned by Tenant when the token and header disagree
+	errOrgMismatch = errcode.New(errcode.OrgAccessDenied, governerrors.CodeForbidden, "the token is for another organization")
+	// errOrgRoleInsufficient is returned by RequireOrgRole
+	errOrgRoleInsufficient = errcode.New(errcode.OrgRoleInsufficient, governerrors.CodeForbidden, "insufficient organization role")
+)
+
+// MembershipResolver returns the membership of a user in an organization,
+// or a forbidden error when there is none
+type MembershipResolver interface {
+	Membership(ctx context.Context, orgID, userID uint) (*model.Membership, error)
+}
+
+// Tenant returns a middleware, placed after Authenticate, that resolves the
+// organization of the request from an organization token or, failing that,
+// the X-Org-ID header, which must agree with the token when both are sent.
+// The caller must be a member, checked on every request so that removed
+// members lose access at once. The organization is set on the principal,
+// with the caller's role, and on the request context for tenant-scoped
+// repositories.
+func Tenant(memberships MembershipResolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := Principal(c)
+			if !ok {
+				return unauthorized(c)
+			}
+
+			orgID := principal.OrgID
+			if header := c.Request().Header.Get(HeaderOrgID); header != "" {
+				headerID, err := strconv.ParseUint(header, 10, 64)
+				if err != nil || headerID == 0 {
+					return governerrors.NewCode(governerrors.CodeInvalid, "invalid "+HeaderOrgID+" header")
+				}
+				if orgID != 0 && uint(headerID) != orgID {
+					return errOrgMismatch
+				}
+				orgID = uint(headerID)
+			}
+			if orgID == 0 {
+				return errOrgRequired
+			}
+
+			ctx := c.Request().Context()
+			membership, err := memberships.Membership(ctx, orgID, principal.UserID)
+			if err != nil {
+				return err
+			}
+
+			scoped := *principal
+			scoped.OrgID = membership.OrgID
+			scoped.OrgRole = membership.Role
+			c.Set(ContextKeyPrincipal, &scoped)
+			c.SetRequest(c.Request().WithContext(tenant.NewContext(ctx, membership.OrgID)))
+			return next(c)
+		}
+	}
+}
+
+// RequireOrgRole returns a middleware, placed after Tenant, that rejects
+// callers whose role in the organization is below role with 403
+func RequireOrgRole(role model.OrgRole) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := Principal(c)
+			if !ok {
+				return unauthorized(c)
+			}
+			if !principal.OrgRole.AtLeast(role) {
+				return errOrgRoleInsufficient
+			}
+			return next(c)
+		}
+	}
+}
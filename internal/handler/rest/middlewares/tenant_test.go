@@ -0,0 +1,111 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/model"
+	"golang-sample/internal/tenant"
+)
+
+// membershipResolverFunc adapts a function to MembershipResolver
+type membershipResolverFunc func(ctx context.Context, orgID, userID uint) (*model.Membership, error)
+
+func (f membershipResolverFunc) Membership(ctx context.Context, orgID, userID uint) (*model.Membership, error) {
+	return f(ctx, orgID, userID)
+}
+
+func TestTenant(t *testing.T) {
+	// User 42 is an admin of organization 7 only
+	resolver := membershipResolverFunc(func(_ context.Context, orgID, userID uint) (*model.Membership, error) {
+		if orgID == 7 && userID == 42 {
+			return &model.Membership{OrgID: 7, UserID: 42, Role: model.OrgRoleAdmin}, nil
+		}
+		return nil, errcode.New(errcode.OrgAccessDenied, governerrors.CodeForbidden, "not a member")
+	})
+
+	tests := []struct {
+		name      string
+		tokenOrg  uint
+		header    string
+		wantCode  governerrors.ErrorCode
+		wantOrgID uint
+	}{
+		{name: "organization token", tokenOrg: 7, wantOrgID: 7},
+		{name: "header", header: "7", wantOrgID: 7},
+		{name: "token and matching header", tokenOrg: 7, header: "7", wantOrgID: 7},
+		{name: "token and other header", tokenOrg: 7, header: "8", wantCode: governerrors.CodeForbidden},
+		{name: "not a member", header: "8", wantCode: governerrors.CodeForbidden},
+		{name: "no organization", wantCode: governerrors.CodeInvalid},
+		{name: "malformed header", header: "acme", wantCode: governerrors.CodeInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/org/members", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderOrgID, tt.header)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			c.Set(ContextKeyPrincipal, &model.Principal{UserID: 42, Method: model.AuthMethodJWT, OrgID: tt.tokenOrg})
+
+			var got *model.Principal
+			var ctxOrgID uint
+			err := Tenant(resolver)(func(c echo.Context) error {
+				got, _ = Principal(c)
+				ctxOrgID, _ = tenant.FromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			if tt.wantCode != "" {
+				assert.True(t, governerrors.IsCode(err, tt.wantCode), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOrgID, got.OrgID)
+			assert.Equal(t, model.OrgRoleAdmin, got.OrgRole)
+			assert.Equal(t, tt.wantOrgID, ctxOrgID)
+		})
+	}
+}
+
+func TestRequireOrgRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *model.Principal
+		wantCode  governerrors.ErrorCode
+	}{
+		{name: "owner", principal: &model.Principal{UserID: 42, OrgID: 7, OrgRole: model.OrgRoleOwner}},
+		{name: "admin", principal: &model.Principal{UserID: 42, OrgID: 7, OrgRole: model.OrgRoleAdmin}},
+		{name: "member", principal: &model.Principal{UserID: 42, OrgID: 7, OrgRole: model.OrgRoleMember}, wantCode: governerrors.CodeForbidden},
+		{name: "outside an organization", principal: &model.Principal{UserID: 42}, wantCode: governerrors.CodeForbidden},
+		{name: "unauthenticated", wantCode: governerrors.CodeUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/api/org/invitations", nil), httptest.NewRecorder())
+			if tt.principal != nil {
+				c.Set(ContextKeyPrincipal, tt.principal)
+			}
+
+			err := RequireOrgRole(model.OrgRoleAdmin)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, governerrors.IsCode(err, tt.wantCode))
+		})
+	}
+}
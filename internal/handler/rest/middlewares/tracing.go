@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "golang-sample/internal/handler/rest"
+
+// Tracing returns a middleware that starts a server span per request,
+// continuing the trace from an incoming W3C traceparent header.
+// Like Metrics it hands errors to the HTTP error handler so the span records
+// the status sent to the client, and the error response can carry the trace ID.
+func Tracing() echo.MiddlewareFunc {
+	tracer := otel.Tracer(tracerName)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			ctx, span := tracer.Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodOriginal(req.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(req.URL.Path),
+					semconv.ClientAddress(c.RealIP()),
+					semconv.UserAgentOriginal(req.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			c.SetRequest(req.WithContext(ctx))
+
+			if err := next(c); err != nil {
+				span.RecordError(err)
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			return nil
+		}
+	}
+}
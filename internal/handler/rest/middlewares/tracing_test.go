@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+)
+
+var (
+	tracingOnce     sync.Once
+	tracingRecorder *tracetest.SpanRecorder
+)
+
+// setupTestTracing installs a recording tracer provider and the W3C propagator once per test binary
+func setupTestTracing() *tracetest.SpanRecorder {
+	tracingOnce.Do(func() {
+		tracingRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracingRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return tracingRecorder
+}
+
+// endedSpan returns the ended span with the given trace ID
+func endedSpan(t *testing.T, recorder *tracetest.SpanRecorder, traceID string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "no span for trace %s", traceID)
+	return nil
+}
+
+func TestTracing_ContinuesIncomingTrace(t *testing.T) {
+	recorder := setupTestTracing()
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	e := echo.New()
+	e.Use(Tracing())
+	var handlerTraceID string
+	e.GET("/tracing-test/users/:id", func(c echo.Context) error {
+		handlerTraceID = tracing.TraceID(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/tracing-test/users/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, traceID, handlerTraceID)
+
+	span := endedSpan(t, recorder, traceID)
+	assert.Equal(t, "GET /tracing-test/users/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.True(t, span.Parent().IsRemote())
+}
+
+func TestTracing_MarksServerErrors(t *testing.T) {
+	recorder := setupTestTracing()
+	const traceID = "5bf92f3577b34da6a3ce929d0e0e4736"
+
+	e := echo.New()
+	e.Use(Tracing())
+	e.GET("/tracing-test/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/tracing-test/fail", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	span := endedSpan(t, recorder, traceID)
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
+
+func TestRequestLogger_UsesSpanTraceID(t *testing.T) {
+	setupTestTracing()
+	core, logs := observer.New(zap.DebugLevel)
+
+	e := echo.New()
+	e.Use(Tracing(), RequestLogger(zap.New(core).Sugar()))
+	var spanTraceID string
+	e.GET("/tracing-test/log", func(c echo.Context) error {
+		spanTraceID = tracing.TraceID(c.Request().Context())
+		logger.FromContext(c.Request().Context(), nil).Info("handled")
+		return c.NoContent(http.StatusOK)
+	})
+
+	// No incoming traceparent: the server span starts a new trace
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tracing-test/log", nil))
+
+	require.NotEmpty(t, spanTraceID)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, spanTraceID, logs.All()[0].ContextMap()["trace_id"])
+}
@@ -0,0 +1,192 @@
+// Package problem maps errors to RFC 7807 problem types. A Registry resolves
+// an error to its Type by checking registered domain errors first, then the
+// govern error code, and finally falls back to TypeInternal.
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/errcode"
+)
+
+// ContentType is the media type of problem detail responses
+const ContentType = "application/problem+json"
+
+// DefaultBaseURI prefixes the slug of every type when no base URI is configured
+const DefaultBaseURI = "/problems/"
+
+// blankURI is the type of problems with no semantics beyond their HTTP status
+const blankURI = "about:blank"
+
+// Type describes a class of problem
+type Type struct {
+	// Slug identifies the type; its URI is the registry base URI followed by the slug
+	Slug   string
+	Title  string
+	Status int
+	// Code is the stable code of errors of this type that carry none of their own
+	Code errcode.Code
+	// Detail is the client-facing explanation; keep it generic so errors
+	// don't leak internals or which of several inputs was wrong
+	Detail string
+}
+
+// Default types for govern error codes
+var (
+	TypeInvalid = Type{
+		Slug:   "invalid-request",
+		Title:  "Invalid request",
+		Status: http.StatusBadRequest,
+		Detail: "invalid request parameters",
+		Code:   errcode.InvalidRequest,
+	}
+	TypeUnauthorized = Type{
+		Slug:   "unauthorized",
+		Title:  "Unauthorized",
+		Status: http.StatusUnauthorized,
+		Detail: "Authentication is required or the credentials are invalid",
+		Code:   errcode.Unauthorized,
+	}
+	TypeForbidden = Type{
+		Slug:   "forbidden",
+		Title:  "Forbidden",
+		Status: http.StatusForbidden,
+		Detail: "You are not allowed to perform this action",
+		Code:   errcode.Forbidden,
+	}
+	TypeNotFound = Type{
+		Slug:   "not-found",
+		Title:  "Resource not found",
+		Status: http.StatusNotFound,
+		Detail: "Resource not found",
+		Code:   errcode.NotFound,
+	}
+	TypeAlreadyExists = Type{
+		Slug:   "already-exists",
+		Title:  "Resource already exists",
+		Status: http.StatusConflict,
+		Detail: "Resource already exists",
+		Code:   errcode.AlreadyExists,
+	}
+	TypeConflict = Type{
+		Slug:   "conflict",
+		Title:  "Conflict",
+		Status: http.StatusConflict,
+		Detail: "Resource already exists",
+		Code:   errcode.Conflict,
+	}
+	TypeRateLimited = Type{
+		Slug:   "rate-limited",
+		Title:  "Too many requests",
+		Status: http.StatusTooManyRequests,
+		Detail: "Rate limit exceeded. Please try again later.",
+		Code:   errcode.RateLimited,
+	}
+	TypeUnavailable = Type{
+		Slug:   "unavailable",
+		Title:  "Service Unavailable",
+		Status: http.StatusServiceUnavailable,
+		Detail: "The server is temporarily overloaded. Please try again later.",
+		Code:   errcode.Unavailable,
+	}
+	TypeInternal = Type{
+		Slug:   "internal",
+		Title:  "Internal Server Error",
+		Status: http.StatusInternalServerError,
+		Detail: "Internal Server Error",
+		Code:   errcode.Internal,
+	}
+)
+
+// errorType maps a domain error to its type
+type errorType struct {
+	target error
+	typ    Type
+}
+
+// Registry resolves errors to problem types
+type Registry struct {
+	baseURI  string
+	errs     []errorType
+	codes    map[governerrors.ErrorCode]Type
+	statuses map[int]Type
+}
+
+// NewRegistry creates a registry with the default types of every govern
+// error code. An empty baseURI uses DefaultBaseURI.
+func NewRegistry(baseURI string) *Registry {
+	if baseURI == "" {
+		baseURI = DefaultBaseURI
+	}
+	if !strings.HasSuffix(baseURI, "/") {
+		baseURI += "/"
+	}
+
+	r := &Registry{
+		baseURI:  baseURI,
+		codes:    map[governerrors.ErrorCode]Type{},
+		statuses: map[int]Type{},
+	}
+	r.RegisterCode(governerrors.CodeInvalid, TypeInvalid)
+	r.RegisterCode(governerrors.CodeUnauthorized, TypeUnauthorized)
+	r.RegisterCode(governerrors.CodeForbidden, TypeForbidden)
+	r.RegisterCode(governerrors.CodeNotFound, TypeNotFound)
+	r.RegisterCode(governerrors.CodeConflict, TypeConflict)
+	r.RegisterCode(governerrors.CodeAlreadyExists, TypeAlreadyExists)
+	r.RegisterCode(governerrors.CodeRateLimit, TypeRateLimited)
+	r.RegisterCode(errcode.CategoryUnavailable, TypeUnavailable)
+	r.RegisterCode(governerrors.CodeInternal, TypeInternal)
+	return r
+}
+
+// RegisterCode sets the type of errors carrying code. The first type
+// registered for a status also describes echo HTTP errors with that status.
+func (r *Registry) RegisterCode(code governerrors.ErrorCode, t Type) {
+	r.codes[code] = t
+	if _, exists := r.statuses[t.Status]; !exists {
+		r.statuses[t.Status] = t
+	}
+}
+
+// RegisterError sets the type of errors matching target with errors.Is.
+// Domain errors take precedence over error codes.
+func (r *Registry) RegisterError(target error, t Type) {
+	r.errs = append(r.errs, errorType{target: target, typ: t})
+}
+
+// Resolve returns the type of err and whether it was registered. Unknown
+// errors resolve to TypeInternal.
+func (r *Registry) Resolve(err error) (Type, bool) {
+	for _, e := range r.errs {
+		if errors.Is(err, e.target) {
+			return e.typ, true
+		}
+	}
+	if code, ok := governerrors.GetCode(err); ok {
+		if t, exists := r.codes[code]; exists {
+			return t, true
+		}
+	}
+	return TypeInternal, false
+}
+
+// ForStatus returns the type describing a bare HTTP status, or an about:blank
+// type titled with the status text when none is registered
+func (r *Registry) ForStatus(status int) Type {
+	if t, exists := r.statuses[status]; exists {
+		return t
+	}
+	return Type{Title: http.StatusText(status), Status: status, Code: errcode.ForStatus(status)}
+}
+
+// URI returns the type URI of t; types without a slug are about:blank
+func (r *Registry) URI(t Type) string {
+	if t.Slug == "" {
+		return blankURI
+	}
+	return r.baseURI + t.Slug
+}
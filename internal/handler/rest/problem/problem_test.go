@@ -0,0 +1,58 @@
+package problem
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+
+	"golang-sample/internal/errcode"
+)
+
+var errDomain = governerrors.NewCode(governerrors.CodeConflict, "domain conflict")
+
+var typeDomain = Type{Slug: "domain", Title: "Domain conflict", Status: http.StatusConflict}
+
+func TestRegistry_Resolve(t *testing.T) {
+	r := NewRegistry("")
+	r.RegisterError(errDomain, typeDomain)
+
+	tests := []struct {
+		name           string
+		err            error
+		want           Type
+		wantRegistered bool
+	}{
+		{"govern code", governerrors.NewCode(governerrors.CodeNotFound, "user 1"), TypeNotFound, true},
+		{"wrapped govern code", fmt.Errorf("lookup: %w", governerrors.ErrUnauthorized), TypeUnauthorized, true},
+		{"domain error takes precedence over its code", errDomain, typeDomain, true},
+		{"wrapped domain error", fmt.Errorf("register: %w", errDomain), typeDomain, true},
+		{"overload", governerrors.NewCode(errcode.CategoryUnavailable, "busy"), TypeUnavailable, true},
+		{"unknown code", governerrors.NewCode("TEAPOT", "short and stout"), TypeInternal, false},
+		{"plain error", fmt.Errorf("boom"), TypeInternal, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, registered := r.Resolve(tt.err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantRegistered, registered)
+		})
+	}
+}
+
+func TestRegistry_ForStatus(t *testing.T) {
+	r := NewRegistry("")
+
+	assert.Equal(t, TypeNotFound, r.ForStatus(http.StatusNotFound))
+	assert.Equal(t, TypeConflict, r.ForStatus(http.StatusConflict), "first type registered for a status wins")
+	assert.Equal(t, Type{Title: "Method Not Allowed", Status: http.StatusMethodNotAllowed, Code: errcode.MethodNotAllowed}, r.ForStatus(http.StatusMethodNotAllowed))
+}
+
+func TestRegistry_URI(t *testing.T) {
+	assert.Equal(t, "/problems/not-found", NewRegistry("").URI(TypeNotFound))
+	assert.Equal(t, "https://api.example.com/problems/not-found", NewRegistry("https://api.example.com/problems").URI(TypeNotFound))
+	assert.Equal(t, "about:blank", NewRegistry("").URI(Type{Status: http.StatusMethodNotAllowed}))
+}
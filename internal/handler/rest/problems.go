@@ -0,0 +1,28 @@
+package rest
+
+import (
+	"net/http"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/handler/rest/problem"
+	authservice "golang-sample/internal/service/auth"
+)
+
+// problemAccountExists is shared by username and email conflicts so the
+// response doesn't reveal which one is taken
+var problemAccountExists = problem.Type{
+	Slug:   "account-exists",
+	Title:  "Account already exists",
+	Status: http.StatusConflict,
+	Detail: "An account with this username or email already exists",
+	Code:   errcode.UserAccountExists,
+}
+
+// newProblemRegistry maps govern error codes and domain errors to problem types
+func newProblemRegistry(baseURI string) *problem.Registry {
+	r := problem.NewRegistry(baseURI)
+	r.RegisterError(authservice.ErrUsernameTaken, problemAccountExists)
+	r.RegisterError(authservice.ErrEmailTaken, problemAccountExists)
+	r.RegisterError(authservice.ErrAccountExists, problemAccountExists)
+	return r
+}
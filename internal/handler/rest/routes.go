@@ -3,9 +3,20 @@ package rest
 import (
 	"context"
 
+	"golang-sample/internal/handler/rest/controllers/admin"
+	"golang-sample/internal/handler/rest/controllers/apikeys"
+	"golang-sample/internal/handler/rest/controllers/audit"
 	"golang-sample/internal/handler/rest/controllers/auth"
+	"golang-sample/internal/handler/rest/controllers/errcodes"
 	"golang-sample/internal/handler/rest/controllers/health"
+	"golang-sample/internal/handler/rest/controllers/magiclink"
+	"golang-sample/internal/handler/rest/controllers/oauth"
+	"golang-sample/internal/handler/rest/controllers/oauthserver"
+	"golang-sample/internal/handler/rest/controllers/orgs"
+	"golang-sample/internal/handler/rest/controllers/passkeys"
+	"golang-sample/internal/handler/rest/controllers/sessions"
 	"golang-sample/internal/handler/rest/middlewares"
+	"golang-sample/internal/model"
 
 	"github.com/labstack/echo/v4"
 )
@@ -14,6 +25,19 @@ func initRouter(
 	e *echo.Echo,
 	authCtrl *auth.Controller,
 	healthCtrl *health.Controller,
+	adminCtrl *admin.Controller,
+	errcodesCtrl *errcodes.Controller,
+	apiKeysCtrl *apikeys.Controller,
+	oauthCtrl *oauth.Controller,
+	magicLinkCtrl *magiclink.Controller,
+	oauthServerCtrl *oauthserver.Controller,
+	passkeysCtrl *passkeys.Controller,
+	sessionsCtrl *sessions.Controller,
+	auditCtrl *audit.Controller,
+	orgsCtrl *orgs.Controller,
+	authenticate echo.MiddlewareFunc,
+	tenant echo.MiddlewareFunc,
+	adminToken string,
 ) *echo.Echo {
 	// Health check endpoints
 	e.GET("/health", healthCtrl.Check)
@@ -28,5 +52,100 @@ func initRouter(
 	public.POST("/login", authCtrl.PostLogin, authRateLimiter)
 	public.POST("/register", authCtrl.PostRegister, authRateLimiter)
 
+	// Passwordless login, when magic_link.base_url is set. Links are also
+	// limited per account by the service.
+	if magicLinkCtrl != nil {
+		public.POST("/login/magic-link", magicLinkCtrl.PostMagicLink, authRateLimiter)
+		public.GET("/login/magic-link/verify", magicLinkCtrl.GetVerify, authRateLimiter)
+	}
+
+	// Passkey login, when passkey.rp_id is set
+	if passkeysCtrl != nil {
+		public.POST("/login/passkey/begin", passkeysCtrl.PostLoginBegin, authRateLimiter)
+		public.POST("/login/passkey/finish", passkeysCtrl.PostLoginFinish, authRateLimiter)
+	}
+
+	// Social login; unconfigured providers answer 404
+	public.GET("/oauth/:provider/authorize", oauthCtrl.GetAuthorize, authRateLimiter)
+	public.GET("/oauth/:provider/callback", oauthCtrl.GetCallback, authRateLimiter)
+
+	// Endpoints of the authenticated user, by JWT or API key
+	me := public.Group("/me", authenticate)
+	// Impersonation tokens may read as the user but not change how the user
+	// signs in or which organizations the user and others belong to
+	denyImpersonation := middlewares.DenyImpersonation()
+	me.PUT("/password", authCtrl.PutPassword, authRateLimiter, middlewares.RequireScope(model.ScopePasswordWrite), denyImpersonation)
+	me.POST("/api-keys", apiKeysCtrl.PostAPIKey, middlewares.RequireScope(model.ScopeAPIKeysWrite), denyImpersonation)
+	me.GET("/api-keys", apiKeysCtrl.GetAPIKeys, middlewares.RequireScope(model.ScopeAPIKeysRead))
+	me.DELETE("/api-keys/:id", apiKeysCtrl.DeleteAPIKey, middlewares.RequireScope(model.ScopeAPIKeysWrite), denyImpersonation)
+
+	// Identities can only be linked with a JWT, not an API key
+	me.POST("/identities/:provider", oauthCtrl.PostLink, authRateLimiter, denyImpersonation)
+
+	// Sessions can only be managed with a JWT, not an API key
+	me.GET("/sessions", sessionsCtrl.GetSessions)
+	me.DELETE("/sessions/:id", sessionsCtrl.DeleteSession, denyImpersonation)
+
+	// Passkeys can only be managed with a JWT, not an API key
+	if passkeysCtrl != nil {
+		me.POST("/passkeys/register/begin", passkeysCtrl.PostRegisterBegin, denyImpersonation)
+		me.POST("/passkeys/register/finish", passkeysCtrl.PostRegisterFinish, denyImpersonation)
+		me.GET("/passkeys", passkeysCtrl.GetPasskeys)
+		me.DELETE("/passkeys/:id", passkeysCtrl.DeletePasskey, denyImpersonation)
+	}
+
+	// Organizations of the authenticated user. Organization tokens are only
+	// issued for a JWT; API keys name the organization in X-Org-ID.
+	requireOrgsRead := middlewares.RequireScope(model.ScopeOrgsRead)
+	requireOrgsWrite := middlewares.RequireScope(model.ScopeOrgsWrite)
+	me.GET("/orgs", orgsCtrl.GetMyOrganizations, requireOrgsRead)
+	public.POST("/orgs", orgsCtrl.PostOrganization, authenticate, requireOrgsWrite, denyImpersonation)
+	public.POST("/orgs/:id/token", orgsCtrl.PostOrgToken, authenticate, denyImpersonation)
+	public.POST("/invitations/accept", orgsCtrl.PostInvitationAcceptance, authenticate, requireOrgsWrite, denyImpersonation)
+
+	// Endpoints acting in the organization of the token or X-Org-ID header,
+	// of which the caller must be a member
+	org := public.Group("/org", authenticate, tenant)
+	requireOrgAdmin := middlewares.RequireOrgRole(model.OrgRoleAdmin)
+	org.GET("", orgsCtrl.GetOrganization, requireOrgsRead)
+	org.GET("/members", orgsCtrl.GetMembers, requireOrgsRead)
+	org.PATCH("/members/:user_id", orgsCtrl.PatchMember, requireOrgsWrite, requireOrgAdmin, denyImpersonation)
+	org.DELETE("/members/:user_id", orgsCtrl.DeleteMember, requireOrgsWrite, denyImpersonation)
+	org.POST("/invitations", orgsCtrl.PostInvitation, requireOrgsWrite, requireOrgAdmin, denyImpersonation)
+	org.GET("/invitations", orgsCtrl.GetInvitations, requireOrgsRead, requireOrgAdmin)
+	org.DELETE("/invitations/:id", orgsCtrl.DeleteInvitation, requireOrgsWrite, requireOrgAdmin, denyImpersonation)
+
+	// Authorization server for other apps, when idp.issuer is set. Its
+	// protocol endpoints sit at the issuer's root, as clients expect.
+	if oauthServerCtrl != nil {
+		e.GET("/.well-known/openid-configuration", oauthServerCtrl.GetDiscovery)
+		e.GET("/oauth2/jwks", oauthServerCtrl.GetJWKS)
+		e.GET("/oauth2/authorize", oauthServerCtrl.GetAuthorize)
+		e.POST("/oauth2/token", oauthServerCtrl.PostToken, authRateLimiter)
+		e.POST("/oauth2/introspect", oauthServerCtrl.PostIntrospect)
+		e.POST("/oauth2/revoke", oauthServerCtrl.PostRevoke)
+		e.GET("/oauth2/userinfo", oauthServerCtrl.GetUserInfo)
+		e.POST("/oauth2/userinfo", oauthServerCtrl.GetUserInfo)
+		me.POST("/oauth2/authorize", oauthServerCtrl.PostAuthorize, denyImpersonation)
+	}
+
+	// Error code catalog for client SDK authors
+	public.GET("/error-codes", errcodesCtrl.GetErrorCodes)
+
+	// Admin endpoints are only exposed when a token is configured
+	if adminToken != "" {
+		adminGroup := e.Group("/admin", middlewares.AdminToken(adminToken))
+		adminGroup.GET("/log/level", adminCtrl.GetLogLevel)
+		adminGroup.PUT("/log/level", adminCtrl.PutLogLevel)
+		// The staff member also signs in as themselves, which names the actor
+		adminGroup.POST("/impersonations", adminCtrl.PostImpersonation, authenticate, denyImpersonation)
+		adminGroup.GET("/audit-events", auditCtrl.GetEvents)
+		adminGroup.GET("/audit-events/verification", auditCtrl.GetVerification)
+		if oauthServerCtrl != nil {
+			adminGroup.POST("/oauth2/clients", oauthServerCtrl.PostClient)
+			adminGroup.GET("/oauth2/clients", oauthServerCtrl.GetClients)
+		}
+	}
+
 	return e
 }
@@ -4,35 +4,119 @@
 package rest
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/google/wire"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	governredis "github.com/haipham22/govern/database/redis"
+	govern "github.com/haipham22/govern/graceful"
 	governhttp "github.com/haipham22/govern/http"
+	"github.com/redis/go-redis/v9"
 
+	adminctrl "golang-sample/internal/handler/rest/controllers/admin"
+	apikeysctrl "golang-sample/internal/handler/rest/controllers/apikeys"
+	auditctrl "golang-sample/internal/handler/rest/controllers/audit"
 	authctrl "golang-sample/internal/handler/rest/controllers/auth"
+	errcodesctrl "golang-sample/internal/handler/rest/controllers/errcodes"
 	healthctrl "golang-sample/internal/handler/rest/controllers/health"
+	magiclinkctrl "golang-sample/internal/handler/rest/controllers/magiclink"
+	oauthctrl "golang-sample/internal/handler/rest/controllers/oauth"
+	oauthserverctrl "golang-sample/internal/handler/rest/controllers/oauthserver"
+	orgsctrl "golang-sample/internal/handler/rest/controllers/orgs"
+	passkeysctrl "golang-sample/internal/handler/rest/controllers/passkeys"
+	sessionsctrl "golang-sample/internal/handler/rest/controllers/sessions"
+	"golang-sample/internal/healthcheck"
+	"golang-sample/internal/metrics"
+	apikeyservice "golang-sample/internal/service/apikey"
+	auditservice "golang-sample/internal/service/audit"
 	authservice "golang-sample/internal/service/auth"
+	oauthserverservice "golang-sample/internal/service/oauthserver"
+	orgservice "golang-sample/internal/service/organization"
+	outboxservice "golang-sample/internal/service/outbox"
+	passkeyservice "golang-sample/internal/service/passkey"
+	sessionservice "golang-sample/internal/service/session"
+	apikeyRepo "golang-sample/internal/storage/apikey"
+	auditRepo "golang-sample/internal/storage/audit"
+	identityRepo "golang-sample/internal/storage/identity"
+	magiclinkRepo "golang-sample/internal/storage/magiclink"
+	oauthserverRepo "golang-sample/internal/storage/oauthserver"
+	orgRepo "golang-sample/internal/storage/organization"
+	outboxRepo "golang-sample/internal/storage/outbox"
+	passkeyRepo "golang-sample/internal/storage/passkey"
+	sessionRepo "golang-sample/internal/storage/session"
 	userRepo "golang-sample/internal/storage/user"
+	"golang-sample/internal/tenant"
 	"golang-sample/pkg/config"
+	"golang-sample/pkg/mailer"
+	"golang-sample/pkg/oidc"
 	"golang-sample/pkg/postgres"
+	"golang-sample/pkg/utils/password"
+	"golang-sample/pkg/webauthn"
 )
 
-// authConfig holds JWT configuration
+// authConfig holds JWT and password configuration
 type authConfig struct {
-	jwtSecret string
+	jwtSecret      string
+	passwordPolicy password.Policy
+	breachList     string
+	hasher         password.Hasher
+	nonEnumerating bool
+	// magicLink is enabled when its URL is set
+	magicLink authservice.MagicLinkConfig
+	// sessionlessCutoff rejects JWTs without a session issued before it
+	sessionlessCutoff time.Time
 }
 
 func provideAuthService(
 	log *zap.SugaredLogger,
 	storage userRepo.Storage,
+	identities identityRepo.Storage,
+	magicLinks magiclinkRepo.Storage,
+	m mailer.Mailer,
+	passkeys passkeyservice.Service,
+	sessions sessionservice.Service,
+	auditLog auditservice.Service,
+	events outboxRepo.Storage,
 	cfg authConfig,
-) authservice.Service {
+) (authservice.Service, error) {
 	jwtExpiration := 72 * time.Hour
-	return authservice.NewAuthService(log, storage, cfg.jwtSecret, jwtExpiration)
+
+	opts := []authservice.Option{
+		authservice.WithPasswordPolicy(cfg.passwordPolicy),
+		authservice.WithHasher(cfg.hasher),
+		authservice.WithIdentityStorage(identities),
+		authservice.WithSessions(sessions),
+		authservice.WithAuditLog(auditLog),
+		authservice.WithEvents(events),
+	}
+	if cfg.magicLink.URL != "" {
+		opts = append(opts, authservice.WithMagicLinks(magicLinks, m, cfg.magicLink))
+	}
+	if passkeys != nil {
+		opts = append(opts, authservice.WithPasskeys(passkeys))
+	}
+	if cfg.nonEnumerating {
+		opts = append(opts, authservice.WithNonEnumeratingRegistration(m))
+	}
+	if cfg.breachList != "" {
+		breaches, err := password.OpenBreachList(cfg.breachList)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, authservice.WithBreachChecker(breaches))
+	}
+
+	return authservice.NewAuthService(log, storage, cfg.jwtSecret, jwtExpiration, opts...), nil
 }
 
 func provideDebugFlag(appConfig *config.EnvConfigMap) bool {
@@ -44,7 +128,79 @@ func provideEnv(appConfig *config.EnvConfigMap) string {
 }
 
 func provideDB(appConfig *config.EnvConfigMap) (*gorm.DB, func(), error) {
-	return postgres.NewGormDB(appConfig.Postgres.DSN)
+	db, cleanup, err := postgres.NewGormDB(appConfig.Postgres.DSN)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	metrics.MustRegisterDBStats(sqlDB, "postgres")
+
+	// Queries on tenant-owned tables fail unless scoped to an organization
+	if err := tenant.Register(db); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return db, cleanup, nil
+}
+
+// provideRedis connects to Redis when redis.url is set; the client is nil otherwise
+func provideRedis(appConfig *config.EnvConfigMap) (*redis.Client, func(), error) {
+	if appConfig.Redis.URL == "" {
+		return nil, func() {}, nil
+	}
+	return governredis.NewFromDSN(appConfig.Redis.URL)
+}
+
+// provideMailer relays through SMTP when mail.smtp_host is set and writes
+// mail to the log otherwise, which config validation only allows in development
+func provideMailer(log *zap.SugaredLogger, appConfig *config.EnvConfigMap) (mailer.Mailer, error) {
+	mail := appConfig.Mail
+	if mail.SMTPHost == "" {
+		return mailer.NewLog(log), nil
+	}
+	return mailer.NewSMTP(mailer.SMTPConfig{
+		Host:     mail.SMTPHost,
+		Port:     mail.SMTPPort,
+		Username: mail.SMTPUsername,
+		Password: mail.SMTPPassword,
+		From:     mail.From,
+	})
+}
+
+// provideHealthChecker registers the readiness checks. The checker starts
+// draining as soon as ctx, the shutdown signal context, is done.
+func provideHealthChecker(
+	ctx context.Context,
+	db *gorm.DB,
+	redisClient *redis.Client,
+	appConfig *config.EnvConfigMap,
+) *healthcheck.Checker {
+	checker := healthcheck.NewChecker(2 * time.Second)
+
+	checker.Register("database", healthcheck.DBCheck(db), healthcheck.Critical())
+	checker.Register("migrations", healthcheck.MigrationsCheck(db), healthcheck.Critical())
+	if redisClient != nil {
+		checker.Register("redis", healthcheck.RedisCheck(redisClient))
+	}
+
+	diskPath := appConfig.Health.DiskPath
+	if diskPath == "" {
+		diskPath = "."
+	}
+	minFree := appConfig.Health.DiskMinFree
+	if minFree == 0 {
+		minFree = 0.05
+	}
+	checker.Register("disk", healthcheck.DiskCheck(diskPath, minFree))
+
+	checker.DrainOnDone(ctx)
+	return checker
 }
 
 // provideAuthConfig extracts JWT config from main config
@@ -52,38 +208,403 @@ func provideAuthConfig(appConfig *config.EnvConfigMap) authConfig {
 	if appConfig.API.Secret == "" {
 		panic("JWT secret is required but not configured. Please set api.secret in your config file.")
 	}
-	return authConfig{
-		jwtSecret: appConfig.API.Secret,
+
+	policy := password.DefaultPolicy()
+	pw := appConfig.Password
+	if pw.MinLength > 0 {
+		policy.MinLength = pw.MinLength
+	}
+	if pw.MaxLength > 0 {
+		policy.MaxLength = pw.MaxLength
+	}
+	if pw.MinStrength > 0 {
+		policy.MinStrength = pw.MinStrength
+	}
+	policy.RequireLower = pw.RequireLower
+	policy.RequireUpper = pw.RequireUpper
+	policy.RequireDigit = pw.RequireDigit
+	policy.RequireSymbol = pw.RequireSymbol
+
+	cfg := authConfig{
+		jwtSecret:      appConfig.API.Secret,
+		passwordPolicy: policy,
+		breachList:     pw.BreachList,
+		hasher:         newPasswordHasher(appConfig),
+		nonEnumerating: appConfig.Auth.NonEnumeratingRegistration,
+	}
+	if cutoff := appConfig.Auth.SessionlessTokenCutoff; cutoff != "" {
+		// Validated as RFC 3339 with the rest of the config
+		cfg.sessionlessCutoff, _ = time.Parse(time.RFC3339, cutoff)
+	}
+	if base := strings.TrimRight(appConfig.MagicLink.BaseURL, "/"); base != "" {
+		ttl := appConfig.MagicLink.TTL
+		if ttl == 0 {
+			ttl = 15 * time.Minute
+		}
+		cfg.magicLink = authservice.MagicLinkConfig{
+			Key:          deriveKey(appConfig.API.Secret, "magic-link"),
+			URL:          base + "/api/login/magic-link/verify",
+			TTL:          ttl,
+			MaxPerWindow: appConfig.MagicLink.MaxPerHour,
+			Window:       time.Hour,
+		}
+	}
+	return cfg
+}
+
+// deriveKey derives the signing key of purpose from the JWT secret, so
+// tokens signed for one purpose never pass as another
+func deriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// newPasswordHasher builds the hasher of new passwords from the password
+// config, bounded by a hashing pool
+func newPasswordHasher(appConfig *config.EnvConfigMap) password.Hasher {
+	queueTimeout := appConfig.Password.HashQueueTimeout
+	if queueTimeout == 0 {
+		queueTimeout = 2 * time.Second
+	}
+	pool := password.NewPool(appConfig.Password.HashConcurrency, queueTimeout)
+	metrics.MustRegisterPasswordPool(pool)
+
+	return pool.Limit(newPasswordAlgorithm(appConfig))
+}
+
+// newPasswordAlgorithm returns the configured hashing algorithm
+func newPasswordAlgorithm(appConfig *config.EnvConfigMap) password.Hasher {
+	pw := appConfig.Password
+	if pw.Algorithm == "bcrypt" {
+		cost := pw.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		return password.NewBcrypt(cost)
+	}
+
+	params := password.DefaultArgon2idParams()
+	if pw.Argon2MemoryKiB > 0 {
+		params.Memory = pw.Argon2MemoryKiB
+	}
+	if pw.Argon2Iterations > 0 {
+		params.Iterations = pw.Argon2Iterations
+	}
+	if pw.Argon2Parallelism > 0 {
+		params.Parallelism = pw.Argon2Parallelism
+	}
+	return password.NewArgon2id(params)
+}
+
+// provideOAuthConfig builds the identity providers that have a client ID;
+// there are none without oauth.redirect_base_url
+func provideOAuthConfig(appConfig *config.EnvConfigMap, auth authConfig) oauthctrl.Config {
+	oauth := appConfig.OAuth
+	base := strings.TrimRight(oauth.RedirectBaseURL, "/")
+
+	// The flow cookie gets its own key, so it can never pass as an access token
+	cfg := oauthctrl.Config{
+		Providers:    map[string]oidc.Provider{},
+		FlowKey:      deriveKey(auth.jwtSecret, "oauth-flow"),
+		SecureCookie: strings.HasPrefix(base, "https://"),
+	}
+	if base == "" {
+		return cfg
+	}
+
+	client := func(name, clientID, clientSecret string) oidc.Config {
+		return oidc.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  base + "/api/oauth/" + name + "/callback",
+		}
+	}
+	if oauth.Google.ClientID != "" {
+		cfg.Providers["google"] = oidc.Google(client("google", oauth.Google.ClientID, oauth.Google.ClientSecret))
+	}
+	if oauth.GitHub.ClientID != "" {
+		cfg.Providers["github"] = oidc.GitHub(client("github", oauth.GitHub.ClientID, oauth.GitHub.ClientSecret))
+	}
+	if oauth.OIDC.ClientID != "" && oauth.OIDC.Issuer != "" {
+		name := oauth.OIDC.Name
+		if name == "" {
+			name = "oidc"
+		}
+		cfg.Providers[name] = oidc.NewOIDC(name, oauth.OIDC.Issuer, client(name, oauth.OIDC.ClientID, oauth.OIDC.ClientSecret))
+	}
+	return cfg
+}
+
+// provideMagicLinkController serves magic link login; it is nil, and its
+// routes are not registered, without magic_link.base_url
+func provideMagicLinkController(service authservice.Service, auth authConfig) *magiclinkctrl.Controller {
+	if auth.magicLink.URL == "" {
+		return nil
+	}
+	return magiclinkctrl.New(service, magiclinkctrl.Config{
+		TTL:          auth.magicLink.TTL,
+		SecureCookie: strings.HasPrefix(auth.magicLink.URL, "https://"),
+	})
+}
+
+// provideOAuthServerService builds the authorization server for other
+// apps; the service is nil without idp.issuer
+func provideOAuthServerService(
+	log *zap.SugaredLogger,
+	storage oauthserverRepo.Storage,
+	users userRepo.Storage,
+	appConfig *config.EnvConfigMap,
+) (oauthserverservice.Service, error) {
+	idp := appConfig.IdP
+	if idp.Issuer == "" {
+		return nil, nil
+	}
+
+	var key *rsa.PrivateKey
+	var err error
+	if idp.SigningKeyFile != "" {
+		pemBytes, err := os.ReadFile(idp.SigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key, err = oauthserverservice.ParseSigningKey(pemBytes); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Warn("idp.signing_key_file is not set; ID tokens are signed with an ephemeral key")
+		if key, err = oauthserverservice.GenerateSigningKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	return oauthserverservice.NewOAuthServerService(log, storage, users, oauthserverservice.Config{
+		Issuer:         idp.Issuer,
+		SigningKey:     key,
+		AccessTokenTTL: idp.AccessTokenTTL,
+	})
+}
+
+// provideOAuthServerController serves the authorization server; it is nil,
+// and its routes are not registered, when the service is disabled
+func provideOAuthServerController(service oauthserverservice.Service, appConfig *config.EnvConfigMap) *oauthserverctrl.Controller {
+	if service == nil {
+		return nil
+	}
+	return oauthserverctrl.New(service, oauthserverctrl.Config{LoginURL: appConfig.IdP.LoginURL})
+}
+
+// provideSessionService records login sessions, emailing users about new
+// devices when auth.notify_new_device is set
+func provideSessionService(
+	log *zap.SugaredLogger,
+	storage sessionRepo.Storage,
+	m mailer.Mailer,
+	auditLog auditservice.Service,
+	appConfig *config.EnvConfigMap,
+) sessionservice.Service {
+	opts := []sessionservice.Option{sessionservice.WithAuditLog(auditLog)}
+	if appConfig.Auth.NotifyNewDevice {
+		opts = append(opts, sessionservice.WithNewDeviceNotifier(sessionservice.NewMailNotifier(m)))
+	}
+	return sessionservice.NewSessionService(log, storage, opts...)
+}
+
+func provideAPIKeyService(
+	log *zap.SugaredLogger,
+	keys apikeyRepo.Storage,
+	users userRepo.Storage,
+	auditLog auditservice.Service,
+) apikeyservice.Service {
+	return apikeyservice.NewAPIKeyService(log, keys, users, apikeyservice.WithAuditLog(auditLog))
+}
+
+// providePasskeyService builds passkey registration and login; the service
+// is nil without passkey.rp_id
+func providePasskeyService(
+	log *zap.SugaredLogger,
+	storage passkeyRepo.Storage,
+	users userRepo.Storage,
+	appConfig *config.EnvConfigMap,
+) (passkeyservice.Service, error) {
+	pk := appConfig.Passkey
+	if pk.RPID == "" {
+		return nil, nil
+	}
+
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:    pk.RPID,
+		RPName:  pk.RPName,
+		Origins: pk.Origins,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return passkeyservice.NewPasskeyService(log, storage, users, rp), nil
+}
+
+// providePasskeysController serves passkeys; it is nil, and its routes are
+// not registered, when the service is disabled
+func providePasskeysController(service passkeyservice.Service, auth authservice.Service) *passkeysctrl.Controller {
+	if service == nil {
+		return nil
+	}
+	return passkeysctrl.New(service, auth)
+}
+
+// provideOrganizationService manages organizations, emailing invitations
+// that open orgs.invitation_url when it is set
+func provideOrganizationService(
+	log *zap.SugaredLogger,
+	storage orgRepo.Storage,
+	m mailer.Mailer,
+	auditLog auditservice.Service,
+	appConfig *config.EnvConfigMap,
+) orgservice.Service {
+	return orgservice.NewOrganizationService(log, storage, m, orgservice.Config{
+		AcceptURL:     appConfig.Orgs.InvitationURL,
+		InvitationTTL: appConfig.Orgs.InvitationTTL,
+	}, orgservice.WithAuditLog(auditLog))
+}
+
+// provideEventSink fans domain events out to the sinks in events.sinks,
+// the log when there are none
+func provideEventSink(log *zap.SugaredLogger, bus *outboxservice.Bus, appConfig *config.EnvConfigMap) outboxservice.Sink {
+	events := appConfig.Events
+	names := events.Sinks
+	if len(names) == 0 {
+		names = []string{"log"}
+	}
+
+	var sinks []outboxservice.Sink
+	for _, name := range names {
+		switch name {
+		case "log":
+			sinks = append(sinks, outboxservice.NewLogSink(log))
+		case "webhook":
+			sinks = append(sinks, outboxservice.NewWebhookSink(outboxservice.WebhookConfig{
+				URL:    events.WebhookURL,
+				Secret: events.WebhookSecret,
+			}))
+		case "bus":
+			sinks = append(sinks, bus)
+		}
+	}
+	return outboxservice.Fanout(sinks...)
+}
+
+func provideRelay(
+	log *zap.SugaredLogger,
+	storage outboxRepo.Storage,
+	sink outboxservice.Sink,
+	appConfig *config.EnvConfigMap,
+) *outboxservice.Relay {
+	events := appConfig.Events
+	return outboxservice.NewRelay(log, storage, sink, outboxservice.RelayConfig{
+		PollInterval: events.PollInterval,
+		BatchSize:    events.BatchSize,
+		Retention:    events.Retention,
+	})
+}
+
+// provideServices lists what serverd runs: the API server and the outbox relay
+func provideServices(server governhttp.Server, relay *outboxservice.Relay) []govern.Service {
+	return []govern.Service{server, relay}
+}
+
+// adminConfig holds admin endpoint configuration
+type adminConfig struct {
+	token string
+}
+
+// provideAdminConfig extracts admin config from main config
+func provideAdminConfig(appConfig *config.EnvConfigMap) adminConfig {
+	return adminConfig{
+		token: appConfig.Admin.Token,
+	}
+}
+
+// errorsConfig holds error response configuration
+type errorsConfig struct {
+	legacyFormat bool
+	typeBaseURI  string
+}
+
+// provideErrorsConfig extracts error response config from main config
+func provideErrorsConfig(appConfig *config.EnvConfigMap) errorsConfig {
+	return errorsConfig{
+		legacyFormat: appConfig.Errors.LegacyFormat,
+		typeBaseURI:  appConfig.Errors.TypeBaseURI,
 	}
 }
 
-// New creates a new Handler with all dependencies wired.
-// Returns: server, cleanup function, error
+// New creates the API server and the outbox relay with all dependencies
+// wired. ctx is the shutdown signal context; readiness starts failing once
+// it is done.
+// Returns: services to run, cleanup function, error
 func New(
+	ctx context.Context,
 	log *zap.SugaredLogger,
 	port int64,
+	adminPort AdminPort,
 	appConfig *config.EnvConfigMap,
-) (governhttp.Server, func(), error) {
+	logLevel zap.AtomicLevel,
+) ([]govern.Service, func(), error) {
 	panic(wire.Build(
 		// Config providers
 		wire.NewSet(provideAuthConfig),
+		wire.NewSet(provideAdminConfig),
+		wire.NewSet(provideErrorsConfig),
+		wire.NewSet(provideOAuthConfig),
 
 		// Database
 		wire.NewSet(provideDB),
 		wire.NewSet(userRepo.New),
+		wire.NewSet(apikeyRepo.New),
+		wire.NewSet(identityRepo.New),
+		wire.NewSet(magiclinkRepo.New),
+		wire.NewSet(oauthserverRepo.New),
+		wire.NewSet(passkeyRepo.New),
+		wire.NewSet(sessionRepo.New),
+		wire.NewSet(auditRepo.New),
+		wire.NewSet(orgRepo.New),
+		wire.NewSet(outboxRepo.New),
+		wire.NewSet(provideRedis),
+		wire.NewSet(provideHealthChecker),
+		wire.NewSet(provideMailer),
 
 		// Services
 		wire.NewSet(provideAuthService),
+		wire.NewSet(provideAPIKeyService),
+		wire.NewSet(provideOAuthServerService),
+		wire.NewSet(providePasskeyService),
+		wire.NewSet(provideSessionService),
+		wire.NewSet(auditservice.NewAuditService),
+		wire.NewSet(provideOrganizationService),
+		wire.NewSet(outboxservice.NewBus),
+		wire.NewSet(provideEventSink),
+		wire.NewSet(provideRelay),
 
 		// Controllers
 		wire.NewSet(authctrl.New),
 		wire.NewSet(healthctrl.New),
+		wire.NewSet(adminctrl.New),
+		wire.NewSet(errcodesctrl.New),
+		wire.NewSet(apikeysctrl.New),
+		wire.NewSet(oauthctrl.New),
+		wire.NewSet(provideMagicLinkController),
+		wire.NewSet(provideOAuthServerController),
+		wire.NewSet(providePasskeysController),
+		wire.NewSet(sessionsctrl.New),
+		wire.NewSet(auditctrl.New),
+		wire.NewSet(orgsctrl.New),
 
 		wire.NewSet(provideDebugFlag),
 		wire.NewSet(provideEnv),
 
 		// HTTP Server
 		wire.NewSet(NewHandler),
+		wire.NewSet(provideServices),
 
 		echo.New,
 	))
@@ -7,56 +7,192 @@
 package rest
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	redis2 "github.com/haipham22/govern/database/redis"
+	"github.com/haipham22/govern/graceful"
 	"github.com/haipham22/govern/http"
 	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"golang-sample/internal/handler/rest/controllers/admin"
+	"golang-sample/internal/handler/rest/controllers/apikeys"
+	audit3 "golang-sample/internal/handler/rest/controllers/audit"
 	"golang-sample/internal/handler/rest/controllers/auth"
+	"golang-sample/internal/handler/rest/controllers/errcodes"
 	"golang-sample/internal/handler/rest/controllers/health"
+	magiclink2 "golang-sample/internal/handler/rest/controllers/magiclink"
+	"golang-sample/internal/handler/rest/controllers/oauth"
+	oauthserver3 "golang-sample/internal/handler/rest/controllers/oauthserver"
+	"golang-sample/internal/handler/rest/controllers/orgs"
+	"golang-sample/internal/handler/rest/controllers/passkeys"
+	"golang-sample/internal/handler/rest/controllers/sessions"
+	"golang-sample/internal/healthcheck"
+	"golang-sample/internal/metrics"
+	apikey2 "golang-sample/internal/service/apikey"
+	audit2 "golang-sample/internal/service/audit"
 	auth2 "golang-sample/internal/service/auth"
+	oauthserver2 "golang-sample/internal/service/oauthserver"
+	organization2 "golang-sample/internal/service/organization"
+	outbox2 "golang-sample/internal/service/outbox"
+	passkey2 "golang-sample/internal/service/passkey"
+	session2 "golang-sample/internal/service/session"
+	"golang-sample/internal/storage/apikey"
+	"golang-sample/internal/storage/audit"
+	"golang-sample/internal/storage/identity"
+	"golang-sample/internal/storage/magiclink"
+	"golang-sample/internal/storage/oauthserver"
+	"golang-sample/internal/storage/organization"
+	"golang-sample/internal/storage/outbox"
+	"golang-sample/internal/storage/passkey"
+	"golang-sample/internal/storage/session"
 	"golang-sample/internal/storage/user"
+	"golang-sample/internal/tenant"
 	"golang-sample/pkg/config"
+	"golang-sample/pkg/mailer"
+	"golang-sample/pkg/oidc"
 	"golang-sample/pkg/postgres"
+	"golang-sample/pkg/utils/password"
+	"golang-sample/pkg/webauthn"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"os"
+	"strings"
 	"time"
 )
 
 // Injectors from wire.go:
 
-// New creates a new Handler with all dependencies wired.
-// Returns: server, cleanup function, error
-func New(log *zap.SugaredLogger, port int64, appConfig *config.EnvConfigMap) (http.Server, func(), error) {
+// New creates the API server and the outbox relay with all dependencies
+// wired. ctx is the shutdown signal context; readiness starts failing once
+// it is done.
+// Returns: services to run, cleanup function, error
+func New(ctx context.Context, log *zap.SugaredLogger, port int64, adminPort AdminPort, appConfig *config.EnvConfigMap, logLevel zap.AtomicLevel) ([]graceful.Service, func(), error) {
 	echoEcho := echo.New()
 	db, cleanup, err := provideDB(appConfig)
 	if err != nil {
 		return nil, nil, err
 	}
 	storage := user.New(log, db)
+	identityStorage := identity.New(log, db)
+	magiclinkStorage := magiclink.New(log, db)
+	mailer, err := provideMailer(log, appConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	passkeyStorage := passkey.New(log, db)
+	service, err := providePasskeyService(log, passkeyStorage, storage, appConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	sessionStorage := session.New(log, db)
+	auditStorage := audit.New(log, db)
+	auditService := audit2.NewAuditService(log, auditStorage)
+	sessionService := provideSessionService(log, sessionStorage, mailer, auditService, appConfig)
+	outboxStorage := outbox.New(log, db)
 	restAuthConfig := provideAuthConfig(appConfig)
-	service := provideAuthService(log, storage, restAuthConfig)
-	controller := auth.New(service)
-	healthController := health.New(db)
+	authService, err := provideAuthService(log, storage, identityStorage, magiclinkStorage, mailer, service, sessionService, auditService, outboxStorage, restAuthConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	controller := auth.New(authService)
+	client, cleanup2, err := provideRedis(appConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	checker := provideHealthChecker(ctx, db, client, appConfig)
+	healthController := health.New(db, checker)
+	adminController := admin.New(logLevel, authService)
+	errcodesController := errcodes.New()
+	apikeyStorage := apikey.New(log, db)
+	apikeyService := provideAPIKeyService(log, apikeyStorage, storage, auditService)
+	apikeysController := apikeys.New(apikeyService)
+	oauthConfig := provideOAuthConfig(appConfig, restAuthConfig)
+	oauthController := oauth.New(log, authService, oauthConfig)
+	magiclinkController := provideMagicLinkController(authService, restAuthConfig)
+	oauthserverStorage := oauthserver.New(log, db)
+	oauthserverService, err := provideOAuthServerService(log, oauthserverStorage, storage, appConfig)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	oauthserverController := provideOAuthServerController(oauthserverService, appConfig)
+	passkeysController := providePasskeysController(service, authService)
+	sessionsController := sessions.New(sessionService)
+	auditController := audit3.New(auditService)
+	organizationStorage := organization.New(log, db)
+	organizationService := provideOrganizationService(log, organizationStorage, mailer, auditService, appConfig)
+	orgsController := orgs.New(organizationService, authService)
+	restAdminConfig := provideAdminConfig(appConfig)
+	restErrorsConfig := provideErrorsConfig(appConfig)
 	bool2 := provideDebugFlag(appConfig)
 	string2 := provideEnv(appConfig)
-	server := NewHandler(log, echoEcho, controller, healthController, port, bool2, string2)
-	return server, func() {
+	server := NewHandler(log, echoEcho, controller, healthController, adminController, errcodesController, apikeysController, oauthController, magiclinkController, oauthserverController, passkeysController, sessionsController, auditController, orgsController, apikeyService, sessionService, auditService, organizationService, restAuthConfig, restAdminConfig, restErrorsConfig, port, adminPort, bool2, string2)
+	bus := outbox2.NewBus()
+	sink := provideEventSink(log, bus, appConfig)
+	relay := provideRelay(log, outboxStorage, sink, appConfig)
+	v := provideServices(server, relay)
+	return v, func() {
+		cleanup2()
 		cleanup()
 	}, nil
 }
 
 // wire.go:
 
-// authConfig holds JWT configuration
+// authConfig holds JWT and password configuration
 type authConfig struct {
-	jwtSecret string
+	jwtSecret      string
+	passwordPolicy password.Policy
+	breachList     string
+	hasher         password.Hasher
+	nonEnumerating bool
+	// magicLink is enabled when its URL is set
+	magicLink auth2.MagicLinkConfig
+	// sessionlessCutoff rejects JWTs without a session issued before it
+	sessionlessCutoff time.Time
 }
 
 func provideAuthService(
 	log *zap.SugaredLogger,
 	storage user.Storage,
+	identities identity.Storage,
+	magicLinks magiclink.Storage,
+	m mailer.Mailer,
+	passkeys passkey2.Service, sessions2 session2.Service,
+
+	auditLog audit2.Service,
+	events outbox.Storage,
 	cfg authConfig,
-) auth2.Service {
+) (auth2.Service, error) {
 	jwtExpiration := 72 * time.Hour
-	return auth2.NewAuthService(log, storage, cfg.jwtSecret, jwtExpiration)
+
+	opts := []auth2.Option{auth2.WithPasswordPolicy(cfg.passwordPolicy), auth2.WithHasher(cfg.hasher), auth2.WithIdentityStorage(identities), auth2.WithSessions(sessions2), auth2.WithAuditLog(auditLog), auth2.WithEvents(events)}
+	if cfg.magicLink.URL != "" {
+		opts = append(opts, auth2.WithMagicLinks(magicLinks, m, cfg.magicLink))
+	}
+	if passkeys != nil {
+		opts = append(opts, auth2.WithPasskeys(passkeys))
+	}
+	if cfg.nonEnumerating {
+		opts = append(opts, auth2.WithNonEnumeratingRegistration(m))
+	}
+	if cfg.breachList != "" {
+		breaches, err := password.OpenBreachList(cfg.breachList)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, auth2.WithBreachChecker(breaches))
+	}
+
+	return auth2.NewAuthService(log, storage, cfg.jwtSecret, jwtExpiration, opts...), nil
 }
 
 func provideDebugFlag(appConfig *config.EnvConfigMap) bool {
@@ -68,7 +204,78 @@ func provideEnv(appConfig *config.EnvConfigMap) string {
 }
 
 func provideDB(appConfig *config.EnvConfigMap) (*gorm.DB, func(), error) {
-	return postgres.NewGormDB(appConfig.Postgres.DSN)
+	db, cleanup, err := postgres.NewGormDB(appConfig.Postgres.DSN)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	metrics.MustRegisterDBStats(sqlDB, "postgres")
+
+	if err := tenant.Register(db); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return db, cleanup, nil
+}
+
+// provideRedis connects to Redis when redis.url is set; the client is nil otherwise
+func provideRedis(appConfig *config.EnvConfigMap) (*redis.Client, func(), error) {
+	if appConfig.Redis.URL == "" {
+		return nil, func() {}, nil
+	}
+	return redis2.NewFromDSN(appConfig.Redis.URL)
+}
+
+// provideMailer relays through SMTP when mail.smtp_host is set and writes
+// mail to the log otherwise, which config validation only allows in development
+func provideMailer(log *zap.SugaredLogger, appConfig *config.EnvConfigMap) (mailer.Mailer, error) {
+	mail := appConfig.Mail
+	if mail.SMTPHost == "" {
+		return mailer.NewLog(log), nil
+	}
+	return mailer.NewSMTP(mailer.SMTPConfig{
+		Host:     mail.SMTPHost,
+		Port:     mail.SMTPPort,
+		Username: mail.SMTPUsername,
+		Password: mail.SMTPPassword,
+		From:     mail.From,
+	})
+}
+
+// provideHealthChecker registers the readiness checks. The checker starts
+// draining as soon as ctx, the shutdown signal context, is done.
+func provideHealthChecker(
+	ctx context.Context,
+	db *gorm.DB,
+	redisClient *redis.Client,
+	appConfig *config.EnvConfigMap,
+) *healthcheck.Checker {
+	checker := healthcheck.NewChecker(2 * time.Second)
+
+	checker.Register("database", healthcheck.DBCheck(db), healthcheck.Critical())
+	checker.Register("migrations", healthcheck.MigrationsCheck(db), healthcheck.Critical())
+	if redisClient != nil {
+		checker.Register("redis", healthcheck.RedisCheck(redisClient))
+	}
+
+	diskPath := appConfig.Health.DiskPath
+	if diskPath == "" {
+		diskPath = "."
+	}
+	minFree := appConfig.Health.DiskMinFree
+	if minFree == 0 {
+		minFree = 0.05
+	}
+	checker.Register("disk", healthcheck.DiskCheck(diskPath, minFree))
+
+	checker.DrainOnDone(ctx)
+	return checker
 }
 
 // provideAuthConfig extracts JWT config from main config
@@ -76,7 +283,331 @@ func provideAuthConfig(appConfig *config.EnvConfigMap) authConfig {
 	if appConfig.API.Secret == "" {
 		panic("JWT secret is required but not configured. Please set api.secret in your config file.")
 	}
-	return authConfig{
-		jwtSecret: appConfig.API.Secret,
+
+	policy := password.DefaultPolicy()
+	pw := appConfig.Password
+	if pw.MinLength > 0 {
+		policy.MinLength = pw.MinLength
+	}
+	if pw.MaxLength > 0 {
+		policy.MaxLength = pw.MaxLength
+	}
+	if pw.MinStrength > 0 {
+		policy.MinStrength = pw.MinStrength
+	}
+	policy.RequireLower = pw.RequireLower
+	policy.RequireUpper = pw.RequireUpper
+	policy.RequireDigit = pw.RequireDigit
+	policy.RequireSymbol = pw.RequireSymbol
+
+	cfg := authConfig{
+		jwtSecret:      appConfig.API.Secret,
+		passwordPolicy: policy,
+		breachList:     pw.BreachList,
+		hasher:         newPasswordHasher(appConfig),
+		nonEnumerating: appConfig.Auth.NonEnumeratingRegistration,
+	}
+	if cutoff := appConfig.Auth.SessionlessTokenCutoff; cutoff != "" {
+
+		cfg.sessionlessCutoff, _ = time.Parse(time.RFC3339, cutoff)
+	}
+	if base := strings.TrimRight(appConfig.MagicLink.BaseURL, "/"); base != "" {
+		ttl := appConfig.MagicLink.TTL
+		if ttl == 0 {
+			ttl = 15 * time.Minute
+		}
+		cfg.magicLink = auth2.MagicLinkConfig{
+			Key:          deriveKey(appConfig.API.Secret, "magic-link"),
+			URL:          base + "/api/login/magic-link/verify",
+			TTL:          ttl,
+			MaxPerWindow: appConfig.MagicLink.MaxPerHour,
+			Window:       time.Hour,
+		}
+	}
+	return cfg
+}
+
+// deriveKey derives the signing key of purpose from the JWT secret, so
+// tokens signed for one purpose never pass as another
+func deriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// newPasswordHasher builds the hasher of new passwords from the password
+// config, bounded by a hashing pool
+func newPasswordHasher(appConfig *config.EnvConfigMap) password.Hasher {
+	queueTimeout := appConfig.Password.HashQueueTimeout
+	if queueTimeout == 0 {
+		queueTimeout = 2 * time.Second
+	}
+	pool := password.NewPool(appConfig.Password.HashConcurrency, queueTimeout)
+	metrics.MustRegisterPasswordPool(pool)
+
+	return pool.Limit(newPasswordAlgorithm(appConfig))
+}
+
+// newPasswordAlgorithm returns the configured hashing algorithm
+func newPasswordAlgorithm(appConfig *config.EnvConfigMap) password.Hasher {
+	pw := appConfig.Password
+	if pw.Algorithm == "bcrypt" {
+		cost := pw.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		return password.NewBcrypt(cost)
+	}
+
+	params := password.DefaultArgon2idParams()
+	if pw.Argon2MemoryKiB > 0 {
+		params.Memory = pw.Argon2MemoryKiB
+	}
+	if pw.Argon2Iterations > 0 {
+		params.Iterations = pw.Argon2Iterations
+	}
+	if pw.Argon2Parallelism > 0 {
+		params.Parallelism = pw.Argon2Parallelism
+	}
+	return password.NewArgon2id(params)
+}
+
+// provideOAuthConfig builds the identity providers that have a client ID;
+// there are none without oauth.redirect_base_url
+func provideOAuthConfig(appConfig *config.EnvConfigMap, auth3 authConfig) oauth.Config {
+	oauth2 := appConfig.OAuth
+	base := strings.TrimRight(oauth2.RedirectBaseURL, "/")
+
+	cfg := oauth.Config{
+		Providers:    map[string]oidc.Provider{},
+		FlowKey:      deriveKey(auth3.jwtSecret, "oauth-flow"),
+		SecureCookie: strings.HasPrefix(base, "https://"),
+	}
+	if base == "" {
+		return cfg
+	}
+
+	client := func(name, clientID, clientSecret string) oidc.Config {
+		return oidc.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  base + "/api/oauth/" + name + "/callback",
+		}
+	}
+	if oauth2.Google.ClientID != "" {
+		cfg.Providers["google"] = oidc.Google(client("google", oauth2.Google.ClientID, oauth2.Google.ClientSecret))
+	}
+	if oauth2.GitHub.ClientID != "" {
+		cfg.Providers["github"] = oidc.GitHub(client("github", oauth2.GitHub.ClientID, oauth2.GitHub.ClientSecret))
+	}
+	if oauth2.OIDC.ClientID != "" && oauth2.OIDC.Issuer != "" {
+		name := oauth2.OIDC.Name
+		if name == "" {
+			name = "oidc"
+		}
+		cfg.Providers[name] = oidc.NewOIDC(name, oauth2.OIDC.Issuer, client(name, oauth2.OIDC.ClientID, oauth2.OIDC.ClientSecret))
+	}
+	return cfg
+}
+
+// provideMagicLinkController serves magic link login; it is nil, and its
+// routes are not registered, without magic_link.base_url
+func provideMagicLinkController(service auth2.Service, auth3 authConfig) *magiclink2.Controller {
+	if auth3.magicLink.URL == "" {
+		return nil
+	}
+	return magiclink2.New(service, magiclink2.Config{
+		TTL:          auth3.magicLink.TTL,
+		SecureCookie: strings.HasPrefix(auth3.magicLink.URL, "https://"),
+	})
+}
+
+// provideOAuthServerService builds the authorization server for other
+// apps; the service is nil without idp.issuer
+func provideOAuthServerService(
+	log *zap.SugaredLogger,
+	storage oauthserver.Storage,
+	users user.Storage,
+	appConfig *config.EnvConfigMap,
+) (oauthserver2.Service, error) {
+	idp := appConfig.IdP
+	if idp.Issuer == "" {
+		return nil, nil
+	}
+
+	var key *rsa.PrivateKey
+	var err error
+	if idp.SigningKeyFile != "" {
+		pemBytes, err := os.ReadFile(idp.SigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key, err = oauthserver2.ParseSigningKey(pemBytes); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Warn("idp.signing_key_file is not set; ID tokens are signed with an ephemeral key")
+		if key, err = oauthserver2.GenerateSigningKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	return oauthserver2.NewOAuthServerService(log, storage, users, oauthserver2.Config{
+		Issuer:         idp.Issuer,
+		SigningKey:     key,
+		AccessTokenTTL: idp.AccessTokenTTL,
+	})
+}
+
+// provideOAuthServerController serves the authorization server; it is nil,
+// and its routes are not registered, when the service is disabled
+func provideOAuthServerController(service oauthserver2.Service, appConfig *config.EnvConfigMap) *oauthserver3.Controller {
+	if service == nil {
+		return nil
+	}
+	return oauthserver3.New(service, oauthserver3.Config{LoginURL: appConfig.IdP.LoginURL})
+}
+
+// provideSessionService records login sessions, emailing users about new
+// devices when auth.notify_new_device is set
+func provideSessionService(
+	log *zap.SugaredLogger,
+	storage session.Storage,
+	m mailer.Mailer,
+	auditLog audit2.Service,
+	appConfig *config.EnvConfigMap,
+) session2.Service {
+	opts := []session2.Option{session2.WithAuditLog(auditLog)}
+	if appConfig.Auth.NotifyNewDevice {
+		opts = append(opts, session2.WithNewDeviceNotifier(session2.NewMailNotifier(m)))
+	}
+	return session2.NewSessionService(log, storage, opts...)
+}
+
+func provideAPIKeyService(
+	log *zap.SugaredLogger,
+	keys apikey.Storage,
+	users user.Storage,
+	auditLog audit2.Service,
+) apikey2.Service {
+	return apikey2.NewAPIKeyService(log, keys, users, apikey2.WithAuditLog(auditLog))
+}
+
+// providePasskeyService builds passkey registration and login; the service
+// is nil without passkey.rp_id
+func providePasskeyService(
+	log *zap.SugaredLogger,
+	storage passkey.Storage,
+	users user.Storage,
+	appConfig *config.EnvConfigMap,
+) (passkey2.Service, error) {
+	pk := appConfig.Passkey
+	if pk.RPID == "" {
+		return nil, nil
+	}
+
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:    pk.RPID,
+		RPName:  pk.RPName,
+		Origins: pk.Origins,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return passkey2.NewPasskeyService(log, storage, users, rp), nil
+}
+
+// providePasskeysController serves passkeys; it is nil, and its routes are
+// not registered, when the service is disabled
+func providePasskeysController(service passkey2.Service, auth3 auth2.Service) *passkeys.Controller {
+	if service == nil {
+		return nil
+	}
+	return passkeys.New(service, auth3)
+}
+
+// provideOrganizationService manages organizations, emailing invitations
+// that open orgs.invitation_url when it is set
+func provideOrganizationService(
+	log *zap.SugaredLogger,
+	storage organization.Storage,
+	m mailer.Mailer,
+	auditLog audit2.Service,
+	appConfig *config.EnvConfigMap,
+) organization2.Service {
+	return organization2.NewOrganizationService(log, storage, m, organization2.Config{
+		AcceptURL:     appConfig.Orgs.InvitationURL,
+		InvitationTTL: appConfig.Orgs.InvitationTTL,
+	}, organization2.WithAuditLog(auditLog))
+}
+
+// provideEventSink fans domain events out to the sinks in events.sinks,
+// the log when there are none
+func provideEventSink(log *zap.SugaredLogger, bus *outbox2.Bus, appConfig *config.EnvConfigMap) outbox2.Sink {
+	events := appConfig.Events
+	names := events.Sinks
+	if len(names) == 0 {
+		names = []string{"log"}
+	}
+
+	var sinks []outbox2.Sink
+	for _, name := range names {
+		switch name {
+		case "log":
+			sinks = append(sinks, outbox2.NewLogSink(log))
+		case "webhook":
+			sinks = append(sinks, outbox2.NewWebhookSink(outbox2.WebhookConfig{
+				URL:    events.WebhookURL,
+				Secret: events.WebhookSecret,
+			}))
+		case "bus":
+			sinks = append(sinks, bus)
+		}
+	}
+	return outbox2.Fanout(sinks...)
+}
+
+func provideRelay(
+	log *zap.SugaredLogger,
+	storage outbox.Storage,
+	sink outbox2.Sink,
+	appConfig *config.EnvConfigMap,
+) *outbox2.Relay {
+	events := appConfig.Events
+	return outbox2.NewRelay(log, storage, sink, outbox2.RelayConfig{
+		PollInterval: events.PollInterval,
+		BatchSize:    events.BatchSize,
+		Retention:    events.Retention,
+	})
+}
+
+// provideServices lists what serverd runs: the API server and the outbox relay
+func provideServices(server http.Server, relay *outbox2.Relay) []graceful.Service {
+	return []graceful.Service{server, relay}
+}
+
+// adminConfig holds admin endpoint configuration
+type adminConfig struct {
+	token string
+}
+
+// provideAdminConfig extracts admin config from main config
+func provideAdminConfig(appConfig *config.EnvConfigMap) adminConfig {
+	return adminConfig{
+		token: appConfig.Admin.Token,
+	}
+}
+
+// errorsConfig holds error response configuration
+type errorsConfig struct {
+	legacyFormat bool
+	typeBaseURI  string
+}
+
+// provideErrorsConfig extracts error response config from main config
+func provideErrorsConfig(appConfig *config.EnvConfigMap) errorsConfig {
+	return errorsConfig{
+		legacyFormat: appConfig.Errors.LegacyFormat,
+		typeBaseURI:  appConfig.Errors.TypeBaseURI,
 	}
 }
@@ -0,0 +1,169 @@
+// Package healthcheck aggregates dependency checks for the readiness probe.
+// Critical checks gate readiness; non-critical checks only degrade it to a
+// warning. Results are cached briefly so frequent probes don't hammer
+// dependencies.
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	governhealth "github.com/haipham22/govern/healthcheck"
+)
+
+// Status values reported for checks and the overall report
+const (
+	StatusPassing = governhealth.StatusPassing
+	StatusWarning = governhealth.StatusWarning
+	StatusFailing = governhealth.StatusFailing
+)
+
+// defaultTimeout bounds a check that has no explicit timeout
+const defaultTimeout = 2 * time.Second
+
+// Result is the outcome of a single check
+type Result struct {
+	Status     governhealth.Status `json:"status"`
+	Critical   bool                `json:"critical"`
+	Message    string              `json:"message,omitempty"`
+	DurationMS int64               `json:"duration_ms"`
+}
+
+// Report is the aggregated outcome of all checks
+type Report struct {
+	// Status fails when a critical check fails and warns when a non-critical one does
+	Status    governhealth.Status `json:"status"`
+	Checks    map[string]Result   `json:"checks"`
+	CheckedAt time.Time           `json:"checked_at"`
+}
+
+// Option configures a registered check
+type Option func(*checkOptions)
+
+type checkOptions struct {
+	critical bool
+	timeout  time.Duration
+}
+
+// Critical marks a check whose failure makes the service not ready
+func Critical() Option {
+	return func(o *checkOptions) { o.critical = true }
+}
+
+// WithTimeout overrides the default 2s timeout of a check
+func WithTimeout(d time.Duration) Option {
+	return func(o *checkOptions) { o.timeout = d }
+}
+
+// Checker runs registered checks and tracks whether the service is draining
+type Checker struct {
+	critical    *governhealth.Registry
+	nonCritical *governhealth.Registry
+	cacheTTL    time.Duration
+	draining    atomic.Bool
+
+	mu       sync.Mutex
+	cached   *Report
+	cachedAt time.Time
+}
+
+// NewChecker creates a checker caching reports for cacheTTL; zero disables caching
+func NewChecker(cacheTTL time.Duration) *Checker {
+	return &Checker{
+		critical:    governhealth.New(),
+		nonCritical: governhealth.New(),
+		cacheTTL:    cacheTTL,
+	}
+}
+
+// Register adds a named check. Checks are non-critical unless Critical is given.
+// Panics if name is already registered.
+func (c *Checker) Register(name string, check governhealth.Check, opts ...Option) {
+	o := &checkOptions{timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	registry := c.nonCritical
+	if o.critical {
+		registry = c.critical
+	}
+	registry.Register(name, check, governhealth.WithTimeout(o.timeout))
+}
+
+// Run returns the current report, re-running checks when the cached one expired
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Since(c.cachedAt) < c.cacheTTL {
+		return c.cached
+	}
+
+	report := &Report{
+		Status:    StatusPassing,
+		Checks:    map[string]Result{},
+		CheckedAt: time.Now().UTC(),
+	}
+
+	var wg sync.WaitGroup
+	var critical, nonCritical *governhealth.Response
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		critical = c.critical.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		nonCritical = c.nonCritical.Run(ctx)
+	}()
+	wg.Wait()
+
+	for name, r := range critical.Checks {
+		report.Checks[name] = newResult(r, true)
+		if r.Status == StatusFailing {
+			report.Status = StatusFailing
+		}
+	}
+	for name, r := range nonCritical.Checks {
+		report.Checks[name] = newResult(r, false)
+		if r.Status != StatusPassing && report.Status == StatusPassing {
+			report.Status = StatusWarning
+		}
+	}
+
+	c.cached = report
+	c.cachedAt = time.Now()
+	return report
+}
+
+func newResult(r governhealth.Result, critical bool) Result {
+	return Result{
+		Status:     r.Status,
+		Critical:   critical,
+		Message:    r.Message,
+		DurationMS: r.Duration.Milliseconds(),
+	}
+}
+
+// StartDraining marks the service as shutting down; readiness fails from now on
+func (c *Checker) StartDraining() {
+	c.draining.Store(true)
+}
+
+// Draining reports whether shutdown has begun
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// DrainOnDone starts draining as soon as ctx is done, which is when the
+// graceful shutdown signal arrives, so load balancers stop routing to us
+// while in-flight requests finish.
+func (c *Checker) DrainOnDone(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		c.StartDraining()
+	}()
+}
@@ -0,0 +1,110 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("unavailable") }
+
+func TestChecker_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		critical func(context.Context) error
+		optional func(context.Context) error
+		want     string
+	}{
+		{"all passing", passing, passing, "pass"},
+		{"non-critical failing warns", passing, failing, "warn"},
+		{"critical failing fails", failing, passing, "fail"},
+		{"both failing fails", failing, failing, "fail"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(0)
+			checker.Register("database", tt.critical, Critical())
+			checker.Register("redis", tt.optional)
+
+			report := checker.Run(context.Background())
+
+			assert.Equal(t, tt.want, string(report.Status))
+			require.Len(t, report.Checks, 2)
+			assert.True(t, report.Checks["database"].Critical)
+			assert.False(t, report.Checks["redis"].Critical)
+		})
+	}
+}
+
+func TestChecker_RunReportsFailureMessage(t *testing.T) {
+	checker := NewChecker(0)
+	checker.Register("redis", failing)
+
+	report := checker.Run(context.Background())
+
+	assert.Equal(t, StatusFailing, report.Checks["redis"].Status)
+	assert.Equal(t, "unavailable", report.Checks["redis"].Message)
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := NewChecker(0)
+	checker.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, Critical(), WithTimeout(10*time.Millisecond))
+
+	report := checker.Run(context.Background())
+
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, StatusFailing, report.Checks["slow"].Status)
+}
+
+func TestChecker_CachesReport(t *testing.T) {
+	var calls atomic.Int32
+	counting := func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}
+
+	t.Run("reuses report within ttl", func(t *testing.T) {
+		calls.Store(0)
+		checker := NewChecker(time.Minute)
+		checker.Register("database", counting, Critical())
+
+		first := checker.Run(context.Background())
+		second := checker.Run(context.Background())
+
+		assert.Same(t, first, second)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("zero ttl runs every time", func(t *testing.T) {
+		calls.Store(0)
+		checker := NewChecker(0)
+		checker.Register("database", counting, Critical())
+
+		checker.Run(context.Background())
+		checker.Run(context.Background())
+
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
+
+func TestChecker_DrainOnDone(t *testing.T) {
+	checker := NewChecker(0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	checker.DrainOnDone(ctx)
+	assert.False(t, checker.Draining())
+
+	cancel()
+	assert.Eventually(t, checker.Draining, time.Second, time.Millisecond)
+}
@@ -0,0 +1,51 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+
+	governhealth "github.com/haipham22/govern/healthcheck"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/migrations"
+)
+
+// DBCheck pings the database connection pool
+func DBCheck(db *gorm.DB) governhealth.Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return fmt.Errorf("database ping failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// RedisCheck pings Redis
+func RedisCheck(client redis.UniversalClient) governhealth.Check {
+	return func(ctx context.Context) error {
+		if err := client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("redis ping failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// MigrationsCheck fails while schema migrations are pending, so a new
+// version isn't routed traffic before `migrate up` has run
+func MigrationsCheck(db *gorm.DB) governhealth.Check {
+	return func(ctx context.Context) error {
+		pending, err := migrations.Pending(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations, first is %d (%s)", len(pending), pending[0].Version, pending[0].Name)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/migrations"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+func TestDBCheck(t *testing.T) {
+	db := newTestDB(t)
+	check := DBCheck(db)
+
+	assert.NoError(t, check(context.Background()))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	assert.ErrorContains(t, check(context.Background()), "database ping failed")
+}
+
+func TestRedisCheck(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	check := RedisCheck(client)
+
+	assert.NoError(t, check(context.Background()))
+
+	server.Close()
+	assert.ErrorContains(t, check(context.Background()), "redis ping failed")
+}
+
+func TestMigrationsCheck(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	check := MigrationsCheck(db)
+
+	assert.ErrorContains(t, check(ctx), "pending migrations")
+
+	_, err := migrations.Up(ctx, db)
+	require.NoError(t, err)
+
+	assert.NoError(t, check(ctx))
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	governhealth "github.com/haipham22/govern/healthcheck"
+)
+
+// DiskCheck fails when the filesystem holding path has less than minFreeRatio
+// (0-1) of its space available
+func DiskCheck(path string, minFreeRatio float64) governhealth.Check {
+	return func(_ context.Context) error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return fmt.Errorf("failed to stat filesystem of %s: %w", path, err)
+		}
+		if stat.Blocks == 0 {
+			return nil
+		}
+
+		free := float64(stat.Bavail) / float64(stat.Blocks)
+		if free < minFreeRatio {
+			return fmt.Errorf("only %.1f%% disk space free on %s (minimum %.1f%%)", free*100, path, minFreeRatio*100)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package healthcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, DiskCheck(dir, 0)(context.Background()))
+	assert.ErrorContains(t, DiskCheck(dir, 1.01)(context.Background()), "disk space free")
+	assert.ErrorContains(t, DiskCheck(dir+"/missing", 0)(context.Background()), "failed to stat filesystem")
+}
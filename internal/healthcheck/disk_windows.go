@@ -0,0 +1,16 @@
+//go:build windows
+
+package healthcheck
+
+import (
+	"context"
+
+	governhealth "github.com/haipham22/govern/healthcheck"
+)
+
+// DiskCheck is not implemented on Windows and always passes
+func DiskCheck(_ string, _ float64) governhealth.Check {
+	return func(_ context.Context) error {
+		return nil
+	}
+}
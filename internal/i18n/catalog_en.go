@@ -0,0 +1,27 @@
+package i18n
+
+// catalogEN is the English catalog and the fallback of every other locale.
+// Problem titles and details default to their problem.Type, so only
+// messages without another source are listed here.
+var catalogEN = map[string]string{
+	"validation.required":       "{0} is required",
+	"validation.email":          "{0} must be a valid email address",
+	"validation.min.string":     "{0} must be at least {1} characters",
+	"validation.min.items":      "{0} must be at least {1} items",
+	"validation.min.number":     "{0} must be at least {1}",
+	"validation.max.string":     "{0} must be at most {1} characters",
+	"validation.max.items":      "{0} must be at most {1} items",
+	"validation.max.number":     "{0} must be at most {1}",
+	"validation.oneof":          "{0} must be one of: {1}",
+	"validation.excludes":       "{0} must not contain {1}",
+	"validation.default":        "Validation failed for field: {0}",
+	"validation.invalid_fields": "{0} fields are invalid",
+
+	"validation.password_lower":    "{0} must contain a lowercase letter",
+	"validation.password_upper":    "{0} must contain an uppercase letter",
+	"validation.password_digit":    "{0} must contain a digit",
+	"validation.password_symbol":   "{0} must contain a symbol",
+	"validation.password_identity": "{0} must not contain your username or email",
+	"validation.password_strength": "{0} is too easy to guess",
+	"validation.password_breached": "{0} has appeared in a data breach; choose a different one",
+}
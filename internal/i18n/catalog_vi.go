@@ -0,0 +1,46 @@
+package i18n
+
+// catalogVI is the Vietnamese catalog
+var catalogVI = map[string]string{
+	"validation.required":       "{0} là bắt buộc",
+	"validation.email":          "{0} phải là địa chỉ email hợp lệ",
+	"validation.min.string":     "{0} phải có ít nhất {1} ký tự",
+	"validation.min.items":      "{0} phải có ít nhất {1} phần tử",
+	"validation.min.number":     "{0} phải lớn hơn hoặc bằng {1}",
+	"validation.max.string":     "{0} chỉ được có tối đa {1} ký tự",
+	"validation.max.items":      "{0} chỉ được có tối đa {1} phần tử",
+	"validation.max.number":     "{0} phải nhỏ hơn hoặc bằng {1}",
+	"validation.oneof":          "{0} phải là một trong: {1}",
+	"validation.excludes":       "{0} không được chứa {1}",
+	"validation.default":        "Trường {0} không hợp lệ",
+	"validation.invalid_fields": "{0} trường không hợp lệ",
+
+	"validation.password_lower":    "{0} phải chứa một chữ cái thường",
+	"validation.password_upper":    "{0} phải chứa một chữ cái in hoa",
+	"validation.password_digit":    "{0} phải chứa một chữ số",
+	"validation.password_symbol":   "{0} phải chứa một ký tự đặc biệt",
+	"validation.password_identity": "{0} không được chứa tên đăng nhập hoặc email của bạn",
+	"validation.password_strength": "{0} quá dễ đoán",
+	"validation.password_breached": "{0} đã xuất hiện trong một vụ rò rỉ dữ liệu; hãy chọn mật khẩu khác",
+
+	"problem.invalid-request.title":  "Yêu cầu không hợp lệ",
+	"problem.invalid-request.detail": "Tham số yêu cầu không hợp lệ",
+	"problem.unauthorized.title":     "Chưa xác thực",
+	"problem.unauthorized.detail":    "Yêu cầu cần xác thực hoặc thông tin đăng nhập không đúng",
+	"problem.forbidden.title":        "Không có quyền",
+	"problem.forbidden.detail":       "Bạn không được phép thực hiện thao tác này",
+	"problem.not-found.title":        "Không tìm thấy tài nguyên",
+	"problem.not-found.detail":       "Không tìm thấy tài nguyên",
+	"problem.already-exists.title":   "Tài nguyên đã tồn tại",
+	"problem.already-exists.detail":  "Tài nguyên đã tồn tại",
+	"problem.conflict.title":         "Xung đột",
+	"problem.conflict.detail":        "Tài nguyên đã tồn tại",
+	"problem.rate-limited.title":     "Quá nhiều yêu cầu",
+	"problem.rate-limited.detail":    "Vượt quá giới hạn yêu cầu. Vui lòng thử lại sau.",
+	"problem.unavailable.title":      "Dịch vụ tạm thời không khả dụng",
+	"problem.unavailable.detail":     "Máy chủ đang quá tải. Vui lòng thử lại sau.",
+	"problem.internal.title":         "Lỗi máy chủ nội bộ",
+	"problem.internal.detail":        "Lỗi máy chủ nội bộ",
+	"problem.account-exists.title":   "Tài khoản đã tồn tại",
+	"problem.account-exists.detail":  "Đã có tài khoản với tên đăng nhập hoặc email này",
+}
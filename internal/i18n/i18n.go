@@ -0,0 +1,126 @@
+// Package i18n localizes client-facing messages. Catalogs are loaded into a
+// go-playground universal translator; lookups fall back from the requested
+// locale to English and finally to a caller-supplied default.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/vi"
+	ut "github.com/go-playground/universal-translator"
+	"golang.org/x/text/language"
+)
+
+// Supported locales
+const (
+	LocaleEN = "en"
+	LocaleVI = "vi"
+
+	// DefaultLocale is used when no requested locale is supported
+	DefaultLocale = LocaleEN
+)
+
+// catalogs holds the messages of each supported locale, keyed by message key
+var catalogs = map[string]map[string]string{
+	LocaleEN: catalogEN,
+	LocaleVI: catalogVI,
+}
+
+// Bundle holds the translators of all supported locales
+type Bundle struct {
+	uni *ut.UniversalTranslator
+}
+
+// New loads the catalogs of every supported locale
+func New() (*Bundle, error) {
+	uni := ut.New(en.New(), en.New(), vi.New())
+
+	for locale, catalog := range catalogs {
+		trans, found := uni.GetTranslator(locale)
+		if !found {
+			return nil, fmt.Errorf("no translator for locale %q", locale)
+		}
+		for key, text := range catalog {
+			if err := trans.Add(key, text, false); err != nil {
+				return nil, fmt.Errorf("failed to add %s message %q: %w", locale, key, err)
+			}
+		}
+	}
+
+	return &Bundle{uni: uni}, nil
+}
+
+var defaultBundle = mustNew()
+
+func mustNew() *Bundle {
+	b, err := New()
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Default returns the bundle built from the compiled-in catalogs
+func Default() *Bundle {
+	return defaultBundle
+}
+
+// Localizer looks up messages for one locale, falling back to English
+type Localizer struct {
+	trans    ut.Translator
+	fallback ut.Translator
+}
+
+// Localizer returns a localizer for the first supported locale among
+// candidates, in order; each candidate also tries its base language, so
+// "vi-VN" matches "vi". Unsupported candidates fall back to DefaultLocale.
+func (b *Bundle) Localizer(candidates ...string) Localizer {
+	var lookup []string
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		lookup = append(lookup, strings.ReplaceAll(candidate, "-", "_"))
+		if tag, err := language.Parse(candidate); err == nil {
+			base, _ := tag.Base()
+			lookup = append(lookup, base.String())
+		}
+	}
+
+	trans, _ := b.uni.FindTranslator(lookup...)
+	return Localizer{trans: trans, fallback: b.uni.GetFallback()}
+}
+
+// Locale returns the locale messages are looked up in
+func (l Localizer) Locale() string {
+	return l.trans.Locale()
+}
+
+// T returns the message for key with {0}, {1}... replaced by params, or def
+// when neither the locale nor English has the key
+func (l Localizer) T(key, def string, params ...string) string {
+	if msg, err := l.trans.T(key, params...); err == nil {
+		return msg
+	}
+	if msg, err := l.fallback.T(key, params...); err == nil {
+		return msg
+	}
+	return def
+}
+
+// ParseAcceptLanguage returns the languages of an Accept-Language header
+// ordered by preference. Malformed headers yield no languages.
+func ParseAcceptLanguage(header string) []string {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil {
+		return nil
+	}
+
+	langs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		langs = append(langs, tag.String())
+	}
+	return langs
+}
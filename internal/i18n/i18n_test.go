@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogs_SameKeysAndPlaceholders(t *testing.T) {
+	for key, text := range catalogVI {
+		if en, ok := catalogEN[key]; ok {
+			assert.Equal(t, placeholders(en), placeholders(text), "placeholders of %q differ", key)
+		}
+	}
+	for key := range catalogEN {
+		assert.Contains(t, catalogVI, key, "vi catalog is missing %q", key)
+	}
+}
+
+// placeholders returns the {n} params used in text
+func placeholders(text string) []string {
+	var found []string
+	for _, p := range []string{"{0}", "{1}", "{2}"} {
+		if strings.Contains(text, p) {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+func TestBundle_Localizer(t *testing.T) {
+	b, err := New()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		{"exact locale", []string{"vi"}, LocaleVI},
+		{"region falls back to base language", []string{"vi-VN"}, LocaleVI},
+		{"first supported candidate wins", []string{"fr", "vi", "en"}, LocaleVI},
+		{"unsupported falls back to default", []string{"fr-FR"}, DefaultLocale},
+		{"no candidates", nil, DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, b.Localizer(tt.candidates...).Locale())
+		})
+	}
+}
+
+func TestLocalizer_T(t *testing.T) {
+	vi := Default().Localizer(LocaleVI)
+	en := Default().Localizer(LocaleEN)
+
+	assert.Equal(t, "email là bắt buộc", vi.T("validation.required", "", "email"))
+	assert.Equal(t, "email is required", en.T("validation.required", "", "email"))
+	assert.Equal(t, "Không có quyền", vi.T("problem.forbidden.title", "Forbidden"))
+	assert.Equal(t, "Forbidden", en.T("problem.forbidden.title", "Forbidden"), "missing key uses the default")
+}
+
+func TestLocalizer_FieldMessage(t *testing.T) {
+	en := Default().Localizer(LocaleEN)
+	vi := Default().Localizer(LocaleVI)
+
+	assert.Equal(t, "name must be at least 3 characters", en.FieldMessage("name", "min", "3", "string"))
+	assert.Equal(t, "tags must be at most 2 items", en.FieldMessage("tags", "max", "2", "slice"))
+	assert.Equal(t, "age must be at least 18", en.FieldMessage("age", "min", "18", "int"))
+	assert.Equal(t, "status must be one of: draft, paid", en.FieldMessage("status", "oneof", "draft paid", "string"))
+	assert.Equal(t, "Validation failed for field: url", en.FieldMessage("url", "url", "", "string"))
+	assert.Equal(t, "username must not contain @", en.FieldMessage("username", "excludes", "@", "string"))
+	assert.Equal(t, "new_password is too easy to guess", en.FieldMessage("new_password", "password_strength", "2", "string"))
+	assert.Equal(t, "name phải có ít nhất 3 ký tự", vi.FieldMessage("name", "min", "3", "string"))
+	assert.Equal(t, "2 trường không hợp lệ", vi.InvalidFields(2))
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"vi-VN", "en-US", "en"}, ParseAcceptLanguage("en-US;q=0.8, vi-VN, en;q=0.5"))
+	assert.Empty(t, ParseAcceptLanguage(""))
+	assert.Empty(t, ParseAcceptLanguage("not a ;; header===="))
+}
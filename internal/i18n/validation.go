@@ -0,0 +1,44 @@
+package i18n
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldMessage returns the message for a field that failed a validation tag.
+// kind is the reflect.Kind name of the rejected value; min and max count
+// characters for strings and items for slices, arrays and maps.
+func (l Localizer) FieldMessage(property, tag, param, kind string) string {
+	switch tag {
+	case "required", "email":
+		return l.T("validation."+tag, "", property)
+	case "min", "max":
+		return l.T("validation."+tag+"."+boundUnit(kind), "", property, param)
+	case "excludes":
+		return l.T("validation.excludes", "", property, param)
+	case "oneof":
+		return l.T("validation.oneof", "", property, strings.Join(strings.Fields(param), ", "))
+	case "password_lower", "password_upper", "password_digit", "password_symbol",
+		"password_identity", "password_strength", "password_breached":
+		return l.T("validation."+tag, "", property)
+	default:
+		return l.T("validation.default", "", property)
+	}
+}
+
+// InvalidFields summarizes a validation failure of several fields
+func (l Localizer) InvalidFields(count int) string {
+	return l.T("validation.invalid_fields", "", strconv.Itoa(count))
+}
+
+func boundUnit(kind string) string {
+	switch kind {
+	case reflect.String.String():
+		return "string"
+	case reflect.Slice.String(), reflect.Array.String(), reflect.Map.String():
+		return "items"
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,101 @@
+// Package metrics defines the application's Prometheus metrics. All metrics
+// are registered on the govern default registry, served by Handler.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	governmetrics "github.com/haipham22/govern/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"golang-sample/pkg/utils/password"
+)
+
+// Label values for auth outcomes
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultError   = "error"
+)
+
+var (
+	// HTTPRequestsTotal counts handled requests by method, route template and status code
+	HTTPRequestsTotal = governmetrics.NewCounter(
+		"http_requests_total",
+		"Total number of HTTP requests",
+		[]string{"method", "route", "status"},
+	)
+
+	// HTTPRequestDuration observes request latency by method, route template and status code
+	HTTPRequestDuration = governmetrics.NewHistogram(
+		"http_request_duration_seconds",
+		"HTTP request latency in seconds",
+		[]string{"method", "route", "status"},
+		nil,
+	)
+
+	// RateLimitRejectionsTotal counts requests rejected by the rate limiter
+	RateLimitRejectionsTotal = governmetrics.NewCounter(
+		"http_rate_limit_rejections_total",
+		"Total number of requests rejected by rate limiting",
+		[]string{"route"},
+	)
+
+	// RegistrationsTotal counts registration attempts by result
+	RegistrationsTotal = governmetrics.NewCounter(
+		"auth_registrations_total",
+		"Total number of user registrations",
+		[]string{"result"},
+	)
+
+	// LoginsTotal counts login attempts by result
+	LoginsTotal = governmetrics.NewCounter(
+		"auth_logins_total",
+		"Total number of login attempts",
+		[]string{"result"},
+	)
+)
+
+func init() {
+	HTTPRequestsTotal.MustRegister()
+	HTTPRequestDuration.MustRegister()
+	RateLimitRejectionsTotal.MustRegister()
+	RegistrationsTotal.MustRegister()
+	LoginsTotal.MustRegister()
+}
+
+// MustRegisterDBStats exposes connection pool statistics (sql.DBStats) of db
+// as go_sql_* metrics labelled with dbName. Call it once per pool.
+func MustRegisterDBStats(db *sql.DB, dbName string) {
+	governmetrics.MustRegisterDefault(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// MustRegisterPasswordPool exposes the queue depth, in-flight operations,
+// size and queue timeouts of the password hashing pool. Call it once.
+func MustRegisterPasswordPool(pool *password.Pool) {
+	governmetrics.MustRegisterDefault(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "password_hash_queue_depth",
+			Help: "Number of password hashing operations waiting for a slot",
+		}, func() float64 { return float64(pool.Waiting()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "password_hash_in_flight",
+			Help: "Number of password hashing operations running",
+		}, func() float64 { return float64(pool.InFlight()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "password_hash_pool_size",
+			Help: "Maximum number of concurrent password hashing operations",
+		}, func() float64 { return float64(pool.Size()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "password_hash_queue_timeouts_total",
+			Help: "Total number of password hashing operations rejected after the queue timeout",
+		}, func() float64 { return float64(pool.Timeouts()) }),
+	)
+}
+
+// Handler serves all registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return governmetrics.HandlerDefault()
+}
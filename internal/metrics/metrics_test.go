@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"golang-sample/pkg/utils/password"
+)
+
+func TestMustRegisterDBStats(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+
+	MustRegisterDBStats(sqlDB, "metrics_test")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `go_sql_open_connections{db_name="metrics_test"}`)
+	assert.Contains(t, rec.Body.String(), `go_sql_max_open_connections{db_name="metrics_test"}`)
+}
+
+func TestMustRegisterPasswordPool(t *testing.T) {
+	MustRegisterPasswordPool(password.NewPool(3, time.Second))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), "password_hash_queue_depth 0")
+	assert.Contains(t, rec.Body.String(), "password_hash_pool_size 3")
+	assert.Contains(t, rec.Body.String(), "password_hash_queue_timeouts_total 0")
+}
+
+func TestAuthCounters(t *testing.T) {
+	LoginsTotal.Inc(ResultFailure)
+	RegistrationsTotal.Inc(ResultSuccess)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `auth_logins_total{result="failure"}`)
+	assert.Contains(t, rec.Body.String(), `auth_registrations_total{result="success"}`)
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "create_users",
+		Up: func(tx *gorm.DB) error {
+			type user struct {
+				ID           uint      `gorm:"primaryKey"`
+				Username     string    `gorm:"size:255;unique;not null"`
+				Email        string    `gorm:"size:255;unique;not null"`
+				PasswordHash string    `gorm:"size:255;not null"`
+				CreatedAt    time.Time `gorm:"autoCreateTime"`
+				UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+			}
+
+			// Databases created before migrations existed already have the table
+			if tx.Migrator().HasTable("users") {
+				return nil
+			}
+			return tx.Table("users").Migrator().CreateTable(&user{})
+		},
+	})
+}
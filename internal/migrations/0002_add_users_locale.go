@@ -0,0 +1,20 @@
+package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "add_users_locale",
+		Up: func(tx *gorm.DB) error {
+			type user struct {
+				Locale string `gorm:"size:16;not null;default:''"`
+			}
+
+			if tx.Table("users").Migrator().HasColumn(&user{}, "Locale") {
+				return nil
+			}
+			return tx.Table("users").Migrator().AddColumn(&user{}, "Locale")
+		},
+	})
+}
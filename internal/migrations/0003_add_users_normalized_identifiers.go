@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "add_users_normalized_identifiers",
+		Up: func(tx *gorm.DB) error {
+			type user struct {
+				ID                 uint
+				Username           string
+				Email              string
+				UsernameNormalized string `gorm:"size:255;not null;default:''"`
+				EmailNormalized    string `gorm:"size:255;not null;default:''"`
+			}
+
+			for _, field := range []string{"UsernameNormalized", "EmailNormalized"} {
+				if tx.Table("users").Migrator().HasColumn(&user{}, field) {
+					continue
+				}
+				if err := tx.Table("users").Migrator().AddColumn(&user{}, field); err != nil {
+					return err
+				}
+			}
+
+			// NFKC and case folding have no portable SQL equivalent, so
+			// backfill row by row
+			var rows []user
+			if err := tx.Table("users").Select("id", "username", "email").Find(&rows).Error; err != nil {
+				return err
+			}
+			for _, row := range rows {
+				err := tx.Table("users").Where("id = ?", row.ID).Updates(map[string]any{
+					"username_normalized": normalizeIdentifier(row.Username),
+					"email_normalized":    normalizeIdentifier(row.Email),
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, column := range []string{"username_normalized", "email_normalized"} {
+				if err := checkNoDuplicates(tx, column); err != nil {
+					return err
+				}
+				index := "idx_users_" + column
+				if tx.Migrator().HasIndex("users", index) {
+					continue
+				}
+				if err := tx.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON users (%s)", index, column)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// normalizeIdentifier is identity.Normalize as of this migration: trimmed,
+// NFKC-normalized and case-folded. It is copied so that a later change to
+// the live function cannot alter what this migration writes.
+func normalizeIdentifier(s string) string {
+	folded := cases.Fold().String(norm.NFKC.String(strings.TrimSpace(s)))
+	return norm.NFKC.String(folded)
+}
+
+// checkNoDuplicates fails with the colliding value when accounts that differ
+// only by case or Unicode form would break the unique index on column; they
+// have to be renamed or merged by hand first
+func checkNoDuplicates(tx *gorm.DB, column string) error {
+	var duplicates []string
+	err := tx.Table("users").
+		Select(column).
+		Group(column).
+		Having("COUNT(*) > 1").
+		Pluck(column, &duplicates).Error
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("users share %s %q; rename or merge them before migrating", column, duplicates[0])
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "create_api_keys",
+		Up: func(tx *gorm.DB) error {
+			type apiKey struct {
+				ID         uint   `gorm:"primaryKey"`
+				UserID     uint   `gorm:"not null;index"`
+				Name       string `gorm:"size:255;not null"`
+				Prefix     string `gorm:"size:32;not null"`
+				KeyHash    string `gorm:"size:64;not null;uniqueIndex"`
+				Scopes     string `gorm:"size:1024;not null;default:''"`
+				ExpiresAt  *time.Time
+				LastUsedAt *time.Time
+				RevokedAt  *time.Time
+				CreatedAt  time.Time `gorm:"autoCreateTime"`
+				UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+			}
+
+			return tx.Table("api_keys").Migrator().CreateTable(&apiKey{})
+		},
+	})
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "create_identities",
+		Up: func(tx *gorm.DB) error {
+			type identity struct {
+				ID        uint      `gorm:"primaryKey"`
+				UserID    uint      `gorm:"not null;index"`
+				Provider  string    `gorm:"size:64;not null;uniqueIndex:idx_identities_provider_subject"`
+				Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_identities_provider_subject"`
+				Email     string    `gorm:"size:255;not null;default:''"`
+				CreatedAt time.Time `gorm:"autoCreateTime"`
+			}
+
+			return tx.Table("identities").Migrator().CreateTable(&identity{})
+		},
+	})
+}
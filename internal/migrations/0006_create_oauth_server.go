@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "create_oauth_server",
+		Up: func(tx *gorm.DB) error {
+			type oauthClient struct {
+				ID           uint      `gorm:"primaryKey"`
+				ClientID     string    `gorm:"size:64;not null;uniqueIndex"`
+				SecretHash   string    `gorm:"size:64;not null;default:''"`
+				Name         string    `gorm:"size:255;not null"`
+				RedirectURIs string    `gorm:"size:4096;not null;default:''"`
+				GrantTypes   string    `gorm:"size:255;not null;default:''"`
+				Scopes       string    `gorm:"size:1024;not null;default:''"`
+				CreatedAt    time.Time `gorm:"autoCreateTime"`
+				UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+			}
+			type oauthCode struct {
+				ID            uint      `gorm:"primaryKey"`
+				CodeHash      string    `gorm:"size:64;not null;uniqueIndex"`
+				ClientID      string    `gorm:"size:64;not null"`
+				UserID        uint      `gorm:"not null"`
+				RedirectURI   string    `gorm:"size:2048;not null"`
+				Scopes        string    `gorm:"size:1024;not null;default:''"`
+				Nonce         string    `gorm:"size:255;not null;default:''"`
+				CodeChallenge string    `gorm:"size:128;not null"`
+				ExpiresAt     time.Time `gorm:"not null"`
+				UsedAt        *time.Time
+				CreatedAt     time.Time `gorm:"autoCreateTime"`
+			}
+			type oauthToken struct {
+				ID        uint      `gorm:"primaryKey"`
+				TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+				ClientID  string    `gorm:"size:64;not null;index"`
+				UserID    uint      `gorm:"not null;default:0"`
+				CodeID    uint      `gorm:"not null;default:0;index"`
+				Scopes    string    `gorm:"size:1024;not null;default:''"`
+				ExpiresAt time.Time `gorm:"not null"`
+				RevokedAt *time.Time
+				CreatedAt time.Time `gorm:"autoCreateTime"`
+			}
+
+			if err := tx.Table("oauth_clients").Migrator().CreateTable(&oauthClient{}); err != nil {
+				return err
+			}
+			if err := tx.Table("oauth_codes").Migrator().CreateTable(&oauthCode{}); err != nil {
+				return err
+			}
+			return tx.Table("oauth_tokens").Migrator().CreateTable(&oauthToken{})
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "create_magic_links",
+		Up: func(tx *gorm.DB) error {
+			type magicLink struct {
+				ID        uint      `gorm:"primaryKey"`
+				UserID    uint      `gorm:"not null;index:idx_magic_links_user_created"`
+				ExpiresAt time.Time `gorm:"not null"`
+				UsedAt    *time.Time
+				CreatedAt time.Time `gorm:"autoCreateTime;index:idx_magic_links_user_created"`
+			}
+
+			return tx.Table("magic_links").Migrator().CreateTable(&magicLink{})
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "create_passkeys",
+		Up: func(tx *gorm.DB) error {
+			type passkey struct {
+				ID             uint   `gorm:"primaryKey"`
+				UserID         uint   `gorm:"not null;index"`
+				Name           string `gorm:"size:255;not null"`
+				CredentialID   []byte `gorm:"not null;uniqueIndex"`
+				PublicKey      []byte `gorm:"not null"`
+				SignCount      int64  `gorm:"not null;default:0"`
+				Transports     string `gorm:"size:255;not null;default:''"`
+				AAGUID         []byte
+				BackupEligible bool      `gorm:"not null;default:false"`
+				CreatedAt      time.Time `gorm:"autoCreateTime"`
+				LastUsedAt     *time.Time
+			}
+			type webAuthnChallenge struct {
+				ID            uint      `gorm:"primaryKey"`
+				UserID        uint      `gorm:"not null;default:0"`
+				Ceremony      string    `gorm:"size:16;not null"`
+				ChallengeHash string    `gorm:"size:64;not null;uniqueIndex"`
+				ExpiresAt     time.Time `gorm:"not null;index"`
+			}
+
+			if err := tx.Table("passkeys").Migrator().CreateTable(&passkey{}); err != nil {
+				return err
+			}
+			return tx.Table("webauthn_challenges").Migrator().CreateTable(&webAuthnChallenge{})
+		},
+	})
+}
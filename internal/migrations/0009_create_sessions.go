@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "create_sessions",
+		Up: func(tx *gorm.DB) error {
+			type session struct {
+				ID         uint      `gorm:"primaryKey"`
+				UserID     uint      `gorm:"not null;index"`
+				Family     string    `gorm:"size:64;not null;uniqueIndex"`
+				UserAgent  string    `gorm:"size:512;not null;default:''"`
+				IP         string    `gorm:"size:45;not null;default:''"`
+				LastSeenAt time.Time `gorm:"not null"`
+				ExpiresAt  time.Time `gorm:"not null"`
+				RevokedAt  *time.Time
+				CreatedAt  time.Time `gorm:"autoCreateTime"`
+			}
+
+			return tx.Table("sessions").Migrator().CreateTable(&session{})
+		},
+	})
+}
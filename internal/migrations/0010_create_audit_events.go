@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "create_audit_events",
+		Up: func(tx *gorm.DB) error {
+			type auditEvent struct {
+				ID        uint      `gorm:"primaryKey"`
+				Action    string    `gorm:"size:64;not null;index"`
+				ActorID   uint      `gorm:"not null;default:0;index"`
+				Actor     string    `gorm:"size:100;not null;default:''"`
+				TargetID  uint      `gorm:"not null;default:0;index"`
+				IP        string    `gorm:"size:45;not null;default:''"`
+				UserAgent string    `gorm:"size:512;not null;default:''"`
+				RequestID string    `gorm:"size:64;not null;default:''"`
+				Metadata  string    `gorm:"type:text;not null;default:'{}'"`
+				CreatedAt time.Time `gorm:"not null;index"`
+				PrevHash  string    `gorm:"size:64;not null;uniqueIndex"`
+				Hash      string    `gorm:"size:64;not null"`
+			}
+
+			if err := tx.Table("audit_events").Migrator().CreateTable(&auditEvent{}); err != nil {
+				return err
+			}
+			if tx.Dialector.Name() != "postgres" {
+				return nil
+			}
+
+			// Refuse changes to recorded events, so the log stays append-only
+			// for the application's database user too
+			return tx.Exec(`
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+	BEFORE TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+`).Error
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "create_organizations",
+		Up: func(tx *gorm.DB) error {
+			type organization struct {
+				ID        uint      `gorm:"primaryKey"`
+				Name      string    `gorm:"size:255;not null"`
+				CreatedAt time.Time `gorm:"autoCreateTime"`
+				UpdatedAt time.Time `gorm:"autoUpdateTime"`
+			}
+			type membership struct {
+				ID        uint      `gorm:"primaryKey"`
+				OrgID     uint      `gorm:"not null;uniqueIndex:idx_memberships_org_user"`
+				UserID    uint      `gorm:"not null;uniqueIndex:idx_memberships_org_user;index"`
+				Role      string    `gorm:"size:16;not null"`
+				CreatedAt time.Time `gorm:"autoCreateTime"`
+				UpdatedAt time.Time `gorm:"autoUpdateTime"`
+			}
+			type invitation struct {
+				ID              uint      `gorm:"primaryKey"`
+				OrgID           uint      `gorm:"not null;index"`
+				Email           string    `gorm:"size:255;not null"`
+				EmailNormalized string    `gorm:"size:255;not null"`
+				Role            string    `gorm:"size:16;not null"`
+				InvitedBy       uint      `gorm:"not null"`
+				TokenHash       string    `gorm:"size:64;not null;uniqueIndex"`
+				ExpiresAt       time.Time `gorm:"not null"`
+				AcceptedAt      *time.Time
+				CreatedAt       time.Time `gorm:"autoCreateTime"`
+			}
+
+			if err := tx.Table("organizations").Migrator().CreateTable(&organization{}); err != nil {
+				return err
+			}
+			if err := tx.Table("memberships").Migrator().CreateTable(&membership{}); err != nil {
+				return err
+			}
+			return tx.Table("org_invitations").Migrator().CreateTable(&invitation{})
+		},
+	})
+}
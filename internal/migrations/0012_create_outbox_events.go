@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "create_outbox_events",
+		Up: func(tx *gorm.DB) error {
+			type outboxEvent struct {
+				ID            uint       `gorm:"primaryKey"`
+				EventID       string     `gorm:"size:64;not null;uniqueIndex"`
+				Type          string     `gorm:"size:64;not null"`
+				UserID        uint       `gorm:"not null;default:0"`
+				Payload       string     `gorm:"type:text;not null;default:'{}'"`
+				OccurredAt    time.Time  `gorm:"not null"`
+				PublishedAt   *time.Time `gorm:"index"`
+				Attempts      int        `gorm:"not null;default:0"`
+				NextAttemptAt time.Time  `gorm:"not null;index"`
+				LastError     string     `gorm:"size:512;not null;default:''"`
+			}
+
+			return tx.Table("outbox_events").Migrator().CreateTable(&outboxEvent{})
+		},
+	})
+}
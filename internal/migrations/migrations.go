@@ -0,0 +1,123 @@
+// Package migrations holds the versioned database schema changes. Each
+// migration is applied once, in Version order, and recorded in the
+// schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned schema change
+type Migration struct {
+	// Version orders migrations; it must be unique and never reused
+	Version int64
+	Name    string
+	// Up applies the change inside a transaction
+	Up func(tx *gorm.DB) error
+}
+
+// schemaMigration records an applied migration
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+var registry = map[int64]Migration{}
+
+// register adds m to the registry; called from init in each migration file.
+// Snapshot the models a migration needs inside its file instead of using the
+// current ORM structs, so later model changes don't rewrite history.
+func register(m Migration) {
+	if _, exists := registry[m.Version]; exists {
+		panic(fmt.Sprintf("migration version %d registered twice", m.Version))
+	}
+	registry[m.Version] = m
+}
+
+// All returns every known migration in Version order
+func All() []Migration {
+	all := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all
+}
+
+// Applied returns the versions recorded in schema_migrations
+func Applied(ctx context.Context, db *gorm.DB) (map[int64]bool, error) {
+	applied := map[int64]bool{}
+
+	db = db.WithContext(ctx)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+
+	var versions []int64
+	if err := db.Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	for _, v := range versions {
+		applied[v] = true
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations not yet applied, in Version order
+func Pending(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	applied, err := Applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range All() {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations, each in its own transaction, and
+// returns the ones applied
+func Up(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	db = db.WithContext(ctx)
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	pending, err := Pending(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0, len(pending))
+	for _, m := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
@@ -0,0 +1,116 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/orm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+func TestAll_SortedAndUnique(t *testing.T) {
+	all := All()
+	require.NotEmpty(t, all)
+
+	for i := 1; i < len(all); i++ {
+		assert.Less(t, all[i-1].Version, all[i].Version)
+	}
+}
+
+func TestUp(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	pending, err := Pending(ctx, db)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(All()), "fresh database has every migration pending")
+
+	applied, err := Up(ctx, db)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(All()))
+
+	pending, err = Pending(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// Re-running is a no-op
+	applied, err = Up(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// The schema matches what the ORM expects
+	require.NoError(t, db.Create(&orm.User{Username: "alice", Email: "alice@example.com", PasswordHash: "hash"}).Error)
+	err = db.Create(&orm.User{Username: "alice", Email: "other@example.com", PasswordHash: "hash"}).Error
+	assert.Error(t, err, "username must be unique")
+	err = db.Create(&orm.User{Username: "ALICE", Email: "other@example.com", PasswordHash: "hash"}).Error
+	assert.Error(t, err, "username must be unique regardless of case")
+}
+
+func TestUp_ExistingUsersTable(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	require.NoError(t, db.AutoMigrate(&orm.User{}))
+	require.NoError(t, db.Create(&orm.User{Username: "alice", Email: "alice@example.com", PasswordHash: "hash"}).Error)
+
+	_, err := Up(ctx, db)
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, db.Model(&orm.User{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "existing rows are kept")
+}
+
+func TestUp_BackfillsNormalizedIdentifiers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("existing rows are normalized", func(t *testing.T) {
+		db := newUsersBeforeNormalization(t, "Alice", "Bob")
+
+		_, err := Up(ctx, db)
+		require.NoError(t, err)
+
+		var normalized []string
+		require.NoError(t, db.Table("users").Order("id").Pluck("username_normalized", &normalized).Error)
+		assert.Equal(t, []string{"alice", "bob"}, normalized)
+	})
+
+	t.Run("case-only duplicates fail the migration", func(t *testing.T) {
+		db := newUsersBeforeNormalization(t, "Alice", "alice")
+
+		_, err := Up(ctx, db)
+		assert.ErrorContains(t, err, `share username_normalized "alice"`)
+	})
+}
+
+// newUsersBeforeNormalization applies the migrations before version 3 and
+// inserts users with the given usernames
+func newUsersBeforeNormalization(t *testing.T, usernames ...string) *gorm.DB {
+	t.Helper()
+	db := newTestDB(t)
+	require.NoError(t, db.AutoMigrate(&schemaMigration{}))
+
+	for _, m := range All() {
+		if m.Version >= 3 {
+			break
+		}
+		require.NoError(t, m.Up(db))
+		require.NoError(t, db.Create(&schemaMigration{Version: m.Version, Name: m.Name}).Error)
+	}
+	for _, username := range usernames {
+		err := db.Exec("INSERT INTO users (username, email, password_hash) VALUES (?, ?, ?)",
+			username, username+"@example.com", "hash").Error
+		require.NoError(t, err)
+	}
+	return db
+}
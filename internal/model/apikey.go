@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// APIKeyPrefix starts every API key, so keys are recognizable in headers and
+// by secret scanners
+const APIKeyPrefix = "sk_"
+
+// APIKey is a user-owned credential for machine clients. The secret is only
+// known when the key is created; Prefix identifies it afterwards.
+type APIKey struct {
+	ID     uint
+	UserID uint
+	Name   string
+	// Prefix is the start of the key, safe to display
+	Prefix     string
+	Scopes     []Scope
+	ExpiresAt  *time.Time // nil never expires
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// Usable reports whether the key can authenticate at now
+func (k *APIKey) Usable(now time.Time) bool {
+	if k == nil || k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// AuditAction names a security-relevant event recorded in the audit log
+type AuditAction string
+
+const (
+	AuditUserRegistered       AuditAction = "user.registered"
+	AuditLoginSucceeded       AuditAction = "auth.login_succeeded"
+	AuditLoginFailed          AuditAction = "auth.login_failed"
+	AuditPasswordChanged      AuditAction = "auth.password_changed"
+	AuditSessionRevoked       AuditAction = "session.revoked"
+	AuditAPIKeyRevoked        AuditAction = "api_key.revoked"
+	AuditImpersonationStarted AuditAction = "impersonation.started"
+	AuditImpersonatedRequest  AuditAction = "impersonation.request"
+	AuditOrgCreated           AuditAction = "org.created"
+	AuditOrgMemberAdded       AuditAction = "org.member_added"
+	AuditOrgRoleChanged       AuditAction = "org.role_changed"
+	AuditOrgMemberRemoved     AuditAction = "org.member_removed"
+	AuditOrgInvitationCreated AuditAction = "org.invitation_created"
+)
+
+// AuditEvent is an entry of the append-only audit log. Each entry's Hash
+// covers its content and the Hash of the entry before it, so altering or
+// removing an entry breaks the chain after it.
+type AuditEvent struct {
+	ID     uint
+	Action AuditAction
+	// ActorID is the user who acted; 0 when no user is known, such as for a
+	// failed login or a member of staff
+	ActorID uint
+	// Actor names a member of staff acting through the admin API or an
+	// impersonation token
+	Actor string
+	// TargetID is the user acted upon; 0 when none is known
+	TargetID  uint
+	IP        string
+	UserAgent string
+	RequestID string
+	Metadata  map[string]string
+	CreatedAt time.Time
+	PrevHash  string
+	Hash      string
+}
+
+// AuditFilter selects audit events; zero fields match every event
+type AuditFilter struct {
+	Action    AuditAction
+	ActorID   uint
+	Actor     string
+	TargetID  uint
+	IP        string
+	RequestID string
+	Since     time.Time
+	Until     time.Time
+	// BeforeID pages back from the oldest event of the previous page
+	BeforeID uint
+	Limit    int
+}
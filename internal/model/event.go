@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType names a domain event other systems can react to
+type EventType string
+
+const (
+	EventUserRegistered  EventType = "user.registered"
+	EventUserLoggedIn    EventType = "user.logged_in"
+	EventPasswordChanged EventType = "user.password_changed"
+)
+
+// Event is a domain event, written to the outbox together with the change
+// it describes and published from there at least once. Consumers drop
+// repeats by ID.
+type Event struct {
+	// ID is unique per event and stays the same across deliveries
+	ID     string
+	Type   EventType
+	UserID uint
+	// Payload is the JSON of the event's type-specific fields
+	Payload    json.RawMessage
+	OccurredAt time.Time
+}
+
+// UserRegistered is the payload of EventUserRegistered
+type UserRegistered struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Locale   string `json:"locale,omitempty"`
+	// Method is how the account was started: "password" or "external"
+	Method string `json:"method"`
+}
+
+// UserLoggedIn is the payload of EventUserLoggedIn
+type UserLoggedIn struct {
+	Method    string `json:"method"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// PasswordChanged is the payload of EventPasswordChanged
+type PasswordChanged struct{}
+
+// OutboxEntry is an event waiting in the outbox together with its
+// delivery state
+type OutboxEntry struct {
+	Event
+	// Attempts counts the failed deliveries so far
+	Attempts int
+}
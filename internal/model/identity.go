@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Identity links a user to an account at an external identity provider
+type Identity struct {
+	ID     uint
+	UserID uint
+	// Provider names the identity provider, e.g. google or github
+	Provider string
+	// Subject is the provider's stable ID of the account
+	Subject string
+	// Email is the address the provider reported when the identity was linked
+	Email     string
+	CreatedAt time.Time
+}
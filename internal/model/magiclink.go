@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// MagicLink is an emailed, single-use sign-in link. The link itself is
+// signed and not stored; the record makes it single-use.
+type MagicLink struct {
+	ID        uint
+	UserID    uint
+	ExpiresAt time.Time
+	// UsedAt is set when the link signed the user in
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
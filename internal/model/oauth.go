@@ -0,0 +1,96 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Grant types of the authorization server
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// OpenID Connect scopes; they decide what a client learns about the user.
+// Clients may also be registered with scopes of their own, which the
+// authorization server only reports back through introspection.
+const (
+	OAuthScopeOpenID  = "openid"
+	OAuthScopeProfile = "profile"
+	OAuthScopeEmail   = "email"
+)
+
+// IsIdentityScope reports whether scope is about the user, and so needs one
+func IsIdentityScope(scope string) bool {
+	return scope == OAuthScopeOpenID || scope == OAuthScopeProfile || scope == OAuthScopeEmail
+}
+
+// OAuthClient is an application that delegates login to this service
+type OAuthClient struct {
+	ID       uint
+	ClientID string
+	Name     string
+	// RedirectURIs are matched exactly
+	RedirectURIs []string
+	GrantTypes   []string
+	// Scopes are the scopes the client may request
+	Scopes []string
+	// Confidential clients authenticate with a secret; public clients, such
+	// as single-page apps, only with PKCE
+	Confidential bool
+	CreatedAt    time.Time
+}
+
+// AllowsGrant reports whether the client is registered for grantType
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	return slices.Contains(c.GrantTypes, grantType)
+}
+
+// AllowsRedirect reports whether uri is one of the client's redirect URIs
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// AllowsScopes reports whether the client may request every scope of scopes
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// OAuthCode is an authorization code a user granted a client
+type OAuthCode struct {
+	ID          uint
+	ClientID    string
+	UserID      uint
+	RedirectURI string
+	Scopes      []string
+	Nonce       string
+	// CodeChallenge is the S256 PKCE challenge of the authorization request
+	CodeChallenge string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
+
+// OAuthToken is an access token issued to a client
+type OAuthToken struct {
+	ID       uint
+	ClientID string
+	// UserID is zero for a token of the client itself
+	UserID uint
+	// CodeID is the authorization code the token was issued for, if any
+	CodeID    uint
+	Scopes    []string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// Active reports whether the token can be used at now
+func (t *OAuthToken) Active(now time.Time) bool {
+	return t != nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// OrgRole is what a member may do in an organization
+type OrgRole string
+
+const (
+	// OrgRoleOwner may do everything, including managing other owners
+	OrgRoleOwner OrgRole = "owner"
+	// OrgRoleAdmin manages members and invitations below owner
+	OrgRoleAdmin  OrgRole = "admin"
+	OrgRoleMember OrgRole = "member"
+)
+
+// OrgRoles lists every role, most privileged first
+func OrgRoles() []OrgRole {
+	return []OrgRole{OrgRoleOwner, OrgRoleAdmin, OrgRoleMember}
+}
+
+// AtLeast reports whether r grants everything other does
+func (r OrgRole) AtLeast(other OrgRole) bool {
+	rank, otherRank := slices.Index(OrgRoles(), r), slices.Index(OrgRoles(), other)
+	return rank >= 0 && otherRank >= 0 && rank <= otherRank
+}
+
+// Organization is a tenant: users see the data of the organizations they
+// are members of
+type Organization struct {
+	ID        uint
+	Name      string
+	CreatedAt time.Time
+}
+
+// Membership makes a user a member of an organization
+type Membership struct {
+	ID        uint
+	OrgID     uint
+	UserID    uint
+	Role      OrgRole
+	CreatedAt time.Time
+	// Organization is set when listing a user's memberships
+	Organization *Organization
+	// User is set when listing an organization's members
+	User *User
+}
+
+// Invitation asks whoever owns Email to join an organization. It is
+// accepted with a single-use token that was emailed to the address.
+type Invitation struct {
+	ID        uint
+	OrgID     uint
+	Email     string
+	Role      OrgRole
+	InvitedBy uint
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	// AcceptedAt is set once the invitation was accepted
+	AcceptedAt *time.Time
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// WebAuthn ceremonies a challenge is issued for
+const (
+	CeremonyRegistration = "registration"
+	CeremonyLogin        = "login"
+)
+
+// Passkey is a WebAuthn credential a user signs in with instead of a password
+type Passkey struct {
+	ID     uint
+	UserID uint
+	// Name is the user's label, e.g. the device it is on
+	Name         string
+	CredentialID []byte
+	// PublicKey is the COSE_Key the credential signs with
+	PublicKey []byte
+	// SignCount is the last signature counter; a counter that does not
+	// increase hints at a cloned authenticator
+	SignCount  uint32
+	Transports []string
+	AAGUID     []byte
+	// BackupEligible passkeys may be synced between devices
+	BackupEligible bool
+	CreatedAt      time.Time
+	LastUsedAt     *time.Time
+}
+
+// WebAuthnChallenge is an outstanding challenge of a ceremony
+type WebAuthnChallenge struct {
+	ID uint
+	// UserID is the user registering a passkey, 0 for a login
+	UserID    uint
+	Ceremony  string
+	ExpiresAt time.Time
+}
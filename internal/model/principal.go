@@ -0,0 +1,66 @@
+package model
+
+import "slices"
+
+// AuthMethod is how a request proved who is calling
+type AuthMethod string
+
+const (
+	AuthMethodJWT    AuthMethod = "jwt"
+	AuthMethodAPIKey AuthMethod = "api_key"
+)
+
+// Scope is a permission an API key can be granted
+type Scope string
+
+const (
+	ScopeAPIKeysRead   Scope = "api_keys:read"
+	ScopeAPIKeysWrite  Scope = "api_keys:write"
+	ScopePasswordWrite Scope = "password:write"
+	ScopeOrgsRead      Scope = "orgs:read"
+	ScopeOrgsWrite     Scope = "orgs:write"
+)
+
+// Scopes lists every scope, in the order they are documented
+func Scopes() []Scope {
+	return []Scope{ScopeAPIKeysRead, ScopeAPIKeysWrite, ScopePasswordWrite, ScopeOrgsRead, ScopeOrgsWrite}
+}
+
+// Principal is the authenticated caller of a request, whichever way it
+// authenticated.
+type Principal struct {
+	UserID   uint
+	Username string
+	Email    string
+	Locale   string
+	Method   AuthMethod
+	// Scopes limits what an API key may do; nil grants every scope, as for
+	// a user's own session
+	Scopes []Scope
+	// APIKeyID is set when Method is AuthMethodAPIKey
+	APIKeyID uint
+	// SessionID is the login session of a JWT, when it names one
+	SessionID uint
+	// Actor is the member of staff acting as the user with an impersonation
+	// token; empty when users act for themselves
+	Actor string
+	// OrgID is the organization the request acts in: the one an
+	// organization token was issued for until the Tenant middleware has
+	// run, the verified one afterwards. 0 outside organization routes.
+	OrgID uint
+	// OrgRole is the caller's role in OrgID, set by the Tenant middleware
+	OrgRole OrgRole
+}
+
+// Impersonated reports whether a member of staff is acting as the user
+func (p *Principal) Impersonated() bool {
+	return p != nil && p.Actor != ""
+}
+
+// HasScope reports whether the principal is allowed scope
+func (p *Principal) HasScope(scope Scope) bool {
+	if p == nil {
+		return false
+	}
+	return p.Scopes == nil || slices.Contains(p.Scopes, scope)
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_HasScope(t *testing.T) {
+	session := &Principal{UserID: 1, Method: AuthMethodJWT}
+	key := &Principal{UserID: 1, Method: AuthMethodAPIKey, Scopes: []Scope{ScopeAPIKeysRead}}
+	noScopes := &Principal{UserID: 1, Method: AuthMethodAPIKey, Scopes: []Scope{}}
+
+	for _, scope := range Scopes() {
+		assert.True(t, session.HasScope(scope), "sessions have every scope")
+		assert.False(t, noScopes.HasScope(scope))
+	}
+	assert.True(t, key.HasScope(ScopeAPIKeysRead))
+	assert.False(t, key.HasScope(ScopeAPIKeysWrite))
+	assert.False(t, (*Principal)(nil).HasScope(ScopeAPIKeysRead))
+}
+
+func TestAPIKey_Usable(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	assert.True(t, (&APIKey{}).Usable(now), "no expiry")
+	assert.True(t, (&APIKey{ExpiresAt: &later}).Usable(now))
+	assert.False(t, (&APIKey{ExpiresAt: &earlier}).Usable(now), "expired")
+	assert.False(t, (&APIKey{RevokedAt: &earlier}).Usable(now), "revoked")
+	assert.False(t, (*APIKey)(nil).Usable(now))
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Session is a login of a user on a device. Every access token issued by
+// the login names the session, so revoking it signs the device out.
+type Session struct {
+	ID     uint
+	UserID uint
+	// Family identifies the tokens issued to the session; they carry it as
+	// their sid claim
+	Family     string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// ExpiresAt is when the session's tokens expire
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// Active reports whether the session's tokens are accepted at now
+func (s *Session) Active(now time.Time) bool {
+	if s == nil || s.RevokedAt != nil {
+		return false
+	}
+	return now.Before(s.ExpiresAt)
+}
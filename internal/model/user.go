@@ -10,6 +10,7 @@ type User struct {
 	ID        uint
 	Username  string
 	Email     string
+	Locale    string // preferred language of client-facing messages; empty uses Accept-Language
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -64,6 +65,7 @@ func (u *User) Clone() *User {
 		ID:        u.ID,
 		Username:  u.Username,
 		Email:     u.Email,
+		Locale:    u.Locale,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
@@ -0,0 +1,24 @@
+package orm
+
+import "time"
+
+type APIKey struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"not null;index"`
+	Name   string `gorm:"size:255;not null"`
+	Prefix string `gorm:"size:32;not null"`
+	// KeyHash is the hex SHA-256 of the key; keys are random, so a slow
+	// password hash adds nothing
+	KeyHash string `gorm:"size:64;not null;uniqueIndex"`
+	// Scopes are space-separated
+	Scopes     string `gorm:"size:1024;not null;default:''"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
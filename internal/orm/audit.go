@@ -0,0 +1,24 @@
+package orm
+
+import "time"
+
+type AuditEvent struct {
+	ID        uint   `gorm:"primaryKey"`
+	Action    string `gorm:"size:64;not null;index"`
+	ActorID   uint   `gorm:"not null;default:0;index"`
+	Actor     string `gorm:"size:100;not null;default:''"`
+	TargetID  uint   `gorm:"not null;default:0;index"`
+	IP        string `gorm:"size:45;not null;default:''"`
+	UserAgent string `gorm:"size:512;not null;default:''"`
+	RequestID string `gorm:"size:64;not null;default:''"`
+	// Metadata is a JSON object of strings
+	Metadata  string    `gorm:"type:text;not null;default:'{}'"`
+	CreatedAt time.Time `gorm:"not null;index"`
+	// PrevHash is unique so two writers cannot both extend the same entry
+	PrevHash string `gorm:"size:64;not null;uniqueIndex"`
+	Hash     string `gorm:"size:64;not null"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
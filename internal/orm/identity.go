@@ -0,0 +1,16 @@
+package orm
+
+import "time"
+
+type Identity struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Provider  string    `gorm:"size:64;not null;uniqueIndex:idx_identities_provider_subject"`
+	Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_identities_provider_subject"`
+	Email     string    `gorm:"size:255;not null;default:''"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (Identity) TableName() string {
+	return "identities"
+}
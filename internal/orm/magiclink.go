@@ -0,0 +1,15 @@
+package orm
+
+import "time"
+
+type MagicLink struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index:idx_magic_links_user_created"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_magic_links_user_created"`
+}
+
+func (MagicLink) TableName() string {
+	return "magic_links"
+}
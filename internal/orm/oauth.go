@@ -0,0 +1,56 @@
+package orm
+
+import "time"
+
+// OAuthClient, OAuthCode and OAuthToken store lists space-separated, and
+// secrets as hex SHA-256 like APIKey
+
+type OAuthClient struct {
+	ID           uint      `gorm:"primaryKey"`
+	ClientID     string    `gorm:"size:64;not null;uniqueIndex"`
+	SecretHash   string    `gorm:"size:64;not null;default:''"`
+	Name         string    `gorm:"size:255;not null"`
+	RedirectURIs string    `gorm:"size:4096;not null;default:''"`
+	GrantTypes   string    `gorm:"size:255;not null;default:''"`
+	Scopes       string    `gorm:"size:1024;not null;default:''"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+type OAuthCode struct {
+	ID            uint      `gorm:"primaryKey"`
+	CodeHash      string    `gorm:"size:64;not null;uniqueIndex"`
+	ClientID      string    `gorm:"size:64;not null"`
+	UserID        uint      `gorm:"not null"`
+	RedirectURI   string    `gorm:"size:2048;not null"`
+	Scopes        string    `gorm:"size:1024;not null;default:''"`
+	Nonce         string    `gorm:"size:255;not null;default:''"`
+	CodeChallenge string    `gorm:"size:128;not null"`
+	ExpiresAt     time.Time `gorm:"not null"`
+	UsedAt        *time.Time
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+func (OAuthCode) TableName() string {
+	return "oauth_codes"
+}
+
+type OAuthToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ClientID  string    `gorm:"size:64;not null;index"`
+	UserID    uint      `gorm:"not null;default:0"`
+	CodeID    uint      `gorm:"not null;default:0;index"`
+	Scopes    string    `gorm:"size:1024;not null;default:''"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (OAuthToken) TableName() string {
+	return "oauth_tokens"
+}
@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"golang-sample/pkg/utils/identity"
+)
+
+type Organization struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"size:255;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+type Membership struct {
+	ID        uint      `gorm:"primaryKey"`
+	OrgID     uint      `gorm:"not null;uniqueIndex:idx_memberships_org_user"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_memberships_org_user;index"`
+	Role      string    `gorm:"size:16;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	Organization *Organization `gorm:"foreignKey:OrgID"`
+	User         *User         `gorm:"foreignKey:UserID"`
+}
+
+func (Membership) TableName() string {
+	return "memberships"
+}
+
+// TenantOwned makes memberships readable only within their organization
+func (Membership) TenantOwned() {}
+
+type Invitation struct {
+	ID    uint   `gorm:"primaryKey"`
+	OrgID uint   `gorm:"not null;index"`
+	Email string `gorm:"size:255;not null"`
+	// EmailNormalized is compared with the accepting user's normalized email
+	EmailNormalized string `gorm:"size:255;not null"`
+	Role            string `gorm:"size:16;not null"`
+	InvitedBy       uint   `gorm:"not null"`
+	// TokenHash is the hex SHA-256 of the emailed token
+	TokenHash  string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	AcceptedAt *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func (Invitation) TableName() string {
+	return "org_invitations"
+}
+
+// TenantOwned makes invitations readable only within their organization
+func (Invitation) TenantOwned() {}
+
+// BeforeSave keeps EmailNormalized in step with Email
+func (i *Invitation) BeforeSave(*gorm.DB) error {
+	i.EmailNormalized = identity.Normalize(i.Email)
+	return nil
+}
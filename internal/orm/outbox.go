@@ -0,0 +1,23 @@
+package orm
+
+import "time"
+
+type OutboxEvent struct {
+	ID         uint      `gorm:"primaryKey"`
+	EventID    string    `gorm:"size:64;not null;uniqueIndex"`
+	Type       string    `gorm:"size:64;not null"`
+	UserID     uint      `gorm:"not null;default:0"`
+	Payload    string    `gorm:"type:text;not null;default:'{}'"`
+	OccurredAt time.Time `gorm:"not null"`
+	// PublishedAt is set once every sink accepted the event
+	PublishedAt *time.Time `gorm:"index"`
+	Attempts    int        `gorm:"not null;default:0"`
+	// NextAttemptAt holds back a failed event, or one claimed by a relay,
+	// until then
+	NextAttemptAt time.Time `gorm:"not null;index"`
+	LastError     string    `gorm:"size:512;not null;default:''"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
@@ -0,0 +1,33 @@
+package orm
+
+import "time"
+
+type Passkey struct {
+	ID             uint   `gorm:"primaryKey"`
+	UserID         uint   `gorm:"not null;index"`
+	Name           string `gorm:"size:255;not null"`
+	CredentialID   []byte `gorm:"not null;uniqueIndex"`
+	PublicKey      []byte `gorm:"not null"`
+	SignCount      int64  `gorm:"not null;default:0"`
+	Transports     string `gorm:"size:255;not null;default:''"`
+	AAGUID         []byte
+	BackupEligible bool      `gorm:"not null;default:false"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	LastUsedAt     *time.Time
+}
+
+func (Passkey) TableName() string {
+	return "passkeys"
+}
+
+type WebAuthnChallenge struct {
+	ID            uint      `gorm:"primaryKey"`
+	UserID        uint      `gorm:"not null;default:0"`
+	Ceremony      string    `gorm:"size:16;not null"`
+	ChallengeHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt     time.Time `gorm:"not null;index"`
+}
+
+func (WebAuthnChallenge) TableName() string {
+	return "webauthn_challenges"
+}
@@ -0,0 +1,19 @@
+package orm
+
+import "time"
+
+type Session struct {
+	ID         uint      `gorm:"primaryKey"`
+	UserID     uint      `gorm:"not null;index"`
+	Family     string    `gorm:"size:64;not null;uniqueIndex"`
+	UserAgent  string    `gorm:"size:512;not null;default:''"`
+	IP         string    `gorm:"size:45;not null;default:''"`
+	LastSeenAt time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -1,16 +1,38 @@
 package orm
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"golang-sample/pkg/utils/identity"
+)
 
 type User struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Username     string    `gorm:"size:255;unique;not null" json:"username"`
-	Email        string    `gorm:"size:255;unique;not null" json:"email"`
-	PasswordHash string    `gorm:"size:255;not null" json:"-"`
-	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Username string `gorm:"size:255;unique;not null" json:"username"`
+	Email    string `gorm:"size:255;unique;not null" json:"email"`
+	// UsernameNormalized and EmailNormalized hold identity.Normalize of
+	// Username and Email; lookups and uniqueness use them
+	UsernameNormalized string    `gorm:"size:255;not null;default:'';uniqueIndex:idx_users_username_normalized" json:"-"`
+	EmailNormalized    string    `gorm:"size:255;not null;default:'';uniqueIndex:idx_users_email_normalized" json:"-"`
+	PasswordHash       string    `gorm:"size:255;not null" json:"-"`
+	Locale             string    `gorm:"size:16;not null;default:''" json:"locale"`
+	CreatedAt          time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
+
+// BeforeSave keeps the normalized columns in step with Username and Email
+func (u *User) BeforeSave(*gorm.DB) error {
+	if u.Username != "" {
+		u.UsernameNormalized = identity.Normalize(u.Username)
+	}
+	if u.Email != "" {
+		u.EmailNormalized = identity.Normalize(u.Email)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package schemas
+
+// LogLevel is the runtime log level exchanged by the admin endpoint
+type LogLevel struct {
+	Level string `json:"level" validate:"required"`
+}
+
+// ImpersonationRequest asks for a token acting as a user on behalf of the
+// signed-in member of staff, who is recorded with every request made with
+// the token
+type ImpersonationRequest struct {
+	UserID uint `json:"user_id" validate:"required"`
+	// Reason is recorded when the token is issued, e.g. a support ticket
+	Reason string `json:"reason" validate:"required,max=500"`
+}
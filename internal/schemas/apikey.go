@@ -0,0 +1,29 @@
+package schemas
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name   string   `form:"name" json:"name" validate:"required,max=100"`
+	Scopes []string `form:"scopes" json:"scopes" validate:"required,min=1,dive,oneof=api_keys:read api_keys:write password:write orgs:read orgs:write"`
+	// ExpiresInDays sets the lifetime of the key; it never expires when omitted
+	ExpiresInDays int `form:"expires_in_days" json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+type APIKey struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	// Prefix is the start of the key, to tell keys apart
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKey is returned once, when the key is created
+type CreatedAPIKey struct {
+	APIKey
+	// Key is the secret to send as "Authorization: Bearer" or X-API-Key;
+	// it cannot be retrieved again
+	Key string `json:"key"`
+}
@@ -0,0 +1,44 @@
+package schemas
+
+import "time"
+
+// AuditEventQuery filters the audit log; every field is optional
+type AuditEventQuery struct {
+	Action    string `query:"action"`
+	ActorID   uint   `query:"actor_id"`
+	Actor     string `query:"actor"`
+	TargetID  uint   `query:"target_id"`
+	IP        string `query:"ip" validate:"omitempty,ip"`
+	RequestID string `query:"request_id"`
+	// Since and Until bound the time of the events, in RFC 3339
+	Since time.Time `query:"since"`
+	Until time.Time `query:"until"`
+	// Before pages back from the id of the last event of the previous page
+	Before uint `query:"before"`
+	Limit  int  `query:"limit" validate:"gte=0,lte=200"`
+}
+
+type AuditEvent struct {
+	ID     uint   `json:"id"`
+	Action string `json:"action"`
+	// ActorID is the user who acted, if any
+	ActorID uint `json:"actor_id,omitempty"`
+	// Actor names the member of staff who acted, if any
+	Actor     string            `json:"actor,omitempty"`
+	TargetID  uint              `json:"target_id,omitempty"`
+	IP        string            `json:"ip"`
+	UserAgent string            `json:"user_agent"`
+	RequestID string            `json:"request_id"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// AuditVerification is the result of checking the audit log's hash chain
+type AuditVerification struct {
+	Checked int  `json:"checked"`
+	Intact  bool `json:"intact"`
+	// BrokenAt is the id of the first event that does not match the chain
+	BrokenAt uint `json:"broken_at,omitempty"`
+}
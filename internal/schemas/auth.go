@@ -3,20 +3,40 @@ package schemas
 import "time"
 
 type UserRegisterRequest struct {
-	Username string `form:"username" json:"username" validate:"required"`
+	// Username must not contain @, so it cannot be mistaken for an email at login
+	Username string `form:"username" json:"username" validate:"required,excludes=@"`
 	Password string `form:"password" json:"password" validate:"required"`
 	Email    string `form:"email" json:"email" validate:"required,email"`
 	FullName string `form:"full_name" json:"full_name" validate:"required"`
+	// Locale is the preferred language of messages; Accept-Language is used when empty
+	Locale string `form:"locale" json:"locale" validate:"omitempty,oneof=en vi"`
 }
 
 type LoginRequest struct {
+	// Username is the username or email of the account; both are case-insensitive
 	Username string `form:"username" json:"username" validate:"required"`
 	Password string `form:"password" json:"password" validate:"required"`
 }
 
+type MagicLinkRequest struct {
+	Email string `form:"email" json:"email" validate:"required,email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `form:"current_password" json:"current_password" validate:"required"`
+	NewPassword     string `form:"new_password" json:"new_password" validate:"required"`
+}
+
 // LoginResponse is the handler-level login response
 type LoginResponse struct {
 	Token     string    `json:"token"`
 	User      *User     `json:"user"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
+
+// LinkIdentityResponse starts linking an identity provider to the account
+type LinkIdentityResponse struct {
+	// AuthorizationURL is the provider's sign-in page, to open in the
+	// browser that made the request
+	AuthorizationURL string `json:"authorization_url"`
+}
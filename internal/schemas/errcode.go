@@ -0,0 +1,8 @@
+package schemas
+
+// ErrorCode documents a stable error code for client SDK authors
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
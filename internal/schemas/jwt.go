@@ -6,9 +6,22 @@ type JwtClaims struct {
 	ID       string `json:"id"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
+	Locale   string `json:"locale,omitempty"`
+	// SessionID is the token family of the login session that issued the token
+	SessionID string `json:"sid,omitempty"`
+	// Actor is set on impersonation tokens to the member of staff acting as
+	// the user, as the "act" claim of RFC 8693
+	Actor *ActorClaim `json:"act,omitempty"`
+	// OrgID is set on organization tokens to the organization they act in
+	OrgID uint `json:"org_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaim is the party acting on behalf of a token's subject
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
 type JwtResponse struct {
 	Token string `json:"token"`
 }
@@ -0,0 +1,121 @@
+package schemas
+
+import "time"
+
+type RegisterOAuthClientRequest struct {
+	Name string `form:"name" json:"name" validate:"required,max=255"`
+	// RedirectURIs are matched exactly; required for authorization_code
+	RedirectURIs []string `form:"redirect_uris" json:"redirect_uris" validate:"dive,url"`
+	GrantTypes   []string `form:"grant_types" json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code client_credentials"`
+	// Scopes the client may request: openid, profile, email or its own
+	Scopes []string `form:"scopes" json:"scopes" validate:"dive,required,excludes= "`
+	// Public clients, such as single-page apps, get no secret and must use PKCE
+	Public bool `form:"public" json:"public"`
+}
+
+type OAuthClient struct {
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	GrantTypes   []string  `json:"grant_types"`
+	Scopes       []string  `json:"scopes"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RegisteredOAuthClient is returned once, when the client is registered
+type RegisteredOAuthClient struct {
+	OAuthClient
+	// ClientSecret cannot be retrieved again; empty for a public client
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// OAuthAuthorizeRequest is the authorization request the signed-in user
+// decides on, with the parameters the client sent to /oauth2/authorize
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id" validate:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" validate:"required"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	// Approve is the user's decision; false sends access_denied to the client
+	Approve bool `form:"approve" json:"approve"`
+}
+
+type OAuthAuthorizeResponse struct {
+	// RedirectURI sends the user back to the client with a code or an error
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// The types below are defined by the OAuth and OpenID Connect
+// specifications and are sent without the response envelope
+
+// OAuthError is an OAuth 2.0 error response (RFC 6749 section 5.2)
+type OAuthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// OAuthIntrospection is a token introspection response (RFC 7662)
+type OAuthIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+}
+
+type OAuthUserInfo struct {
+	Sub               string `json:"sub"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Email             string `json:"email,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+}
+
+// OpenIDConfiguration is the OpenID Provider metadata
+type OpenIDConfiguration struct {
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint"`
+	TokenEndpoint                              string   `json:"token_endpoint"`
+	UserinfoEndpoint                           string   `json:"userinfo_endpoint"`
+	JWKSURI                                    string   `json:"jwks_uri"`
+	IntrospectionEndpoint                      string   `json:"introspection_endpoint"`
+	RevocationEndpoint                         string   `json:"revocation_endpoint"`
+	ScopesSupported                            []string `json:"scopes_supported"`
+	ResponseTypesSupported                     []string `json:"response_types_supported"`
+	GrantTypesSupported                        []string `json:"grant_types_supported"`
+	SubjectTypesSupported                      []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                            []string `json:"claims_supported"`
+	AuthorizationResponseIssParameterSupported bool     `json:"authorization_response_iss_parameter_supported"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
@@ -0,0 +1,58 @@
+package schemas
+
+import "time"
+
+type CreateOrganizationRequest struct {
+	Name string `form:"name" json:"name" validate:"required,max=255"`
+}
+
+type Organization struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Membership is an organization of the authenticated user
+type Membership struct {
+	Organization *Organization `json:"organization"`
+	Role         string        `json:"role"`
+	JoinedAt     time.Time     `json:"joined_at"`
+}
+
+// OrgToken is an access token acting in one organization
+type OrgToken struct {
+	Token     string    `json:"token"`
+	OrgID     uint      `json:"org_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Member is a user in the current organization
+type Member struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `form:"role" json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `form:"email" json:"email" validate:"required,email,max=255"`
+	Role  string `form:"role" json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type Invitation struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy uint      `json:"invited_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `form:"token" json:"token" validate:"required,max=128"`
+}
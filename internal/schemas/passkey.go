@@ -0,0 +1,42 @@
+package schemas
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang-sample/pkg/webauthn"
+)
+
+// PasskeyCreationOptions are passed to navigator.credentials.create after
+// PublicKeyCredential.parseCreationOptionsFromJSON(publicKey)
+type PasskeyCreationOptions struct {
+	PublicKey *webauthn.CreationOptions `json:"publicKey"`
+}
+
+// PasskeyRequestOptions are passed to navigator.credentials.get after
+// PublicKeyCredential.parseRequestOptionsFromJSON(publicKey)
+type PasskeyRequestOptions struct {
+	PublicKey *webauthn.RequestOptions `json:"publicKey"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	// Name labels the passkey, e.g. with its device; defaults to "Passkey"
+	Name string `json:"name" validate:"max=100"`
+	// Credential is the PublicKeyCredential the browser created, as its toJSON() returns it
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
+type PasskeyLoginRequest struct {
+	// Credential is the PublicKeyCredential the browser signed, as its toJSON() returns it
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
+type Passkey struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	Transports []string `json:"transports"`
+	// BackupEligible passkeys may be synced between devices
+	BackupEligible bool       `json:"backup_eligible"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
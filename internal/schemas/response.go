@@ -33,18 +33,27 @@ func NewPaginationResponse[T any](data T, currentPage, perPage, total uint32) Re
 	}
 }
 
-type ErrResponseBody struct {
-	Timestamp int64          `json:"timestamp"`
-	Msg       string         `json:"msg"`
-	ErrorCode int            `json:"error_code"`
-	Errors    []*ErrorDetail `json:"errors"`
-	Path      string         `json:"path"`
+// Problem is an RFC 7807 problem details body, served as application/problem+json
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the stable machine-readable error code, see docs/error-codes.md
+	Code string `json:"code"`
+	// RequestID and TraceID let clients quote the failing request when reporting errors
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+	// Errors lists the invalid fields of a validation problem
+	Errors []*ErrorDetail `json:"errors,omitempty"`
 }
 
+// ErrorDetail describes a single invalid field
 type ErrorDetail struct {
 	Msg       string                 `json:"msg"`
-	MsgValues map[string]interface{} `json:"msg_values"`
-	ErrorCode int                    `json:"error_code"`
+	MsgValues map[string]interface{} `json:"msg_values,omitempty"`
+	Code      string                 `json:"code,omitempty"`
 	Property  string                 `json:"property"`
-	Detail    string                 `json:"detail"`
+	Detail    string                 `json:"detail,omitempty"`
 }
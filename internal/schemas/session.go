@@ -0,0 +1,14 @@
+package schemas
+
+import "time"
+
+type Session struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session of the request's token
+	Current bool `json:"current"`
+}
@@ -0,0 +1,200 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/service/audit"
+	apikeyRepo "golang-sample/internal/storage/apikey"
+	userRepo "golang-sample/internal/storage/user"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+	utilstring "golang-sample/pkg/utils/string"
+)
+
+var tracer = otel.Tracer("golang-sample/internal/service/apikey")
+
+const (
+	// secretLength is the number of hex characters after model.APIKeyPrefix (256 bits)
+	secretLength = 64
+	// displayLength is how much of the key is kept as its displayable prefix
+	displayLength = len(model.APIKeyPrefix) + 8
+	// lastUsedResolution limits last-used writes to one per key per interval
+	lastUsedResolution = time.Minute
+)
+
+type impl struct {
+	log      *zap.SugaredLogger
+	keys     apikeyRepo.Storage
+	users    userRepo.Storage
+	auditLog audit.Recorder
+}
+
+// Option configures optional behavior of the API key service
+type Option func(*impl)
+
+// WithAuditLog records revoked keys to recorder
+func WithAuditLog(recorder audit.Recorder) Option {
+	return func(s *impl) {
+		s.auditLog = recorder
+	}
+}
+
+func NewAPIKeyService(log *zap.SugaredLogger, keys apikeyRepo.Storage, users userRepo.Storage, opts ...Option) Service {
+	s := &impl{
+		log:   log,
+		keys:  keys,
+		users: users,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *impl) Create(ctx context.Context, req CreateRequest) (_ *CreatedKey, err error) {
+	ctx, span := tracer.Start(ctx, "apikey.Create")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", req.Creator.UserID)
+
+	for _, scope := range req.Scopes {
+		if !req.Creator.HasScope(scope) {
+			log.Warnf("API key creation attempted with scope %s the caller lacks", scope)
+			return nil, ErrScopeExceeded
+		}
+	}
+
+	secret, err := utilstring.RandomHexString(secretLength)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	key := model.APIKeyPrefix + secret
+
+	apiKey := &model.APIKey{
+		UserID: req.Creator.UserID,
+		Name:   req.Name,
+		Prefix: key[:displayLength],
+		Scopes: req.Scopes,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(req.ExpiresIn).UTC()
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	created, err := s.keys.Create(ctx, apiKey, hashKey(key))
+	if err != nil {
+		log.Errorf("Failed to create API key: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("API key created: ID=%d", created.ID)
+	return &CreatedKey{APIKey: created, Key: key}, nil
+}
+
+func (s *impl) List(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	keys, err := s.keys.ListByUser(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list API keys: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return keys, nil
+}
+
+func (s *impl) Revoke(ctx context.Context, userID, keyID uint) error {
+	log := logger.FromContext(ctx, s.log).With("user_id", userID, "api_key_id", keyID)
+
+	if err := s.keys.Revoke(ctx, userID, keyID, time.Now().UTC()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		log.Errorf("Failed to revoke API key: %v", err)
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("API key revoked")
+	if s.auditLog != nil {
+		s.auditLog.Record(ctx, model.AuditEvent{
+			Action:   model.AuditAPIKeyRevoked,
+			ActorID:  userID,
+			TargetID: userID,
+			Metadata: map[string]string{"api_key_id": strconv.FormatUint(uint64(keyID), 10)},
+		})
+	}
+	return nil
+}
+
+func (s *impl) Authenticate(ctx context.Context, key string) (_ *model.Principal, err error) {
+	ctx, span := tracer.Start(ctx, "apikey.Authenticate")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log)
+
+	if !strings.HasPrefix(key, model.APIKeyPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	apiKey, err := s.keys.FindByHash(ctx, hashKey(key))
+	if err != nil {
+		log.Errorf("Failed to find API key: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	now := time.Now().UTC()
+	if !apiKey.Usable(now) {
+		log.Warnf("Authentication attempted with an unknown, expired or revoked API key")
+		return nil, ErrInvalidKey
+	}
+	span.SetAttributes(attribute.Int64("api_key.id", int64(apiKey.ID)))
+
+	owner, err := s.users.FindUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		log.Errorf("Failed to find API key owner: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if owner == nil {
+		log.Warnf("Authentication attempted with an API key of a deleted user")
+		return nil, ErrInvalidKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedResolution {
+		// Bookkeeping only; the request goes on if it fails
+		if err := s.keys.TouchLastUsed(ctx, apiKey.ID, now); err != nil {
+			log.Errorf("Failed to record API key use: %v", err)
+		}
+	}
+
+	scopes := apiKey.Scopes
+	if scopes == nil {
+		// A key without scopes grants none, unlike a nil Principal.Scopes
+		scopes = []model.Scope{}
+	}
+
+	return &model.Principal{
+		UserID:   owner.ID,
+		Username: owner.Username,
+		Email:    owner.Email,
+		Locale:   owner.Locale,
+		Method:   model.AuthMethodAPIKey,
+		Scopes:   scopes,
+		APIKeyID: apiKey.ID,
+	}, nil
+}
+
+// hashKey returns the hex SHA-256 of key, the form keys are stored and looked up in
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,46 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/model"
+)
+
+// Domain errors; match them with errors.Is
+var (
+	// ErrInvalidKey is returned by Authenticate for unknown, expired and revoked keys alike
+	ErrInvalidKey = errcode.New(errcode.AuthInvalidAPIKey, governerrors.CodeUnauthorized, "invalid API key")
+	// ErrScopeExceeded is returned when a key would get a scope its creator lacks
+	ErrScopeExceeded = errcode.New(errcode.AuthInsufficientScope, governerrors.CodeForbidden, "cannot grant a scope the caller lacks")
+	ErrNotFound      = errcode.New(errcode.APIKeyNotFound, governerrors.CodeNotFound, "API key not found")
+)
+
+type Service interface {
+	// Create issues a key to the creator's user. The key is only returned
+	// here; only its hash is stored.
+	Create(ctx context.Context, req CreateRequest) (*CreatedKey, error)
+	// List returns the keys of userID that are not revoked
+	List(ctx context.Context, userID uint) ([]*model.APIKey, error)
+	// Revoke disables key keyID of userID
+	Revoke(ctx context.Context, userID, keyID uint) error
+	// Authenticate returns the principal of a usable key
+	Authenticate(ctx context.Context, key string) (*model.Principal, error)
+}
+
+type CreateRequest struct {
+	// Creator owns the key; an API key can only grant the scopes it has
+	Creator   *model.Principal
+	Name      string
+	Scopes    []model.Scope
+	ExpiresIn time.Duration // zero never expires
+}
+
+type CreatedKey struct {
+	APIKey *model.APIKey
+	// Key is the secret; it cannot be retrieved again
+	Key string
+}
@@ -0,0 +1,165 @@
+package apikey
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+	"golang-sample/internal/service/audit"
+)
+
+func newTestService(t *testing.T) (Service, *storageMocks.MockAPIKeyStorage, *storageMocks.MockStorage) {
+	t.Helper()
+	keys := storageMocks.NewMockAPIKeyStorage(t)
+	users := storageMocks.NewMockStorage(t)
+	return NewAPIKeyService(zap.NewNop().Sugar(), keys, users), keys, users
+}
+
+func TestService_Create(t *testing.T) {
+	t.Run("issues a prefixed key and stores its hash", func(t *testing.T) {
+		service, keys, _ := newTestService(t)
+
+		var storedHash string
+		keys.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.APIKey"), mock.AnythingOfType("string")).
+			RunAndReturn(func(_ context.Context, key *model.APIKey, keyHash string) (*model.APIKey, error) {
+				storedHash = keyHash
+				created := *key
+				created.ID = 7
+				return &created, nil
+			})
+
+		created, err := service.Create(context.Background(), CreateRequest{
+			Creator:   &model.Principal{UserID: 42, Method: model.AuthMethodJWT},
+			Name:      "ci",
+			Scopes:    []model.Scope{model.ScopeAPIKeysRead},
+			ExpiresIn: 24 * time.Hour,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(created.Key, model.APIKeyPrefix))
+		assert.Len(t, created.Key, len(model.APIKeyPrefix)+secretLength)
+		assert.Equal(t, hashKey(created.Key), storedHash)
+		assert.NotContains(t, storedHash, created.Key[len(model.APIKeyPrefix):], "the secret is not stored")
+		assert.Equal(t, uint(42), created.APIKey.UserID)
+		assert.Equal(t, created.Key[:displayLength], created.APIKey.Prefix)
+		require.NotNil(t, created.APIKey.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), *created.APIKey.ExpiresAt, time.Minute)
+	})
+
+	t.Run("an API key cannot grant scopes it lacks", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+
+		_, err := service.Create(context.Background(), CreateRequest{
+			Creator: &model.Principal{UserID: 42, Method: model.AuthMethodAPIKey, Scopes: []model.Scope{model.ScopeAPIKeysWrite}},
+			Name:    "escalation",
+			Scopes:  []model.Scope{model.ScopeAPIKeysWrite, model.ScopePasswordWrite},
+		})
+
+		assert.ErrorIs(t, err, ErrScopeExceeded)
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeForbidden))
+	})
+}
+
+func TestService_Revoke(t *testing.T) {
+	t.Run("revokes an own key", func(t *testing.T) {
+		keys := storageMocks.NewMockAPIKeyStorage(t)
+		keys.EXPECT().Revoke(mock.Anything, uint(42), uint(7), mock.AnythingOfType("time.Time")).Return(nil)
+		var recorded []model.AuditEvent
+		service := NewAPIKeyService(zap.NewNop().Sugar(), keys, storageMocks.NewMockStorage(t),
+			WithAuditLog(audit.RecorderFunc(func(_ context.Context, event model.AuditEvent) {
+				recorded = append(recorded, event)
+			})))
+
+		assert.NoError(t, service.Revoke(context.Background(), 42, 7))
+		require.Len(t, recorded, 1)
+		assert.Equal(t, model.AuditAPIKeyRevoked, recorded[0].Action)
+		assert.Equal(t, uint(42), recorded[0].ActorID)
+		assert.Equal(t, "7", recorded[0].Metadata["api_key_id"])
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		service, keys, _ := newTestService(t)
+		keys.EXPECT().Revoke(mock.Anything, uint(42), uint(7), mock.AnythingOfType("time.Time")).Return(gorm.ErrRecordNotFound)
+
+		assert.ErrorIs(t, service.Revoke(context.Background(), 42, 7), ErrNotFound)
+	})
+}
+
+func TestService_Authenticate(t *testing.T) {
+	const key = model.APIKeyPrefix + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	owner := &model.User{ID: 42, Username: "alice", Email: "alice@example.com", Locale: "vi"}
+	past := time.Now().Add(-time.Hour)
+	recent := time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name      string
+		key       string
+		stored    *model.APIKey
+		owner     *model.User
+		wantTouch bool
+		wantErr   error
+	}{
+		{
+			name:      "usable key",
+			key:       key,
+			stored:    &model.APIKey{ID: 7, UserID: 42, Scopes: []model.Scope{model.ScopeAPIKeysRead}},
+			owner:     owner,
+			wantTouch: true,
+		},
+		{
+			name:   "recently used key is not touched again",
+			key:    key,
+			stored: &model.APIKey{ID: 7, UserID: 42, Scopes: []model.Scope{model.ScopeAPIKeysRead}, LastUsedAt: &recent},
+			owner:  owner,
+		},
+		{name: "without prefix", key: "0123456789abcdef", wantErr: ErrInvalidKey},
+		{name: "unknown", key: key, wantErr: ErrInvalidKey},
+		{name: "expired", key: key, stored: &model.APIKey{ID: 7, UserID: 42, ExpiresAt: &past}, wantErr: ErrInvalidKey},
+		{name: "revoked", key: key, stored: &model.APIKey{ID: 7, UserID: 42, RevokedAt: &past}, wantErr: ErrInvalidKey},
+		{name: "deleted owner", key: key, stored: &model.APIKey{ID: 7, UserID: 42}, wantErr: ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, keys, users := newTestService(t)
+			if strings.HasPrefix(tt.key, model.APIKeyPrefix) {
+				keys.EXPECT().FindByHash(mock.Anything, hashKey(tt.key)).Return(tt.stored, nil)
+			}
+			if tt.stored.Usable(time.Now()) {
+				users.EXPECT().FindUserByID(mock.Anything, tt.stored.UserID).Return(tt.owner, nil)
+			}
+			if tt.wantTouch {
+				keys.EXPECT().TouchLastUsed(mock.Anything, tt.stored.ID, mock.AnythingOfType("time.Time")).Return(nil)
+			}
+
+			principal, err := service.Authenticate(context.Background(), tt.key)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &model.Principal{
+				UserID:   42,
+				Username: "alice",
+				Email:    "alice@example.com",
+				Locale:   "vi",
+				Method:   model.AuthMethodAPIKey,
+				Scopes:   []model.Scope{model.ScopeAPIKeysRead},
+				APIKeyID: 7,
+			}, principal)
+			assert.False(t, principal.HasScope(model.ScopePasswordWrite))
+		})
+	}
+}
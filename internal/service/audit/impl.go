@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	auditRepo "golang-sample/internal/storage/audit"
+	"golang-sample/pkg/clientinfo"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+)
+
+var tracer = otel.Tracer("golang-sample/internal/service/audit")
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+
+	// appendAttempts bounds retries when other instances extend the chain
+	// between reading its end and appending
+	appendAttempts = 5
+	// verifyBatch is how many entries Verify reads at a time
+	verifyBatch = 500
+)
+
+type impl struct {
+	log    *zap.SugaredLogger
+	events auditRepo.Storage
+
+	// appendMu keeps this instance's appends from racing each other;
+	// the storage rejects forks made by other instances
+	appendMu sync.Mutex
+}
+
+func NewAuditService(log *zap.SugaredLogger, events auditRepo.Storage) Service {
+	return &impl{
+		log:    log,
+		events: events,
+	}
+}
+
+func (s *impl) Record(ctx context.Context, event model.AuditEvent) {
+	ctx, span := tracer.Start(ctx, "audit.Record")
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	// The event happened whether or not the client waits for the answer
+	ctx = context.WithoutCancel(ctx)
+	log := logger.FromContext(ctx, s.log).With("action", event.Action)
+
+	client := clientinfo.FromContext(ctx)
+	if event.IP == "" {
+		event.IP = client.IP
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = client.UserAgent
+	}
+	if event.RequestID == "" {
+		event.RequestID = client.RequestID
+	}
+	// Databases keep microseconds; hash what will be read back
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	if err = s.append(ctx, event); err != nil {
+		log.Errorw("Failed to record audit event", "error", err, "event", event)
+	}
+}
+
+func (s *impl) append(ctx context.Context, event model.AuditEvent) error {
+	s.appendMu.Lock()
+	defer s.appendMu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		last, err := s.events.Last(ctx)
+		if err != nil {
+			return err
+		}
+		event.PrevHash = ""
+		if last != nil {
+			event.PrevHash = last.Hash
+		}
+		event.Hash, err = hash(&event)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.events.Append(ctx, &event)
+		if !errors.Is(err, auditRepo.ErrChainConflict) || attempt == appendAttempts {
+			return err
+		}
+	}
+}
+
+func (s *impl) List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+	filter.Limit = min(filter.Limit, MaxListLimit)
+
+	events, err := s.events.List(ctx, filter)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return events, nil
+}
+
+func (s *impl) Verify(ctx context.Context) (_ *VerifyResult, err error) {
+	ctx, span := tracer.Start(ctx, "audit.Verify")
+	defer func() { tracing.End(span, err) }()
+
+	result := &VerifyResult{}
+	var afterID uint
+	prevHash := ""
+	for {
+		events, err := s.events.ListAfter(ctx, afterID, verifyBatch)
+		if err != nil {
+			return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+		}
+
+		for _, event := range events {
+			result.Checked++
+			want, err := hash(event)
+			if err != nil {
+				return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+			}
+			if event.PrevHash != prevHash || event.Hash != want {
+				result.BrokenAt = event.ID
+				logger.FromContext(ctx, s.log).Errorw("Audit log chain is broken", "audit_event_id", event.ID)
+				return result, nil
+			}
+			prevHash = event.Hash
+			afterID = event.ID
+		}
+
+		if len(events) < verifyBatch {
+			return result, nil
+		}
+	}
+}
+
+// hash returns the hex SHA-256 of event's content and PrevHash. Fields are
+// encoded in a fixed order and map keys sorted, so it is stable.
+func hash(event *model.AuditEvent) (string, error) {
+	// Storage reads no metadata back as an empty map
+	metadata := event.Metadata
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+
+	content, err := json.Marshal(struct {
+		PrevHash  string            `json:"prev_hash"`
+		Action    string            `json:"action"`
+		ActorID   uint              `json:"actor_id"`
+		Actor     string            `json:"actor"`
+		TargetID  uint              `json:"target_id"`
+		IP        string            `json:"ip"`
+		UserAgent string            `json:"user_agent"`
+		RequestID string            `json:"request_id"`
+		Metadata  map[string]string `json:"metadata"`
+		CreatedAt string            `json:"created_at"`
+	}{
+		PrevHash:  event.PrevHash,
+		Action:    string(event.Action),
+		ActorID:   event.ActorID,
+		Actor:     event.Actor,
+		TargetID:  event.TargetID,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		RequestID: event.RequestID,
+		Metadata:  metadata,
+		CreatedAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
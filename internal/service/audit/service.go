@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"context"
+
+	"golang-sample/internal/model"
+)
+
+// Recorder appends events to the audit log. Recording never fails the
+// operation being audited; errors are logged instead.
+type Recorder interface {
+	// Record appends event. The client's IP, user agent and request ID
+	// are taken from ctx when event leaves them empty.
+	Record(ctx context.Context, event model.AuditEvent)
+}
+
+// RecorderFunc adapts a function to Recorder
+type RecorderFunc func(ctx context.Context, event model.AuditEvent)
+
+func (f RecorderFunc) Record(ctx context.Context, event model.AuditEvent) {
+	f(ctx, event)
+}
+
+type Service interface {
+	Recorder
+	// List returns the events matching filter, newest first. Limit
+	// defaults to DefaultListLimit and is capped at MaxListLimit.
+	List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEvent, error)
+	// Verify walks the whole log and checks every entry's hash and link
+	// to the entry before it
+	Verify(ctx context.Context) (*VerifyResult, error)
+}
+
+// VerifyResult is the outcome of Verify
+type VerifyResult struct {
+	// Checked is the number of entries checked
+	Checked int
+	// BrokenAt is the first entry whose hash or link does not match; 0
+	// when the chain is intact
+	BrokenAt uint
+}
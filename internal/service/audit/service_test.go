@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+	auditRepo "golang-sample/internal/storage/audit"
+	"golang-sample/pkg/clientinfo"
+)
+
+func TestService_Record(t *testing.T) {
+	t.Run("links the event to the end of the chain", func(t *testing.T) {
+		events := storageMocks.NewMockAuditStorage(t)
+		events.EXPECT().Last(mock.Anything).Return(&model.AuditEvent{ID: 3, Hash: "prev"}, nil)
+		var appended *model.AuditEvent
+		events.EXPECT().Append(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, event *model.AuditEvent) (*model.AuditEvent, error) {
+				appended = event
+				return event, nil
+			})
+
+		ctx := clientinfo.NewContext(context.Background(), clientinfo.Info{UserAgent: "Firefox", IP: "203.0.113.7", RequestID: "req-1"})
+		NewAuditService(zap.NewNop().Sugar(), events).Record(ctx, model.AuditEvent{
+			Action:   model.AuditLoginSucceeded,
+			ActorID:  7,
+			TargetID: 7,
+			Metadata: map[string]string{"method": "password"},
+		})
+
+		require.NotNil(t, appended)
+		assert.Equal(t, "203.0.113.7", appended.IP)
+		assert.Equal(t, "Firefox", appended.UserAgent)
+		assert.Equal(t, "req-1", appended.RequestID)
+		assert.WithinDuration(t, time.Now(), appended.CreatedAt, time.Second)
+		assert.Equal(t, "prev", appended.PrevHash)
+		want, err := hash(appended)
+		require.NoError(t, err)
+		assert.Equal(t, want, appended.Hash)
+	})
+
+	t.Run("retries when another writer extended the chain", func(t *testing.T) {
+		events := storageMocks.NewMockAuditStorage(t)
+		events.EXPECT().Last(mock.Anything).Return(&model.AuditEvent{Hash: "old"}, nil).Once()
+		events.EXPECT().Last(mock.Anything).Return(&model.AuditEvent{Hash: "new"}, nil).Once()
+		events.EXPECT().Append(mock.Anything, mock.Anything).Return(nil, auditRepo.ErrChainConflict).Once()
+		var appended *model.AuditEvent
+		events.EXPECT().Append(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, event *model.AuditEvent) (*model.AuditEvent, error) {
+				appended = event
+				return event, nil
+			}).Once()
+
+		NewAuditService(zap.NewNop().Sugar(), events).Record(context.Background(), model.AuditEvent{Action: model.AuditPasswordChanged})
+
+		require.NotNil(t, appended)
+		assert.Equal(t, "new", appended.PrevHash)
+	})
+}
+
+func TestService_Verify(t *testing.T) {
+	chain := func(t *testing.T, n int) []*model.AuditEvent {
+		t.Helper()
+		events := make([]*model.AuditEvent, n)
+		prev := ""
+		for i := range events {
+			event := &model.AuditEvent{
+				ID:        uint(i + 1),
+				Action:    model.AuditLoginSucceeded,
+				ActorID:   uint(i + 1),
+				CreatedAt: time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC),
+				PrevHash:  prev,
+			}
+			var err error
+			event.Hash, err = hash(event)
+			require.NoError(t, err)
+			prev = event.Hash
+			events[i] = event
+		}
+		return events
+	}
+
+	tests := []struct {
+		name         string
+		tamper       func(events []*model.AuditEvent)
+		wantBrokenAt uint
+	}{
+		{name: "intact"},
+		{name: "altered entry", tamper: func(events []*model.AuditEvent) { events[1].ActorID = 99 }, wantBrokenAt: 2},
+		{name: "removed entry", tamper: func(events []*model.AuditEvent) { events[1] = events[2] }, wantBrokenAt: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := chain(t, 3)
+			if tt.tamper != nil {
+				tt.tamper(events)
+			}
+			storage := storageMocks.NewMockAuditStorage(t)
+			storage.EXPECT().ListAfter(mock.Anything, uint(0), verifyBatch).Return(events, nil)
+
+			result, err := NewAuditService(zap.NewNop().Sugar(), storage).Verify(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBrokenAt, result.BrokenAt)
+		})
+	}
+}
+
+func TestService_List(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int
+	}{
+		{"default", 0, DefaultListLimit},
+		{"within bounds", 10, 10},
+		{"capped", 1000, MaxListLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := storageMocks.NewMockAuditStorage(t)
+			storage.EXPECT().List(mock.Anything, model.AuditFilter{TargetID: 7, Limit: tt.wantLimit}).Return(nil, nil)
+
+			_, err := NewAuditService(zap.NewNop().Sugar(), storage).List(context.Background(), model.AuditFilter{TargetID: 7, Limit: tt.limit})
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/service/audit"
+)
+
+// Login methods recorded in the audit log and in events
+const (
+	loginMethodPassword  = "password"
+	loginMethodExternal  = "external"
+	loginMethodMagicLink = "magic_link"
+	loginMethodPasskey   = "passkey"
+)
+
+// WithAuditLog records registrations, logins, password changes and
+// impersonations to recorder
+func WithAuditLog(recorder audit.Recorder) Option {
+	return func(s *impl) {
+		s.auditLog = recorder
+	}
+}
+
+func (s *impl) audit(ctx context.Context, event model.AuditEvent) {
+	if s.auditLog != nil {
+		s.auditLog.Record(ctx, event)
+	}
+}
+
+func (s *impl) auditLoginSucceeded(ctx context.Context, userID uint, metadata map[string]string) {
+	s.audit(ctx, model.AuditEvent{
+		Action:   model.AuditLoginSucceeded,
+		ActorID:  userID,
+		TargetID: userID,
+		Metadata: metadata,
+	})
+}
+
+// auditLoginFailed records a rejected login; targetID is 0 when the
+// account is unknown
+func (s *impl) auditLoginFailed(ctx context.Context, targetID uint, metadata map[string]string) {
+	s.audit(ctx, model.AuditEvent{
+		Action:   model.AuditLoginFailed,
+		TargetID: targetID,
+		Metadata: metadata,
+	})
+}
@@ -15,6 +15,7 @@ func modelToSchemaUser(u *model.User) *schemas.User {
 		ID:        u.ID,
 		Username:  u.Username,
 		Email:     u.Email,
+		Locale:    u.Locale,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
@@ -30,6 +31,7 @@ func schemaToModelUser(u *schemas.User) *model.User {
 		ID:        u.ID,
 		Username:  u.Username,
 		Email:     u.Email,
+		Locale:    u.Locale,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+
+	"golang-sample/internal/model"
+	outboxservice "golang-sample/internal/service/outbox"
+	"golang-sample/internal/storage/outbox"
+	"golang-sample/pkg/clientinfo"
+)
+
+// WithEvents writes UserRegistered, PasswordChanged and UserLoggedIn events
+// to the outbox in the transaction of the change; a login's is stored with
+// its session
+func WithEvents(events outbox.Storage) Option {
+	return func(s *impl) {
+		s.events = events
+	}
+}
+
+// newEvents returns the event to store with a change, or none when events
+// are off
+func (s *impl) newEvents(eventType model.EventType, userID uint, payload any) ([]*model.Event, error) {
+	if s.events == nil {
+		return nil, nil
+	}
+	event, err := outboxservice.NewEvent(eventType, userID, payload)
+	if err != nil {
+		return nil, err
+	}
+	return []*model.Event{event}, nil
+}
+
+// loginEvents returns the UserLoggedIn event of a login of userID by
+// method from the client in ctx, or none when events are off
+func (s *impl) loginEvents(ctx context.Context, userID uint, method string) ([]*model.Event, error) {
+	client := clientinfo.FromContext(ctx)
+	return s.newEvents(model.EventUserLoggedIn, userID, model.UserLoggedIn{
+		Method:    method,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+)
+
+// ImpersonationExpiration is the lifetime of impersonation tokens. They
+// start no session, so they cannot be revoked and must expire soon.
+const ImpersonationExpiration = 15 * time.Minute
+
+func (s *impl) Impersonate(ctx context.Context, req ImpersonateRequest) (_ *LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "auth.Impersonate")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", req.UserID, "actor_id", req.ActorID)
+
+	if req.ActorID == 0 {
+		return nil, governerrors.NewCode(governerrors.CodeInvalid, "actor is required")
+	}
+
+	// The actor is named after the staff member's own account, never after
+	// what the caller claims
+	staff, err := s.storage.FindUserByID(ctx, req.ActorID)
+	if err != nil {
+		log.Errorf("Failed to find actor: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if staff == nil {
+		return nil, governerrors.ErrUnauthorized
+	}
+
+	account, err := s.storage.FindUserByID(ctx, req.UserID)
+	if err != nil {
+		log.Errorf("Failed to find user: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if account == nil {
+		return nil, ErrUserNotFound
+	}
+
+	expiresAt := time.Now().Add(ImpersonationExpiration)
+	token, err := s.signToken(schemas.JwtClaims{
+		ID:       strconv.FormatUint(uint64(account.ID), 10),
+		Email:    account.Email,
+		Username: account.Username,
+		Locale:   account.Locale,
+		Actor:    &schemas.ActorClaim{Subject: staff.Username},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		log.Errorf("Failed to generate token: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Impersonation of %s by %s started", account.Username, staff.Username)
+	s.audit(ctx, model.AuditEvent{
+		Action:   model.AuditImpersonationStarted,
+		ActorID:  staff.ID,
+		Actor:    staff.Username,
+		TargetID: account.ID,
+		Metadata: map[string]string{
+			"reason":     req.Reason,
+			"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		},
+	})
+	return &LoginResponse{
+		Token:     token,
+		User:      account,
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -2,27 +2,138 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	governerrors "github.com/haipham22/govern/errors"
-	"gorm.io/gorm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
+	"golang-sample/internal/metrics"
 	"golang-sample/internal/model"
 	schemas2 "golang-sample/internal/schemas"
+	"golang-sample/internal/service/audit"
+	"golang-sample/internal/storage"
+	"golang-sample/internal/storage/identity"
+	"golang-sample/internal/storage/magiclink"
+	"golang-sample/internal/storage/outbox"
 	"golang-sample/internal/storage/user"
+	"golang-sample/internal/validator"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/mailer"
+	"golang-sample/pkg/tracing"
+	identifier "golang-sample/pkg/utils/identity"
 	"golang-sample/pkg/utils/password"
 )
 
+var tracer = otel.Tracer("golang-sample/internal/service/auth")
+
 type impl struct {
-	log           *zap.SugaredLogger
-	storage       user.Storage
-	jwtSecret     string
-	jwtExpiration time.Duration
+	log            *zap.SugaredLogger
+	storage        user.Storage
+	identities     identity.Storage
+	jwtSecret      string
+	jwtExpiration  time.Duration
+	passwordPolicy password.Policy
+	breachChecker  password.BreachChecker
+	hasher         password.Hasher
+	nonEnumerating bool
+	magicLinks     magiclink.Storage
+	mailer         mailer.Mailer
+	magicLinkCfg   MagicLinkConfig
+	passkeys       PasskeyVerifier
+	sessions       SessionStarter
+	auditLog       audit.Recorder
+	events         outbox.Storage
+
+	// pending tracks mail being sent after its request returned
+	pending sync.WaitGroup
+
+	// dummyHash is verified against when no account matches, so a login
+	// costs the same whether or not the username exists
+	dummyHashMu sync.Mutex
+	dummyHash   string
+}
+
+// Option configures optional behavior of the auth service
+type Option func(*impl)
+
+// WithPasswordPolicy sets the policy new passwords must meet; without it
+// any password is accepted
+func WithPasswordPolicy(policy password.Policy) Option {
+	return func(s *impl) {
+		s.passwordPolicy = policy
+	}
+}
+
+// WithHasher sets the hasher of new passwords; password.DefaultHasher is
+// used otherwise. Stored hashes of other formats keep verifying and are
+// upgraded on the next successful login.
+func WithHasher(hasher password.Hasher) Option {
+	return func(s *impl) {
+		s.hasher = hasher
+	}
+}
+
+// WithBreachChecker rejects new passwords found by checker
+func WithBreachChecker(checker password.BreachChecker) Option {
+	return func(s *impl) {
+		s.breachChecker = checker
+	}
+}
+
+// WithNonEnumeratingRegistration makes Register answer every registration
+// alike, with no user and no error, after hashing the password either way,
+// so the response does not reveal whether the username or email is taken.
+// The outcome of a conflict goes by m to the email given: its owner learns
+// someone tried to register it, or the applicant that the username is taken.
+func WithNonEnumeratingRegistration(m mailer.Mailer) Option {
+	return func(s *impl) {
+		s.nonEnumerating = true
+		s.mailer = m
+	}
+}
+
+// WithIdentityStorage enables ExternalLogin, storing linked identities in
+// identities
+func WithIdentityStorage(identities identity.Storage) Option {
+	return func(s *impl) {
+		s.identities = identities
+	}
+}
+
+// WithMagicLinks enables passwordless login with links sent by m and
+// recorded in links
+func WithMagicLinks(links magiclink.Storage, m mailer.Mailer, cfg MagicLinkConfig) Option {
+	return func(s *impl) {
+		s.magicLinks = links
+		s.mailer = m
+		s.magicLinkCfg = cfg.withDefaults()
+	}
+}
+
+// SessionStarter records login sessions; the session service is one
+type SessionStarter interface {
+	// Start records a login of user whose tokens expire at expiresAt,
+	// storing events with it
+	Start(ctx context.Context, user *model.User, expiresAt time.Time, events ...*model.Event) (*model.Session, error)
+}
+
+// WithSessions records a session for every login; its tokens name the
+// session, so that revoking it signs them out
+func WithSessions(sessions SessionStarter) Option {
+	return func(s *impl) {
+		s.sessions = sessions
+	}
 }
 
 func NewAuthService(
@@ -30,84 +141,340 @@ func NewAuthService(
 	storage user.Storage,
 	jwtSecret string,
 	jwtExpiration time.Duration,
+	opts ...Option,
 ) Service {
-	return &impl{
+	s := &impl{
 		log:           log,
 		storage:       storage,
 		jwtSecret:     jwtSecret,
 		jwtExpiration: jwtExpiration,
+		hasher:        password.DefaultHasher(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *impl) Register(ctx context.Context, req RegisterRequest) (*model.User, error) {
+func (s *impl) Register(ctx context.Context, req RegisterRequest) (_ *model.User, err error) {
+	ctx, span := tracer.Start(ctx, "auth.Register")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log)
+
+	// Schema validation sees the raw username, but logins compare the
+	// normalized one: a fullwidth "＠" would pass it and then normalize into
+	// another account's email, which a username match takes precedence over
+	if strings.Contains(identifier.Normalize(req.Username), "@") {
+		log.Warnf("Registration attempted with a username that normalizes to contain @")
+		metrics.RegistrationsTotal.Inc(metrics.ResultFailure)
+		return nil, validator.Invalid(validator.NewErrorDetail("username", "excludes", "@", reflect.String.String()))
+	}
+
+	if err := s.checkPassword(ctx, "password", req.Password, req.Username, req.Email); err != nil {
+		log.Warnf("Registration attempted with a password rejected by policy")
+		metrics.RegistrationsTotal.Inc(metrics.ResultFailure)
+		return nil, err
+	}
+
 	usernameExists, emailExists, err := s.storage.CheckUniqueness(ctx, req.Username, req.Email)
 	if err != nil {
-		s.log.Errorf("Failed to check uniqueness: %v", err)
+		log.Errorf("Failed to check uniqueness: %v", err)
+		metrics.RegistrationsTotal.Inc(metrics.ResultError)
 		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
 	}
 
+	if s.nonEnumerating && (usernameExists || emailExists) {
+		log.Warnf("Registration attempted with existing username or email")
+		metrics.RegistrationsTotal.Inc(metrics.ResultFailure)
+		hashCtx, hashSpan := tracer.Start(ctx, "password.Hash")
+		_, err := s.hasher.Hash(hashCtx, req.Password)
+		hashSpan.End()
+		if errors.Is(err, password.ErrBusy) {
+			return nil, ErrBusy
+		}
+		s.notifyRegistrationConflict(ctx, log, req, emailExists)
+		return nil, nil
+	}
+
 	if usernameExists {
-		s.log.Warnf("Registration attempted with existing username")
-		return nil, governerrors.NewCode(governerrors.CodeConflict, "username already exists")
+		log.Warnf("Registration attempted with existing username")
+		metrics.RegistrationsTotal.Inc(metrics.ResultFailure)
+		return nil, ErrUsernameTaken
 	}
 
 	if emailExists {
-		s.log.Warnf("Registration attempted with existing email")
-		return nil, governerrors.NewCode(governerrors.CodeConflict, "email already exists")
+		log.Warnf("Registration attempted with existing email")
+		metrics.RegistrationsTotal.Inc(metrics.ResultFailure)
+		return nil, ErrEmailTaken
 	}
 
-	hashedPassword, err := password.HashPassword(req.Password)
+	hashCtx, hashSpan := tracer.Start(ctx, "password.Hash")
+	hashedPassword, err := s.hasher.Hash(hashCtx, req.Password)
+	hashSpan.End()
 	if err != nil {
-		s.log.Errorf("Failed to hash password: %v", err)
-		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+		log.Errorf("Failed to hash password: %v", err)
+		metrics.RegistrationsTotal.Inc(metrics.ResultError)
+		return nil, hashingError(err)
 	}
 
 	m := &model.User{
 		Username: req.Username,
 		Email:    req.Email,
+		Locale:   req.Locale,
 	}
 
-	createdUser, err := s.storage.CreateUserWithPassword(ctx, m, hashedPassword)
+	events, err := s.newEvents(model.EventUserRegistered, 0, model.UserRegistered{
+		Username: req.Username,
+		Email:    req.Email,
+		Locale:   req.Locale,
+		Method:   loginMethodPassword,
+	})
+	if err != nil {
+		log.Errorf("Failed to create registration event: %v", err)
+		metrics.RegistrationsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	createdUser, err := s.storage.CreateUserWithPassword(ctx, m, hashedPassword, events...)
 	if err != nil {
 		// Handle race condition: if user was created between uniqueness check and now
-		// PostgreSQL duplicate key error code is 23505
-		if errors.Is(err, gorm.ErrDuplicatedKey) ||
-			strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			s.log.Warnf("User creation failed due to duplicate (race condition)")
-			return nil, governerrors.NewCode(governerrors.CodeConflict, "username or email already exists")
+		if storage.IsDuplicate(err) {
+			log.Warnf("User creation failed due to duplicate (race condition)")
+			metrics.RegistrationsTotal.Inc(metrics.ResultFailure)
+			if s.nonEnumerating {
+				s.notifyRegistrationConflict(ctx, log, req, false)
+				return nil, nil
+			}
+			return nil, ErrAccountExists
 		}
-		s.log.Errorf("Failed to create user: %v", err)
+		log.Errorf("Failed to create user: %v", err)
+		metrics.RegistrationsTotal.Inc(metrics.ResultError)
 		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
 	}
 
-	s.log.Infof("User registered successfully: ID=%d", createdUser.ID)
+	log.Infof("User registered successfully: ID=%d", createdUser.ID)
+	s.audit(ctx, model.AuditEvent{
+		Action:   model.AuditUserRegistered,
+		ActorID:  createdUser.ID,
+		TargetID: createdUser.ID,
+	})
+	metrics.RegistrationsTotal.Inc(metrics.ResultSuccess)
+	if s.nonEnumerating {
+		return nil, nil
+	}
 	return createdUser, nil
 }
 
-func (s *impl) Login(ctx context.Context, req LoginRequest) (*LoginResponse, error) {
-	account, passwordHash, err := s.storage.FindUserByUsernameWithPassword(ctx, req.Username)
+// notifyRegistrationConflict emails the address of a registration that
+// WithNonEnumeratingRegistration answered as accepted but did not create.
+// Sending takes long enough to tell conflicts from registrations, so it
+// happens after the response.
+func (s *impl) notifyRegistrationConflict(ctx context.Context, log *zap.SugaredLogger, req RegisterRequest, emailTaken bool) {
+	msg := mailer.Message{
+		To:      req.Email,
+		Subject: "Your registration",
+		Body: fmt.Sprintf("Hi,\n\nThe username %q or this email address is already registered, so no account was created. "+
+			"Please try again with another username.\n", req.Username),
+	}
+	if emailTaken {
+		msg.Body = "Hi,\n\nSomeone tried to create an account with this email address, which already has one. " +
+			"If it was you, sign in or reset your password instead. Otherwise you can ignore this email.\n"
+	}
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.mailer.Send(context.WithoutCancel(ctx), msg); err != nil {
+			log.Errorf("Failed to send registration conflict email: %v", err)
+		}
+	}()
+}
+
+func (s *impl) Login(ctx context.Context, req LoginRequest) (_ *LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "auth.Login")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log)
+
+	account, passwordHash, err := s.storage.FindUserByLoginWithPassword(ctx, req.Username)
 	if err != nil {
-		s.log.Errorf("Failed to find account by username: %v", err)
+		log.Errorf("Failed to find account by username or email: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultError)
 		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
 	}
 
 	if account == nil {
-		s.log.Warnf("Login attempted with non-existent username")
-		return nil, governerrors.ErrUnauthorized
+		log.Warnf("Login attempted with non-existent username or email")
+		if err := s.verifyDummy(ctx, req.Password); err != nil {
+			log.Errorf("Failed to verify dummy password hash: %v", err)
+			metrics.LoginsTotal.Inc(metrics.ResultError)
+			return nil, hashingError(err)
+		}
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		s.auditLoginFailed(ctx, 0, map[string]string{"method": loginMethodPassword, "login": req.Username, "reason": "unknown_account"})
+		return nil, ErrInvalidCredentials
 	}
 
-	if !password.CheckPasswordHash(req.Password, passwordHash) {
-		s.log.Warnf("Login attempted with invalid password")
-		return nil, governerrors.ErrUnauthorized
+	// Accounts started by an external login have no password
+	if passwordHash == "" {
+		log.Warnf("Password login attempted for an account without a password")
+		if err := s.verifyDummy(ctx, req.Password); err != nil {
+			log.Errorf("Failed to verify dummy password hash: %v", err)
+			metrics.LoginsTotal.Inc(metrics.ResultError)
+			return nil, hashingError(err)
+		}
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		s.auditLoginFailed(ctx, account.ID, map[string]string{"method": loginMethodPassword, "reason": "no_password"})
+		return nil, ErrInvalidCredentials
+	}
+
+	compareCtx, compareSpan := tracer.Start(ctx, "password.Compare")
+	passwordMatches, err := s.hasher.Verify(compareCtx, req.Password, passwordHash)
+	compareSpan.End()
+	if err != nil {
+		log.Errorf("Failed to verify password hash: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, hashingError(err)
+	}
+
+	if !passwordMatches {
+		log.Warnf("Login attempted with invalid password")
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		s.auditLoginFailed(ctx, account.ID, map[string]string{"method": loginMethodPassword, "reason": "wrong_password"})
+		return nil, ErrInvalidCredentials
+	}
+
+	log = log.With("user_id", account.ID)
+	span.SetAttributes(attribute.Int64("user.id", int64(account.ID)))
+
+	if s.hasher.NeedsRehash(passwordHash) {
+		s.rehash(ctx, log, account.ID, req.Password)
+	}
+
+	token, expiresAt, err := s.generateToken(ctx, account, loginMethodPassword)
+	if err != nil {
+		log.Errorf("Failed to generate token: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("User logged in successfully: %s", account.Username)
+	s.auditLoginSucceeded(ctx, account.ID, map[string]string{"method": loginMethodPassword})
+	metrics.LoginsTotal.Inc(metrics.ResultSuccess)
+	return &LoginResponse{
+		Token:     token,
+		User:      account,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *impl) ChangePassword(ctx context.Context, req ChangePasswordRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "auth.ChangePassword")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", req.UserID)
+	span.SetAttributes(attribute.Int64("user.id", int64(req.UserID)))
+
+	account, passwordHash, err := s.storage.FindUserByIDWithPassword(ctx, req.UserID)
+	if err != nil {
+		log.Errorf("Failed to find account by ID: %v", err)
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	// A deleted account, or one without a password, is indistinguishable
+	// from a wrong password
+	if account == nil || passwordHash == "" {
+		log.Warnf("Password change attempted for non-existent account or one without a password")
+		if err := s.verifyDummy(ctx, req.CurrentPassword); err != nil {
+			log.Errorf("Failed to verify dummy password hash: %v", err)
+			return hashingError(err)
+		}
+		return ErrInvalidCredentials
+	}
+
+	compareCtx, compareSpan := tracer.Start(ctx, "password.Compare")
+	passwordMatches, err := s.hasher.Verify(compareCtx, req.CurrentPassword, passwordHash)
+	compareSpan.End()
+	if err != nil {
+		log.Errorf("Failed to verify password hash: %v", err)
+		return hashingError(err)
+	}
+
+	if !passwordMatches {
+		log.Warnf("Password change attempted with invalid current password")
+		return ErrInvalidCredentials
+	}
+
+	if err := s.checkPassword(ctx, "new_password", req.NewPassword, account.Username, account.Email); err != nil {
+		log.Warnf("Password change attempted with a password rejected by policy")
+		return err
+	}
+
+	hashCtx, hashSpan := tracer.Start(ctx, "password.Hash")
+	hashedPassword, err := s.hasher.Hash(hashCtx, req.NewPassword)
+	hashSpan.End()
+	if err != nil {
+		log.Errorf("Failed to hash password: %v", err)
+		return hashingError(err)
+	}
+
+	events, err := s.newEvents(model.EventPasswordChanged, account.ID, model.PasswordChanged{})
+	if err != nil {
+		log.Errorf("Failed to create password change event: %v", err)
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	if err := s.storage.UpdatePassword(ctx, account.ID, hashedPassword, events...); err != nil {
+		log.Errorf("Failed to update password: %v", err)
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Password changed successfully")
+	s.audit(ctx, model.AuditEvent{
+		Action:   model.AuditPasswordChanged,
+		ActorID:  account.ID,
+		TargetID: account.ID,
+	})
+	return nil
+}
+
+func (s *impl) ExternalLogin(ctx context.Context, req ExternalLoginRequest) (_ *LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "auth.ExternalLogin")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("provider", req.Provider)
+	span.SetAttributes(attribute.String("auth.provider", req.Provider))
+
+	if s.identities == nil {
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.NewCode(governerrors.CodeInternal, "external login is not configured")
+	}
+
+	account, err := s.externalAccount(ctx, log, req)
+	if err != nil {
+		if _, ok := governerrors.GetCode(err); ok {
+			metrics.LoginsTotal.Inc(metrics.ResultFailure)
+			s.auditLoginFailed(ctx, 0, map[string]string{"method": loginMethodExternal, "provider": req.Provider})
+			return nil, err
+		}
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
 	}
 
-	token, expiresAt, err := s.generateToken(account)
+	log = log.With("user_id", account.ID)
+	span.SetAttributes(attribute.Int64("user.id", int64(account.ID)))
+
+	token, expiresAt, err := s.generateToken(ctx, account, loginMethodExternal)
 	if err != nil {
-		s.log.Errorf("Failed to generate token: %v", err)
+		log.Errorf("Failed to generate token: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultError)
 		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
 	}
 
-	s.log.Infof("User logged in successfully: %s", account.Username)
+	log.Infof("User logged in with external identity: %s", account.Username)
+	s.auditLoginSucceeded(ctx, account.ID, map[string]string{"method": loginMethodExternal, "provider": req.Provider})
+	metrics.LoginsTotal.Inc(metrics.ResultSuccess)
 	return &LoginResponse{
 		Token:     token,
 		User:      account,
@@ -115,23 +482,341 @@ func (s *impl) Login(ctx context.Context, req LoginRequest) (*LoginResponse, err
 	}, nil
 }
 
-func (s *impl) generateToken(user *model.User) (string, time.Time, error) {
-	expiresAt := time.Now().Add(s.jwtExpiration)
+// externalAccount finds the account of an external identity, linking or
+// creating it on first login. Errors carrying a govern code are the
+// caller's fault; others are internal.
+func (s *impl) externalAccount(ctx context.Context, log *zap.SugaredLogger, req ExternalLoginRequest) (*model.User, error) {
+	linked, err := s.identities.Find(ctx, req.Provider, req.Subject)
+	if err != nil {
+		log.Errorf("Failed to find identity: %v", err)
+		return nil, err
+	}
+	if linked != nil {
+		account, err := s.storage.FindUserByID(ctx, linked.UserID)
+		if err != nil {
+			log.Errorf("Failed to find account by ID: %v", err)
+			return nil, err
+		}
+		if account == nil {
+			log.Warnf("External login attempted for the identity of a deleted account")
+			return nil, ErrInvalidCredentials
+		}
+		return account, nil
+	}
+
+	// Only a verified email proves the identity owns the account it matches
+	if !req.EmailVerified || req.Email == "" {
+		log.Warnf("External login attempted with an unverified email")
+		return nil, ErrExternalEmailUnverified
+	}
+
+	newIdentity := &model.Identity{Provider: req.Provider, Subject: req.Subject, Email: req.Email}
+
+	account, err := s.storage.FindUserByEmail(ctx, req.Email)
+	if err != nil {
+		log.Errorf("Failed to find account by email: %v", err)
+		return nil, err
+	}
+	if account != nil {
+		// Local emails are not verified, so an account with a password may
+		// have been registered by someone else waiting for its owner to sign
+		// in; its owner has to sign in and link the identity
+		_, passwordHash, err := s.storage.FindUserByIDWithPassword(ctx, account.ID)
+		if err != nil {
+			log.Errorf("Failed to find account by ID: %v", err)
+			return nil, err
+		}
+		if passwordHash != "" {
+			log.Warnf("External login matched the email of an account with a password: ID=%d", account.ID)
+			return nil, ErrExternalAccountExists
+		}
+
+		newIdentity.UserID = account.ID
+		if _, err := s.identities.Create(ctx, newIdentity); err != nil {
+			if storage.IsDuplicate(err) {
+				log.Warnf("Identity linking failed due to duplicate (race condition)")
+				return nil, ErrAccountExists
+			}
+			log.Errorf("Failed to link identity: %v", err)
+			return nil, err
+		}
+		log.Infof("Identity linked to account by verified email: ID=%d", account.ID)
+		return account, nil
+	}
+
+	username, err := s.availableUsername(ctx, req.Email)
+	if err != nil {
+		log.Errorf("Failed to choose a username: %v", err)
+		return nil, err
+	}
+
+	events, err := s.newEvents(model.EventUserRegistered, 0, model.UserRegistered{
+		Username: username,
+		Email:    req.Email,
+		Locale:   req.Locale,
+		Method:   loginMethodExternal,
+	})
+	if err != nil {
+		log.Errorf("Failed to create registration event: %v", err)
+		return nil, err
+	}
+
+	account, _, err = s.identities.CreateWithUser(ctx, &model.User{
+		Username: username,
+		Email:    req.Email,
+		Locale:   req.Locale,
+	}, newIdentity, events...)
+	if err != nil {
+		if storage.IsDuplicate(err) {
+			log.Warnf("User creation failed due to duplicate (race condition)")
+			return nil, ErrAccountExists
+		}
+		log.Errorf("Failed to create user with identity: %v", err)
+		return nil, err
+	}
+	log.Infof("User registered with external identity: ID=%d", account.ID)
+	metrics.RegistrationsTotal.Inc(metrics.ResultSuccess)
+	return account, nil
+}
+
+func (s *impl) LinkIdentity(ctx context.Context, req LinkIdentityRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "auth.LinkIdentity")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("provider", req.Provider)
+	span.SetAttributes(attribute.String("auth.provider", req.Provider))
+
+	if s.identities == nil {
+		return governerrors.NewCode(governerrors.CodeInternal, "external login is not configured")
+	}
+
+	linked, err := s.identities.Find(ctx, req.Provider, req.Subject)
+	if err != nil {
+		log.Errorf("Failed to find identity: %v", err)
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if linked != nil {
+		if linked.UserID != req.UserID {
+			log.Warnf("Linking attempted for an identity of another account")
+			return ErrIdentityLinked
+		}
+		return nil
+	}
+
+	_, err = s.identities.Create(ctx, &model.Identity{
+		UserID:   req.UserID,
+		Provider: req.Provider,
+		Subject:  req.Subject,
+		Email:    req.Email,
+	})
+	if err != nil {
+		if storage.IsDuplicate(err) {
+			log.Warnf("Identity linking failed due to duplicate (race condition)")
+			return ErrIdentityLinked
+		}
+		log.Errorf("Failed to link identity: %v", err)
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Identity linked to the signed-in account")
+	return nil
+}
+
+// availableUsername derives an unused username from the local part of
+// email, adding a random suffix when it is taken
+func (s *impl) availableUsername(ctx context.Context, email string) (string, error) {
+	base := usernameFromEmail(email)
+	candidate := base
+	for range 5 {
+		existing, err := s.storage.FindUserByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s-%04d", base, suffix)
+	}
+	return "", errors.New("no available username for " + base)
+}
+
+// usernameFromEmail keeps the letters, digits, dots, dashes and underscores
+// of the local part of email, lowercased and short enough for a suffix
+func usernameFromEmail(email string) string {
+	local, _, _ := strings.Cut(email, "@")
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(local) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			b.WriteRune(r)
+		}
+		if b.Len() == 40 {
+			break
+		}
+	}
+	if b.Len() < 3 {
+		return "user"
+	}
+	return b.String()
+}
+
+// rehash upgrades a verified password to the current hasher. Failures are
+// only logged: the old hash still works and the next login retries.
+func (s *impl) rehash(ctx context.Context, log *zap.SugaredLogger, userID uint, plain string) {
+	hashCtx, hashSpan := tracer.Start(ctx, "password.Rehash")
+	defer hashSpan.End()
+
+	hashedPassword, err := s.hasher.Hash(hashCtx, plain)
+	if err != nil {
+		log.Errorf("Failed to rehash password: %v", err)
+		return
+	}
+	if err := s.storage.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		log.Errorf("Failed to store rehashed password: %v", err)
+		return
+	}
+	log.Infof("Password hash upgraded")
+}
+
+// verifyDummy spends the time of a password verification when there is no
+// account to verify against. The dummy hash is made by the current hasher
+// on first use, so it costs what a verification of an up-to-date hash does.
+func (s *impl) verifyDummy(ctx context.Context, plain string) error {
+	compareCtx, compareSpan := tracer.Start(ctx, "password.Compare")
+	defer compareSpan.End()
+
+	dummyHash, err := s.getDummyHash(compareCtx)
+	if err != nil {
+		return err
+	}
+	_, err = s.hasher.Verify(compareCtx, plain, dummyHash)
+	return err
+}
+
+// getDummyHash returns the dummy hash, creating it on first use. A failed
+// attempt is retried by the next call.
+func (s *impl) getDummyHash(ctx context.Context) (string, error) {
+	s.dummyHashMu.Lock()
+	defer s.dummyHashMu.Unlock()
+
+	if s.dummyHash == "" {
+		hash, err := s.hasher.Hash(ctx, "dummy password for unknown accounts")
+		if err != nil {
+			return "", err
+		}
+		s.dummyHash = hash
+	}
+	return s.dummyHash, nil
+}
+
+// hashingError maps a failed hash or verification to ErrBusy when the
+// hashing pool is saturated, and to an internal error otherwise. A request
+// whose context ended while it queued for a slot also gets ErrBusy: it gave
+// up on the same saturated pool, and must not be reported as a server fault.
+func hashingError(err error) error {
+	if errors.Is(err, password.ErrBusy) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrBusy
+	}
+	return governerrors.WrapCode(governerrors.CodeInternal, err)
+}
+
+// checkPassword applies the password policy and breach check to a new
+// password, reporting every failure as a validation error of property.
+// Every flow that sets a password must call it.
+func (s *impl) checkPassword(ctx context.Context, property, newPassword, username, email string) error {
+	var details []*schemas2.ErrorDetail
+
+	if err := s.passwordPolicy.Check(newPassword, username, email); err != nil {
+		var policyErr *password.PolicyError
+		if !errors.As(err, &policyErr) {
+			return governerrors.WrapCode(governerrors.CodeInternal, err)
+		}
+		for _, violation := range policyErr.Violations {
+			details = append(details, policyViolationDetail(property, violation))
+		}
+	}
+
+	if s.breachChecker != nil {
+		_, breachSpan := tracer.Start(ctx, "password.BreachCheck")
+		breached, err := s.breachChecker.Breached(ctx, newPassword)
+		breachSpan.End()
+		if err != nil {
+			return governerrors.WrapCode(governerrors.CodeInternal, err)
+		}
+		if breached {
+			details = append(details, policyViolationDetail(property, password.Violation{Rule: password.RuleBreached}))
+		}
+	}
+
+	if len(details) > 0 {
+		return validator.Invalid(details...)
+	}
+	return nil
+}
+
+// policyViolationDetail reports length violations with the generic min and
+// max validation tags and the other rules as password_<rule>
+func policyViolationDetail(property string, violation password.Violation) *schemas2.ErrorDetail {
+	tag := "password_" + string(violation.Rule)
+	switch violation.Rule {
+	case password.RuleMinLength:
+		tag = "min"
+	case password.RuleMaxLength:
+		tag = "max"
+	}
+	return validator.NewErrorDetail(property, tag, violation.Param, reflect.String.String())
+}
+
+// generateToken signs a token for a login of user by method, storing the
+// UserLoggedIn event with the login's session
+func (s *impl) generateToken(ctx context.Context, user *model.User, method string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.jwtExpiration)
+
+	events, err := s.loginEvents(ctx, user.ID, method)
+	if err != nil {
+		return "", time.Time{}, err
+	}
 
 	claims := schemas2.JwtClaims{
 		ID:       strconv.FormatUint(uint64(user.ID), 10),
 		Email:    user.Email,
 		Username: user.Username,
+		Locale:   user.Locale,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	if s.sessions != nil {
+		session, err := s.sessions.Start(ctx, user, expiresAt, events...)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		claims.SessionID = session.Family
+	} else if len(events) > 0 {
+		// Without sessions the login stores nothing else
+		if err := s.events.Add(ctx, events...); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	_, span := tracer.Start(ctx, "jwt.Sign")
+	tokenString, err := s.signToken(claims)
+	span.End()
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
 	return tokenString, expiresAt, nil
 }
+
+func (s *impl) signToken(claims schemas2.JwtClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.jwtSecret))
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	governerrors "github.com/haipham22/govern/errors"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/metrics"
+	"golang-sample/internal/model"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/mailer"
+	"golang-sample/pkg/tracing"
+)
+
+// magicLinkAudience keeps magic link tokens apart from other tokens that
+// might share a key
+const magicLinkAudience = "magic-link"
+
+// MagicLinkConfig configures passwordless login
+type MagicLinkConfig struct {
+	// Key signs the links; it must differ from the access token key
+	Key []byte
+	// URL is the endpoint the link opens; the token is added as the token
+	// query parameter
+	URL string
+	// TTL defaults to 15 minutes
+	TTL time.Duration
+	// MaxPerWindow links are sent to an account per Window at most;
+	// default 3 per hour. Further requests are answered alike but send nothing.
+	MaxPerWindow int
+	Window       time.Duration
+}
+
+func (c MagicLinkConfig) withDefaults() MagicLinkConfig {
+	if c.TTL == 0 {
+		c.TTL = 15 * time.Minute
+	}
+	if c.MaxPerWindow == 0 {
+		c.MaxPerWindow = 3
+	}
+	if c.Window == 0 {
+		c.Window = time.Hour
+	}
+	return c
+}
+
+// magicLinkClaims are the signed content of a link. The subject is the
+// user ID and the ID is the link's record, which makes it single-use.
+type magicLinkClaims struct {
+	// Binding is the SHA-256 of the requesting browser's binding
+	Binding string `json:"bnd"`
+	jwt.RegisteredClaims
+}
+
+func (s *impl) RequestMagicLink(ctx context.Context, req MagicLinkRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "auth.RequestMagicLink")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log)
+
+	if s.magicLinks == nil {
+		return governerrors.NewCode(governerrors.CodeInternal, "magic link login is not configured")
+	}
+
+	account, err := s.storage.FindUserByEmail(ctx, req.Email)
+	if err != nil {
+		log.Errorf("Failed to find account by email: %v", err)
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if account == nil {
+		log.Infof("Magic link requested for an unknown email")
+		return nil
+	}
+
+	// Sending takes long enough to tell known emails from unknown ones, so
+	// it happens after the response
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		s.sendMagicLink(context.WithoutCancel(ctx), log.With("user_id", account.ID), account, req.Binding)
+	}()
+	return nil
+}
+
+// sendMagicLink issues and emails a link to account unless it has had
+// MaxPerWindow links within the window
+func (s *impl) sendMagicLink(ctx context.Context, log *zap.SugaredLogger, account *model.User, binding string) {
+	now := time.Now().UTC()
+	cfg := s.magicLinkCfg
+
+	link, err := s.magicLinks.CreateWithinLimit(ctx,
+		&model.MagicLink{UserID: account.ID, ExpiresAt: now.Add(cfg.TTL)},
+		now.Add(-cfg.Window), cfg.MaxPerWindow)
+	if err != nil {
+		log.Errorf("Failed to create magic link: %v", err)
+		return
+	}
+	if link == nil {
+		log.Warnf("Magic link not sent: %d already sent within %s", cfg.MaxPerWindow, cfg.Window)
+		return
+	}
+
+	bindingHash := sha256.Sum256([]byte(binding))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, magicLinkClaims{
+		Binding: hex.EncodeToString(bindingHash[:]),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(account.ID), 10),
+			ID:        strconv.FormatUint(uint64(link.ID), 10),
+			Audience:  jwt.ClaimStrings{magicLinkAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(link.ExpiresAt),
+		},
+	}).SignedString(cfg.Key)
+	if err != nil {
+		log.Errorf("Failed to sign magic link: %v", err)
+		return
+	}
+
+	linkURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		log.Errorf("Failed to build magic link: %v", err)
+		return
+	}
+	query := linkURL.Query()
+	query.Set("token", token)
+	linkURL.RawQuery = query.Encode()
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      account.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link in the browser where you asked for it to sign in:\n\n%s\n\n"+
+			"It works once and expires in %d minutes. If you did not ask for it, you can ignore this email.\n",
+			account.Username, linkURL.String(), int(cfg.TTL.Minutes())),
+	})
+	if err != nil {
+		log.Errorf("Failed to send magic link: %v", err)
+		return
+	}
+	log.Infof("Magic link sent")
+}
+
+func (s *impl) MagicLinkLogin(ctx context.Context, req MagicLinkLoginRequest) (_ *LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "auth.MagicLinkLogin")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log)
+
+	if s.magicLinks == nil {
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.NewCode(governerrors.CodeInternal, "magic link login is not configured")
+	}
+
+	var claims magicLinkClaims
+	_, err = jwt.ParseWithClaims(req.Token, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.magicLinkCfg.Key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithAudience(magicLinkAudience),
+	)
+	if err != nil {
+		log.Infof("Magic link rejected: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		s.auditLoginFailed(ctx, 0, map[string]string{"method": loginMethodMagicLink, "reason": "invalid_link"})
+		return nil, ErrMagicLinkInvalid
+	}
+	userID, err1 := strconv.ParseUint(claims.Subject, 10, 64)
+	linkID, err2 := strconv.ParseUint(claims.ID, 10, 64)
+	if err1 != nil || err2 != nil {
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		s.auditLoginFailed(ctx, 0, map[string]string{"method": loginMethodMagicLink, "reason": "invalid_link"})
+		return nil, ErrMagicLinkInvalid
+	}
+	log = log.With("user_id", userID)
+
+	// Checked before the link is used up, so that following it without the
+	// binding, as link scanners do, leaves it working for the user
+	bindingHash := sha256.Sum256([]byte(req.Binding))
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(bindingHash[:])), []byte(claims.Binding)) != 1 {
+		log.Warnf("Magic link opened without the binding of the browser that requested it")
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		s.auditLoginFailed(ctx, uint(userID), map[string]string{"method": loginMethodMagicLink, "reason": "binding_mismatch"})
+		return nil, ErrMagicLinkInvalid
+	}
+
+	consumed, err := s.magicLinks.Consume(ctx, uint(linkID), uint(userID), time.Now().UTC())
+	if err != nil {
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if !consumed {
+		log.Warnf("Magic link reused or expired")
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		s.auditLoginFailed(ctx, uint(userID), map[string]string{"method": loginMethodMagicLink, "reason": "used_or_expired"})
+		return nil, ErrMagicLinkInvalid
+	}
+
+	account, err := s.storage.FindUserByID(ctx, uint(userID))
+	if err != nil {
+		log.Errorf("Failed to find user of magic link: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if account == nil {
+		metrics.LoginsTotal.Inc(metrics.ResultFailure)
+		return nil, ErrMagicLinkInvalid
+	}
+
+	token, expiresAt, err := s.generateToken(ctx, account, loginMethodMagicLink)
+	if err != nil {
+		log.Errorf("Failed to generate token: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("User logged in with magic link: %s", account.Username)
+	s.auditLoginSucceeded(ctx, account.ID, map[string]string{"method": loginMethodMagicLink})
+	metrics.LoginsTotal.Inc(metrics.ResultSuccess)
+	return &LoginResponse{
+		Token:     token,
+		User:      account,
+		ExpiresAt: expiresAt,
+	}, nil
+}
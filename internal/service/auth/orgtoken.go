@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/schemas"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+)
+
+func (s *impl) OrgToken(ctx context.Context, claims *schemas.JwtClaims, orgID uint) (_ *LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "auth.OrgToken")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("org_id", orgID)
+
+	if claims.ExpiresAt == nil {
+		return nil, governerrors.NewCode(governerrors.CodeInvalid, "token without expiry")
+	}
+
+	// The same login, narrowed to one organization: it keeps the session,
+	// and with it revocation, and expires with the token it came from
+	orgClaims := *claims
+	orgClaims.OrgID = orgID
+	token, err := s.signToken(orgClaims)
+	if err != nil {
+		log.Errorf("Failed to generate token: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	return &LoginResponse{
+		Token:     token,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/metrics"
+	"golang-sample/internal/model"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+)
+
+// PasskeyVerifier verifies passkey assertions; the passkey service is one
+type PasskeyVerifier interface {
+	// FinishLogin returns the user whose passkey made the assertion.
+	// Errors carrying a govern code other than CodeInternal reject it.
+	FinishLogin(ctx context.Context, response []byte) (*model.User, error)
+}
+
+// WithPasskeys enables PasskeyLogin with assertions verified by verifier
+func WithPasskeys(verifier PasskeyVerifier) Option {
+	return func(s *impl) {
+		s.passkeys = verifier
+	}
+}
+
+func (s *impl) PasskeyLogin(ctx context.Context, response []byte) (_ *LoginResponse, err error) {
+	ctx, span := tracer.Start(ctx, "auth.PasskeyLogin")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log)
+
+	if s.passkeys == nil {
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.NewCode(governerrors.CodeInternal, "passkey login is not configured")
+	}
+
+	account, err := s.passkeys.FinishLogin(ctx, response)
+	if err != nil {
+		if code, ok := governerrors.GetCode(err); ok && code != governerrors.CodeInternal {
+			metrics.LoginsTotal.Inc(metrics.ResultFailure)
+			s.auditLoginFailed(ctx, 0, map[string]string{"method": loginMethodPasskey})
+			return nil, err
+		}
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	log = log.With("user_id", account.ID)
+
+	token, expiresAt, err := s.generateToken(ctx, account, loginMethodPasskey)
+	if err != nil {
+		log.Errorf("Failed to generate token: %v", err)
+		metrics.LoginsTotal.Inc(metrics.ResultError)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("User logged in with passkey: %s", account.Username)
+	s.auditLoginSucceeded(ctx, account.ID, map[string]string{"method": loginMethodPasskey})
+	metrics.LoginsTotal.Inc(metrics.ResultSuccess)
+	return &LoginResponse{
+		Token:     token,
+		User:      account,
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -4,12 +4,73 @@ import (
 	"context"
 	"time"
 
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/errcode"
 	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+)
+
+// Domain errors; match them with errors.Is
+var (
+	ErrUsernameTaken = errcode.New(errcode.UserUsernameTaken, governerrors.CodeConflict, "username already exists")
+	ErrEmailTaken    = errcode.New(errcode.UserEmailTaken, governerrors.CodeConflict, "email already exists")
+	// ErrAccountExists is returned when a concurrent registration claimed the username or email
+	// first
+	ErrAccountExists = errcode.New(errcode.UserAccountExists, governerrors.CodeConflict, "username or email already exists")
+	// ErrInvalidCredentials is returned by Login for an unknown username or a wrong password alike,
+	// after the same amount of hashing work
+	ErrInvalidCredentials = errcode.New(errcode.AuthInvalidCredentials, governerrors.CodeUnauthorized, "invalid credentials")
+	// ErrExternalEmailUnverified is returned by ExternalLogin for an unlinked identity whose
+	// provider did not verify the email, which can neither be linked nor start an account
+	ErrExternalEmailUnverified = errcode.New(errcode.AuthExternalEmailUnverified, governerrors.CodeForbidden, "the identity provider has not verified the email")
+	// ErrExternalAccountExists is returned by ExternalLogin for an unlinked identity whose email
+	// belongs to an account with a password. Local emails are unverified, so only the account's
+	// owner, signed in, may link it with LinkIdentity.
+	ErrExternalAccountExists = errcode.New(errcode.AuthExternalAccountExists, governerrors.CodeConflict, "an account with this email exists; sign in and link the provider to it")
+	// ErrIdentityLinked is returned by LinkIdentity for an identity linked to another account
+	ErrIdentityLinked = errcode.New(errcode.AuthIdentityLinked, governerrors.CodeConflict, "the identity is linked to another account")
+	// ErrMagicLinkInvalid is returned by MagicLinkLogin for a forged, expired or used link,
+	// and for a link opened without the binding of the browser that requested it
+	ErrMagicLinkInvalid = errcode.New(errcode.AuthMagicLinkInvalid, governerrors.CodeUnauthorized, "invalid sign-in link")
+	// ErrUserNotFound is returned by Impersonate for an unknown user
+	ErrUserNotFound = errcode.New(errcode.UserNotFound, governerrors.CodeNotFound, "user not found")
+	// ErrBusy is returned when password hashing is saturated; clients should retry
+	ErrBusy = errcode.New(errcode.Unavailable, errcode.CategoryUnavailable, "too many concurrent password operations")
 )
 
 type Service interface {
+	// Register creates an account. With WithNonEnumeratingRegistration it
+	// returns no user, whether or not it created one.
 	Register(ctx context.Context, req RegisterRequest) (*model.User, error)
 	Login(ctx context.Context, req LoginRequest) (*LoginResponse, error)
+	// ChangePassword replaces the password of an authenticated user after
+	// verifying the current one
+	ChangePassword(ctx context.Context, req ChangePasswordRequest) error
+	// ExternalLogin logs in the user linked to an identity verified by an
+	// external provider. An unlinked identity is linked to the account with
+	// the same verified email if that account has no password, or starts a
+	// new account without a password.
+	ExternalLogin(ctx context.Context, req ExternalLoginRequest) (*LoginResponse, error)
+	// LinkIdentity links an identity verified by an external provider to
+	// the signed-in user's account
+	LinkIdentity(ctx context.Context, req LinkIdentityRequest) error
+	// RequestMagicLink emails a single-use sign-in link to the account with
+	// the email, if there is one. It answers every email alike, so it does
+	// not reveal which have accounts.
+	RequestMagicLink(ctx context.Context, req MagicLinkRequest) error
+	// MagicLinkLogin logs in with a link sent by RequestMagicLink
+	MagicLinkLogin(ctx context.Context, req MagicLinkLoginRequest) (*LoginResponse, error)
+	// PasskeyLogin logs in with a passkey assertion, the JSON of the
+	// PublicKeyCredential the browser signed
+	PasskeyLogin(ctx context.Context, response []byte) (*LoginResponse, error)
+	// Impersonate issues a short-lived token for a user to a signed-in
+	// member of staff. The token names the staff member's username in its
+	// "act" claim and starts no session.
+	Impersonate(ctx context.Context, req ImpersonateRequest) (*LoginResponse, error)
+	// OrgToken reissues the token of claims to act in organization orgID,
+	// named in its "org_id" claim. Membership is the caller's to check.
+	OrgToken(ctx context.Context, claims *schemas.JwtClaims, orgID uint) (*LoginResponse, error)
 }
 
 type RegisterRequest struct {
@@ -17,15 +78,60 @@ type RegisterRequest struct {
 	Email    string
 	Password string
 	FullName string
+	Locale   string
 }
 
 type LoginRequest struct {
+	// Username is the username or email of the account, in any case
 	Username string
 	Password string
 }
 
+type ChangePasswordRequest struct {
+	UserID          uint
+	CurrentPassword string
+	NewPassword     string
+}
+
+// ExternalLoginRequest is an identity an external provider has verified
+type ExternalLoginRequest struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Locale        string
+}
+
+type LinkIdentityRequest struct {
+	UserID   uint
+	Provider string
+	Subject  string
+	Email    string
+}
+
+type MagicLinkRequest struct {
+	Email string
+	// Binding is a secret kept by the requesting browser. The link only
+	// works together with it, so a mail scanner that follows the link
+	// cannot use it up.
+	Binding string
+}
+
+type MagicLinkLoginRequest struct {
+	Token   string
+	Binding string
+}
+
+type ImpersonateRequest struct {
+	UserID uint
+	// ActorID is the authenticated member of staff asking for the token
+	ActorID uint
+	Reason  string
+}
+
 type LoginResponse struct {
-	Token     string
+	Token string
+	// User is not set by OrgToken
 	User      *model.User
 	ExpiresAt time.Time
 }
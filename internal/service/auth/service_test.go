@@ -2,6 +2,10 @@ package auth
 
 import (
 	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,9 +15,18 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
+	"golang-sample/internal/errcode"
+	mailerMocks "golang-sample/internal/mocks/mailer"
 	storageMocks "golang-sample/internal/mocks/storage"
 	"golang-sample/internal/model"
+	"golang-sample/internal/schemas"
+	"golang-sample/internal/service/audit"
+	"golang-sample/internal/validator"
+	"golang-sample/pkg/clientinfo"
+	"golang-sample/pkg/mailer"
 	"golang-sample/pkg/utils/password"
 )
 
@@ -34,7 +47,7 @@ func newTestService(t *testing.T, storage *storageMocks.MockStorage) Service {
 // Returns (model.User, passwordHash) for testing authentication
 func newMockUser(t *testing.T, username, plainPassword string) (*model.User, string) {
 	t.Helper()
-	hash, err := password.HashPassword(plainPassword)
+	hash, err := password.DefaultHasher().Hash(context.Background(), plainPassword)
 	require.NoError(t, err)
 	return &model.User{
 		ID:       1,
@@ -80,6 +93,25 @@ func TestService_Register_ValidationErrors(t *testing.T) {
 			wantErrCode: governerrors.CodeConflict,
 			wantErrMsg:  "email",
 		},
+		{
+			// Passes the schema's excludes=@ but normalizes into an email
+			name:        "username with fullwidth at sign",
+			username:    "victim\uff20corp.com",
+			email:       "attacker@example.com",
+			password:    "password",
+			fullName:    "Test User",
+			setupMock:   func(*storageMocks.MockStorage) {},
+			wantErrCode: governerrors.CodeInvalid,
+		},
+		{
+			name:        "username with small at sign",
+			username:    "victim\ufe6bcorp.com",
+			email:       "attacker@example.com",
+			password:    "password",
+			fullName:    "Test User",
+			setupMock:   func(*storageMocks.MockStorage) {},
+			wantErrCode: governerrors.CodeInvalid,
+		},
 		{
 			name:     "storage error on uniqueness check",
 			username: "testuser",
@@ -147,7 +179,7 @@ func TestService_Register_Success(t *testing.T) {
 
 		mockStorage := storageMocks.NewMockStorage(t)
 		mockStorage.EXPECT().CheckUniqueness(mock.Anything, "testuser", "test@example.com").Return(false, false, nil)
-		mockStorage.EXPECT().CreateUserWithPassword(mock.Anything, mock.AnythingOfType("*model.User"), mock.AnythingOfType("string")).RunAndReturn(func(ctx context.Context, user *model.User, passwordHash string) (*model.User, error) {
+		mockStorage.EXPECT().CreateUserWithPassword(mock.Anything, mock.AnythingOfType("*model.User"), mock.AnythingOfType("string")).RunAndReturn(func(ctx context.Context, user *model.User, passwordHash string, _ ...*model.Event) (*model.User, error) {
 			// Following Uber: "Verify important invariants in mocks"
 			assert.NotEmpty(t, passwordHash, "password should be hashed")
 			assert.NotEqual(t, "SecurePass123!", passwordHash, "password hash should not equal plaintext")
@@ -191,9 +223,9 @@ func TestService_Login_Errors(t *testing.T) {
 			username: "nonexistent",
 			password: "password",
 			setupMock: func(m *storageMocks.MockStorage) {
-				m.EXPECT().FindUserByUsernameWithPassword(mock.Anything, "nonexistent").Return(nil, "", nil)
+				m.EXPECT().FindUserByLoginWithPassword(mock.Anything, "nonexistent").Return(nil, "", nil)
 			},
-			wantErr: governerrors.ErrUnauthorized,
+			wantErr: ErrInvalidCredentials,
 		},
 		{
 			name:     "invalid password",
@@ -201,16 +233,16 @@ func TestService_Login_Errors(t *testing.T) {
 			password: "wrongpass",
 			setupMock: func(m *storageMocks.MockStorage) {
 				mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
-				m.EXPECT().FindUserByUsernameWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+				m.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
 			},
-			wantErr: governerrors.ErrUnauthorized,
+			wantErr: ErrInvalidCredentials,
 		},
 		{
 			name:     "storage error",
 			username: "testuser",
 			password: "password",
 			setupMock: func(m *storageMocks.MockStorage) {
-				m.EXPECT().FindUserByUsernameWithPassword(mock.Anything, "testuser").Return(nil, "", assert.AnError)
+				m.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(nil, "", assert.AnError)
 			},
 			wantErr: nil, // Service wraps storage errors in ErrorWithCode
 		},
@@ -253,7 +285,7 @@ func TestService_Login_Success(t *testing.T) {
 
 		mockStorage := storageMocks.NewMockStorage(t)
 		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
-		mockStorage.EXPECT().FindUserByUsernameWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
 
 		service := newTestService(t, mockStorage)
 
@@ -290,6 +322,40 @@ func TestService_Login_Success(t *testing.T) {
 		assert.Equal(t, "testuser@example.com", claims.Email)
 		assert.Equal(t, "testuser", claims.Username)
 	})
+
+	t.Run("the token names the login session", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+
+		var started *model.User
+		sessions := sessionStarterFunc(func(_ context.Context, user *model.User, expiresAt time.Time, _ ...*model.Event) (*model.Session, error) {
+			started = user
+			assert.WithinDuration(t, time.Now().Add(testJWTExpiration), expiresAt, time.Second)
+			return &model.Session{ID: 9, Family: "family-1"}, nil
+		})
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, WithSessions(sessions))
+
+		resp, err := service.Login(context.Background(), LoginRequest{Username: "testuser", Password: "correctpass"})
+
+		require.NoError(t, err)
+		assert.Equal(t, mockUser, started)
+		claims := &schemas.JwtClaims{}
+		_, err = jwt.ParseWithClaims(resp.Token, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte("test-secret"), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "family-1", claims.SessionID)
+	})
+}
+
+// sessionStarterFunc adapts a function to SessionStarter
+type sessionStarterFunc func(ctx context.Context, user *model.User, expiresAt time.Time, events ...*model.Event) (*model.Session, error)
+
+func (f sessionStarterFunc) Start(ctx context.Context, user *model.User, expiresAt time.Time, events ...*model.Event) (*model.Session, error) {
+	return f(ctx, user, expiresAt, events...)
 }
 
 func TestService_Login_TokenExpiration(t *testing.T) {
@@ -298,7 +364,7 @@ func TestService_Login_TokenExpiration(t *testing.T) {
 
 		mockStorage := storageMocks.NewMockStorage(t)
 		mockUser, passwordHash := newMockUser(t, "testuser", "password")
-		mockStorage.EXPECT().FindUserByUsernameWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
 
 		service := newTestService(t, mockStorage)
 
@@ -329,7 +395,7 @@ func BenchmarkService_Register(b *testing.B) {
 		b.StopTimer()
 		mockStorage := storageMocks.NewMockStorage(b)
 		mockStorage.EXPECT().CheckUniqueness(mock.Anything, "testuser", "test@example.com").Return(false, false, nil)
-		mockStorage.EXPECT().CreateUserWithPassword(mock.Anything, mock.AnythingOfType("*model.User"), mock.AnythingOfType("string")).RunAndReturn(func(ctx context.Context, user *model.User, passwordHash string) (*model.User, error) {
+		mockStorage.EXPECT().CreateUserWithPassword(mock.Anything, mock.AnythingOfType("*model.User"), mock.AnythingOfType("string")).RunAndReturn(func(ctx context.Context, user *model.User, passwordHash string, _ ...*model.Event) (*model.User, error) {
 			user.ID = 1
 			return user, nil
 		})
@@ -356,7 +422,7 @@ func BenchmarkService_Login(b *testing.B) {
 		b.StopTimer()
 		hash, _ := password.HashPassword("correctpass")
 		mockStorage := storageMocks.NewMockStorage(b)
-		mockStorage.EXPECT().FindUserByUsernameWithPassword(mock.Anything, "testuser").Return(&model.User{
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(&model.User{
 			ID:       1,
 			Username: "testuser",
 			Email:    "test@example.com",
@@ -371,3 +437,967 @@ func BenchmarkService_Login(b *testing.B) {
 		_, _ = service.Login(context.Background(), req)
 	}
 }
+
+// stubBreachChecker reports the passwords in its set as breached
+type stubBreachChecker map[string]bool
+
+func (s stubBreachChecker) Breached(_ context.Context, pw string) (bool, error) {
+	return s[pw], nil
+}
+
+// validationTags returns property:tag of every field in a validation error
+func validationTags(t *testing.T, err error) []string {
+	t.Helper()
+	var validationErr *validator.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	tags := make([]string, 0, len(validationErr.Errors))
+	for _, detail := range validationErr.Errors {
+		tags = append(tags, detail.Property+":"+detail.MsgValues[validator.MsgValueTag].(string))
+	}
+	return tags
+}
+
+func TestService_Register_PasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantTags []string
+	}{
+		{
+			name:     "too short and too weak",
+			password: "abc",
+			wantTags: []string{"password:min", "password:password_strength"},
+		},
+		{
+			name:     "contains username",
+			password: "Xq9#testuser!",
+			wantTags: []string{"password:password_identity"},
+		},
+		{
+			name:     "breached",
+			password: "Gx7#pLm2qR!",
+			wantTags: []string{"password:password_breached"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// No storage expectations: rejected passwords never reach the database
+			mockStorage := storageMocks.NewMockStorage(t)
+			service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+				WithPasswordPolicy(password.DefaultPolicy()),
+				WithBreachChecker(stubBreachChecker{"Gx7#pLm2qR!": true}),
+			)
+
+			_, err := service.Register(context.Background(), RegisterRequest{
+				Username: "testuser",
+				Email:    "test@example.com",
+				Password: tt.password,
+			})
+
+			assert.True(t, governerrors.IsCode(err, governerrors.CodeInvalid))
+			assert.Equal(t, tt.wantTags, validationTags(t, err))
+		})
+	}
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	const newPassword = "Xq9#vLm2pR!z"
+
+	tests := []struct {
+		name      string
+		current   string
+		next      string
+		setupMock func(*storageMocks.MockStorage)
+		wantErr   error
+		wantTags  []string
+	}{
+		{
+			name:    "success",
+			current: "correctpass",
+			next:    newPassword,
+			setupMock: func(m *storageMocks.MockStorage) {
+				mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+				m.EXPECT().FindUserByIDWithPassword(mock.Anything, uint(1)).Return(mockUser, passwordHash, nil)
+				m.EXPECT().UpdatePassword(mock.Anything, uint(1), mock.AnythingOfType("string")).RunAndReturn(func(_ context.Context, _ uint, hash string, _ ...*model.Event) error {
+					assert.True(t, password.CheckPasswordHash(newPassword, hash), "new password should be hashed")
+					return nil
+				})
+			},
+		},
+		{
+			name:    "wrong current password",
+			current: "wrongpass",
+			next:    newPassword,
+			setupMock: func(m *storageMocks.MockStorage) {
+				mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+				m.EXPECT().FindUserByIDWithPassword(mock.Anything, uint(1)).Return(mockUser, passwordHash, nil)
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "account deleted",
+			current: "correctpass",
+			next:    newPassword,
+			setupMock: func(m *storageMocks.MockStorage) {
+				m.EXPECT().FindUserByIDWithPassword(mock.Anything, uint(1)).Return(nil, "", nil)
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "new password rejected by policy",
+			current: "correctpass",
+			next:    "testuser123",
+			setupMock: func(m *storageMocks.MockStorage) {
+				mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+				m.EXPECT().FindUserByIDWithPassword(mock.Anything, uint(1)).Return(mockUser, passwordHash, nil)
+			},
+			wantTags: []string{"new_password:password_identity", "new_password:password_strength"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockStorage := storageMocks.NewMockStorage(t)
+			tt.setupMock(mockStorage)
+			service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+				WithPasswordPolicy(password.DefaultPolicy()),
+			)
+
+			err := service.ChangePassword(context.Background(), ChangePasswordRequest{
+				UserID:          1,
+				CurrentPassword: tt.current,
+				NewPassword:     tt.next,
+			})
+
+			switch {
+			case tt.wantErr != nil:
+				assert.Equal(t, tt.wantErr, err)
+			case tt.wantTags != nil:
+				assert.Equal(t, tt.wantTags, validationTags(t, err))
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_Login_RehashesOutdatedHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		storeErr error
+	}{
+		{name: "upgrades bcrypt to argon2id"},
+		{name: "login succeeds when storing the new hash fails", storeErr: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bcryptHash, err := password.HashPasswordWithCost("correctpass", bcrypt.MinCost)
+			require.NoError(t, err)
+
+			mockStorage := storageMocks.NewMockStorage(t)
+			mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").
+				Return(&model.User{ID: 1, Username: "testuser"}, bcryptHash, nil)
+			mockStorage.EXPECT().UpdatePassword(mock.Anything, uint(1), mock.AnythingOfType("string")).RunAndReturn(func(_ context.Context, _ uint, hash string, _ ...*model.Event) error {
+				assert.True(t, strings.HasPrefix(hash, "$argon2id$"), "hash should use the current hasher")
+				assert.True(t, password.CheckPasswordHash("correctpass", hash))
+				return tt.storeErr
+			})
+
+			service := newTestService(t, mockStorage)
+
+			resp, err := service.Login(context.Background(), LoginRequest{Username: "testuser", Password: "correctpass"})
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, resp.Token)
+		})
+	}
+}
+
+// busyHasher is a hasher whose pool never frees a slot
+type busyHasher struct{ password.Hasher }
+
+func (busyHasher) Verify(context.Context, string, string) (bool, error) {
+	return false, password.ErrBusy
+}
+
+func TestService_Login_HashingBusy(t *testing.T) {
+	mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+	mockStorage := storageMocks.NewMockStorage(t)
+	mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+
+	service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+		WithHasher(busyHasher{password.DefaultHasher()}),
+	)
+
+	_, err := service.Login(context.Background(), LoginRequest{Username: "testuser", Password: "correctpass"})
+
+	assert.ErrorIs(t, err, ErrBusy)
+	assert.True(t, governerrors.IsCode(err, errcode.CategoryUnavailable))
+}
+
+func TestService_Login_QueueAbandoned(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func(t *testing.T) context.Context
+	}{
+		{
+			name: "request cancelled while queued",
+			ctx: func(t *testing.T) context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+		},
+		{
+			name: "request deadline exceeded while queued",
+			ctx: func(t *testing.T) context.Context {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Hold the only slot for the whole test, with a queue timeout
+			// the request cannot reach
+			pool := password.NewPool(1, time.Minute)
+			held, release := make(chan struct{}), make(chan struct{})
+			go func() {
+				_ = pool.Do(context.Background(), func() {
+					close(held)
+					<-release
+				})
+			}()
+			<-held
+			t.Cleanup(func() { close(release) })
+
+			mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+			mockStorage := storageMocks.NewMockStorage(t)
+			mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+
+			service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+				WithHasher(pool.Limit(password.DefaultHasher())),
+			)
+
+			_, err := service.Login(tt.ctx(t), LoginRequest{Username: "testuser", Password: "correctpass"})
+
+			assert.ErrorIs(t, err, ErrBusy)
+			assert.True(t, governerrors.IsCode(err, errcode.CategoryUnavailable))
+		})
+	}
+}
+
+// countingHasher counts the hashing operations of the wrapped hasher
+type countingHasher struct {
+	password.Hasher
+	hashes, verifies atomic.Int32
+}
+
+func (h *countingHasher) Hash(ctx context.Context, plain string) (string, error) {
+	h.hashes.Add(1)
+	return h.Hasher.Hash(ctx, plain)
+}
+
+func (h *countingHasher) Verify(ctx context.Context, plain, encoded string) (bool, error) {
+	h.verifies.Add(1)
+	return h.Hasher.Verify(ctx, plain, encoded)
+}
+
+func TestService_Login_UnknownUserVerifiesDummyHash(t *testing.T) {
+	mockStorage := storageMocks.NewMockStorage(t)
+	mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "nonexistent").Return(nil, "", nil)
+
+	hasher := &countingHasher{Hasher: password.DefaultHasher()}
+	service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, WithHasher(hasher))
+
+	for range 2 {
+		_, err := service.Login(context.Background(), LoginRequest{Username: "nonexistent", Password: "password"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	assert.Equal(t, int32(2), hasher.verifies.Load(), "every login should verify a hash")
+	assert.Equal(t, int32(1), hasher.hashes.Load(), "the dummy hash should be made once")
+}
+
+func TestService_Register_NonEnumerating(t *testing.T) {
+	tests := []struct {
+		name           string
+		usernameExists bool
+		emailExists    bool
+		wantBody       string
+	}{
+		{name: "username taken", usernameExists: true, wantBody: `The username "testuser"`},
+		{name: "email taken", emailExists: true, wantBody: "Someone tried to create an account"},
+		{name: "both taken", usernameExists: true, emailExists: true, wantBody: "Someone tried to create an account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockStorage := storageMocks.NewMockStorage(t)
+			mockStorage.EXPECT().CheckUniqueness(mock.Anything, "testuser", "test@example.com").
+				Return(tt.usernameExists, tt.emailExists, nil)
+			mockMailer := mailerMocks.NewMockMailer(t)
+			var sent mailer.Message
+			mockMailer.EXPECT().Send(mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, msg mailer.Message) error {
+					sent = msg
+					return nil
+				})
+
+			hasher := &countingHasher{Hasher: password.DefaultHasher()}
+			service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+				WithHasher(hasher), WithNonEnumeratingRegistration(mockMailer),
+			).(*impl)
+
+			user, err := service.Register(context.Background(), RegisterRequest{
+				Username: "testuser",
+				Email:    "test@example.com",
+				Password: "password123",
+			})
+			service.pending.Wait()
+
+			assert.Nil(t, user)
+			assert.NoError(t, err, "a conflict is answered like a registration")
+			assert.Equal(t, int32(1), hasher.hashes.Load(), "a conflict should cost a hash like a registration")
+			assert.Equal(t, "test@example.com", sent.To)
+			assert.Contains(t, sent.Body, tt.wantBody)
+		})
+	}
+
+	t.Run("registration returns no user either", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockStorage.EXPECT().CheckUniqueness(mock.Anything, "testuser", "test@example.com").Return(false, false, nil)
+		mockStorage.EXPECT().CreateUserWithPassword(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, user *model.User, _ string, _ ...*model.Event) (*model.User, error) {
+				user.ID = 1
+				return user, nil
+			})
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+			WithNonEnumeratingRegistration(mailerMocks.NewMockMailer(t)))
+
+		user, err := service.Register(context.Background(), RegisterRequest{
+			Username: "testuser",
+			Email:    "test@example.com",
+			Password: "password123",
+		})
+
+		assert.Nil(t, user)
+		assert.NoError(t, err)
+	})
+}
+
+func TestService_Login_AccountWithoutPassword(t *testing.T) {
+	mockStorage := storageMocks.NewMockStorage(t)
+	mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "social").
+		Return(&model.User{ID: 1, Username: "social", Email: "social@example.com"}, "", nil)
+
+	hasher := &countingHasher{Hasher: password.DefaultHasher()}
+	service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, WithHasher(hasher))
+
+	_, err := service.Login(context.Background(), LoginRequest{Username: "social", Password: ""})
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, int32(1), hasher.verifies.Load(), "the dummy hash is verified like an unknown account")
+}
+
+func TestService_ExternalLogin(t *testing.T) {
+	existing := &model.User{ID: 7, Username: "alice", Email: "alice@example.com"}
+	verified := ExternalLoginRequest{Provider: "google", Subject: "g-1", Email: "Alice@Example.com", EmailVerified: true}
+
+	newService := func(t *testing.T) (Service, *storageMocks.MockStorage, *storageMocks.MockIdentityStorage) {
+		t.Helper()
+		users := storageMocks.NewMockStorage(t)
+		identities := storageMocks.NewMockIdentityStorage(t)
+		return NewAuthService(zap.NewNop().Sugar(), users, "test-secret", testJWTExpiration, WithIdentityStorage(identities)),
+			users, identities
+	}
+
+	t.Run("linked identity", func(t *testing.T) {
+		service, users, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(&model.Identity{UserID: 7}, nil)
+		users.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(existing, nil)
+
+		resp, err := service.ExternalLogin(context.Background(), ExternalLoginRequest{Provider: "google", Subject: "g-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, existing, resp.User)
+		assert.NotEmpty(t, resp.Token)
+	})
+
+	t.Run("links the account without a password with the verified email", func(t *testing.T) {
+		service, users, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(nil, nil)
+		users.EXPECT().FindUserByEmail(mock.Anything, "Alice@Example.com").Return(existing, nil)
+		users.EXPECT().FindUserByIDWithPassword(mock.Anything, uint(7)).Return(existing, "", nil)
+		identities.EXPECT().Create(mock.Anything, &model.Identity{UserID: 7, Provider: "google", Subject: "g-1", Email: "Alice@Example.com"}).
+			Return(&model.Identity{ID: 1, UserID: 7}, nil)
+
+		resp, err := service.ExternalLogin(context.Background(), verified)
+
+		require.NoError(t, err)
+		assert.Equal(t, existing, resp.User)
+	})
+
+	t.Run("does not link an account with a password", func(t *testing.T) {
+		service, users, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(nil, nil)
+		users.EXPECT().FindUserByEmail(mock.Anything, "Alice@Example.com").Return(existing, nil)
+		users.EXPECT().FindUserByIDWithPassword(mock.Anything, uint(7)).Return(existing, "$argon2id$hash", nil)
+
+		resp, err := service.ExternalLogin(context.Background(), verified)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrExternalAccountExists)
+		identities.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unverified email is neither linked nor registered", func(t *testing.T) {
+		service, _, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(nil, nil)
+
+		req := verified
+		req.EmailVerified = false
+		resp, err := service.ExternalLogin(context.Background(), req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrExternalEmailUnverified)
+	})
+
+	t.Run("registers a new account", func(t *testing.T) {
+		service, users, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(nil, nil)
+		users.EXPECT().FindUserByEmail(mock.Anything, "Alice@Example.com").Return(nil, nil)
+		users.EXPECT().FindUserByUsername(mock.Anything, "alice").Return(existing, nil)
+		users.EXPECT().FindUserByUsername(mock.Anything, mock.MatchedBy(func(username string) bool {
+			return strings.HasPrefix(username, "alice-")
+		})).Return(nil, nil)
+		identities.EXPECT().CreateWithUser(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, user *model.User, identity *model.Identity, _ ...*model.Event) (*model.User, *model.Identity, error) {
+				assert.Regexp(t, `^alice-\d{4}$`, user.Username, "a taken username gets a suffix")
+				assert.Equal(t, "Alice@Example.com", user.Email)
+				assert.Equal(t, "g-1", identity.Subject)
+				created := *user
+				created.ID = 8
+				return &created, identity, nil
+			})
+
+		resp, err := service.ExternalLogin(context.Background(), verified)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(8), resp.User.ID)
+	})
+
+	t.Run("identity of a deleted account", func(t *testing.T) {
+		service, users, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(&model.Identity{UserID: 7}, nil)
+		users.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(nil, nil)
+
+		_, err := service.ExternalLogin(context.Background(), verified)
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		service := NewAuthService(zap.NewNop().Sugar(), storageMocks.NewMockStorage(t), "test-secret", testJWTExpiration)
+
+		_, err := service.ExternalLogin(context.Background(), verified)
+
+		code, _ := governerrors.GetCode(err)
+		assert.Equal(t, governerrors.CodeInternal, code)
+	})
+}
+
+func TestService_LinkIdentity(t *testing.T) {
+	req := LinkIdentityRequest{UserID: 7, Provider: "google", Subject: "g-1", Email: "alice@gmail.com"}
+
+	newService := func(t *testing.T) (Service, *storageMocks.MockIdentityStorage) {
+		t.Helper()
+		identities := storageMocks.NewMockIdentityStorage(t)
+		return NewAuthService(zap.NewNop().Sugar(), storageMocks.NewMockStorage(t), "test-secret", testJWTExpiration, WithIdentityStorage(identities)),
+			identities
+	}
+
+	t.Run("links the identity", func(t *testing.T) {
+		service, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(nil, nil)
+		identities.EXPECT().Create(mock.Anything, &model.Identity{UserID: 7, Provider: "google", Subject: "g-1", Email: "alice@gmail.com"}).
+			Return(&model.Identity{ID: 1, UserID: 7}, nil)
+
+		assert.NoError(t, service.LinkIdentity(context.Background(), req))
+	})
+
+	t.Run("identity already linked to the account", func(t *testing.T) {
+		service, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(&model.Identity{UserID: 7}, nil)
+
+		assert.NoError(t, service.LinkIdentity(context.Background(), req))
+	})
+
+	t.Run("identity linked to another account", func(t *testing.T) {
+		service, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(&model.Identity{UserID: 8}, nil)
+
+		assert.ErrorIs(t, service.LinkIdentity(context.Background(), req), ErrIdentityLinked)
+	})
+
+	t.Run("identity linked concurrently", func(t *testing.T) {
+		service, identities := newService(t)
+		identities.EXPECT().Find(mock.Anything, "google", "g-1").Return(nil, nil)
+		identities.EXPECT().Create(mock.Anything, mock.Anything).Return(nil, gorm.ErrDuplicatedKey)
+
+		assert.ErrorIs(t, service.LinkIdentity(context.Background(), req), ErrIdentityLinked)
+	})
+}
+
+func TestUsernameFromEmail(t *testing.T) {
+	tests := map[string]string{
+		"Alice.Smith@example.com":      "alice.smith",
+		"bob+news@example.com":         "bobnews",
+		"jo@example.com":               "user",
+		"名前@example.com":               "user",
+		strings.Repeat("a", 60) + "@x": strings.Repeat("a", 40),
+	}
+	for email, want := range tests {
+		assert.Equal(t, want, usernameFromEmail(email), email)
+	}
+}
+
+func TestService_MagicLink(t *testing.T) {
+	account := &model.User{ID: 7, Username: "alice", Email: "alice@example.com"}
+	cfg := MagicLinkConfig{Key: []byte("magic-link-key"), URL: "https://api.example.com/api/login/magic-link/verify"}
+
+	newService := func(t *testing.T) (*impl, *storageMocks.MockStorage, *storageMocks.MockMagicLinkStorage, *mailerMocks.MockMailer) {
+		users := storageMocks.NewMockStorage(t)
+		links := storageMocks.NewMockMagicLinkStorage(t)
+		m := mailerMocks.NewMockMailer(t)
+		service := NewAuthService(zap.NewNop().Sugar(), users, "test-secret", testJWTExpiration, WithMagicLinks(links, m, cfg))
+		return service.(*impl), users, links, m
+	}
+
+	// request asks for a link bound to binding and returns the token it emailed
+	request := func(t *testing.T, service *impl, users *storageMocks.MockStorage, links *storageMocks.MockMagicLinkStorage, m *mailerMocks.MockMailer, binding string) string {
+		users.EXPECT().FindUserByEmail(mock.Anything, "Alice@Example.com").Return(account, nil).Once()
+		links.EXPECT().CreateWithinLimit(mock.Anything, mock.Anything, mock.Anything, 3).RunAndReturn(func(_ context.Context, link *model.MagicLink, _ time.Time, _ int) (*model.MagicLink, error) {
+			assert.Equal(t, uint(7), link.UserID)
+			assert.WithinDuration(t, time.Now().Add(15*time.Minute), link.ExpiresAt, time.Minute)
+			created := *link
+			created.ID = 99
+			return &created, nil
+		}).Once()
+		var sent mailer.Message
+		m.EXPECT().Send(mock.Anything, mock.Anything).Run(func(_ context.Context, msg mailer.Message) { sent = msg }).Return(nil).Once()
+
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: "Alice@Example.com", Binding: binding}))
+		service.pending.Wait()
+
+		assert.Equal(t, "alice@example.com", sent.To)
+		link := regexp.MustCompile(`https://\S+`).FindString(sent.Body)
+		require.NotEmpty(t, link)
+		parsed, err := url.Parse(link)
+		require.NoError(t, err)
+		assert.Equal(t, "/api/login/magic-link/verify", parsed.Path)
+		return parsed.Query().Get("token")
+	}
+
+	t.Run("emails a link that logs in once", func(t *testing.T) {
+		service, users, links, m := newService(t)
+		token := request(t, service, users, links, m, "browser")
+
+		links.EXPECT().Consume(mock.Anything, uint(99), uint(7), mock.Anything).Return(true, nil).Once()
+		users.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(account, nil)
+
+		resp, err := service.MagicLinkLogin(context.Background(), MagicLinkLoginRequest{Token: token, Binding: "browser"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.Token)
+		assert.Equal(t, account, resp.User)
+
+		links.EXPECT().Consume(mock.Anything, uint(99), uint(7), mock.Anything).Return(false, nil).Once()
+
+		_, err = service.MagicLinkLogin(context.Background(), MagicLinkLoginRequest{Token: token, Binding: "browser"})
+		assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+	})
+
+	t.Run("a scanner without the binding does not use up the link", func(t *testing.T) {
+		service, users, links, m := newService(t)
+		token := request(t, service, users, links, m, "browser")
+
+		_, err := service.MagicLinkLogin(context.Background(), MagicLinkLoginRequest{Token: token})
+		assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+		_, err = service.MagicLinkLogin(context.Background(), MagicLinkLoginRequest{Token: token, Binding: "other"})
+		assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+		links.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects forged and expired links", func(t *testing.T) {
+		service, _, _, _ := newService(t)
+
+		sign := func(key []byte, expiresAt time.Time) string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, magicLinkClaims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "7",
+					ID:        "99",
+					Audience:  jwt.ClaimStrings{magicLinkAudience},
+					ExpiresAt: jwt.NewNumericDate(expiresAt),
+				},
+			}).SignedString(key)
+			require.NoError(t, err)
+			return token
+		}
+
+		for _, token := range []string{
+			sign([]byte("test-secret"), time.Now().Add(time.Minute)),
+			sign(cfg.Key, time.Now().Add(-time.Minute)),
+			"not-a-token",
+		} {
+			_, err := service.MagicLinkLogin(context.Background(), MagicLinkLoginRequest{Token: token})
+			assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+		}
+	})
+
+	t.Run("sends nothing for an unknown email", func(t *testing.T) {
+		service, users, _, _ := newService(t)
+		users.EXPECT().FindUserByEmail(mock.Anything, "nobody@example.com").Return(nil, nil)
+
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: "nobody@example.com", Binding: "browser"}))
+		service.pending.Wait()
+	})
+
+	t.Run("sends nothing past the limit per account", func(t *testing.T) {
+		service, users, links, _ := newService(t)
+		users.EXPECT().FindUserByEmail(mock.Anything, "alice@example.com").Return(account, nil)
+		links.EXPECT().CreateWithinLimit(mock.Anything, mock.Anything, mock.Anything, 3).
+			RunAndReturn(func(_ context.Context, link *model.MagicLink, since time.Time, _ int) (*model.MagicLink, error) {
+				assert.Equal(t, uint(7), link.UserID)
+				assert.WithinDuration(t, time.Now().Add(-time.Hour), since, time.Minute)
+				return nil, nil
+			})
+
+		require.NoError(t, service.RequestMagicLink(context.Background(), MagicLinkRequest{Email: "alice@example.com", Binding: "browser"}))
+		service.pending.Wait()
+	})
+}
+
+// passkeyVerifierFunc adapts a function to PasskeyVerifier; the generated
+// mock would import this package
+type passkeyVerifierFunc func(ctx context.Context, response []byte) (*model.User, error)
+
+func (f passkeyVerifierFunc) FinishLogin(ctx context.Context, response []byte) (*model.User, error) {
+	return f(ctx, response)
+}
+
+func TestService_PasskeyLogin(t *testing.T) {
+	account := &model.User{ID: 7, Username: "alice", Email: "alice@example.com"}
+
+	newService := func(t *testing.T, verify passkeyVerifierFunc) Service {
+		return NewAuthService(zap.NewNop().Sugar(), storageMocks.NewMockStorage(t), "test-secret", testJWTExpiration, WithPasskeys(verify))
+	}
+
+	t.Run("issues a token for the verified user", func(t *testing.T) {
+		service := newService(t, func(_ context.Context, response []byte) (*model.User, error) {
+			assert.Equal(t, `{"id":"x"}`, string(response))
+			return account, nil
+		})
+
+		resp, err := service.PasskeyLogin(context.Background(), []byte(`{"id":"x"}`))
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.Token)
+		assert.Equal(t, account, resp.User)
+	})
+
+	t.Run("passes rejections through", func(t *testing.T) {
+		rejected := errcode.New(errcode.AuthPasskeyFailed, governerrors.CodeUnauthorized, "passkey login failed")
+		service := newService(t, func(context.Context, []byte) (*model.User, error) {
+			return nil, rejected
+		})
+
+		_, err := service.PasskeyLogin(context.Background(), []byte(`{}`))
+
+		assert.ErrorIs(t, err, rejected)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		service := newTestService(t, storageMocks.NewMockStorage(t))
+
+		_, err := service.PasskeyLogin(context.Background(), []byte(`{}`))
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeInternal))
+	})
+}
+
+func TestService_Impersonate(t *testing.T) {
+	t.Run("issues a short-lived token naming the actor", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, _ := newMockUser(t, "testuser", "password")
+		mockStorage.EXPECT().FindUserByID(mock.Anything, uint(5)).Return(&model.User{ID: 5, Username: "support"}, nil)
+		mockStorage.EXPECT().FindUserByID(mock.Anything, uint(1)).Return(mockUser, nil)
+		sessions := sessionStarterFunc(func(context.Context, *model.User, time.Time, ...*model.Event) (*model.Session, error) {
+			t.Error("impersonation must not start a session")
+			return nil, nil
+		})
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, WithSessions(sessions))
+
+		resp, err := service.Impersonate(context.Background(), ImpersonateRequest{UserID: 1, ActorID: 5, Reason: "ticket 42"})
+
+		require.NoError(t, err)
+		assert.Equal(t, mockUser, resp.User)
+		assert.WithinDuration(t, time.Now().Add(ImpersonationExpiration), resp.ExpiresAt, time.Second)
+		claims := &schemas.JwtClaims{}
+		_, err = jwt.ParseWithClaims(resp.Token, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte("test-secret"), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "1", claims.ID)
+		require.NotNil(t, claims.Actor)
+		assert.Equal(t, "support", claims.Actor.Subject)
+		assert.Empty(t, claims.SessionID)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockStorage.EXPECT().FindUserByID(mock.Anything, uint(5)).Return(&model.User{ID: 5, Username: "support"}, nil)
+		mockStorage.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(nil, nil)
+
+		_, err := newTestService(t, mockStorage).Impersonate(context.Background(), ImpersonateRequest{UserID: 7, ActorID: 5})
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("unknown actor", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockStorage.EXPECT().FindUserByID(mock.Anything, uint(5)).Return(nil, nil)
+
+		_, err := newTestService(t, mockStorage).Impersonate(context.Background(), ImpersonateRequest{UserID: 7, ActorID: 5})
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+	})
+}
+
+func TestService_OrgToken(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	claims := &schemas.JwtClaims{
+		ID:        "1",
+		Username:  "testuser",
+		SessionID: "family",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	resp, err := newTestService(t, storageMocks.NewMockStorage(t)).OrgToken(context.Background(), claims, 7)
+
+	require.NoError(t, err)
+	assert.True(t, expiresAt.Equal(resp.ExpiresAt), "the token expires with the one it came from")
+	orgClaims := &schemas.JwtClaims{}
+	_, err = jwt.ParseWithClaims(resp.Token, orgClaims, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), orgClaims.OrgID)
+	assert.Equal(t, "family", orgClaims.SessionID, "the token keeps the login session")
+	assert.Equal(t, "1", orgClaims.ID)
+	assert.Zero(t, claims.OrgID, "the original claims are left alone")
+}
+
+func TestService_AuditLog(t *testing.T) {
+	recorder := func(recorded *[]model.AuditEvent) Option {
+		return WithAuditLog(audit.RecorderFunc(func(_ context.Context, event model.AuditEvent) {
+			*recorded = append(*recorded, event)
+		}))
+	}
+
+	t.Run("successful login", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+		var recorded []model.AuditEvent
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, recorder(&recorded))
+
+		_, err := service.Login(context.Background(), LoginRequest{Username: "testuser", Password: "correctpass"})
+
+		require.NoError(t, err)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, model.AuditLoginSucceeded, recorded[0].Action)
+		assert.Equal(t, mockUser.ID, recorded[0].ActorID)
+		assert.Equal(t, mockUser.ID, recorded[0].TargetID)
+		assert.Equal(t, "password", recorded[0].Metadata["method"])
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+		var recorded []model.AuditEvent
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, recorder(&recorded))
+
+		_, err := service.Login(context.Background(), LoginRequest{Username: "testuser", Password: "wrongpass"})
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, model.AuditLoginFailed, recorded[0].Action)
+		assert.Zero(t, recorded[0].ActorID)
+		assert.Equal(t, mockUser.ID, recorded[0].TargetID)
+		assert.Equal(t, "wrong_password", recorded[0].Metadata["reason"])
+	})
+
+	t.Run("impersonation names the actor", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, _ := newMockUser(t, "testuser", "password")
+		mockStorage.EXPECT().FindUserByID(mock.Anything, uint(5)).Return(&model.User{ID: 5, Username: "support"}, nil)
+		mockStorage.EXPECT().FindUserByID(mock.Anything, mockUser.ID).Return(mockUser, nil)
+		var recorded []model.AuditEvent
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, recorder(&recorded))
+
+		_, err := service.Impersonate(context.Background(), ImpersonateRequest{UserID: mockUser.ID, ActorID: 5, Reason: "ticket 42"})
+
+		require.NoError(t, err)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, model.AuditImpersonationStarted, recorded[0].Action)
+		assert.Equal(t, "support", recorded[0].Actor)
+		assert.Equal(t, uint(5), recorded[0].ActorID)
+		assert.Equal(t, mockUser.ID, recorded[0].TargetID)
+		assert.Equal(t, "ticket 42", recorded[0].Metadata["reason"])
+	})
+}
+
+func TestService_Events(t *testing.T) {
+	t.Run("registration stores the event with the user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockStorage.EXPECT().CheckUniqueness(mock.Anything, "testuser", "test@example.com").Return(false, false, nil)
+		var stored []*model.Event
+		mockStorage.EXPECT().CreateUserWithPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, user *model.User, _ string, events ...*model.Event) (*model.User, error) {
+				stored = events
+				user.ID = 1
+				return user, nil
+			})
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+			WithEvents(storageMocks.NewMockOutboxStorage(t)))
+
+		_, err := service.Register(context.Background(), RegisterRequest{Username: "testuser", Email: "test@example.com", Password: "SecurePass123!"})
+
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.Equal(t, model.EventUserRegistered, stored[0].Type)
+		assert.NotEmpty(t, stored[0].ID)
+		assert.JSONEq(t, `{"username":"testuser","email":"test@example.com","method":"password"}`, string(stored[0].Payload))
+	})
+
+	t.Run("login stores the event with the session", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+		var stored []*model.Event
+		sessions := sessionStarterFunc(func(_ context.Context, _ *model.User, _ time.Time, events ...*model.Event) (*model.Session, error) {
+			stored = events
+			return &model.Session{ID: 9, Family: "family-1"}, nil
+		})
+		outbox := storageMocks.NewMockOutboxStorage(t)
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, WithEvents(outbox), WithSessions(sessions))
+
+		ctx := clientinfo.NewContext(context.Background(), clientinfo.Info{IP: "203.0.113.7", UserAgent: "Firefox"})
+		_, err := service.Login(ctx, LoginRequest{Username: "testuser", Password: "correctpass"})
+
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.Equal(t, model.EventUserLoggedIn, stored[0].Type)
+		assert.Equal(t, mockUser.ID, stored[0].UserID)
+		assert.JSONEq(t, `{"method":"password","ip":"203.0.113.7","user_agent":"Firefox"}`, string(stored[0].Payload))
+	})
+
+	t.Run("login without sessions adds the event to the outbox", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+		outbox := storageMocks.NewMockOutboxStorage(t)
+		var added *model.Event
+		outbox.EXPECT().Add(mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, events ...*model.Event) error {
+				added = events[0]
+				return nil
+			})
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, WithEvents(outbox))
+
+		ctx := clientinfo.NewContext(context.Background(), clientinfo.Info{IP: "203.0.113.7", UserAgent: "Firefox"})
+		_, err := service.Login(ctx, LoginRequest{Username: "testuser", Password: "correctpass"})
+
+		require.NoError(t, err)
+		require.NotNil(t, added)
+		assert.Equal(t, model.EventUserLoggedIn, added.Type)
+		assert.Equal(t, mockUser.ID, added.UserID)
+		assert.JSONEq(t, `{"method":"password","ip":"203.0.113.7","user_agent":"Firefox"}`, string(added.Payload))
+	})
+
+	t.Run("a login whose event is not stored fails", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+		outbox := storageMocks.NewMockOutboxStorage(t)
+		outbox.EXPECT().Add(mock.Anything, mock.Anything).Return(assert.AnError)
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration, WithEvents(outbox))
+
+		_, err := service.Login(context.Background(), LoginRequest{Username: "testuser", Password: "correctpass"})
+
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeInternal))
+	})
+
+	t.Run("password change stores the event with the change", func(t *testing.T) {
+		t.Parallel()
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "OldPass123!")
+		mockStorage.EXPECT().FindUserByIDWithPassword(mock.Anything, mockUser.ID).Return(mockUser, passwordHash, nil)
+		var stored []*model.Event
+		mockStorage.EXPECT().UpdatePassword(mock.Anything, mockUser.ID, mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, _ uint, _ string, events ...*model.Event) error {
+				stored = events
+				return nil
+			})
+		service := NewAuthService(zap.NewNop().Sugar(), mockStorage, "test-secret", testJWTExpiration,
+			WithEvents(storageMocks.NewMockOutboxStorage(t)))
+
+		err := service.ChangePassword(context.Background(), ChangePasswordRequest{UserID: mockUser.ID, CurrentPassword: "OldPass123!", NewPassword: "NewPass456!"})
+
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.Equal(t, model.EventPasswordChanged, stored[0].Type)
+		assert.Equal(t, mockUser.ID, stored[0].UserID)
+	})
+}
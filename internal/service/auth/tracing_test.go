@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// startTestTrace installs a recording tracer provider (once per test binary,
+// since the package tracer binds to the first global provider) and returns a
+// context holding a root span plus a function listing the spans ended in that trace.
+func startTestTrace(t *testing.T) (context.Context, func() []sdktrace.ReadOnlySpan) {
+	t.Helper()
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	})
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "test")
+	t.Cleanup(func() { root.End() })
+	traceID := root.SpanContext().TraceID()
+
+	return ctx, func() []sdktrace.ReadOnlySpan {
+		var spans []sdktrace.ReadOnlySpan
+		for _, span := range recorder.Ended() {
+			if span.SpanContext().TraceID() == traceID {
+				spans = append(spans, span)
+			}
+		}
+		return spans
+	}
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	return names
+}
+
+func TestService_Login_Spans(t *testing.T) {
+	t.Run("success records bcrypt and JWT child spans", func(t *testing.T) {
+		ctx, ended := startTestTrace(t)
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+
+		_, err := newTestService(t, mockStorage).Login(ctx, LoginRequest{Username: "testuser", Password: "correctpass"})
+		require.NoError(t, err)
+
+		spans := ended()
+		assert.ElementsMatch(t, []string{"password.Compare", "jwt.Sign", "auth.Login"}, spanNames(spans))
+		for _, span := range spans {
+			if span.Name() == "auth.Login" {
+				assert.Equal(t, codes.Unset, span.Status().Code)
+			} else {
+				assert.Equal(t, "auth.Login", parentName(spans, span.Parent()), "%s should be a child of auth.Login", span.Name())
+			}
+		}
+	})
+
+	t.Run("invalid password marks the span as failed", func(t *testing.T) {
+		ctx, ended := startTestTrace(t)
+
+		mockStorage := storageMocks.NewMockStorage(t)
+		mockUser, passwordHash := newMockUser(t, "testuser", "correctpass")
+		mockStorage.EXPECT().FindUserByLoginWithPassword(mock.Anything, "testuser").Return(mockUser, passwordHash, nil)
+
+		_, err := newTestService(t, mockStorage).Login(ctx, LoginRequest{Username: "testuser", Password: "wrong"})
+		require.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+
+		spans := ended()
+		require.Contains(t, spanNames(spans), "auth.Login")
+		for _, span := range spans {
+			if span.Name() == "auth.Login" {
+				assert.Equal(t, codes.Error, span.Status().Code)
+			}
+		}
+	})
+}
+
+func TestService_Register_Spans(t *testing.T) {
+	ctx, ended := startTestTrace(t)
+
+	mockStorage := storageMocks.NewMockStorage(t)
+	mockStorage.EXPECT().CheckUniqueness(mock.Anything, "newuser", "new@example.com").Return(false, false, nil)
+	mockStorage.EXPECT().CreateUserWithPassword(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, u *model.User, _ string, _ ...*model.Event) (*model.User, error) {
+			u.ID = 7
+			return u, nil
+		})
+
+	_, err := newTestService(t, mockStorage).Register(ctx, RegisterRequest{
+		Username: "newuser",
+		Email:    "new@example.com",
+		Password: "password",
+		FullName: "New User",
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"password.Hash", "auth.Register"}, spanNames(ended()))
+}
+
+// parentName returns the name of the span in spans whose context is parent
+func parentName(spans []sdktrace.ReadOnlySpan, parent trace.SpanContext) string {
+	for _, span := range spans {
+		if span.SpanContext().SpanID() == parent.SpanID() {
+			return span.Name()
+		}
+	}
+	return ""
+}
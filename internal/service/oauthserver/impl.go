@@ -0,0 +1,557 @@
+package oauthserver
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	governerrors "github.com/haipham22/govern/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	oauthRepo "golang-sample/internal/storage/oauthserver"
+	userRepo "golang-sample/internal/storage/user"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/oidc"
+	"golang-sample/pkg/tracing"
+	utilstring "golang-sample/pkg/utils/string"
+)
+
+var tracer = otel.Tracer("golang-sample/internal/service/oauthserver")
+
+const (
+	// AccessTokenPrefix starts every access token, so they are recognizable
+	// by secret scanners
+	AccessTokenPrefix = "oat_"
+	// ClientSecretPrefix starts every client secret
+	ClientSecretPrefix = "ocs_"
+	// secretLength is the number of hex characters of codes, tokens and
+	// client secrets (256 bits)
+	secretLength = 64
+	// clientIDLength is the number of hex characters of client IDs
+	clientIDLength = 24
+	// codeTTL bounds the time between consent and the code exchange
+	codeTTL = 5 * time.Minute
+	// defaultAccessTokenTTL applies when Config.AccessTokenTTL is zero
+	defaultAccessTokenTTL = time.Hour
+)
+
+type impl struct {
+	log            *zap.SugaredLogger
+	storage        oauthRepo.Storage
+	users          userRepo.Storage
+	issuer         string
+	signingKey     *rsa.PrivateKey
+	kid            string
+	accessTokenTTL time.Duration
+}
+
+func NewOAuthServerService(
+	log *zap.SugaredLogger,
+	storage oauthRepo.Storage,
+	users userRepo.Storage,
+	cfg Config,
+) (Service, error) {
+	kid, err := keyID(&cfg.SigningKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenTTL := cfg.AccessTokenTTL
+	if accessTokenTTL == 0 {
+		accessTokenTTL = defaultAccessTokenTTL
+	}
+
+	return &impl{
+		log:            log,
+		storage:        storage,
+		users:          users,
+		issuer:         strings.TrimRight(cfg.Issuer, "/"),
+		signingKey:     cfg.SigningKey,
+		kid:            kid,
+		accessTokenTTL: accessTokenTTL,
+	}, nil
+}
+
+func (s *impl) Issuer() string {
+	return s.issuer
+}
+
+func (s *impl) SigningKey() (string, *rsa.PublicKey) {
+	return s.kid, &s.signingKey.PublicKey
+}
+
+func (s *impl) RegisterClient(ctx context.Context, req RegisterClientRequest) (*RegisteredClient, error) {
+	log := logger.FromContext(ctx, s.log)
+
+	for _, grantType := range req.GrantTypes {
+		if grantType != model.GrantAuthorizationCode && grantType != model.GrantClientCredentials {
+			return nil, governerrors.NewCode(governerrors.CodeInvalid, "unsupported grant type "+grantType)
+		}
+	}
+	if slices.Contains(req.GrantTypes, model.GrantAuthorizationCode) && len(req.RedirectURIs) == 0 {
+		return nil, governerrors.NewCode(governerrors.CodeInvalid, "the authorization_code grant needs a redirect URI")
+	}
+	if req.Public && slices.Contains(req.GrantTypes, model.GrantClientCredentials) {
+		return nil, governerrors.NewCode(governerrors.CodeInvalid, "a public client cannot use the client_credentials grant")
+	}
+
+	clientID, err := utilstring.RandomHexString(clientIDLength)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	var secret, secretHash string
+	if !req.Public {
+		random, err := utilstring.RandomHexString(secretLength)
+		if err != nil {
+			return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+		}
+		secret = ClientSecretPrefix + random
+		secretHash = hashSecret(secret)
+	}
+
+	client, err := s.storage.CreateClient(ctx, &model.OAuthClient{
+		ClientID:     clientID,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+	}, secretHash)
+	if err != nil {
+		log.Errorf("Failed to register OAuth client: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("OAuth client registered: %s", client.ClientID)
+	return &RegisteredClient{Client: client, Secret: secret}, nil
+}
+
+func (s *impl) ListClients(ctx context.Context) ([]*model.OAuthClient, error) {
+	clients, err := s.storage.ListClients(ctx)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list OAuth clients: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return clients, nil
+}
+
+func (s *impl) CheckAuthorization(ctx context.Context, req AuthorizationRequest) (*model.OAuthClient, error) {
+	client, _, err := s.storage.FindClient(ctx, req.ClientID)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to find OAuth client: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if client == nil {
+		return nil, newError(ErrorInvalidRequest, "unknown client_id")
+	}
+	if !client.AllowsRedirect(req.RedirectURI) {
+		return nil, newError(ErrorInvalidRequest, "redirect_uri is not registered for the client")
+	}
+	return client, nil
+}
+
+func (s *impl) Authorize(ctx context.Context, userID uint, req AuthorizationRequest, approved bool) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "oauthserver.Authorize")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", userID, "client_id", req.ClientID)
+
+	client, err := s.CheckAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	// From here on errors go back to the client through the redirect
+	reply := func(params url.Values) string {
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+		// RFC 9207: lets the client tell which server answered
+		params.Set("iss", s.issuer)
+		return appendQuery(req.RedirectURI, params)
+	}
+	replyError := func(code, description string) string {
+		return reply(url.Values{"error": {code}, "error_description": {description}})
+	}
+
+	scopes := strings.Fields(req.Scope)
+	switch {
+	case req.ResponseType != "code":
+		return replyError(ErrorUnsupportedResponseType, "only the code response type is supported"), nil
+	case !client.AllowsGrant(model.GrantAuthorizationCode):
+		return replyError(ErrorUnauthorizedClient, "the client may not use the authorization code grant"), nil
+	case req.CodeChallenge == "" || req.CodeChallengeMethod != "S256":
+		return replyError(ErrorInvalidRequest, "PKCE with the S256 method is required"), nil
+	case !client.AllowsScopes(scopes):
+		return replyError(ErrorInvalidScope, "the client may not request these scopes"), nil
+	case !approved:
+		log.Infof("Authorization denied by the user")
+		return replyError(ErrorAccessDenied, "the user denied the request"), nil
+	}
+
+	code, err := utilstring.RandomHexString(secretLength)
+	if err != nil {
+		return "", governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	_, err = s.storage.CreateCode(ctx, &model.OAuthCode{
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(codeTTL).UTC(),
+	}, hashSecret(code))
+	if err != nil {
+		log.Errorf("Failed to create authorization code: %v", err)
+		return "", governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Authorization code issued")
+	return reply(url.Values{"code": {code}}), nil
+}
+
+func (s *impl) Token(ctx context.Context, req TokenRequest) (_ *TokenResponse, err error) {
+	ctx, span := tracer.Start(ctx, "oauthserver.Token")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.String("oauth.grant_type", req.GrantType))
+
+	switch req.GrantType {
+	case model.GrantAuthorizationCode:
+		return s.exchangeCode(ctx, req)
+	case model.GrantClientCredentials:
+		return s.clientCredentials(ctx, req)
+	case "":
+		return nil, newError(ErrorInvalidRequest, "grant_type is required")
+	default:
+		return nil, newError(ErrorUnsupportedGrantType, "unsupported grant_type")
+	}
+}
+
+func (s *impl) exchangeCode(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	log := logger.FromContext(ctx, s.log).With("client_id", req.Client.ClientID)
+
+	client, err := s.authenticateClient(ctx, req.Client, true)
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsGrant(model.GrantAuthorizationCode) {
+		return nil, newError(ErrorUnauthorizedClient, "the client may not use the authorization code grant")
+	}
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, newError(ErrorInvalidRequest, "code and code_verifier are required")
+	}
+
+	now := time.Now().UTC()
+	code, firstUse, err := s.storage.ConsumeCode(ctx, hashSecret(req.Code), now)
+	if err != nil {
+		log.Errorf("Failed to consume authorization code: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if code == nil {
+		return nil, newError(ErrorInvalidGrant, "unknown authorization code")
+	}
+	if !firstUse {
+		// A replayed code may have been stolen; RFC 6749 section 4.1.2
+		// asks to revoke what it was exchanged for
+		log.Warnf("Authorization code reused; revoking its tokens")
+		if err := s.storage.RevokeTokensByCode(ctx, code.ID, now); err != nil {
+			log.Errorf("Failed to revoke tokens of a reused code: %v", err)
+		}
+		return nil, newError(ErrorInvalidGrant, "authorization code already used")
+	}
+
+	challenge := oidc.S256Challenge(req.CodeVerifier)
+	switch {
+	case code.ClientID != client.ClientID:
+		return nil, newError(ErrorInvalidGrant, "authorization code issued to another client")
+	case !now.Before(code.ExpiresAt):
+		return nil, newError(ErrorInvalidGrant, "authorization code expired")
+	case code.RedirectURI != req.RedirectURI:
+		return nil, newError(ErrorInvalidGrant, "redirect_uri does not match the authorization request")
+	case subtle.ConstantTimeCompare([]byte(challenge), []byte(code.CodeChallenge)) != 1:
+		return nil, newError(ErrorInvalidGrant, "code_verifier does not match the code challenge")
+	}
+
+	user, err := s.users.FindUserByID(ctx, code.UserID)
+	if err != nil {
+		log.Errorf("Failed to find user: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if user == nil {
+		return nil, newError(ErrorInvalidGrant, "the user no longer exists")
+	}
+
+	resp, err := s.issueToken(ctx, &model.OAuthToken{
+		ClientID: client.ClientID,
+		UserID:   user.ID,
+		CodeID:   code.ID,
+		Scopes:   code.Scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(code.Scopes, model.OAuthScopeOpenID) {
+		resp.IDToken, err = s.idToken(client.ClientID, user, code, now)
+		if err != nil {
+			log.Errorf("Failed to sign ID token: %v", err)
+			return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+		}
+	}
+
+	log.With("user_id", user.ID).Infof("Access token issued for an authorization code")
+	return resp, nil
+}
+
+func (s *impl) clientCredentials(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.Client, false)
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsGrant(model.GrantClientCredentials) {
+		return nil, newError(ErrorUnauthorizedClient, "the client may not use the client credentials grant")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if req.Scope == "" {
+		// Every scope the client may request, except those about a user
+		for _, scope := range client.Scopes {
+			if !model.IsIdentityScope(scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	if slices.ContainsFunc(scopes, model.IsIdentityScope) || !client.AllowsScopes(scopes) {
+		return nil, newError(ErrorInvalidScope, "the client may not request these scopes")
+	}
+
+	resp, err := s.issueToken(ctx, &model.OAuthToken{ClientID: client.ClientID, Scopes: scopes})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx, s.log).With("client_id", client.ClientID).Infof("Access token issued for client credentials")
+	return resp, nil
+}
+
+func (s *impl) Introspect(ctx context.Context, creds ClientCredentials, token string) (_ *Introspection, err error) {
+	ctx, span := tracer.Start(ctx, "oauthserver.Introspect")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.authenticateClient(ctx, creds, false); err != nil {
+		return nil, err
+	}
+
+	found, user, err := s.activeToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return &Introspection{Active: false}, nil
+	}
+
+	result := &Introspection{
+		Active:    true,
+		ClientID:  found.ClientID,
+		Scopes:    found.Scopes,
+		ExpiresAt: found.ExpiresAt,
+		IssuedAt:  found.CreatedAt,
+	}
+	if user != nil {
+		result.Subject = subject(user.ID)
+		result.Username = user.Username
+	}
+	return result, nil
+}
+
+func (s *impl) Revoke(ctx context.Context, creds ClientCredentials, token string) error {
+	client, err := s.authenticateClient(ctx, creds, true)
+	if err != nil {
+		return err
+	}
+
+	// Unknown tokens and those of other clients are ignored (RFC 7009 section 2.2)
+	if err := s.storage.RevokeToken(ctx, hashSecret(token), client.ClientID, time.Now().UTC()); err != nil {
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return nil
+}
+
+func (s *impl) UserInfo(ctx context.Context, accessToken string) (_ *UserInfo, err error) {
+	ctx, span := tracer.Start(ctx, "oauthserver.UserInfo")
+	defer func() { tracing.End(span, err) }()
+
+	found, user, err := s.activeToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, newError(ErrorInvalidToken, "the access token is invalid or expired")
+	}
+	if user == nil || !slices.Contains(found.Scopes, model.OAuthScopeOpenID) {
+		return nil, newError(ErrorInsufficientScope, "the access token lacks the openid scope")
+	}
+
+	info := &UserInfo{Subject: subject(user.ID)}
+	if slices.Contains(found.Scopes, model.OAuthScopeProfile) {
+		info.PreferredUsername = user.Username
+		info.Locale = user.Locale
+	}
+	if slices.Contains(found.Scopes, model.OAuthScopeEmail) {
+		info.Email = user.Email
+	}
+	return info, nil
+}
+
+// activeToken finds an active access token and its user, nil for a token
+// of the client itself. token is nil when it is unknown, expired, revoked
+// or its user is gone.
+func (s *impl) activeToken(ctx context.Context, accessToken string) (token *model.OAuthToken, user *model.User, err error) {
+	log := logger.FromContext(ctx, s.log)
+
+	if !strings.HasPrefix(accessToken, AccessTokenPrefix) {
+		return nil, nil, nil
+	}
+	token, err = s.storage.FindToken(ctx, hashSecret(accessToken))
+	if err != nil {
+		log.Errorf("Failed to find access token: %v", err)
+		return nil, nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if !token.Active(time.Now()) {
+		return nil, nil, nil
+	}
+	if token.UserID == 0 {
+		return token, nil, nil
+	}
+
+	user, err = s.users.FindUserByID(ctx, token.UserID)
+	if err != nil {
+		log.Errorf("Failed to find user of access token: %v", err)
+		return nil, nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if user == nil {
+		return nil, nil, nil
+	}
+	return token, user, nil
+}
+
+// authenticateClient checks client credentials. A public client is
+// identified by its client ID alone, which allowPublic permits.
+func (s *impl) authenticateClient(ctx context.Context, creds ClientCredentials, allowPublic bool) (*model.OAuthClient, error) {
+	if creds.ClientID == "" {
+		return nil, newError(ErrorInvalidClient, "client authentication is required")
+	}
+
+	client, secretHash, err := s.storage.FindClient(ctx, creds.ClientID)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to find OAuth client: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	switch {
+	case client == nil:
+		return nil, newError(ErrorInvalidClient, "client authentication failed")
+	case !client.Confidential:
+		if !allowPublic || creds.ClientSecret != "" {
+			return nil, newError(ErrorInvalidClient, "client authentication failed")
+		}
+	case subtle.ConstantTimeCompare([]byte(hashSecret(creds.ClientSecret)), []byte(secretHash)) != 1:
+		logger.FromContext(ctx, s.log).Warnf("OAuth client authentication failed for %s", creds.ClientID)
+		return nil, newError(ErrorInvalidClient, "client authentication failed")
+	}
+	return client, nil
+}
+
+// issueToken creates an access token for token's client, user and scopes
+func (s *impl) issueToken(ctx context.Context, token *model.OAuthToken) (*TokenResponse, error) {
+	random, err := utilstring.RandomHexString(secretLength)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	accessToken := AccessTokenPrefix + random
+
+	token.ExpiresAt = time.Now().Add(s.accessTokenTTL).UTC()
+	if _, err := s.storage.CreateToken(ctx, token, hashSecret(accessToken)); err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create access token: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   s.accessTokenTTL,
+		Scopes:      token.Scopes,
+	}, nil
+}
+
+// idToken signs the ID token of user for clientID, with the claims the
+// code's scopes allow
+func (s *impl) idToken(clientID string, user *model.User, code *model.OAuthCode, now time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"iss": s.issuer,
+		"sub": subject(user.ID),
+		"aud": clientID,
+		"iat": now.Unix(),
+		"exp": now.Add(s.accessTokenTTL).Unix(),
+	}
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+	if slices.Contains(code.Scopes, model.OAuthScopeProfile) {
+		claims["preferred_username"] = user.Username
+		if user.Locale != "" {
+			claims["locale"] = user.Locale
+		}
+	}
+	if slices.Contains(code.Scopes, model.OAuthScopeEmail) {
+		claims["email"] = user.Email
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	return token.SignedString(s.signingKey)
+}
+
+// subject is the sub claim of a user, stable across username changes
+func subject(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// hashSecret returns the hex SHA-256 of a code, token or client secret,
+// the form they are stored and looked up in
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// keyID derives a stable key ID from the public key
+func keyID(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// appendQuery adds params to the query of uri, keeping the query it has
+func appendQuery(uri string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(uri, "?") {
+		sep = "&"
+	}
+	return uri + sep + params.Encode()
+}
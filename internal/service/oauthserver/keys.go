@@ -0,0 +1,37 @@
+package oauthserver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// ParseSigningKey parses a PEM RSA private key in PKCS #1 or PKCS #8 form
+func ParseSigningKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is a %T, not an RSA key", parsed)
+	}
+	return key, nil
+}
+
+// GenerateSigningKey creates an RSA key for ID tokens. Tokens signed with
+// it stop verifying when the process restarts, so it only suits development.
+func GenerateSigningKey() (*rsa.PrivateKey, error) {
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
@@ -0,0 +1,147 @@
+package oauthserver
+
+import (
+	"context"
+	"crypto/rsa"
+	"time"
+
+	"golang-sample/internal/model"
+)
+
+// OAuth 2.0 error codes (RFC 6749 section 5.2, RFC 6750 section 3.1)
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorAccessDenied            = "access_denied"
+	ErrorInvalidToken            = "invalid_token"
+	ErrorInsufficientScope       = "insufficient_scope"
+)
+
+// Error is an OAuth 2.0 error. Clients of the protocol expect these codes
+// in the protocol's own format rather than the API's error codes.
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newError(code, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
+
+// Service is a minimal OAuth 2.0 authorization server and OpenID Connect
+// provider for the users of this service
+type Service interface {
+	// Issuer is the identifier of the authorization server, the base URL of its endpoints
+	Issuer() string
+	// SigningKey returns the key ID and public key of ID token signatures
+	SigningKey() (kid string, key *rsa.PublicKey)
+
+	// RegisterClient creates a client. The secret of a confidential client
+	// is only returned here.
+	RegisterClient(ctx context.Context, req RegisterClientRequest) (*RegisteredClient, error)
+	ListClients(ctx context.Context) ([]*model.OAuthClient, error)
+
+	// CheckAuthorization validates the client and redirect URI of an
+	// authorization request. Its errors must be shown to the user rather
+	// than sent to the redirect URI, which is not trusted yet.
+	CheckAuthorization(ctx context.Context, req AuthorizationRequest) (*model.OAuthClient, error)
+	// Authorize answers an authorization request the user has approved or
+	// denied with the redirect back to the client, carrying a code or an error
+	Authorize(ctx context.Context, userID uint, req AuthorizationRequest, approved bool) (redirectURI string, err error)
+	// Token runs the token endpoint for the authorization_code and
+	// client_credentials grants
+	Token(ctx context.Context, req TokenRequest) (*TokenResponse, error)
+	// Introspect describes token to a confidential client (RFC 7662)
+	Introspect(ctx context.Context, client ClientCredentials, token string) (*Introspection, error)
+	// Revoke revokes token if it was issued to client (RFC 7009)
+	Revoke(ctx context.Context, client ClientCredentials, token string) error
+	// UserInfo returns the claims of the user an access token was issued for
+	UserInfo(ctx context.Context, accessToken string) (*UserInfo, error)
+}
+
+// Config configures the authorization server
+type Config struct {
+	Issuer string
+	// SigningKey signs ID tokens
+	SigningKey     *rsa.PrivateKey
+	AccessTokenTTL time.Duration
+}
+
+type RegisterClientRequest struct {
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	// Public clients have no secret and must use PKCE
+	Public bool
+}
+
+type RegisteredClient struct {
+	Client *model.OAuthClient
+	// Secret is empty for a public client
+	Secret string
+}
+
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// AuthorizationRequest holds the parameters of an authorization request
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+type TokenRequest struct {
+	Client       ClientCredentials
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Scope        string
+}
+
+type TokenResponse struct {
+	AccessToken string
+	ExpiresIn   time.Duration
+	Scopes      []string
+	// IDToken is set when the openid scope was granted
+	IDToken string
+}
+
+// Introspection describes a token; only Active is set for a token that is
+// not active
+type Introspection struct {
+	Active   bool
+	ClientID string
+	Scopes   []string
+	// Subject and Username are empty for a token of the client itself
+	Subject   string
+	Username  string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+}
+
+// UserInfo holds the claims the granted scopes allow
+type UserInfo struct {
+	Subject           string
+	PreferredUsername string
+	Email             string
+	Locale            string
+}
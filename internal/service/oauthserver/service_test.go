@@ -0,0 +1,477 @@
+package oauthserver
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+	"golang-sample/pkg/oidc"
+)
+
+const (
+	testIssuer   = "https://auth.example"
+	testSecret   = "ocs_secret"
+	testVerifier = "verifier-verifier-verifier-verifier-verifier"
+)
+
+var testKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+var (
+	testUser   = &model.User{ID: 7, Username: "alice", Email: "alice@example.com", Locale: "vi"}
+	testClient = &model.OAuthClient{
+		ClientID:     "app",
+		RedirectURIs: []string{"https://app.example/cb"},
+		GrantTypes:   []string{model.GrantAuthorizationCode, model.GrantClientCredentials},
+		Scopes:       []string{model.OAuthScopeOpenID, model.OAuthScopeProfile, model.OAuthScopeEmail, "reports:read"},
+		Confidential: true,
+	}
+	publicClient = &model.OAuthClient{
+		ClientID:     "spa",
+		RedirectURIs: []string{"https://spa.example/cb"},
+		GrantTypes:   []string{model.GrantAuthorizationCode},
+		Scopes:       []string{model.OAuthScopeOpenID},
+	}
+)
+
+func newTestService(t *testing.T) (Service, *storageMocks.MockOAuthServerStorage, *storageMocks.MockStorage) {
+	t.Helper()
+	storage := storageMocks.NewMockOAuthServerStorage(t)
+	users := storageMocks.NewMockStorage(t)
+	service, err := NewOAuthServerService(zap.NewNop().Sugar(), storage, users, Config{
+		Issuer:     testIssuer + "/",
+		SigningKey: testKey(),
+	})
+	require.NoError(t, err)
+
+	storage.EXPECT().FindClient(mock.Anything, "app").Return(testClient, hashSecret(testSecret), nil).Maybe()
+	storage.EXPECT().FindClient(mock.Anything, "spa").Return(publicClient, "", nil).Maybe()
+	storage.EXPECT().FindClient(mock.Anything, mock.Anything).Return(nil, "", nil).Maybe()
+	return service, storage, users
+}
+
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *Error
+	require.True(t, errors.As(err, &oauthErr), "want an OAuth error, got %v", err)
+	assert.Equal(t, code, oauthErr.Code)
+}
+
+func authorizationRequest() AuthorizationRequest {
+	return AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "app",
+		RedirectURI:         "https://app.example/cb",
+		Scope:               "openid profile",
+		State:               "the-state",
+		Nonce:               "the-nonce",
+		CodeChallenge:       oidc.S256Challenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestService_RegisterClient(t *testing.T) {
+	t.Run("confidential client gets a secret", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		var storedHash string
+		storage.EXPECT().CreateClient(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, client *model.OAuthClient, secretHash string) (*model.OAuthClient, error) {
+				storedHash = secretHash
+				assert.Len(t, client.ClientID, clientIDLength)
+				return client, nil
+			})
+
+		registered, err := service.RegisterClient(context.Background(), RegisterClientRequest{
+			Name:         "Reports",
+			RedirectURIs: []string{"https://reports.example/cb"},
+			GrantTypes:   []string{model.GrantAuthorizationCode},
+		})
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(registered.Secret, ClientSecretPrefix))
+		assert.Equal(t, hashSecret(registered.Secret), storedHash, "only the hash is stored")
+	})
+
+	t.Run("public client has no secret", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		storage.EXPECT().CreateClient(mock.Anything, mock.Anything, "").
+			RunAndReturn(func(_ context.Context, client *model.OAuthClient, _ string) (*model.OAuthClient, error) {
+				return client, nil
+			})
+
+		registered, err := service.RegisterClient(context.Background(), RegisterClientRequest{
+			Name:         "SPA",
+			RedirectURIs: []string{"https://spa.example/cb"},
+			GrantTypes:   []string{model.GrantAuthorizationCode},
+			Public:       true,
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, registered.Secret)
+	})
+
+	invalid := []RegisterClientRequest{
+		{Name: "no redirect", GrantTypes: []string{model.GrantAuthorizationCode}},
+		{Name: "public machine", GrantTypes: []string{model.GrantClientCredentials}, Public: true},
+		{Name: "implicit", GrantTypes: []string{"implicit"}, RedirectURIs: []string{"https://x.example/cb"}},
+	}
+	for _, req := range invalid {
+		t.Run(req.Name, func(t *testing.T) {
+			service, _, _ := newTestService(t)
+
+			_, err := service.RegisterClient(context.Background(), req)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestService_Authorize(t *testing.T) {
+	t.Run("issues a code", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		storage.EXPECT().CreateCode(mock.Anything, mock.MatchedBy(func(code *model.OAuthCode) bool {
+			return code.UserID == 7 && code.ClientID == "app" && code.Nonce == "the-nonce" &&
+				code.CodeChallenge == oidc.S256Challenge(testVerifier) &&
+				assert.ObjectsAreEqual([]string{"openid", "profile"}, code.Scopes)
+		}), mock.Anything).Return(&model.OAuthCode{ID: 1}, nil)
+
+		redirect, err := service.Authorize(context.Background(), 7, authorizationRequest(), true)
+
+		require.NoError(t, err)
+		u, err := url.Parse(redirect)
+		require.NoError(t, err)
+		assert.Equal(t, "app.example", u.Host)
+		assert.Len(t, u.Query().Get("code"), secretLength)
+		assert.Equal(t, "the-state", u.Query().Get("state"))
+		assert.Equal(t, testIssuer, u.Query().Get("iss"))
+	})
+
+	t.Run("untrusted redirect is not followed", func(t *testing.T) {
+		for name, req := range map[string]AuthorizationRequest{
+			"unknown client":        {ClientID: "unknown", RedirectURI: "https://app.example/cb"},
+			"unregistered redirect": {ClientID: "app", RedirectURI: "https://evil.example/cb"},
+		} {
+			service, _, _ := newTestService(t)
+
+			redirect, err := service.Authorize(context.Background(), 7, req, true)
+
+			assert.Empty(t, redirect, name)
+			assertOAuthError(t, err, ErrorInvalidRequest)
+		}
+	})
+
+	redirected := []struct {
+		name     string
+		mutate   func(*AuthorizationRequest)
+		approved bool
+		want     string
+	}{
+		{name: "denied", approved: false, want: ErrorAccessDenied},
+		{name: "token response type", mutate: func(r *AuthorizationRequest) { r.ResponseType = "token" }, approved: true, want: ErrorUnsupportedResponseType},
+		{name: "without PKCE", mutate: func(r *AuthorizationRequest) { r.CodeChallenge = "" }, approved: true, want: ErrorInvalidRequest},
+		{name: "plain PKCE", mutate: func(r *AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, approved: true, want: ErrorInvalidRequest},
+		{name: "scope not registered", mutate: func(r *AuthorizationRequest) { r.Scope = "openid admin" }, approved: true, want: ErrorInvalidScope},
+	}
+	for _, tt := range redirected {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _ := newTestService(t)
+			req := authorizationRequest()
+			if tt.mutate != nil {
+				tt.mutate(&req)
+			}
+
+			redirect, err := service.Authorize(context.Background(), 7, req, tt.approved)
+
+			require.NoError(t, err)
+			u, err := url.Parse(redirect)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, u.Query().Get("error"))
+			assert.Equal(t, "the-state", u.Query().Get("state"))
+			assert.Empty(t, u.Query().Get("code"))
+		})
+	}
+}
+
+func validCode() *model.OAuthCode {
+	return &model.OAuthCode{
+		ID:            3,
+		ClientID:      "app",
+		UserID:        7,
+		RedirectURI:   "https://app.example/cb",
+		Scopes:        []string{model.OAuthScopeOpenID, model.OAuthScopeEmail},
+		Nonce:         "the-nonce",
+		CodeChallenge: oidc.S256Challenge(testVerifier),
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+}
+
+func codeRequest() TokenRequest {
+	return TokenRequest{
+		Client:       ClientCredentials{ClientID: "app", ClientSecret: testSecret},
+		GrantType:    model.GrantAuthorizationCode,
+		Code:         "the-code",
+		RedirectURI:  "https://app.example/cb",
+		CodeVerifier: testVerifier,
+	}
+}
+
+func TestService_Token_AuthorizationCode(t *testing.T) {
+	t.Run("exchanges the code for tokens", func(t *testing.T) {
+		service, storage, users := newTestService(t)
+		storage.EXPECT().ConsumeCode(mock.Anything, hashSecret("the-code"), mock.Anything).Return(validCode(), true, nil)
+		users.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(testUser, nil)
+		storage.EXPECT().CreateToken(mock.Anything, mock.MatchedBy(func(token *model.OAuthToken) bool {
+			return token.UserID == 7 && token.CodeID == 3 && token.ClientID == "app"
+		}), mock.Anything).Return(&model.OAuthToken{ID: 1}, nil)
+
+		resp, err := service.Token(context.Background(), codeRequest())
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(resp.AccessToken, AccessTokenPrefix))
+		assert.Equal(t, time.Hour, resp.ExpiresIn)
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(resp.IDToken, claims, func(token *jwt.Token) (interface{}, error) {
+			kid, key := service.SigningKey()
+			assert.Equal(t, kid, token.Header["kid"])
+			return key, nil
+		}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(testIssuer), jwt.WithAudience("app"))
+		require.NoError(t, err)
+		assert.Equal(t, "7", claims["sub"])
+		assert.Equal(t, "the-nonce", claims["nonce"])
+		assert.Equal(t, "alice@example.com", claims["email"])
+		assert.NotContains(t, claims, "preferred_username", "profile was not granted")
+	})
+
+	t.Run("public client with PKCE", func(t *testing.T) {
+		service, storage, users := newTestService(t)
+		code := validCode()
+		code.ClientID = "spa"
+		code.RedirectURI = "https://spa.example/cb"
+		storage.EXPECT().ConsumeCode(mock.Anything, mock.Anything, mock.Anything).Return(code, true, nil)
+		users.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(testUser, nil)
+		storage.EXPECT().CreateToken(mock.Anything, mock.Anything, mock.Anything).Return(&model.OAuthToken{ID: 1}, nil)
+
+		req := codeRequest()
+		req.Client = ClientCredentials{ClientID: "spa"}
+		req.RedirectURI = "https://spa.example/cb"
+		_, err := service.Token(context.Background(), req)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("reused code revokes its tokens", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		storage.EXPECT().ConsumeCode(mock.Anything, mock.Anything, mock.Anything).Return(validCode(), false, nil)
+		storage.EXPECT().RevokeTokensByCode(mock.Anything, uint(3), mock.Anything).Return(nil)
+
+		_, err := service.Token(context.Background(), codeRequest())
+
+		assertOAuthError(t, err, ErrorInvalidGrant)
+	})
+
+	rejected := []struct {
+		name   string
+		code   func(*model.OAuthCode)
+		req    func(*TokenRequest)
+		noCode bool
+		want   string
+	}{
+		{name: "wrong verifier", req: func(r *TokenRequest) { r.CodeVerifier = "another-verifier" }, want: ErrorInvalidGrant},
+		{name: "other redirect", req: func(r *TokenRequest) { r.RedirectURI = "https://app.example/other" }, want: ErrorInvalidGrant},
+		{name: "expired", code: func(c *model.OAuthCode) { c.ExpiresAt = time.Now().Add(-time.Second) }, want: ErrorInvalidGrant},
+		{name: "issued to another client", code: func(c *model.OAuthCode) { c.ClientID = "spa" }, want: ErrorInvalidGrant},
+		{name: "wrong client secret", req: func(r *TokenRequest) { r.Client.ClientSecret = "ocs_wrong" }, noCode: true, want: ErrorInvalidClient},
+		{name: "confidential client without secret", req: func(r *TokenRequest) { r.Client.ClientSecret = "" }, noCode: true, want: ErrorInvalidClient},
+		{name: "unknown client", req: func(r *TokenRequest) { r.Client.ClientID = "unknown" }, noCode: true, want: ErrorInvalidClient},
+		{name: "missing verifier", req: func(r *TokenRequest) { r.CodeVerifier = "" }, noCode: true, want: ErrorInvalidRequest},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			service, storage, _ := newTestService(t)
+			if !tt.noCode {
+				code := validCode()
+				if tt.code != nil {
+					tt.code(code)
+				}
+				storage.EXPECT().ConsumeCode(mock.Anything, mock.Anything, mock.Anything).Return(code, true, nil)
+			}
+			req := codeRequest()
+			if tt.req != nil {
+				tt.req(&req)
+			}
+
+			resp, err := service.Token(context.Background(), req)
+
+			assert.Nil(t, resp)
+			assertOAuthError(t, err, tt.want)
+		})
+	}
+}
+
+func TestService_Token_ClientCredentials(t *testing.T) {
+	t.Run("defaults to the client's own scopes", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		storage.EXPECT().CreateToken(mock.Anything, mock.MatchedBy(func(token *model.OAuthToken) bool {
+			return token.UserID == 0 && assert.ObjectsAreEqual([]string{"reports:read"}, token.Scopes)
+		}), mock.Anything).Return(&model.OAuthToken{ID: 1}, nil)
+
+		resp, err := service.Token(context.Background(), TokenRequest{
+			Client:    ClientCredentials{ClientID: "app", ClientSecret: testSecret},
+			GrantType: model.GrantClientCredentials,
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, resp.IDToken)
+	})
+
+	t.Run("identity scopes need a user", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+
+		_, err := service.Token(context.Background(), TokenRequest{
+			Client:    ClientCredentials{ClientID: "app", ClientSecret: testSecret},
+			GrantType: model.GrantClientCredentials,
+			Scope:     "openid",
+		})
+
+		assertOAuthError(t, err, ErrorInvalidScope)
+	})
+
+	t.Run("public clients cannot", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+
+		_, err := service.Token(context.Background(), TokenRequest{
+			Client:    ClientCredentials{ClientID: "spa"},
+			GrantType: model.GrantClientCredentials,
+		})
+
+		assertOAuthError(t, err, ErrorInvalidClient)
+	})
+
+	t.Run("unsupported grant", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+
+		_, err := service.Token(context.Background(), TokenRequest{GrantType: "password"})
+
+		assertOAuthError(t, err, ErrorUnsupportedGrantType)
+	})
+}
+
+func TestService_IntrospectAndUserInfo(t *testing.T) {
+	const accessToken = AccessTokenPrefix + "token"
+	userToken := &model.OAuthToken{
+		ClientID:  "app",
+		UserID:    7,
+		Scopes:    []string{model.OAuthScopeOpenID, model.OAuthScopeProfile},
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+	creds := ClientCredentials{ClientID: "app", ClientSecret: testSecret}
+
+	t.Run("introspect an active token", func(t *testing.T) {
+		service, storage, users := newTestService(t)
+		storage.EXPECT().FindToken(mock.Anything, hashSecret(accessToken)).Return(userToken, nil)
+		users.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(testUser, nil)
+
+		result, err := service.Introspect(context.Background(), creds, accessToken)
+
+		require.NoError(t, err)
+		assert.True(t, result.Active)
+		assert.Equal(t, "7", result.Subject)
+		assert.Equal(t, "alice", result.Username)
+	})
+
+	t.Run("introspect a revoked token", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		revoked := *userToken
+		revokedAt := time.Now()
+		revoked.RevokedAt = &revokedAt
+		storage.EXPECT().FindToken(mock.Anything, mock.Anything).Return(&revoked, nil)
+
+		result, err := service.Introspect(context.Background(), creds, accessToken)
+
+		require.NoError(t, err)
+		assert.Equal(t, &Introspection{Active: false}, result)
+	})
+
+	t.Run("introspection needs a confidential client", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+
+		_, err := service.Introspect(context.Background(), ClientCredentials{ClientID: "spa"}, accessToken)
+
+		assertOAuthError(t, err, ErrorInvalidClient)
+	})
+
+	t.Run("userinfo follows the scopes", func(t *testing.T) {
+		service, storage, users := newTestService(t)
+		storage.EXPECT().FindToken(mock.Anything, mock.Anything).Return(userToken, nil)
+		users.EXPECT().FindUserByID(mock.Anything, uint(7)).Return(testUser, nil)
+
+		info, err := service.UserInfo(context.Background(), accessToken)
+
+		require.NoError(t, err)
+		assert.Equal(t, &UserInfo{Subject: "7", PreferredUsername: "alice", Locale: "vi"}, info)
+	})
+
+	t.Run("userinfo of a client token", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		storage.EXPECT().FindToken(mock.Anything, mock.Anything).Return(&model.OAuthToken{ClientID: "app", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+		_, err := service.UserInfo(context.Background(), accessToken)
+
+		assertOAuthError(t, err, ErrorInsufficientScope)
+	})
+
+	t.Run("userinfo with an unknown token", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		storage.EXPECT().FindToken(mock.Anything, mock.Anything).Return(nil, nil)
+
+		_, err := service.UserInfo(context.Background(), accessToken)
+
+		assertOAuthError(t, err, ErrorInvalidToken)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		service, storage, _ := newTestService(t)
+		storage.EXPECT().RevokeToken(mock.Anything, hashSecret(accessToken), "app", mock.Anything).Return(nil)
+
+		require.NoError(t, service.Revoke(context.Background(), creds, accessToken))
+	})
+}
+
+func TestParseSigningKey(t *testing.T) {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(testKey())
+	require.NoError(t, err)
+
+	for name, block := range map[string]*pem.Block{
+		"PKCS #1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(testKey())},
+		"PKCS #8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		key, err := ParseSigningKey(pem.EncodeToMemory(block))
+		require.NoError(t, err, name)
+		assert.True(t, key.Equal(testKey()), name)
+	}
+
+	_, err = ParseSigningKey([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,419 @@
+package organization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/service/audit"
+	orgRepo "golang-sample/internal/storage/organization"
+	"golang-sample/internal/tenant"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/mailer"
+	"golang-sample/pkg/tracing"
+	"golang-sample/pkg/utils/identity"
+	utilstring "golang-sample/pkg/utils/string"
+)
+
+var tracer = otel.Tracer("golang-sample/internal/service/organization")
+
+// tokenLength is the number of hex characters of an invitation token (256 bits)
+const tokenLength = 64
+
+type impl struct {
+	log      *zap.SugaredLogger
+	orgs     orgRepo.Storage
+	mailer   mailer.Mailer
+	cfg      Config
+	auditLog audit.Recorder
+}
+
+// Option configures optional behavior of the organization service
+type Option func(*impl)
+
+// WithAuditLog records organizations, invitations and membership changes
+// to recorder
+func WithAuditLog(recorder audit.Recorder) Option {
+	return func(s *impl) {
+		s.auditLog = recorder
+	}
+}
+
+func NewOrganizationService(log *zap.SugaredLogger, orgs orgRepo.Storage, m mailer.Mailer, cfg Config, opts ...Option) Service {
+	s := &impl{
+		log:    log,
+		orgs:   orgs,
+		mailer: m,
+		cfg:    cfg.withDefaults(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *impl) Create(ctx context.Context, creator *model.Principal, name string) (_ *model.Organization, err error) {
+	ctx, span := tracer.Start(ctx, "organization.Create")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", creator.UserID)
+
+	org, err := s.orgs.Create(ctx, &model.Organization{Name: name}, creator.UserID)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Organization %d created", org.ID)
+	s.audit(ctx, creator, org.ID, model.AuditEvent{
+		Action:   model.AuditOrgCreated,
+		TargetID: creator.UserID,
+	})
+	return org, nil
+}
+
+func (s *impl) ListForUser(ctx context.Context, userID uint) (_ []*model.Membership, err error) {
+	ctx, span := tracer.Start(ctx, "organization.ListForUser")
+	defer func() { tracing.End(span, err) }()
+
+	memberships, err := s.orgs.ListMembershipsByUser(ctx, userID)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return memberships, nil
+}
+
+func (s *impl) Membership(ctx context.Context, orgID, userID uint) (_ *model.Membership, err error) {
+	ctx, span := tracer.Start(ctx, "organization.Membership")
+	defer func() { tracing.End(span, err) }()
+
+	membership, err := s.orgs.FindMembership(tenant.NewContext(ctx, orgID), userID)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to find membership: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if membership == nil {
+		return nil, ErrAccessDenied
+	}
+	return membership, nil
+}
+
+func (s *impl) Get(ctx context.Context) (_ *model.Organization, err error) {
+	ctx, span := tracer.Start(ctx, "organization.Get")
+	defer func() { tracing.End(span, err) }()
+
+	orgID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, tenant.ErrMissing)
+	}
+	org, err := s.orgs.FindByID(ctx, orgID)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to find organization: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if org == nil {
+		return nil, ErrNotFound
+	}
+	return org, nil
+}
+
+func (s *impl) ListMembers(ctx context.Context) (_ []*model.Membership, err error) {
+	ctx, span := tracer.Start(ctx, "organization.ListMembers")
+	defer func() { tracing.End(span, err) }()
+
+	members, err := s.orgs.ListMembers(ctx)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return members, nil
+}
+
+func (s *impl) ChangeRole(ctx context.Context, caller *model.Principal, userID uint, role model.OrgRole) (err error) {
+	ctx, span := tracer.Start(ctx, "organization.ChangeRole")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", caller.UserID, "org_id", caller.OrgID)
+
+	if !slices.Contains(model.OrgRoles(), role) {
+		return governerrors.NewCode(governerrors.CodeInvalid, "unknown role")
+	}
+
+	member, err := s.member(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !mayManage(caller.OrgRole, member.Role, role) {
+		log.Warnf("Role change of member %d from %s to %s refused to a %s", userID, member.Role, role, caller.OrgRole)
+		return ErrRoleInsufficient
+	}
+	if member.Role == role {
+		return nil
+	}
+
+	if err := s.orgs.UpdateRole(ctx, userID, role); err != nil {
+		return s.memberChangeError(log, err)
+	}
+
+	log.Infof("Role of member %d changed from %s to %s", userID, member.Role, role)
+	s.audit(ctx, caller, caller.OrgID, model.AuditEvent{
+		Action:   model.AuditOrgRoleChanged,
+		TargetID: userID,
+		Metadata: map[string]string{"from": string(member.Role), "to": string(role)},
+	})
+	return nil
+}
+
+func (s *impl) RemoveMember(ctx context.Context, caller *model.Principal, userID uint) (err error) {
+	ctx, span := tracer.Start(ctx, "organization.RemoveMember")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", caller.UserID, "org_id", caller.OrgID)
+
+	member, err := s.member(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if userID != caller.UserID && !mayManage(caller.OrgRole, member.Role, "") {
+		log.Warnf("Removal of %s %d refused to a %s", member.Role, userID, caller.OrgRole)
+		return ErrRoleInsufficient
+	}
+
+	if err := s.orgs.DeleteMembership(ctx, userID); err != nil {
+		return s.memberChangeError(log, err)
+	}
+
+	log.Infof("Member %d removed", userID)
+	s.audit(ctx, caller, caller.OrgID, model.AuditEvent{
+		Action:   model.AuditOrgMemberRemoved,
+		TargetID: userID,
+		Metadata: map[string]string{"role": string(member.Role)},
+	})
+	return nil
+}
+
+// member returns the membership of userID in the organization of ctx
+func (s *impl) member(ctx context.Context, userID uint) (*model.Membership, error) {
+	member, err := s.orgs.FindMembership(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to find member: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// memberChangeError maps a failed role change or removal to a domain error
+func (s *impl) memberChangeError(log *zap.SugaredLogger, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrMemberNotFound
+	case errors.Is(err, orgRepo.ErrLastOwner):
+		return ErrLastOwner
+	}
+	log.Errorf("Failed to change member: %v", err)
+	return governerrors.WrapCode(governerrors.CodeInternal, err)
+}
+
+// mayManage reports whether a caller with role caller may change a member
+// with role target, granting grant unless it is empty. Owners may do
+// anything; admins may not touch owners or make anyone owner.
+func mayManage(caller, target, grant model.OrgRole) bool {
+	if caller == model.OrgRoleOwner {
+		return true
+	}
+	if !caller.AtLeast(model.OrgRoleAdmin) {
+		return false
+	}
+	return target != model.OrgRoleOwner && grant != model.OrgRoleOwner
+}
+
+func (s *impl) Invite(ctx context.Context, req InviteRequest) (_ *model.Invitation, err error) {
+	ctx, span := tracer.Start(ctx, "organization.Invite")
+	defer func() { tracing.End(span, err) }()
+
+	inviter := req.Inviter
+	log := logger.FromContext(ctx, s.log).With("user_id", inviter.UserID, "org_id", inviter.OrgID)
+
+	if !slices.Contains(model.OrgRoles(), req.Role) {
+		return nil, governerrors.NewCode(governerrors.CodeInvalid, "unknown role")
+	}
+	if !mayManage(inviter.OrgRole, "", req.Role) {
+		log.Warnf("Invitation as %s refused to a %s", req.Role, inviter.OrgRole)
+		return nil, ErrRoleInsufficient
+	}
+
+	org, err := s.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utilstring.RandomHexString(tokenLength)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	invitation, err := s.orgs.CreateInvitation(ctx, &model.Invitation{
+		Email:     req.Email,
+		Role:      req.Role,
+		InvitedBy: inviter.UserID,
+		ExpiresAt: time.Now().UTC().Add(s.cfg.InvitationTTL),
+	}, hashToken(token))
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	if err := s.sendInvitation(ctx, org, inviter, invitation, token); err != nil {
+		log.Errorf("Failed to send invitation: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Invitation %d sent", invitation.ID)
+	s.audit(ctx, inviter, org.ID, model.AuditEvent{
+		Action: model.AuditOrgInvitationCreated,
+		Metadata: map[string]string{
+			"invitation_id": strconv.FormatUint(uint64(invitation.ID), 10),
+			"role":          string(invitation.Role),
+		},
+	})
+	return invitation, nil
+}
+
+// sendInvitation emails token to the invitee
+func (s *impl) sendInvitation(ctx context.Context, org *model.Organization, inviter *model.Principal, invitation *model.Invitation, token string) error {
+	action := "Accept it with this token:\n\n" + token
+	if s.cfg.AcceptURL != "" {
+		acceptURL, err := url.Parse(s.cfg.AcceptURL)
+		if err != nil {
+			return err
+		}
+		query := acceptURL.Query()
+		query.Set("token", token)
+		acceptURL.RawQuery = query.Encode()
+		action = "Open this link to accept it:\n\n" + acceptURL.String()
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You are invited to join %s", org.Name),
+		Body: fmt.Sprintf("Hello,\n\n%s invited you to join %s as %s. %s\n\n"+
+			"Sign in with this email address first. The invitation expires in %d days; "+
+			"if you did not expect it, you can ignore this email.\n",
+			inviter.Username, org.Name, invitation.Role, action, int(s.cfg.InvitationTTL.Hours()/24)),
+	})
+}
+
+func (s *impl) ListInvitations(ctx context.Context) (_ []*model.Invitation, err error) {
+	ctx, span := tracer.Start(ctx, "organization.ListInvitations")
+	defer func() { tracing.End(span, err) }()
+
+	invitations, err := s.orgs.ListPendingInvitations(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return invitations, nil
+}
+
+func (s *impl) RevokeInvitation(ctx context.Context, caller *model.Principal, id uint) (err error) {
+	ctx, span := tracer.Start(ctx, "organization.RevokeInvitation")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", caller.UserID, "org_id", caller.OrgID)
+
+	if !caller.OrgRole.AtLeast(model.OrgRoleAdmin) {
+		return ErrRoleInsufficient
+	}
+	if err := s.orgs.DeleteInvitation(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Invitation %d revoked", id)
+	return nil
+}
+
+func (s *impl) AcceptInvitation(ctx context.Context, user *model.Principal, token string) (_ *model.Membership, err error) {
+	ctx, span := tracer.Start(ctx, "organization.AcceptInvitation")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", user.UserID)
+
+	invitation, err := s.orgs.FindInvitationByToken(ctx, hashToken(token))
+	if err != nil {
+		log.Errorf("Failed to find invitation: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	now := time.Now().UTC()
+	if invitation == nil || invitation.AcceptedAt != nil || !now.Before(invitation.ExpiresAt) {
+		log.Infof("Unknown, accepted or expired invitation rejected")
+		return nil, ErrInvitationInvalid
+	}
+	if identity.Normalize(invitation.Email) != identity.Normalize(user.Email) {
+		log.Warnf("Invitation %d addressed to another email rejected", invitation.ID)
+		return nil, ErrInvitationInvalid
+	}
+
+	ctx = tenant.NewContext(ctx, invitation.OrgID)
+	membership, err := s.orgs.AcceptInvitation(ctx, invitation.ID, user.UserID, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, ErrInvitationInvalid
+		case errors.Is(err, orgRepo.ErrAlreadyMember):
+			return nil, ErrAlreadyMember
+		}
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	// For the caller to tell where it joined; the membership stands without it
+	if membership.Organization, err = s.orgs.FindByID(ctx, invitation.OrgID); err != nil {
+		log.Errorf("Failed to find organization: %v", err)
+	}
+
+	log.Infof("Invitation %d accepted", invitation.ID)
+	s.audit(ctx, user, invitation.OrgID, model.AuditEvent{
+		Action:   model.AuditOrgMemberAdded,
+		TargetID: user.UserID,
+		Metadata: map[string]string{
+			"invitation_id": strconv.FormatUint(uint64(invitation.ID), 10),
+			"role":          string(membership.Role),
+		},
+	})
+	return membership, nil
+}
+
+// audit records event by caller in organization orgID, when an audit log
+// is configured
+func (s *impl) audit(ctx context.Context, caller *model.Principal, orgID uint, event model.AuditEvent) {
+	if s.auditLog == nil {
+		return
+	}
+	event.ActorID = caller.UserID
+	event.Actor = caller.Actor
+	if event.Metadata == nil {
+		event.Metadata = map[string]string{}
+	}
+	event.Metadata["org_id"] = strconv.FormatUint(uint64(orgID), 10)
+	s.auditLog.Record(ctx, event)
+}
+
+// hashToken returns the hex SHA-256 of an invitation token, the form
+// tokens are stored and looked up in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
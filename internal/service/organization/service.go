@@ -0,0 +1,91 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/model"
+)
+
+// Domain errors; match them with errors.Is
+var (
+	// ErrAccessDenied is returned by Membership for users who are not
+	// members, and for unknown organizations alike
+	ErrAccessDenied = errcode.New(errcode.OrgAccessDenied, governerrors.CodeForbidden, "not a member of the organization")
+	// ErrRoleInsufficient is returned when the caller's role does not allow
+	// the change
+	ErrRoleInsufficient = errcode.New(errcode.OrgRoleInsufficient, governerrors.CodeForbidden, "insufficient organization role")
+	ErrNotFound         = errcode.New(errcode.OrgNotFound, governerrors.CodeNotFound, "organization not found")
+	ErrMemberNotFound   = errcode.New(errcode.OrgMemberNotFound, governerrors.CodeNotFound, "member not found")
+	// ErrLastOwner is returned when a change would leave the organization
+	// without an owner
+	ErrLastOwner          = errcode.New(errcode.OrgLastOwner, governerrors.CodeConflict, "the organization needs an owner")
+	ErrAlreadyMember      = errcode.New(errcode.OrgAlreadyMember, governerrors.CodeConflict, "already a member of the organization")
+	ErrInvitationNotFound = errcode.New(errcode.OrgInvitationNotFound, governerrors.CodeNotFound, "invitation not found")
+	// ErrInvitationInvalid is returned by AcceptInvitation for unknown,
+	// expired and accepted invitations, and for invitations addressed to
+	// another email alike
+	ErrInvitationInvalid = errcode.New(errcode.OrgInvitationInvalid, governerrors.CodeInvalid, "invalid invitation")
+)
+
+// Service manages organizations and their members. Apart from Create,
+// ListForUser, Membership and AcceptInvitation, methods act in the
+// organization of ctx (see tenant.NewContext), as the Tenant middleware
+// sets it, and take the caller's role from its principal.
+type Service interface {
+	// Create starts an organization with creator as its owner
+	Create(ctx context.Context, creator *model.Principal, name string) (*model.Organization, error)
+	// ListForUser returns the memberships of userID, each with its
+	// organization
+	ListForUser(ctx context.Context, userID uint) ([]*model.Membership, error)
+	// Membership returns the membership of userID in orgID, or
+	// ErrAccessDenied when there is none
+	Membership(ctx context.Context, orgID, userID uint) (*model.Membership, error)
+
+	// Get returns the organization of ctx
+	Get(ctx context.Context) (*model.Organization, error)
+	// ListMembers returns the members, each with its user
+	ListMembers(ctx context.Context) ([]*model.Membership, error)
+	// ChangeRole gives member userID role. Admins manage admins and
+	// members; only owners grant or take away ownership.
+	ChangeRole(ctx context.Context, caller *model.Principal, userID uint, role model.OrgRole) error
+	// RemoveMember removes member userID, under the rules of ChangeRole.
+	// Any member may remove themselves.
+	RemoveMember(ctx context.Context, caller *model.Principal, userID uint) error
+
+	// Invite emails an invitation to join with a role up to the caller's
+	Invite(ctx context.Context, req InviteRequest) (*model.Invitation, error)
+	// ListInvitations returns the pending invitations
+	ListInvitations(ctx context.Context) ([]*model.Invitation, error)
+	// RevokeInvitation deletes pending invitation id
+	RevokeInvitation(ctx context.Context, caller *model.Principal, id uint) error
+	// AcceptInvitation makes user a member with the role of the invitation
+	// token was sent with, returning the membership with its organization.
+	// The invitation must be addressed to the user's email.
+	AcceptInvitation(ctx context.Context, user *model.Principal, token string) (*model.Membership, error)
+}
+
+type InviteRequest struct {
+	Inviter *model.Principal
+	Email   string
+	Role    model.OrgRole
+}
+
+// Config configures invitations
+type Config struct {
+	// AcceptURL is the page invitees open; the token is added as the token
+	// query parameter. Without it the email contains the bare token.
+	AcceptURL string
+	// InvitationTTL defaults to 7 days
+	InvitationTTL time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.InvitationTTL == 0 {
+		c.InvitationTTL = 7 * 24 * time.Hour
+	}
+	return c
+}
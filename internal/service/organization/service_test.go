@@ -0,0 +1,238 @@
+package organization
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	mailerMocks "golang-sample/internal/mocks/mailer"
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+	"golang-sample/internal/service/audit"
+	orgRepo "golang-sample/internal/storage/organization"
+	"golang-sample/internal/tenant"
+	"golang-sample/pkg/mailer"
+)
+
+func newTestService(t *testing.T, opts ...Option) (Service, *storageMocks.MockOrganizationStorage, *mailerMocks.MockMailer) {
+	t.Helper()
+	orgs := storageMocks.NewMockOrganizationStorage(t)
+	m := mailerMocks.NewMockMailer(t)
+	cfg := Config{AcceptURL: "https://app.example.com/invitations/accept"}
+	return NewOrganizationService(zap.NewNop().Sugar(), orgs, m, cfg, opts...), orgs, m
+}
+
+func caller(role model.OrgRole) *model.Principal {
+	return &model.Principal{UserID: 1, Username: "alice", Email: "alice@example.com", OrgID: 9, OrgRole: role}
+}
+
+func TestService_Membership(t *testing.T) {
+	service, orgs, _ := newTestService(t)
+	orgs.EXPECT().FindMembership(mock.Anything, uint(1)).
+		RunAndReturn(func(ctx context.Context, _ uint) (*model.Membership, error) {
+			orgID, _ := tenant.FromContext(ctx)
+			if orgID == 9 {
+				return &model.Membership{OrgID: 9, UserID: 1, Role: model.OrgRoleAdmin}, nil
+			}
+			return nil, nil
+		})
+
+	membership, err := service.Membership(context.Background(), 9, 1)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrgRoleAdmin, membership.Role)
+
+	_, err = service.Membership(context.Background(), 10, 1)
+	assert.ErrorIs(t, err, ErrAccessDenied)
+	assert.True(t, governerrors.IsCode(err, governerrors.CodeForbidden))
+}
+
+func TestService_ChangeRole(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), 9)
+
+	tests := []struct {
+		name    string
+		caller  model.OrgRole
+		target  model.OrgRole
+		role    model.OrgRole
+		wantErr error
+	}{
+		{name: "owner makes an admin owner", caller: model.OrgRoleOwner, target: model.OrgRoleAdmin, role: model.OrgRoleOwner},
+		{name: "admin promotes a member", caller: model.OrgRoleAdmin, target: model.OrgRoleMember, role: model.OrgRoleAdmin},
+		{name: "admin cannot grant ownership", caller: model.OrgRoleAdmin, target: model.OrgRoleMember, role: model.OrgRoleOwner, wantErr: ErrRoleInsufficient},
+		{name: "admin cannot demote an owner", caller: model.OrgRoleAdmin, target: model.OrgRoleOwner, role: model.OrgRoleMember, wantErr: ErrRoleInsufficient},
+		{name: "member cannot change roles", caller: model.OrgRoleMember, target: model.OrgRoleMember, role: model.OrgRoleAdmin, wantErr: ErrRoleInsufficient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded []model.AuditEvent
+			service, orgs, _ := newTestService(t, WithAuditLog(audit.RecorderFunc(func(_ context.Context, event model.AuditEvent) {
+				recorded = append(recorded, event)
+			})))
+			orgs.EXPECT().FindMembership(mock.Anything, uint(2)).Return(&model.Membership{OrgID: 9, UserID: 2, Role: tt.target}, nil)
+			if tt.wantErr == nil {
+				orgs.EXPECT().UpdateRole(mock.Anything, uint(2), tt.role).Return(nil)
+			}
+
+			err := service.ChangeRole(ctx, caller(tt.caller), 2, tt.role)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, recorded)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, recorded, 1, "role changes are audited")
+			assert.Equal(t, model.AuditOrgRoleChanged, recorded[0].Action)
+			assert.Equal(t, uint(1), recorded[0].ActorID)
+			assert.Equal(t, uint(2), recorded[0].TargetID)
+			assert.Equal(t, map[string]string{"from": string(tt.target), "to": string(tt.role), "org_id": "9"}, recorded[0].Metadata)
+		})
+	}
+
+	t.Run("keeps the last owner", func(t *testing.T) {
+		service, orgs, _ := newTestService(t)
+		orgs.EXPECT().FindMembership(mock.Anything, uint(1)).Return(&model.Membership{UserID: 1, Role: model.OrgRoleOwner}, nil)
+		orgs.EXPECT().UpdateRole(mock.Anything, uint(1), model.OrgRoleMember).Return(orgRepo.ErrLastOwner)
+
+		err := service.ChangeRole(ctx, caller(model.OrgRoleOwner), 1, model.OrgRoleMember)
+		assert.ErrorIs(t, err, ErrLastOwner)
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeConflict))
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		service, orgs, _ := newTestService(t)
+		orgs.EXPECT().FindMembership(mock.Anything, uint(5)).Return(nil, nil)
+
+		assert.ErrorIs(t, service.ChangeRole(ctx, caller(model.OrgRoleOwner), 5, model.OrgRoleAdmin), ErrMemberNotFound)
+	})
+}
+
+func TestService_RemoveMember(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), 9)
+
+	t.Run("members may leave", func(t *testing.T) {
+		service, orgs, _ := newTestService(t)
+		orgs.EXPECT().FindMembership(mock.Anything, uint(1)).Return(&model.Membership{UserID: 1, Role: model.OrgRoleMember}, nil)
+		orgs.EXPECT().DeleteMembership(mock.Anything, uint(1)).Return(nil)
+
+		assert.NoError(t, service.RemoveMember(ctx, caller(model.OrgRoleMember), 1))
+	})
+
+	t.Run("members cannot remove others", func(t *testing.T) {
+		service, orgs, _ := newTestService(t)
+		orgs.EXPECT().FindMembership(mock.Anything, uint(2)).Return(&model.Membership{UserID: 2, Role: model.OrgRoleMember}, nil)
+
+		assert.ErrorIs(t, service.RemoveMember(ctx, caller(model.OrgRoleMember), 2), ErrRoleInsufficient)
+	})
+
+	t.Run("member removed concurrently", func(t *testing.T) {
+		service, orgs, _ := newTestService(t)
+		orgs.EXPECT().FindMembership(mock.Anything, uint(2)).Return(&model.Membership{UserID: 2, Role: model.OrgRoleMember}, nil)
+		orgs.EXPECT().DeleteMembership(mock.Anything, uint(2)).Return(gorm.ErrRecordNotFound)
+
+		assert.ErrorIs(t, service.RemoveMember(ctx, caller(model.OrgRoleAdmin), 2), ErrMemberNotFound)
+	})
+}
+
+func TestService_Invite(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), 9)
+
+	t.Run("emails a link with a token whose hash is stored", func(t *testing.T) {
+		service, orgs, m := newTestService(t)
+		orgs.EXPECT().FindByID(mock.Anything, uint(9)).Return(&model.Organization{ID: 9, Name: "Acme"}, nil)
+
+		var storedHash string
+		orgs.EXPECT().CreateInvitation(mock.Anything, mock.AnythingOfType("*model.Invitation"), mock.AnythingOfType("string")).
+			RunAndReturn(func(_ context.Context, invitation *model.Invitation, tokenHash string) (*model.Invitation, error) {
+				storedHash = tokenHash
+				created := *invitation
+				created.ID, created.OrgID = 3, 9
+				return &created, nil
+			})
+		var sent mailer.Message
+		m.EXPECT().Send(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, msg mailer.Message) error {
+			sent = msg
+			return nil
+		})
+
+		invitation, err := service.Invite(ctx, InviteRequest{Inviter: caller(model.OrgRoleAdmin), Email: "carol@example.com", Role: model.OrgRoleMember})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), invitation.InvitedBy)
+		assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), invitation.ExpiresAt, time.Minute)
+
+		assert.Equal(t, "carol@example.com", sent.To)
+		assert.Contains(t, sent.Subject, "Acme")
+		_, token, found := strings.Cut(sent.Body, "accept?token=")
+		require.True(t, found, sent.Body)
+		token = token[:tokenLength]
+		assert.Equal(t, hashToken(token), storedHash)
+	})
+
+	t.Run("admins cannot invite owners", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+
+		_, err := service.Invite(ctx, InviteRequest{Inviter: caller(model.OrgRoleAdmin), Email: "carol@example.com", Role: model.OrgRoleOwner})
+		assert.ErrorIs(t, err, ErrRoleInsufficient)
+	})
+}
+
+func TestService_AcceptInvitation(t *testing.T) {
+	user := &model.Principal{UserID: 3, Email: "Carol@Example.com"}
+	pending := func() *model.Invitation {
+		return &model.Invitation{ID: 4, OrgID: 9, Email: "carol@example.com", Role: model.OrgRoleAdmin, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	t.Run("joins the organization of the invitation", func(t *testing.T) {
+		var recorded []model.AuditEvent
+		service, orgs, _ := newTestService(t, WithAuditLog(audit.RecorderFunc(func(_ context.Context, event model.AuditEvent) {
+			recorded = append(recorded, event)
+		})))
+		orgs.EXPECT().FindInvitationByToken(mock.Anything, hashToken("token")).Return(pending(), nil)
+		orgs.EXPECT().AcceptInvitation(mock.Anything, uint(4), uint(3), mock.AnythingOfType("time.Time")).
+			RunAndReturn(func(ctx context.Context, _, userID uint, _ time.Time) (*model.Membership, error) {
+				orgID, ok := tenant.FromContext(ctx)
+				require.True(t, ok)
+				return &model.Membership{OrgID: orgID, UserID: userID, Role: model.OrgRoleAdmin}, nil
+			})
+		orgs.EXPECT().FindByID(mock.Anything, uint(9)).Return(&model.Organization{ID: 9, Name: "Acme"}, nil)
+
+		membership, err := service.AcceptInvitation(context.Background(), user, "token")
+		require.NoError(t, err)
+		assert.Equal(t, uint(9), membership.OrgID)
+		assert.Equal(t, "Acme", membership.Organization.Name)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, model.AuditOrgMemberAdded, recorded[0].Action)
+	})
+
+	for name, invitation := range map[string]*model.Invitation{
+		"unknown":           nil,
+		"expired":           {ID: 4, OrgID: 9, Email: "carol@example.com", ExpiresAt: time.Now().Add(-time.Minute)},
+		"accepted":          {ID: 4, OrgID: 9, Email: "carol@example.com", ExpiresAt: time.Now().Add(time.Hour), AcceptedAt: new(time.Time)},
+		"for another email": {ID: 4, OrgID: 9, Email: "mallory@example.com", ExpiresAt: time.Now().Add(time.Hour)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			service, orgs, _ := newTestService(t)
+			orgs.EXPECT().FindInvitationByToken(mock.Anything, hashToken("token")).Return(invitation, nil)
+
+			_, err := service.AcceptInvitation(context.Background(), user, "token")
+			assert.ErrorIs(t, err, ErrInvitationInvalid)
+		})
+	}
+
+	t.Run("already a member", func(t *testing.T) {
+		service, orgs, _ := newTestService(t)
+		orgs.EXPECT().FindInvitationByToken(mock.Anything, hashToken("token")).Return(pending(), nil)
+		orgs.EXPECT().AcceptInvitation(mock.Anything, uint(4), uint(3), mock.AnythingOfType("time.Time")).Return(nil, orgRepo.ErrAlreadyMember)
+
+		_, err := service.AcceptInvitation(context.Background(), user, "token")
+		assert.ErrorIs(t, err, ErrAlreadyMember)
+	})
+}
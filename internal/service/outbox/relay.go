@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/storage/outbox"
+	"golang-sample/pkg/tracing"
+)
+
+var tracer = otel.Tracer("golang-sample/internal/service/outbox")
+
+// RelayConfig tunes a Relay; zero fields take their defaults
+type RelayConfig struct {
+	// PollInterval is the pause between polls of an empty outbox; defaults to 1s
+	PollInterval time.Duration
+	// BatchSize is the number of events claimed at once; defaults to 100
+	BatchSize int
+	// Lease holds claimed events back from other relays; it must exceed
+	// the time a batch takes to publish. Defaults to 1m.
+	Lease time.Duration
+	// MinBackoff and MaxBackoff bound the delay before retrying a failed
+	// event, which doubles with each attempt; they default to 1s and 1h
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Retention is how long published events are kept; defaults to 7 days
+	Retention time.Duration
+}
+
+func (c RelayConfig) withDefaults() RelayConfig {
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.Lease <= 0 {
+		c.Lease = time.Minute
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = time.Second
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		c.MaxBackoff = max(time.Hour, c.MinBackoff)
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+	return c
+}
+
+// cleanupInterval is how often a relay removes expired published events
+const cleanupInterval = time.Hour
+
+// Relay publishes the events in the outbox to a sink. It runs as a
+// graceful service; several relays may share one outbox.
+type Relay struct {
+	log     *zap.SugaredLogger
+	storage outbox.Storage
+	sink    Sink
+	cfg     RelayConfig
+	now     func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewRelay(log *zap.SugaredLogger, storage outbox.Storage, sink Sink, cfg RelayConfig) *Relay {
+	return &Relay{
+		log:     log,
+		storage: storage,
+		sink:    sink,
+		cfg:     cfg.withDefaults(),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start relays events until ctx is done or Shutdown is called
+func (r *Relay) Start(ctx context.Context) error {
+	defer close(r.done)
+
+	r.log.Infof("Relaying outbox events every %s", r.cfg.PollInterval)
+	var lastCleanup time.Time
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.stop:
+			return nil
+		case <-timer.C:
+		}
+
+		// A batch runs to the end even when shutdown starts meanwhile, so
+		// no claimed event waits out its lease
+		batchCtx := context.WithoutCancel(ctx)
+		for {
+			n, err := r.relayBatch(batchCtx)
+			if err != nil || n < r.cfg.BatchSize || r.stopping(ctx) {
+				break
+			}
+		}
+
+		if now := r.now(); now.Sub(lastCleanup) >= cleanupInterval {
+			r.cleanup(batchCtx, now)
+			lastCleanup = now
+		}
+		timer.Reset(r.cfg.PollInterval)
+	}
+}
+
+// Shutdown stops the relay and waits for the batch in progress
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Relay) stopping(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// relayBatch publishes one batch of due events and returns its size
+func (r *Relay) relayBatch(ctx context.Context) (n int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Lease)
+	defer cancel()
+
+	entries, err := r.storage.Claim(ctx, r.now(), r.cfg.Lease, r.cfg.BatchSize)
+	if err != nil {
+		r.log.Errorf("Failed to claim outbox events: %v", err)
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "outbox.RelayBatch")
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(attribute.Int("outbox.batch_size", len(entries)))
+
+	for _, entry := range entries {
+		log := r.log.With("event_id", entry.ID, "event_type", entry.Type)
+
+		if err := r.sink.Publish(ctx, &entry.Event); err != nil {
+			retryAt := r.now().Add(r.backoff(entry.Attempts))
+			log.Warnf("Failed to publish event (attempt %d), retrying at %s: %v", entry.Attempts+1, retryAt.Format(time.RFC3339), err)
+			if err := r.storage.MarkFailed(ctx, entry.ID, retryAt, err.Error()); err != nil {
+				log.Errorf("Failed to record the failed publish: %v", err)
+			}
+			continue
+		}
+
+		// Should this fail, the event is published again after its lease
+		if err := r.storage.MarkPublished(ctx, entry.ID, r.now()); err != nil {
+			log.Errorf("Failed to mark event published: %v", err)
+		}
+	}
+	return len(entries), nil
+}
+
+// backoff returns the delay before retrying an event that failed attempts
+// times before
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.cfg.MinBackoff
+	for range attempts {
+		if delay >= r.cfg.MaxBackoff/2 {
+			return r.cfg.MaxBackoff
+		}
+		delay *= 2
+	}
+	return min(delay, r.cfg.MaxBackoff)
+}
+
+func (r *Relay) cleanup(ctx context.Context, now time.Time) {
+	deleted, err := r.storage.DeletePublishedBefore(ctx, now.Add(-r.cfg.Retention))
+	if err != nil {
+		r.log.Errorf("Failed to delete published outbox events: %v", err)
+		return
+	}
+	if deleted > 0 {
+		r.log.Infof("Deleted %d published outbox events", deleted)
+	}
+}
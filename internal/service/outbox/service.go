@@ -0,0 +1,64 @@
+// Package outbox publishes the domain events written to the outbox. A
+// Relay claims due events and hands each to a Sink; an event is marked
+// published only once the sink accepted it, so sinks see every event at
+// least once and must drop repeats by event ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"golang-sample/internal/model"
+	utilstring "golang-sample/pkg/utils/string"
+)
+
+// Sink delivers events to the systems that react to them
+type Sink interface {
+	// Publish delivers event; an error has it retried later
+	Publish(ctx context.Context, event *model.Event) error
+}
+
+// SinkFunc adapts a function to Sink
+type SinkFunc func(ctx context.Context, event *model.Event) error
+
+func (f SinkFunc) Publish(ctx context.Context, event *model.Event) error {
+	return f(ctx, event)
+}
+
+// NewEvent returns an event of eventType about userID that occurred now,
+// with a new ID and payload as JSON. userID may be 0 when the storage
+// writing the event fills it in.
+func NewEvent(eventType model.EventType, userID uint, payload any) (*model.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	id, err := utilstring.RandomHexString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Event{
+		ID:         id,
+		Type:       eventType,
+		UserID:     userID,
+		Payload:    data,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+// Fanout publishes each event to all of sinks. An event one of them
+// rejects is retried on every sink, which at-least-once delivery allows.
+func Fanout(sinks ...Sink) Sink {
+	return SinkFunc(func(ctx context.Context, event *model.Event) error {
+		var errs []error
+		for _, sink := range sinks {
+			if err := sink.Publish(ctx, event); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
@@ -0,0 +1,170 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+)
+
+func TestNewEvent(t *testing.T) {
+	first, err := NewEvent(model.EventUserLoggedIn, 7, model.UserLoggedIn{Method: "password"})
+	require.NoError(t, err)
+	second, err := NewEvent(model.EventUserLoggedIn, 7, model.UserLoggedIn{Method: "password"})
+	require.NoError(t, err)
+
+	assert.Len(t, first.ID, 32)
+	assert.NotEqual(t, first.ID, second.ID, "each event gets its own ID")
+	assert.Equal(t, model.EventUserLoggedIn, first.Type)
+	assert.Equal(t, uint(7), first.UserID)
+	assert.JSONEq(t, `{"method":"password"}`, string(first.Payload))
+	assert.WithinDuration(t, time.Now(), first.OccurredAt, time.Minute)
+}
+
+func TestRelay_RelayBatch(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []*model.OutboxEntry{
+		{Event: model.Event{ID: "event-1", Type: model.EventUserRegistered, UserID: 7}},
+		{Event: model.Event{ID: "event-2", Type: model.EventUserLoggedIn, UserID: 7}, Attempts: 2},
+	}
+
+	storage := storageMocks.NewMockOutboxStorage(t)
+	storage.EXPECT().Claim(mock.Anything, now, time.Minute, 100).Return(entries, nil)
+	storage.EXPECT().MarkPublished(mock.Anything, "event-1", now).Return(nil)
+	// The third attempt waits four times the minimum backoff
+	storage.EXPECT().MarkFailed(mock.Anything, "event-2", now.Add(4*time.Second), "sink unavailable").Return(nil)
+
+	var published []string
+	sink := SinkFunc(func(_ context.Context, event *model.Event) error {
+		published = append(published, event.ID)
+		if event.ID == "event-2" {
+			return errors.New("sink unavailable")
+		}
+		return nil
+	})
+
+	relay := NewRelay(zap.NewNop().Sugar(), storage, sink, RelayConfig{})
+	relay.now = func() time.Time { return now }
+
+	n, err := relay.relayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"event-1", "event-2"}, published)
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := NewRelay(zap.NewNop().Sugar(), nil, nil, RelayConfig{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, 2*time.Second, relay.backoff(1))
+	assert.Equal(t, 8*time.Second, relay.backoff(3))
+	assert.Equal(t, 10*time.Second, relay.backoff(4))
+	assert.Equal(t, 10*time.Second, relay.backoff(1000))
+}
+
+func TestRelay_StartAndShutdown(t *testing.T) {
+	storage := storageMocks.NewMockOutboxStorage(t)
+	claimed := make(chan struct{}, 1)
+	storage.EXPECT().Claim(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(context.Context, time.Time, time.Duration, int) ([]*model.OutboxEntry, error) {
+			select {
+			case claimed <- struct{}{}:
+			default:
+			}
+			return nil, nil
+		})
+	storage.EXPECT().DeletePublishedBefore(mock.Anything, mock.Anything).Return(0, nil)
+
+	relay := NewRelay(zap.NewNop().Sugar(), storage, NewBus(), RelayConfig{PollInterval: time.Millisecond})
+	errc := make(chan error, 1)
+	go func() { errc <- relay.Start(context.Background()) }()
+
+	<-claimed
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, relay.Shutdown(ctx))
+	require.NoError(t, <-errc)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var gotHeader http.Header
+	var gotBody []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(WebhookConfig{URL: server.URL, Secret: "secret"})
+	event := &model.Event{
+		ID:         "event-1",
+		Type:       model.EventUserRegistered,
+		UserID:     7,
+		Payload:    []byte(`{"method":"password"}`),
+		OccurredAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	require.NoError(t, sink.Publish(context.Background(), event))
+	assert.Equal(t, "event-1", gotHeader.Get(HeaderEventID))
+	assert.Equal(t, "user.registered", gotHeader.Get(HeaderEventType))
+	assert.Equal(t, Sign([]byte("secret"), gotBody), gotHeader.Get(HeaderSignature))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(gotBody, &body))
+	assert.Equal(t, "event-1", body["id"])
+	assert.Equal(t, "user.registered", body["type"])
+	assert.Equal(t, float64(7), body["user_id"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", body["occurred_at"])
+	assert.Equal(t, map[string]any{"method": "password"}, body["payload"])
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, sink.Publish(context.Background(), event), "a non-2xx answer fails the delivery")
+}
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var registered, loggedIn int
+	bus.Subscribe(model.EventUserRegistered, func(context.Context, *model.Event) error {
+		registered++
+		return nil
+	})
+	bus.Subscribe(model.EventUserLoggedIn, func(context.Context, *model.Event) error {
+		loggedIn++
+		return errors.New("handler failed")
+	})
+
+	require.NoError(t, bus.Publish(context.Background(), &model.Event{Type: model.EventUserRegistered}))
+	assert.Error(t, bus.Publish(context.Background(), &model.Event{Type: model.EventUserLoggedIn}))
+	require.NoError(t, bus.Publish(context.Background(), &model.Event{Type: model.EventPasswordChanged}), "events without subscribers are dropped")
+	assert.Equal(t, 1, registered)
+	assert.Equal(t, 1, loggedIn)
+}
+
+func TestFanout(t *testing.T) {
+	var calls int
+	ok := SinkFunc(func(context.Context, *model.Event) error {
+		calls++
+		return nil
+	})
+	failing := SinkFunc(func(context.Context, *model.Event) error {
+		return errors.New("sink unavailable")
+	})
+
+	err := Fanout(failing, ok).Publish(context.Background(), &model.Event{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "a failing sink does not keep the others from the event")
+}
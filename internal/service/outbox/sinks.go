@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+)
+
+// Webhook request headers
+const (
+	// HeaderEventID carries the event ID, for receivers to drop repeats
+	HeaderEventID = "X-Event-ID"
+	// HeaderEventType carries the event type
+	HeaderEventType = "X-Event-Type"
+	// HeaderSignature carries "sha256=" and the hex HMAC-SHA256 of the body
+	// under the webhook secret
+	HeaderSignature = "X-Signature"
+)
+
+// envelope is the JSON form of an event sent to webhooks
+type envelope struct {
+	ID         string          `json:"id"`
+	Type       model.EventType `json:"type"`
+	UserID     uint            `json:"user_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// NewLogSink writes each event to log, for development and for tracing
+// what other sinks were sent
+func NewLogSink(log *zap.SugaredLogger) Sink {
+	return SinkFunc(func(_ context.Context, event *model.Event) error {
+		log.Infow("Domain event",
+			"event_id", event.ID,
+			"event_type", event.Type,
+			"user_id", event.UserID,
+			"payload", string(event.Payload),
+		)
+		return nil
+	})
+}
+
+// WebhookConfig configures a webhook sink
+type WebhookConfig struct {
+	URL string
+	// Secret signs each request in HeaderSignature; requests are unsigned
+	// when it is empty
+	Secret string
+	// Client sends the requests; defaults to a client with a 10s timeout
+	Client *http.Client
+}
+
+var defaultWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewWebhookSink POSTs each event as JSON to cfg.URL. A response other
+// than 2xx counts as a failed delivery.
+func NewWebhookSink(cfg WebhookConfig) Sink {
+	client := cfg.Client
+	if client == nil {
+		client = defaultWebhookClient
+	}
+
+	return SinkFunc(func(ctx context.Context, event *model.Event) error {
+		body, err := json.Marshal(envelope{
+			ID:         event.ID,
+			Type:       event.Type,
+			UserID:     event.UserID,
+			OccurredAt: event.OccurredAt,
+			Payload:    event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(HeaderEventID, event.ID)
+		req.Header.Set(HeaderEventType, string(event.Type))
+		if cfg.Secret != "" {
+			req.Header.Set(HeaderSignature, Sign([]byte(cfg.Secret), body))
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook answered %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// Sign returns the HeaderSignature value of body under secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler reacts to an event delivered through a Bus
+type Handler func(ctx context.Context, event *model.Event) error
+
+// Bus is an in-process sink that hands each event to the handlers
+// subscribed to its type, so that parts of the app can react to events
+// without a broker
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[model.EventType][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[model.EventType][]Handler{}}
+}
+
+// Subscribe has handler called for every event of eventType. A handler
+// error has the event retried, on every handler, so handlers must drop
+// events they have already seen.
+func (b *Bus) Subscribe(eventType model.EventType, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, event *model.Event) error {
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,280 @@
+package passkey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	passkeyRepo "golang-sample/internal/storage/passkey"
+	userRepo "golang-sample/internal/storage/user"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+	"golang-sample/pkg/webauthn"
+)
+
+var tracer = otel.Tracer("golang-sample/internal/service/passkey")
+
+// challengeLength is the number of random bytes of a challenge
+const challengeLength = 32
+
+type impl struct {
+	log      *zap.SugaredLogger
+	passkeys passkeyRepo.Storage
+	users    userRepo.Storage
+	rp       *webauthn.RelyingParty
+}
+
+func NewPasskeyService(log *zap.SugaredLogger, passkeys passkeyRepo.Storage, users userRepo.Storage, rp *webauthn.RelyingParty) Service {
+	return &impl{
+		log:      log,
+		passkeys: passkeys,
+		users:    users,
+		rp:       rp,
+	}
+}
+
+func (s *impl) BeginRegistration(ctx context.Context, userID uint) (_ *webauthn.CreationOptions, err error) {
+	ctx, span := tracer.Start(ctx, "passkey.BeginRegistration")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", userID)
+
+	account, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		log.Errorf("Failed to find user: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if account == nil {
+		return nil, governerrors.ErrUnauthorized
+	}
+
+	existing, err := s.passkeys.ListByUser(ctx, userID)
+	if err != nil {
+		log.Errorf("Failed to list passkeys: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	exclude := make([]webauthn.CredentialDescriptor, len(existing))
+	for i, p := range existing {
+		exclude[i] = webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         webauthn.Encode(p.CredentialID),
+			Transports: p.Transports,
+		}
+	}
+
+	challenge, err := s.issueChallenge(ctx, model.CeremonyRegistration, userID)
+	if err != nil {
+		log.Errorf("Failed to issue WebAuthn challenge: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	return s.rp.CreationOptions(challenge, webauthn.User{
+		ID:          userHandle(userID),
+		Name:        account.Username,
+		DisplayName: account.Username,
+	}, exclude), nil
+}
+
+func (s *impl) FinishRegistration(ctx context.Context, req FinishRegistrationRequest) (_ *model.Passkey, err error) {
+	ctx, span := tracer.Start(ctx, "passkey.FinishRegistration")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", req.UserID)
+
+	resp, challenge, err := webauthn.ParseRegistration(req.Response)
+	if err != nil {
+		log.Infof("Passkey registration rejected: %v", err)
+		return nil, ErrRegistrationFailed
+	}
+	if ok, err := s.consumeChallenge(ctx, model.CeremonyRegistration, req.UserID, challenge); err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	} else if !ok {
+		log.Infof("Passkey registration answers no outstanding challenge")
+		return nil, ErrRegistrationFailed
+	}
+
+	credential, err := s.rp.VerifyRegistration(resp, challenge)
+	if err != nil {
+		log.Infof("Passkey registration rejected: %v", err)
+		return nil, ErrRegistrationFailed
+	}
+
+	created, err := s.passkeys.Create(ctx, &model.Passkey{
+		UserID:         req.UserID,
+		Name:           defaultName(req.Name),
+		CredentialID:   credential.ID,
+		PublicKey:      credential.PublicKey,
+		SignCount:      credential.SignCount,
+		Transports:     credential.Transports,
+		AAGUID:         credential.AAGUID,
+		BackupEligible: credential.BackupEligible,
+	})
+	if err != nil {
+		existing, findErr := s.passkeys.FindByCredentialID(ctx, credential.ID)
+		if findErr == nil && existing != nil {
+			log.Warnf("Passkey registration of an already registered credential")
+			return nil, ErrRegistrationFailed
+		}
+		log.Errorf("Failed to store passkey: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Passkey registered: ID=%d", created.ID)
+	return created, nil
+}
+
+func (s *impl) BeginLogin(ctx context.Context) (_ *webauthn.RequestOptions, err error) {
+	ctx, span := tracer.Start(ctx, "passkey.BeginLogin")
+	defer func() { tracing.End(span, err) }()
+
+	challenge, err := s.issueChallenge(ctx, model.CeremonyLogin, 0)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to issue WebAuthn challenge: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return s.rp.RequestOptions(challenge), nil
+}
+
+func (s *impl) FinishLogin(ctx context.Context, response []byte) (_ *model.User, err error) {
+	ctx, span := tracer.Start(ctx, "passkey.FinishLogin")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log)
+
+	resp, challenge, err := webauthn.ParseAssertion(response)
+	if err != nil {
+		log.Infof("Passkey login rejected: %v", err)
+		return nil, ErrLoginFailed
+	}
+	credentialID, err := resp.CredentialID()
+	if err != nil {
+		return nil, ErrLoginFailed
+	}
+	if ok, err := s.consumeChallenge(ctx, model.CeremonyLogin, 0, challenge); err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	} else if !ok {
+		log.Infof("Passkey login answers no outstanding challenge")
+		return nil, ErrLoginFailed
+	}
+
+	passkey, err := s.passkeys.FindByCredentialID(ctx, credentialID)
+	if err != nil {
+		log.Errorf("Failed to find passkey: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if passkey == nil {
+		log.Infof("Passkey login with an unknown credential")
+		return nil, ErrLoginFailed
+	}
+	log = log.With("user_id", passkey.UserID, "passkey_id", passkey.ID)
+
+	// A discoverable credential names its user; it must be the owner
+	handle, err := resp.UserHandle()
+	if err != nil || (handle != nil && subtle.ConstantTimeCompare(handle, userHandle(passkey.UserID)) != 1) {
+		log.Warnf("Passkey login with a user handle of another user")
+		return nil, ErrLoginFailed
+	}
+
+	signCount, err := s.rp.VerifyAssertion(resp, challenge, passkey.PublicKey, passkey.SignCount)
+	if errors.Is(err, webauthn.ErrSignCountRegression) {
+		log.Warnf("Passkey signature counter did not increase from %d (got %d); the authenticator may be cloned", passkey.SignCount, signCount)
+		return nil, ErrLoginFailed
+	}
+	if err != nil {
+		log.Infof("Passkey login rejected: %v", err)
+		return nil, ErrLoginFailed
+	}
+
+	if err := s.passkeys.RecordUse(ctx, passkey.ID, signCount, time.Now().UTC()); err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	account, err := s.users.FindUserByID(ctx, passkey.UserID)
+	if err != nil {
+		log.Errorf("Failed to find user of passkey: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if account == nil {
+		return nil, ErrLoginFailed
+	}
+	return account, nil
+}
+
+func (s *impl) List(ctx context.Context, userID uint) ([]*model.Passkey, error) {
+	passkeys, err := s.passkeys.ListByUser(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list passkeys: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return passkeys, nil
+}
+
+func (s *impl) Delete(ctx context.Context, userID, id uint) error {
+	log := logger.FromContext(ctx, s.log).With("user_id", userID, "passkey_id", id)
+
+	deleted, err := s.passkeys.Delete(ctx, userID, id)
+	if err != nil {
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+
+	log.Infof("Passkey deleted")
+	return nil
+}
+
+// issueChallenge stores a new challenge of ceremony for userID
+func (s *impl) issueChallenge(ctx context.Context, ceremony string, userID uint) ([]byte, error) {
+	challenge := make([]byte, challengeLength)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+
+	err := s.passkeys.CreateChallenge(ctx, &model.WebAuthnChallenge{
+		UserID:    userID,
+		Ceremony:  ceremony,
+		ExpiresAt: time.Now().Add(s.rp.Timeout()).UTC(),
+	}, hashChallenge(challenge))
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge uses up the challenge a response answers, so that it
+// cannot be replayed
+func (s *impl) consumeChallenge(ctx context.Context, ceremony string, userID uint, challenge []byte) (bool, error) {
+	return s.passkeys.ConsumeChallenge(ctx, ceremony, userID, hashChallenge(challenge), time.Now().UTC())
+}
+
+// hashChallenge returns the stored form of a challenge
+func hashChallenge(challenge []byte) string {
+	sum := sha256.Sum256(challenge)
+	return hex.EncodeToString(sum[:])
+}
+
+// userHandle is the WebAuthn user handle of userID. It is the ID itself,
+// which carries no personal information.
+func userHandle(userID uint) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+// defaultName names a passkey registered without a name
+func defaultName(name string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return "Passkey"
+}
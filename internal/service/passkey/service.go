@@ -0,0 +1,49 @@
+package passkey
+
+import (
+	"context"
+
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/model"
+	"golang-sample/pkg/webauthn"
+)
+
+// Domain errors; match them with errors.Is
+var (
+	// ErrRegistrationFailed is returned by FinishRegistration for a response
+	// that does not verify, answers no outstanding challenge of the user or
+	// registers a credential twice
+	ErrRegistrationFailed = errcode.New(errcode.PasskeyRegistrationFailed, governerrors.CodeInvalid, "passkey registration failed")
+	// ErrLoginFailed is returned by FinishLogin for any assertion that does
+	// not log in, including one from a possibly cloned authenticator
+	ErrLoginFailed = errcode.New(errcode.AuthPasskeyFailed, governerrors.CodeUnauthorized, "passkey login failed")
+	ErrNotFound    = errcode.New(errcode.PasskeyNotFound, governerrors.CodeNotFound, "passkey not found")
+)
+
+type Service interface {
+	// BeginRegistration issues the options for navigator.credentials.create
+	// to add a passkey to user userID
+	BeginRegistration(ctx context.Context, userID uint) (*webauthn.CreationOptions, error)
+	// FinishRegistration verifies the credential created with the options of
+	// BeginRegistration and stores it
+	FinishRegistration(ctx context.Context, req FinishRegistrationRequest) (*model.Passkey, error)
+	// BeginLogin issues the options for navigator.credentials.get. Passkeys
+	// are discoverable, so the user is not known yet.
+	BeginLogin(ctx context.Context) (*webauthn.RequestOptions, error)
+	// FinishLogin verifies an assertion made with the options of BeginLogin
+	// and returns the user whose passkey made it
+	FinishLogin(ctx context.Context, response []byte) (*model.User, error)
+	// List returns the passkeys of userID
+	List(ctx context.Context, userID uint) ([]*model.Passkey, error)
+	// Delete removes passkey id of userID
+	Delete(ctx context.Context, userID, id uint) error
+}
+
+type FinishRegistrationRequest struct {
+	UserID uint
+	Name   string
+	// Response is the JSON of the PublicKeyCredential the browser created
+	Response []byte
+}
@@ -0,0 +1,259 @@
+package passkey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+	"golang-sample/pkg/webauthn"
+	"golang-sample/pkg/webauthn/webauthntest"
+)
+
+const origin = "https://app.example.com"
+
+var alice = &model.User{ID: 42, Username: "alice", Email: "alice@example.com"}
+
+func newTestService(t *testing.T) (Service, *storageMocks.MockPasskeyStorage, *storageMocks.MockStorage) {
+	t.Helper()
+	rp, err := webauthn.New(webauthn.Config{RPID: "example.com", RPName: "Example", Origins: []string{origin}})
+	require.NoError(t, err)
+
+	passkeys := storageMocks.NewMockPasskeyStorage(t)
+	users := storageMocks.NewMockStorage(t)
+	return NewPasskeyService(zap.NewNop().Sugar(), passkeys, users, rp), passkeys, users
+}
+
+// expectChallenges records issued challenges in the mock and lets each be
+// consumed once by its ceremony and user
+func expectChallenges(passkeys *storageMocks.MockPasskeyStorage) {
+	type key struct {
+		ceremony string
+		userID   uint
+		hash     string
+	}
+	issued := map[key]bool{}
+	passkeys.EXPECT().CreateChallenge(mock.Anything, mock.AnythingOfType("*model.WebAuthnChallenge"), mock.AnythingOfType("string")).
+		RunAndReturn(func(_ context.Context, c *model.WebAuthnChallenge, hash string) error {
+			issued[key{c.Ceremony, c.UserID, hash}] = true
+			return nil
+		}).Maybe()
+	passkeys.EXPECT().ConsumeChallenge(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, ceremony string, userID uint, hash string, _ time.Time) (bool, error) {
+			k := key{ceremony, userID, hash}
+			ok := issued[k]
+			delete(issued, k)
+			return ok, nil
+		}).Maybe()
+}
+
+// registerPasskey runs a registration of authenticator for alice and returns the stored passkey
+func registerPasskey(t *testing.T, service Service, passkeys *storageMocks.MockPasskeyStorage, users *storageMocks.MockStorage, authenticator *webauthntest.Authenticator) *model.Passkey {
+	t.Helper()
+	users.EXPECT().FindUserByID(mock.Anything, alice.ID).Return(alice, nil).Once()
+	passkeys.EXPECT().ListByUser(mock.Anything, alice.ID).Return(nil, nil).Once()
+	passkeys.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.Passkey")).
+		RunAndReturn(func(_ context.Context, p *model.Passkey) (*model.Passkey, error) {
+			created := *p
+			created.ID = 5
+			return &created, nil
+		}).Once()
+
+	opts, err := service.BeginRegistration(context.Background(), alice.ID)
+	require.NoError(t, err)
+	created, err := service.FinishRegistration(context.Background(), FinishRegistrationRequest{
+		UserID:   alice.ID,
+		Name:     "Laptop",
+		Response: authenticator.Create(opts),
+	})
+	require.NoError(t, err)
+	return created
+}
+
+func TestService_Registration(t *testing.T) {
+	t.Run("stores the verified credential", func(t *testing.T) {
+		service, passkeys, users := newTestService(t)
+		expectChallenges(passkeys)
+		authenticator := webauthntest.New(origin)
+
+		created := registerPasskey(t, service, passkeys, users, authenticator)
+
+		assert.Equal(t, alice.ID, created.UserID)
+		assert.Equal(t, "Laptop", created.Name)
+		assert.Equal(t, authenticator.CredentialID(), created.CredentialID)
+		assert.NotEmpty(t, created.PublicKey)
+	})
+
+	t.Run("options identify the user by ID and exclude registered passkeys", func(t *testing.T) {
+		service, passkeys, users := newTestService(t)
+		expectChallenges(passkeys)
+		users.EXPECT().FindUserByID(mock.Anything, alice.ID).Return(alice, nil)
+		passkeys.EXPECT().ListByUser(mock.Anything, alice.ID).
+			Return([]*model.Passkey{{ID: 5, CredentialID: []byte{1, 2}, Transports: []string{"internal"}}}, nil)
+
+		opts, err := service.BeginRegistration(context.Background(), alice.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, webauthn.Encode(userHandle(alice.ID)), opts.User.ID)
+		assert.Equal(t, "alice", opts.User.Name)
+		require.Len(t, opts.ExcludeCredentials, 1)
+		assert.Equal(t, webauthn.Encode([]byte{1, 2}), opts.ExcludeCredentials[0].ID)
+	})
+
+	t.Run("a challenge of another user is rejected", func(t *testing.T) {
+		service, passkeys, users := newTestService(t)
+		expectChallenges(passkeys)
+		users.EXPECT().FindUserByID(mock.Anything, alice.ID).Return(alice, nil)
+		passkeys.EXPECT().ListByUser(mock.Anything, alice.ID).Return(nil, nil)
+
+		opts, err := service.BeginRegistration(context.Background(), alice.ID)
+		require.NoError(t, err)
+		_, err = service.FinishRegistration(context.Background(), FinishRegistrationRequest{
+			UserID:   7,
+			Response: webauthntest.New(origin).Create(opts),
+		})
+
+		assert.ErrorIs(t, err, ErrRegistrationFailed)
+	})
+
+	t.Run("a response of another origin is rejected", func(t *testing.T) {
+		service, passkeys, users := newTestService(t)
+		expectChallenges(passkeys)
+		users.EXPECT().FindUserByID(mock.Anything, alice.ID).Return(alice, nil)
+		passkeys.EXPECT().ListByUser(mock.Anything, alice.ID).Return(nil, nil)
+		authenticator := webauthntest.New("https://evil.example")
+
+		opts, err := service.BeginRegistration(context.Background(), alice.ID)
+		require.NoError(t, err)
+		_, err = service.FinishRegistration(context.Background(), FinishRegistrationRequest{
+			UserID:   alice.ID,
+			Response: authenticator.Create(opts),
+		})
+
+		assert.ErrorIs(t, err, ErrRegistrationFailed)
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeInvalid))
+	})
+}
+
+func TestService_Login(t *testing.T) {
+	// setup registers a passkey and makes the mock return it with the
+	// counters recorded by later logins
+	setup := func(t *testing.T) (Service, *storageMocks.MockPasskeyStorage, *storageMocks.MockStorage, *webauthntest.Authenticator, *model.Passkey) {
+		service, passkeys, users := newTestService(t)
+		expectChallenges(passkeys)
+		authenticator := webauthntest.New(origin)
+		stored := registerPasskey(t, service, passkeys, users, authenticator)
+
+		passkeys.EXPECT().FindByCredentialID(mock.Anything, authenticator.CredentialID()).
+			RunAndReturn(func(context.Context, []byte) (*model.Passkey, error) {
+				found := *stored
+				return &found, nil
+			}).Maybe()
+		passkeys.EXPECT().RecordUse(mock.Anything, stored.ID, mock.AnythingOfType("uint32"), mock.AnythingOfType("time.Time")).
+			RunAndReturn(func(_ context.Context, _ uint, signCount uint32, _ time.Time) error {
+				stored.SignCount = signCount
+				return nil
+			}).Maybe()
+		users.EXPECT().FindUserByID(mock.Anything, alice.ID).Return(alice, nil).Maybe()
+		return service, passkeys, users, authenticator, stored
+	}
+
+	login := func(t *testing.T, service Service, authenticator *webauthntest.Authenticator) (*model.User, error) {
+		t.Helper()
+		opts, err := service.BeginLogin(context.Background())
+		require.NoError(t, err)
+		return service.FinishLogin(context.Background(), authenticator.Get(opts))
+	}
+
+	t.Run("logs in the owner and records the counter", func(t *testing.T) {
+		service, _, _, authenticator, stored := setup(t)
+
+		account, err := login(t, service, authenticator)
+
+		require.NoError(t, err)
+		assert.Equal(t, alice, account)
+		assert.Equal(t, authenticator.SignCount, stored.SignCount)
+	})
+
+	t.Run("a response cannot be replayed", func(t *testing.T) {
+		service, _, _, authenticator, _ := setup(t)
+		opts, err := service.BeginLogin(context.Background())
+		require.NoError(t, err)
+		response := authenticator.Get(opts)
+
+		_, err = service.FinishLogin(context.Background(), response)
+		require.NoError(t, err)
+		_, err = service.FinishLogin(context.Background(), response)
+		assert.ErrorIs(t, err, ErrLoginFailed)
+	})
+
+	t.Run("a counter that does not increase is rejected as a possible clone", func(t *testing.T) {
+		service, _, _, authenticator, stored := setup(t)
+		_, err := login(t, service, authenticator)
+		require.NoError(t, err)
+
+		clone := *authenticator
+		clone.SignCount = stored.SignCount - 1
+		_, err = login(t, service, &clone)
+
+		assert.ErrorIs(t, err, ErrLoginFailed)
+		assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+	})
+
+	t.Run("an unknown credential is rejected", func(t *testing.T) {
+		service, passkeys, _, _, _ := setup(t)
+		stranger := webauthntest.New(origin)
+		passkeys.EXPECT().FindByCredentialID(mock.Anything, stranger.CredentialID()).Return(nil, nil)
+
+		_, err := login(t, service, stranger)
+
+		assert.ErrorIs(t, err, ErrLoginFailed)
+	})
+
+	t.Run("a user handle of another user is rejected", func(t *testing.T) {
+		service, _, _, authenticator, _ := setup(t)
+		opts, err := service.BeginLogin(context.Background())
+		require.NoError(t, err)
+
+		var resp webauthn.AssertionResponse
+		require.NoError(t, json.Unmarshal(authenticator.Get(opts), &resp))
+		resp.Response.UserHandle = webauthn.Encode(userHandle(7))
+		response, err := json.Marshal(resp)
+		require.NoError(t, err)
+
+		_, err = service.FinishLogin(context.Background(), response)
+		assert.ErrorIs(t, err, ErrLoginFailed)
+	})
+}
+
+func TestService_Delete(t *testing.T) {
+	t.Run("deletes an own passkey", func(t *testing.T) {
+		service, passkeys, _ := newTestService(t)
+		passkeys.EXPECT().Delete(mock.Anything, uint(42), uint(5)).Return(true, nil)
+
+		assert.NoError(t, service.Delete(context.Background(), 42, 5))
+	})
+
+	t.Run("unknown passkey", func(t *testing.T) {
+		service, passkeys, _ := newTestService(t)
+		passkeys.EXPECT().Delete(mock.Anything, uint(42), uint(5)).Return(false, nil)
+
+		assert.ErrorIs(t, service.Delete(context.Background(), 42, 5), ErrNotFound)
+	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		service, passkeys, _ := newTestService(t)
+		passkeys.EXPECT().Delete(mock.Anything, uint(42), uint(5)).Return(false, errors.New("db down"))
+
+		assert.True(t, governerrors.IsCode(service.Delete(context.Background(), 42, 5), governerrors.CodeInternal))
+	})
+}
@@ -0,0 +1,177 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/service/audit"
+	sessionRepo "golang-sample/internal/storage/session"
+	"golang-sample/pkg/clientinfo"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/tracing"
+	utilstring "golang-sample/pkg/utils/string"
+)
+
+var tracer = otel.Tracer("golang-sample/internal/service/session")
+
+const (
+	// familyLength is the number of hex characters of a token family (128 bits)
+	familyLength = 32
+	// lastSeenResolution limits last-seen writes to one per session per interval
+	lastSeenResolution = time.Minute
+)
+
+type impl struct {
+	log      *zap.SugaredLogger
+	sessions sessionRepo.Storage
+	notifier NewDeviceNotifier
+	auditLog audit.Recorder
+
+	// pending tracks new device notifications sent after their login returned
+	pending sync.WaitGroup
+}
+
+// Option configures optional behavior of the session service
+type Option func(*impl)
+
+// WithNewDeviceNotifier reports logins from new devices to notifier
+func WithNewDeviceNotifier(notifier NewDeviceNotifier) Option {
+	return func(s *impl) {
+		s.notifier = notifier
+	}
+}
+
+// WithAuditLog records revoked sessions to recorder
+func WithAuditLog(recorder audit.Recorder) Option {
+	return func(s *impl) {
+		s.auditLog = recorder
+	}
+}
+
+func NewSessionService(log *zap.SugaredLogger, sessions sessionRepo.Storage, opts ...Option) Service {
+	s := &impl{
+		log:      log,
+		sessions: sessions,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *impl) Start(ctx context.Context, user *model.User, expiresAt time.Time, events ...*model.Event) (_ *model.Session, err error) {
+	ctx, span := tracer.Start(ctx, "session.Start")
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.FromContext(ctx, s.log).With("user_id", user.ID)
+	client := clientinfo.FromContext(ctx)
+
+	// A user's first session is not a new device; there is nothing to compare it with
+	var newDevice bool
+	if s.notifier != nil {
+		anySession, sameUserAgent, err := s.sessions.SeenDevice(ctx, user.ID, client.UserAgent)
+		if err != nil {
+			log.Errorf("Failed to look up known devices: %v", err)
+			return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+		}
+		newDevice = anySession && !sameUserAgent
+	}
+
+	family, err := utilstring.RandomHexString(familyLength)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	now := time.Now().UTC()
+	created, err := s.sessions.Create(ctx, &model.Session{
+		UserID:     user.ID,
+		Family:     family,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt.UTC(),
+	}, events...)
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	if newDevice {
+		s.notifyNewDevice(ctx, log, user, created)
+	}
+
+	log.Infof("Session started: ID=%d", created.ID)
+	return created, nil
+}
+
+// notifyNewDevice reports session to the notifier without delaying the login
+func (s *impl) notifyNewDevice(ctx context.Context, log *zap.SugaredLogger, user *model.User, session *model.Session) {
+	ctx = context.WithoutCancel(ctx)
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.notifier.NotifyNewDevice(ctx, user, session); err != nil {
+			log.Errorf("Failed to notify about a login from a new device: %v", err)
+		}
+	}()
+}
+
+func (s *impl) Check(ctx context.Context, family string) (*model.Session, error) {
+	log := logger.FromContext(ctx, s.log)
+
+	session, err := s.sessions.FindByFamily(ctx, family)
+	if err != nil {
+		log.Errorf("Failed to find session: %v", err)
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	now := time.Now().UTC()
+	if !session.Active(now) {
+		return nil, ErrSessionRevoked
+	}
+
+	if now.Sub(session.LastSeenAt) >= lastSeenResolution {
+		// Bookkeeping only; the request goes on if it fails
+		if err := s.sessions.Touch(ctx, session.ID, now); err != nil {
+			log.Errorf("Failed to record session use: %v", err)
+		}
+	}
+	return session, nil
+}
+
+func (s *impl) List(ctx context.Context, userID uint) ([]*model.Session, error) {
+	sessions, err := s.sessions.ListActiveByUser(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return nil, governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+	return sessions, nil
+}
+
+func (s *impl) Revoke(ctx context.Context, userID, id uint) error {
+	log := logger.FromContext(ctx, s.log).With("user_id", userID, "session_id", id)
+
+	if err := s.sessions.Revoke(ctx, userID, id, time.Now().UTC()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return governerrors.WrapCode(governerrors.CodeInternal, err)
+	}
+
+	log.Infof("Session revoked")
+	if s.auditLog != nil {
+		s.auditLog.Record(ctx, model.AuditEvent{
+			Action:   model.AuditSessionRevoked,
+			ActorID:  userID,
+			TargetID: userID,
+			Metadata: map[string]string{"session_id": strconv.FormatUint(uint64(id), 10)},
+		})
+	}
+	return nil
+}
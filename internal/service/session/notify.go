@@ -0,0 +1,41 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang-sample/internal/model"
+	"golang-sample/pkg/mailer"
+)
+
+type mailNotifier struct {
+	mailer mailer.Mailer
+}
+
+// NewMailNotifier emails the user about logins from new devices
+func NewMailNotifier(m mailer.Mailer) NewDeviceNotifier {
+	return &mailNotifier{mailer: m}
+}
+
+func (n *mailNotifier) NotifyNewDevice(ctx context.Context, user *model.User, session *model.Session) error {
+	userAgent := session.UserAgent
+	if userAgent == "" {
+		userAgent = "unknown"
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hello %s,\n\n", user.Username)
+	body.WriteString("Your account was just signed in to from a new device:\n\n")
+	fmt.Fprintf(&body, "  Device:  %s\n", userAgent)
+	fmt.Fprintf(&body, "  IP:      %s\n", session.IP)
+	fmt.Fprintf(&body, "  Time:    %s\n\n", session.CreatedAt.UTC().Format(time.RFC1123))
+	body.WriteString("If this was not you, sign the device out from your sessions and change your password.\n")
+
+	return n.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "New sign-in to your account",
+		Body:    body.String(),
+	})
+}
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/model"
+)
+
+// Domain errors; match them with errors.Is
+var (
+	// ErrSessionRevoked is returned by Check for a token of a revoked,
+	// expired or unknown session
+	ErrSessionRevoked = errcode.New(errcode.AuthSessionRevoked, governerrors.CodeUnauthorized, "session revoked")
+	ErrNotFound       = errcode.New(errcode.SessionNotFound, governerrors.CodeNotFound, "session not found")
+)
+
+type Service interface {
+	// Start records a login of user from the client in ctx, whose tokens
+	// expire at expiresAt. A login from a device the user has not signed
+	// in from before is reported to the NewDeviceNotifier. events are
+	// stored with the session.
+	Start(ctx context.Context, user *model.User, expiresAt time.Time, events ...*model.Event) (*model.Session, error)
+	// Check returns the active session of a token family and records that
+	// it was seen
+	Check(ctx context.Context, family string) (*model.Session, error)
+	// List returns the active sessions of userID
+	List(ctx context.Context, userID uint) ([]*model.Session, error)
+	// Revoke signs session id of userID out; its tokens stop working
+	// immediately
+	Revoke(ctx context.Context, userID, id uint) error
+}
+
+// NewDeviceNotifier is told about logins from a device the user has not
+// signed in from before, such as to warn the user by email
+type NewDeviceNotifier interface {
+	NotifyNewDevice(ctx context.Context, user *model.User, session *model.Session) error
+}
@@ -0,0 +1,182 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	mailerMocks "golang-sample/internal/mocks/mailer"
+	serviceMocks "golang-sample/internal/mocks/service"
+	storageMocks "golang-sample/internal/mocks/storage"
+	"golang-sample/internal/model"
+	"golang-sample/internal/service/audit"
+	"golang-sample/pkg/clientinfo"
+	"golang-sample/pkg/mailer"
+)
+
+var alice = &model.User{ID: 42, Username: "alice", Email: "alice@example.com"}
+
+func newTestService(t *testing.T) (*impl, *storageMocks.MockSessionStorage, *serviceMocks.MockSessionNewDeviceNotifier) {
+	t.Helper()
+	sessions := storageMocks.NewMockSessionStorage(t)
+	notifier := serviceMocks.NewMockSessionNewDeviceNotifier(t)
+	service := NewSessionService(zap.NewNop().Sugar(), sessions, WithNewDeviceNotifier(notifier))
+	return service.(*impl), sessions, notifier
+}
+
+func expectCreate(sessions *storageMocks.MockSessionStorage) {
+	sessions.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.Session")).
+		RunAndReturn(func(_ context.Context, session *model.Session, _ ...*model.Event) (*model.Session, error) {
+			created := *session
+			created.ID = 9
+			return &created, nil
+		})
+}
+
+func TestService_Start(t *testing.T) {
+	ctx := clientinfo.NewContext(context.Background(), clientinfo.Info{UserAgent: "Firefox", IP: "203.0.113.7"})
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("records the client and a random family", func(t *testing.T) {
+		service, sessions, _ := newTestService(t)
+		sessions.EXPECT().SeenDevice(mock.Anything, uint(42), "Firefox").Return(true, true, nil)
+		expectCreate(sessions)
+
+		first, err := service.Start(ctx, alice, expiresAt)
+		require.NoError(t, err)
+		second, err := service.Start(ctx, alice, expiresAt)
+		require.NoError(t, err)
+		service.pending.Wait()
+
+		assert.Equal(t, "Firefox", first.UserAgent)
+		assert.Equal(t, "203.0.113.7", first.IP)
+		assert.Len(t, first.Family, familyLength)
+		assert.NotEqual(t, first.Family, second.Family)
+		assert.WithinDuration(t, expiresAt, first.ExpiresAt, time.Second)
+	})
+
+	t.Run("notifies about a new device", func(t *testing.T) {
+		service, sessions, notifier := newTestService(t)
+		sessions.EXPECT().SeenDevice(mock.Anything, uint(42), "Firefox").Return(true, false, nil)
+		expectCreate(sessions)
+		notifier.EXPECT().NotifyNewDevice(mock.Anything, alice, mock.MatchedBy(func(s *model.Session) bool {
+			return s.ID == 9 && s.UserAgent == "Firefox"
+		})).Return(nil).Once()
+
+		_, err := service.Start(ctx, alice, expiresAt)
+		require.NoError(t, err)
+		service.pending.Wait()
+	})
+
+	t.Run("the first session is not a new device", func(t *testing.T) {
+		service, sessions, _ := newTestService(t)
+		sessions.EXPECT().SeenDevice(mock.Anything, uint(42), "Firefox").Return(false, false, nil)
+		expectCreate(sessions)
+
+		_, err := service.Start(ctx, alice, expiresAt)
+		require.NoError(t, err)
+		service.pending.Wait()
+	})
+
+	t.Run("a failed notification does not fail the login", func(t *testing.T) {
+		service, sessions, notifier := newTestService(t)
+		sessions.EXPECT().SeenDevice(mock.Anything, uint(42), "Firefox").Return(true, false, nil)
+		expectCreate(sessions)
+		notifier.EXPECT().NotifyNewDevice(mock.Anything, alice, mock.Anything).Return(errors.New("smtp down"))
+
+		_, err := service.Start(ctx, alice, expiresAt)
+		require.NoError(t, err)
+		service.pending.Wait()
+	})
+}
+
+func TestService_Check(t *testing.T) {
+	now := time.Now().UTC()
+	revokedAt := now.Add(-time.Minute)
+
+	t.Run("accepts an active session and records it was seen", func(t *testing.T) {
+		service, sessions, _ := newTestService(t)
+		sessions.EXPECT().FindByFamily(mock.Anything, "fam").
+			Return(&model.Session{ID: 9, UserID: 42, LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}, nil)
+		sessions.EXPECT().Touch(mock.Anything, uint(9), mock.AnythingOfType("time.Time")).Return(nil)
+
+		session, err := service.Check(context.Background(), "fam")
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(9), session.ID)
+	})
+
+	t.Run("does not write last seen on every request", func(t *testing.T) {
+		service, sessions, _ := newTestService(t)
+		sessions.EXPECT().FindByFamily(mock.Anything, "fam").
+			Return(&model.Session{ID: 9, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}, nil)
+
+		_, err := service.Check(context.Background(), "fam")
+		require.NoError(t, err)
+	})
+
+	for name, session := range map[string]*model.Session{
+		"unknown": nil,
+		"revoked": {ID: 9, LastSeenAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt},
+		"expired": {ID: 9, LastSeenAt: now, ExpiresAt: now.Add(-time.Second)},
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			service, sessions, _ := newTestService(t)
+			sessions.EXPECT().FindByFamily(mock.Anything, "fam").Return(session, nil)
+
+			_, err := service.Check(context.Background(), "fam")
+
+			assert.ErrorIs(t, err, ErrSessionRevoked)
+			assert.True(t, governerrors.IsCode(err, governerrors.CodeUnauthorized))
+		})
+	}
+}
+
+func TestService_Revoke(t *testing.T) {
+	t.Run("revokes an own session", func(t *testing.T) {
+		service, sessions, _ := newTestService(t)
+		sessions.EXPECT().Revoke(mock.Anything, uint(42), uint(9), mock.AnythingOfType("time.Time")).Return(nil)
+		var recorded []model.AuditEvent
+		WithAuditLog(audit.RecorderFunc(func(_ context.Context, event model.AuditEvent) {
+			recorded = append(recorded, event)
+		}))(service)
+
+		assert.NoError(t, service.Revoke(context.Background(), 42, 9))
+		require.Len(t, recorded, 1)
+		assert.Equal(t, model.AuditSessionRevoked, recorded[0].Action)
+		assert.Equal(t, uint(42), recorded[0].TargetID)
+		assert.Equal(t, "9", recorded[0].Metadata["session_id"])
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		service, sessions, _ := newTestService(t)
+		sessions.EXPECT().Revoke(mock.Anything, uint(42), uint(9), mock.AnythingOfType("time.Time")).Return(gorm.ErrRecordNotFound)
+
+		assert.ErrorIs(t, service.Revoke(context.Background(), 42, 9), ErrNotFound)
+	})
+}
+
+func TestMailNotifier(t *testing.T) {
+	m := mailerMocks.NewMockMailer(t)
+	m.EXPECT().Send(mock.Anything, mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == "alice@example.com" &&
+			assert.Contains(t, msg.Body, "Firefox") &&
+			assert.Contains(t, msg.Body, "203.0.113.7")
+	})).Return(nil)
+
+	err := NewMailNotifier(m).NotifyNewDevice(context.Background(), alice, &model.Session{
+		UserAgent: "Firefox",
+		IP:        "203.0.113.7",
+		CreatedAt: time.Now(),
+	})
+
+	assert.NoError(t, err)
+}
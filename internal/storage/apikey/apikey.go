@@ -0,0 +1,69 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/pkg/logger"
+)
+
+func (s *repo) Create(ctx context.Context, key *model.APIKey, keyHash string) (*model.APIKey, error) {
+	ormKey := modelToORM(key)
+	ormKey.KeyHash = keyHash
+
+	if err := s.db.WithContext(ctx).Create(ormKey).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create API key: %v", err)
+		return nil, err
+	}
+	return ormToModel(ormKey), nil
+}
+
+func (s *repo) ListByUser(ctx context.Context, userID uint) ([]*model.APIKey, error) {
+	var ormKeys []*orm.APIKey
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC, id DESC").
+		Find(&ormKeys).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list API keys: %v", err)
+		return nil, err
+	}
+	return ormSliceToModelSlice(ormKeys), nil
+}
+
+func (s *repo) FindByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var ormKey *orm.APIKey
+	err := s.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&ormKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ormToModel(ormKey), nil
+}
+
+func (s *repo) Revoke(ctx context.Context, userID, id uint, at time.Time) error {
+	result := s.db.WithContext(ctx).Model(&orm.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to revoke API key: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *repo) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&orm.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
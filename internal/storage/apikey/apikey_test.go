@@ -0,0 +1,93 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	storage := New(zap.NewNop().Sugar(), storagetest.OpenDB(t, &orm.APIKey{}))
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	first, err := storage.Create(ctx, &model.APIKey{
+		UserID:    1,
+		Name:      "ci",
+		Prefix:    "sk_aaaaaaaa",
+		Scopes:    []model.Scope{model.ScopeAPIKeysRead, model.ScopePasswordWrite},
+		ExpiresAt: &expiresAt,
+	}, "hash-1")
+	require.NoError(t, err)
+	assert.NotZero(t, first.ID)
+
+	second, err := storage.Create(ctx, &model.APIKey{UserID: 1, Name: "deploy", Prefix: "sk_bbbbbbbb"}, "hash-2")
+	require.NoError(t, err)
+	_, err = storage.Create(ctx, &model.APIKey{UserID: 2, Name: "other user", Prefix: "sk_cccccccc"}, "hash-3")
+	require.NoError(t, err)
+
+	t.Run("duplicate hash is rejected", func(t *testing.T) {
+		_, err := storage.Create(ctx, &model.APIKey{UserID: 1, Name: "dup", Prefix: "sk_aaaaaaaa"}, "hash-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("find by hash", func(t *testing.T) {
+		found, err := storage.FindByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, first.ID, found.ID)
+		assert.Equal(t, []model.Scope{model.ScopeAPIKeysRead, model.ScopePasswordWrite}, found.Scopes)
+		require.NotNil(t, found.ExpiresAt)
+		assert.True(t, expiresAt.Equal(*found.ExpiresAt))
+
+		found, err = storage.FindByHash(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("list only own keys", func(t *testing.T) {
+		keys, err := storage.ListByUser(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, second.ID, keys[0].ID, "newest first")
+	})
+
+	t.Run("touch last used", func(t *testing.T) {
+		now := time.Now().UTC().Truncate(time.Second)
+		require.NoError(t, storage.TouchLastUsed(ctx, first.ID, now))
+
+		found, err := storage.FindByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		require.NotNil(t, found.LastUsedAt)
+		assert.True(t, now.Equal(*found.LastUsedAt))
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		assert.ErrorIs(t, storage.Revoke(ctx, 2, first.ID, time.Now()), gorm.ErrRecordNotFound, "another user's key")
+		require.NoError(t, storage.Revoke(ctx, 1, first.ID, time.Now()))
+		assert.ErrorIs(t, storage.Revoke(ctx, 1, first.ID, time.Now()), gorm.ErrRecordNotFound, "already revoked")
+
+		keys, err := storage.ListByUser(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, second.ID, keys[0].ID)
+
+		found, err := storage.FindByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.NotNil(t, found.RevokedAt)
+	})
+}
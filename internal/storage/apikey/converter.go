@@ -0,0 +1,65 @@
+package apikey
+
+import (
+	"strings"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// ormToModel converts ORM APIKey to domain APIKey
+func ormToModel(k *orm.APIKey) *model.APIKey {
+	if k == nil {
+		return nil
+	}
+
+	var scopes []model.Scope
+	for _, scope := range strings.Fields(k.Scopes) {
+		scopes = append(scopes, model.Scope(scope))
+	}
+
+	return &model.APIKey{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+// modelToORM converts domain APIKey to ORM APIKey, without the key hash
+func modelToORM(k *model.APIKey) *orm.APIKey {
+	if k == nil {
+		return nil
+	}
+
+	scopes := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return &orm.APIKey{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     strings.Join(scopes, " "),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+// ormSliceToModelSlice converts slice of ORM APIKeys to domain APIKeys
+func ormSliceToModelSlice(keys []*orm.APIKey) []*model.APIKey {
+	result := make([]*model.APIKey, len(keys))
+	for i, k := range keys {
+		result[i] = ormToModel(k)
+	}
+	return result
+}
@@ -0,0 +1,38 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+type Storage interface {
+	// Create stores key with the hash of its secret and returns it with its ID
+	Create(ctx context.Context, key *model.APIKey, keyHash string) (*model.APIKey, error)
+	// ListByUser returns the keys of userID that are not revoked, newest first
+	ListByUser(ctx context.Context, userID uint) ([]*model.APIKey, error)
+	// FindByHash finds the key whose secret hashes to keyHash, revoked or not;
+	// key is nil when not found
+	FindByHash(ctx context.Context, keyHash string) (key *model.APIKey, err error)
+	// Revoke marks key id of userID revoked; gorm.ErrRecordNotFound when
+	// userID has no such active key
+	Revoke(ctx context.Context, userID, id uint, at time.Time) error
+	// TouchLastUsed records that key id authenticated a request at at
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage"
+	"golang-sample/pkg/logger"
+)
+
+func (s *repo) Append(ctx context.Context, event *model.AuditEvent) (*model.AuditEvent, error) {
+	ormEvent, err := modelToORM(event)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Create(ormEvent).Error; err != nil {
+		if storage.IsDuplicate(err) {
+			return nil, ErrChainConflict
+		}
+		logger.FromContext(ctx, s.log).Errorf("Failed to append audit event: %v", err)
+		return nil, err
+	}
+	return ormToModel(ormEvent)
+}
+
+func (s *repo) Last(ctx context.Context) (*model.AuditEvent, error) {
+	var ormEvent *orm.AuditEvent
+	err := s.db.WithContext(ctx).Order("id DESC").First(&ormEvent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ormToModel(ormEvent)
+}
+
+func (s *repo) List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEvent, error) {
+	query := s.db.WithContext(ctx).Order("id DESC").Limit(filter.Limit)
+	if filter.Action != "" {
+		query = query.Where("action = ?", string(filter.Action))
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	var ormEvents []*orm.AuditEvent
+	if err := query.Find(&ormEvents).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list audit events: %v", err)
+		return nil, err
+	}
+	return ormListToModel(ormEvents)
+}
+
+func (s *repo) ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.AuditEvent, error) {
+	var ormEvents []*orm.AuditEvent
+	err := s.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&ormEvents).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list audit events: %v", err)
+		return nil, err
+	}
+	return ormListToModel(ormEvents)
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	storage := New(zap.NewNop().Sugar(), storagetest.OpenDB(t, &orm.AuditEvent{}))
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	last, err := storage.Last(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, last)
+
+	first, err := storage.Append(ctx, &model.AuditEvent{
+		Action:    model.AuditLoginFailed,
+		TargetID:  7,
+		IP:        "203.0.113.7",
+		Metadata:  map[string]string{"method": "password"},
+		CreatedAt: now.Add(-time.Hour),
+		Hash:      "hash-1",
+	})
+	require.NoError(t, err)
+	second, err := storage.Append(ctx, &model.AuditEvent{
+		Action:    model.AuditLoginSucceeded,
+		ActorID:   7,
+		TargetID:  7,
+		RequestID: "req-2",
+		CreatedAt: now,
+		PrevHash:  "hash-1",
+		Hash:      "hash-2",
+	})
+	require.NoError(t, err)
+
+	_, err = storage.Append(ctx, &model.AuditEvent{Action: model.AuditLoginSucceeded, CreatedAt: now, PrevHash: "hash-1", Hash: "fork"})
+	assert.ErrorIs(t, err, ErrChainConflict)
+
+	last, err = storage.Last(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, last.ID)
+	assert.Equal(t, "hash-2", last.Hash)
+
+	all, err := storage.List(ctx, model.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, second.ID, all[0].ID, "newest first")
+	assert.Equal(t, map[string]string{"method": "password"}, all[1].Metadata)
+
+	filtered, err := storage.List(ctx, model.AuditFilter{TargetID: 7, Action: model.AuditLoginFailed, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, first.ID, filtered[0].ID)
+
+	filtered, err = storage.List(ctx, model.AuditFilter{Since: now.Add(-time.Minute), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "req-2", filtered[0].RequestID)
+
+	page, err := storage.List(ctx, model.AuditFilter{BeforeID: second.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, first.ID, page[0].ID)
+
+	walked, err := storage.ListAfter(ctx, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, walked, 1)
+	assert.Equal(t, first.ID, walked[0].ID, "oldest first")
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"encoding/json"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// ormToModel converts ORM AuditEvent to domain AuditEvent
+func ormToModel(e *orm.AuditEvent) (*model.AuditEvent, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(e.Metadata), &metadata); err != nil {
+		return nil, err
+	}
+
+	return &model.AuditEvent{
+		ID:        e.ID,
+		Action:    model.AuditAction(e.Action),
+		ActorID:   e.ActorID,
+		Actor:     e.Actor,
+		TargetID:  e.TargetID,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		RequestID: e.RequestID,
+		Metadata:  metadata,
+		CreatedAt: e.CreatedAt,
+		PrevHash:  e.PrevHash,
+		Hash:      e.Hash,
+	}, nil
+}
+
+// modelToORM converts domain AuditEvent to ORM AuditEvent
+func modelToORM(e *model.AuditEvent) (*orm.AuditEvent, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	metadata := e.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &orm.AuditEvent{
+		ID:        e.ID,
+		Action:    string(e.Action),
+		ActorID:   e.ActorID,
+		Actor:     e.Actor,
+		TargetID:  e.TargetID,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		RequestID: e.RequestID,
+		Metadata:  string(encoded),
+		CreatedAt: e.CreatedAt,
+		PrevHash:  e.PrevHash,
+		Hash:      e.Hash,
+	}, nil
+}
+
+func ormListToModel(events []*orm.AuditEvent) ([]*model.AuditEvent, error) {
+	result := make([]*model.AuditEvent, len(events))
+	for i, event := range events {
+		converted, err := ormToModel(event)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = converted
+	}
+	return result, nil
+}
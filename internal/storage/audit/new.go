@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+// ErrChainConflict is returned by Append when a concurrent writer extended
+// the chain first; read the new last entry and try again
+var ErrChainConflict = errors.New("audit chain was extended concurrently")
+
+// Storage appends to and reads the audit log; it offers no way to change
+// or remove an entry
+type Storage interface {
+	// Append stores event with its hashes already set. It returns
+	// ErrChainConflict when another entry already follows PrevHash.
+	Append(ctx context.Context, event *model.AuditEvent) (*model.AuditEvent, error)
+	// Last returns the most recent event; event is nil when the log is empty
+	Last(ctx context.Context) (event *model.AuditEvent, err error)
+	// List returns the events matching filter, newest first
+	List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEvent, error)
+	// ListAfter returns up to limit events with an ID above afterID, oldest
+	// first, for walking the chain
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]*model.AuditEvent, error)
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,68 @@
+package identity
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// ormToModel converts ORM Identity to domain Identity
+func ormToModel(i *orm.Identity) *model.Identity {
+	if i == nil {
+		return nil
+	}
+
+	return &model.Identity{
+		ID:        i.ID,
+		UserID:    i.UserID,
+		Provider:  i.Provider,
+		Subject:   i.Subject,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+// modelToORM converts domain Identity to ORM Identity
+func modelToORM(i *model.Identity) *orm.Identity {
+	if i == nil {
+		return nil
+	}
+
+	return &orm.Identity{
+		ID:        i.ID,
+		UserID:    i.UserID,
+		Provider:  i.Provider,
+		Subject:   i.Subject,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
+// userORMToModel converts ORM User to domain User
+func userORMToModel(u *orm.User) *model.User {
+	if u == nil {
+		return nil
+	}
+
+	return &model.User{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Locale:    u.Locale,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+// userModelToORM converts domain User to ORM User, without a password hash
+func userModelToORM(u *model.User) *orm.User {
+	if u == nil {
+		return nil
+	}
+
+	return &orm.User{
+		ID:       u.ID,
+		Username: u.Username,
+		Email:    u.Email,
+		Locale:   u.Locale,
+	}
+}
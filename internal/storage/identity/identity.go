@@ -0,0 +1,61 @@
+package identity
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/outbox"
+	"golang-sample/pkg/logger"
+)
+
+func (s *repo) Find(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	var ormIdentity *orm.Identity
+	err := s.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&ormIdentity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ormToModel(ormIdentity), nil
+}
+
+func (s *repo) Create(ctx context.Context, identity *model.Identity) (*model.Identity, error) {
+	ormIdentity := modelToORM(identity)
+
+	if err := s.db.WithContext(ctx).Create(ormIdentity).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create identity: %v", err)
+		return nil, err
+	}
+	return ormToModel(ormIdentity), nil
+}
+
+func (s *repo) CreateWithUser(ctx context.Context, user *model.User, identity *model.Identity, events ...*model.Event) (*model.User, *model.Identity, error) {
+	ormUser := userModelToORM(user)
+	ormIdentity := modelToORM(identity)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ormUser).Error; err != nil {
+			return err
+		}
+		ormIdentity.UserID = ormUser.ID
+		if err := tx.Create(ormIdentity).Error; err != nil {
+			return err
+		}
+		for _, event := range events {
+			if event.UserID == 0 {
+				event.UserID = ormUser.ID
+			}
+		}
+		return outbox.Append(tx, events)
+	})
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create user with identity: %v", err)
+		return nil, nil, err
+	}
+	return userORMToModel(ormUser), ormToModel(ormIdentity), nil
+}
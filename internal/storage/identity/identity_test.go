@@ -0,0 +1,93 @@
+package identity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.User{}, &orm.Identity{}, &orm.OutboxEvent{})
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+
+	existing := &orm.User{Username: "alice", Email: "alice@example.com", PasswordHash: "hash"}
+	require.NoError(t, db.Create(existing).Error)
+
+	t.Run("link to an existing user", func(t *testing.T) {
+		created, err := storage.Create(ctx, &model.Identity{UserID: existing.ID, Provider: "google", Subject: "g-1", Email: "alice@example.com"})
+		require.NoError(t, err)
+		assert.NotZero(t, created.ID)
+
+		found, err := storage.Find(ctx, "google", "g-1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, existing.ID, found.UserID)
+		assert.Equal(t, "alice@example.com", found.Email)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		found, err := storage.Find(ctx, "github", "g-1")
+		require.NoError(t, err)
+		assert.Nil(t, found, "subjects are scoped to their provider")
+	})
+
+	t.Run("subject is unique per provider", func(t *testing.T) {
+		_, err := storage.Create(ctx, &model.Identity{UserID: existing.ID + 1, Provider: "google", Subject: "g-1"})
+		assert.Error(t, err)
+	})
+
+	t.Run("create with user", func(t *testing.T) {
+		user, created, err := storage.CreateWithUser(ctx,
+			&model.User{Username: "bob", Email: "bob@example.com"},
+			&model.Identity{Provider: "github", Subject: "42", Email: "bob@example.com"})
+		require.NoError(t, err)
+		assert.NotZero(t, user.ID)
+		assert.Equal(t, user.ID, created.UserID)
+
+		var stored orm.User
+		require.NoError(t, db.First(&stored, user.ID).Error)
+		assert.Empty(t, stored.PasswordHash)
+		assert.Equal(t, "bob", stored.UsernameNormalized)
+	})
+
+	t.Run("create with user writes events", func(t *testing.T) {
+		event := &model.Event{ID: "event-dave", Type: model.EventUserRegistered, OccurredAt: time.Now()}
+		user, _, err := storage.CreateWithUser(ctx,
+			&model.User{Username: "dave", Email: "dave@example.com"},
+			&model.Identity{Provider: "github", Subject: "43"},
+			event)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, event.UserID)
+
+		var stored orm.OutboxEvent
+		require.NoError(t, db.Where("event_id = ?", "event-dave").First(&stored).Error)
+		assert.Equal(t, user.ID, stored.UserID)
+	})
+
+	t.Run("create with user is atomic", func(t *testing.T) {
+		_, _, err := storage.CreateWithUser(ctx,
+			&model.User{Username: "carol", Email: "carol@example.com"},
+			&model.Identity{Provider: "google", Subject: "g-1"},
+			&model.Event{ID: "event-carol", Type: model.EventUserRegistered, OccurredAt: time.Now()})
+		require.Error(t, err)
+
+		var count int64
+		require.NoError(t, db.Model(&orm.User{}).Where("username = ?", "carol").Count(&count).Error)
+		assert.Zero(t, count, "the user is rolled back with the identity")
+		require.NoError(t, db.Model(&orm.OutboxEvent{}).Where("event_id = ?", "event-carol").Count(&count).Error)
+		assert.Zero(t, count, "the event is rolled back with the identity")
+	})
+}
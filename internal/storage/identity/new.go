@@ -0,0 +1,33 @@
+package identity
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+type Storage interface {
+	// Find finds the identity of subject at provider; identity is nil when not found
+	Find(ctx context.Context, provider, subject string) (identity *model.Identity, err error)
+	// Create links identity to its existing user
+	Create(ctx context.Context, identity *model.Identity) (*model.Identity, error)
+	// CreateWithUser creates user without a password, links identity to it
+	// and writes events to the outbox, all or none; events without a UserID
+	// get the new user's
+	CreateWithUser(ctx context.Context, user *model.User, identity *model.Identity, events ...*model.Event) (*model.User, *model.Identity, error)
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,36 @@
+package magiclink
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// ormToModel converts ORM MagicLink to domain MagicLink
+func ormToModel(l *orm.MagicLink) *model.MagicLink {
+	if l == nil {
+		return nil
+	}
+
+	return &model.MagicLink{
+		ID:        l.ID,
+		UserID:    l.UserID,
+		ExpiresAt: l.ExpiresAt,
+		UsedAt:    l.UsedAt,
+		CreatedAt: l.CreatedAt,
+	}
+}
+
+// modelToORM converts domain MagicLink to ORM MagicLink
+func modelToORM(l *model.MagicLink) *orm.MagicLink {
+	if l == nil {
+		return nil
+	}
+
+	return &orm.MagicLink{
+		ID:        l.ID,
+		UserID:    l.UserID,
+		ExpiresAt: l.ExpiresAt,
+		UsedAt:    l.UsedAt,
+		CreatedAt: l.CreatedAt,
+	}
+}
@@ -0,0 +1,72 @@
+package magiclink
+
+import (
+	"context"
+	"time"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *repo) Create(ctx context.Context, link *model.MagicLink) (*model.MagicLink, error) {
+	ormLink := modelToORM(link)
+
+	if err := s.db.WithContext(ctx).Create(ormLink).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create magic link: %v", err)
+		return nil, err
+	}
+	return ormToModel(ormLink), nil
+}
+
+func (s *repo) CreateWithinLimit(ctx context.Context, link *model.MagicLink, since time.Time, max int) (*model.MagicLink, error) {
+	ormLink := modelToORM(link)
+	created := false
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the user's row makes concurrent requests for one user
+		// count and insert one after another
+		var user orm.User
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Select("id").
+			Where("id = ?", link.UserID).
+			Take(&user).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&orm.MagicLink{}).
+			Where("user_id = ? AND created_at >= ?", link.UserID, since).
+			Count(&count).Error
+		if err != nil || count >= int64(max) {
+			return err
+		}
+
+		created = true
+		return tx.Create(ormLink).Error
+	})
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create magic link: %v", err)
+		return nil, err
+	}
+	if !created {
+		return nil, nil
+	}
+	return ormToModel(ormLink), nil
+}
+
+func (s *repo) Consume(ctx context.Context, id, userID uint, at time.Time) (bool, error) {
+	// A single conditional update settles concurrent uses of one link
+	result := s.db.WithContext(ctx).Model(&orm.MagicLink{}).
+		Where("id = ? AND user_id = ? AND used_at IS NULL AND expires_at > ?", id, userID, at).
+		Update("used_at", at)
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to consume magic link: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,76 @@
+package magiclink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.User{}, &orm.MagicLink{})
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	t.Run("a link is consumed once", func(t *testing.T) {
+		link, err := storage.Create(ctx, &model.MagicLink{UserID: 1, ExpiresAt: now.Add(15 * time.Minute)})
+		require.NoError(t, err)
+		require.NotZero(t, link.ID)
+
+		ok, err := storage.Consume(ctx, link.ID, 2, now)
+		require.NoError(t, err)
+		assert.False(t, ok, "another user's link")
+
+		ok, err = storage.Consume(ctx, link.ID, 1, now)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = storage.Consume(ctx, link.ID, 1, now)
+		require.NoError(t, err)
+		assert.False(t, ok, "second use")
+	})
+
+	t.Run("an expired link is not consumed", func(t *testing.T) {
+		link, err := storage.Create(ctx, &model.MagicLink{UserID: 1, ExpiresAt: now.Add(-time.Second)})
+		require.NoError(t, err)
+
+		ok, err := storage.Consume(ctx, link.ID, 1, now)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("creates links up to the limit of a user", func(t *testing.T) {
+		require.NoError(t, db.Create(&orm.User{ID: 3, Username: "linker", Email: "linker@example.com"}).Error)
+		_, err := storage.Create(ctx, &model.MagicLink{UserID: 3, ExpiresAt: now, CreatedAt: now.Add(-2 * time.Hour)})
+		require.NoError(t, err)
+
+		link, err := storage.CreateWithinLimit(ctx, &model.MagicLink{UserID: 3, ExpiresAt: now}, now.Add(-time.Hour), 2)
+		require.NoError(t, err)
+		require.NotNil(t, link)
+		assert.NotZero(t, link.ID)
+
+		link, err = storage.CreateWithinLimit(ctx, &model.MagicLink{UserID: 3, ExpiresAt: now}, now.Add(-time.Hour), 2)
+		require.NoError(t, err)
+		require.NotNil(t, link)
+
+		link, err = storage.CreateWithinLimit(ctx, &model.MagicLink{UserID: 3, ExpiresAt: now}, now.Add(-time.Hour), 2)
+		require.NoError(t, err)
+		assert.Nil(t, link, "past the limit")
+
+		var count int64
+		require.NoError(t, db.Model(&orm.MagicLink{}).Where("user_id = ?", 3).Count(&count).Error)
+		assert.Equal(t, int64(3), count)
+	})
+}
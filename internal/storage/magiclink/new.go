@@ -0,0 +1,35 @@
+package magiclink
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+type Storage interface {
+	// Create records a link that has been issued
+	Create(ctx context.Context, link *model.MagicLink) (*model.MagicLink, error)
+	// CreateWithinLimit records link unless its user has been issued max
+	// links at or after since; created is nil then. Concurrent calls for one
+	// user are serialized, so together they cannot exceed max either.
+	CreateWithinLimit(ctx context.Context, link *model.MagicLink, since time.Time, max int) (created *model.MagicLink, err error)
+	// Consume marks link id of user userID used at at. It reports false when
+	// the link is unknown, already used or expired by at.
+	Consume(ctx context.Context, id, userID uint, at time.Time) (bool, error)
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,119 @@
+package oauthserver
+
+import (
+	"strings"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// clientORMToModel converts ORM OAuthClient to domain OAuthClient
+func clientORMToModel(c *orm.OAuthClient) *model.OAuthClient {
+	if c == nil {
+		return nil
+	}
+
+	return &model.OAuthClient{
+		ID:           c.ID,
+		ClientID:     c.ClientID,
+		Name:         c.Name,
+		RedirectURIs: strings.Fields(c.RedirectURIs),
+		GrantTypes:   strings.Fields(c.GrantTypes),
+		Scopes:       strings.Fields(c.Scopes),
+		Confidential: c.SecretHash != "",
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+// clientModelToORM converts domain OAuthClient to ORM OAuthClient, without the secret hash
+func clientModelToORM(c *model.OAuthClient) *orm.OAuthClient {
+	if c == nil {
+		return nil
+	}
+
+	return &orm.OAuthClient{
+		ID:           c.ID,
+		ClientID:     c.ClientID,
+		Name:         c.Name,
+		RedirectURIs: strings.Join(c.RedirectURIs, " "),
+		GrantTypes:   strings.Join(c.GrantTypes, " "),
+		Scopes:       strings.Join(c.Scopes, " "),
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+// codeORMToModel converts ORM OAuthCode to domain OAuthCode
+func codeORMToModel(c *orm.OAuthCode) *model.OAuthCode {
+	if c == nil {
+		return nil
+	}
+
+	return &model.OAuthCode{
+		ID:            c.ID,
+		ClientID:      c.ClientID,
+		UserID:        c.UserID,
+		RedirectURI:   c.RedirectURI,
+		Scopes:        strings.Fields(c.Scopes),
+		Nonce:         c.Nonce,
+		CodeChallenge: c.CodeChallenge,
+		ExpiresAt:     c.ExpiresAt,
+		UsedAt:        c.UsedAt,
+		CreatedAt:     c.CreatedAt,
+	}
+}
+
+// codeModelToORM converts domain OAuthCode to ORM OAuthCode, without the code hash
+func codeModelToORM(c *model.OAuthCode) *orm.OAuthCode {
+	if c == nil {
+		return nil
+	}
+
+	return &orm.OAuthCode{
+		ID:            c.ID,
+		ClientID:      c.ClientID,
+		UserID:        c.UserID,
+		RedirectURI:   c.RedirectURI,
+		Scopes:        strings.Join(c.Scopes, " "),
+		Nonce:         c.Nonce,
+		CodeChallenge: c.CodeChallenge,
+		ExpiresAt:     c.ExpiresAt,
+		UsedAt:        c.UsedAt,
+		CreatedAt:     c.CreatedAt,
+	}
+}
+
+// tokenORMToModel converts ORM OAuthToken to domain OAuthToken
+func tokenORMToModel(t *orm.OAuthToken) *model.OAuthToken {
+	if t == nil {
+		return nil
+	}
+
+	return &model.OAuthToken{
+		ID:        t.ID,
+		ClientID:  t.ClientID,
+		UserID:    t.UserID,
+		CodeID:    t.CodeID,
+		Scopes:    strings.Fields(t.Scopes),
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// tokenModelToORM converts domain OAuthToken to ORM OAuthToken, without the token hash
+func tokenModelToORM(t *model.OAuthToken) *orm.OAuthToken {
+	if t == nil {
+		return nil
+	}
+
+	return &orm.OAuthToken{
+		ID:        t.ID,
+		ClientID:  t.ClientID,
+		UserID:    t.UserID,
+		CodeID:    t.CodeID,
+		Scopes:    strings.Join(t.Scopes, " "),
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+		CreatedAt: t.CreatedAt,
+	}
+}
@@ -0,0 +1,45 @@
+package oauthserver
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+// Storage keeps the clients, authorization codes and access tokens of the
+// authorization server. Secrets are passed in hashed.
+type Storage interface {
+	// CreateClient stores client with the hash of its secret, empty for a
+	// public client
+	CreateClient(ctx context.Context, client *model.OAuthClient, secretHash string) (*model.OAuthClient, error)
+	ListClients(ctx context.Context) ([]*model.OAuthClient, error)
+	// FindClient finds a client by its client ID; client is nil when not found
+	FindClient(ctx context.Context, clientID string) (client *model.OAuthClient, secretHash string, err error)
+	CreateCode(ctx context.Context, code *model.OAuthCode, codeHash string) (*model.OAuthCode, error)
+	// ConsumeCode marks the code used at at. firstUse is false when it was
+	// already used; code is nil when not found.
+	ConsumeCode(ctx context.Context, codeHash string, at time.Time) (code *model.OAuthCode, firstUse bool, err error)
+	CreateToken(ctx context.Context, token *model.OAuthToken, tokenHash string) (*model.OAuthToken, error)
+	// FindToken finds a token, active or not; token is nil when not found
+	FindToken(ctx context.Context, tokenHash string) (token *model.OAuthToken, err error)
+	// RevokeToken revokes the token if it was issued to clientID
+	RevokeToken(ctx context.Context, tokenHash, clientID string, at time.Time) error
+	// RevokeTokensByCode revokes the tokens issued for code codeID
+	RevokeTokensByCode(ctx context.Context, codeID uint, at time.Time) error
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,129 @@
+package oauthserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/pkg/logger"
+)
+
+func (s *repo) CreateClient(ctx context.Context, client *model.OAuthClient, secretHash string) (*model.OAuthClient, error) {
+	ormClient := clientModelToORM(client)
+	ormClient.SecretHash = secretHash
+
+	if err := s.db.WithContext(ctx).Create(ormClient).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create OAuth client: %v", err)
+		return nil, err
+	}
+	return clientORMToModel(ormClient), nil
+}
+
+func (s *repo) ListClients(ctx context.Context) ([]*model.OAuthClient, error) {
+	var ormClients []*orm.OAuthClient
+	if err := s.db.WithContext(ctx).Order("id").Find(&ormClients).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list OAuth clients: %v", err)
+		return nil, err
+	}
+
+	clients := make([]*model.OAuthClient, len(ormClients))
+	for i, c := range ormClients {
+		clients[i] = clientORMToModel(c)
+	}
+	return clients, nil
+}
+
+func (s *repo) FindClient(ctx context.Context, clientID string) (*model.OAuthClient, string, error) {
+	var ormClient *orm.OAuthClient
+	err := s.db.WithContext(ctx).Where("client_id = ?", clientID).First(&ormClient).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return clientORMToModel(ormClient), ormClient.SecretHash, nil
+}
+
+func (s *repo) CreateCode(ctx context.Context, code *model.OAuthCode, codeHash string) (*model.OAuthCode, error) {
+	ormCode := codeModelToORM(code)
+	ormCode.CodeHash = codeHash
+
+	if err := s.db.WithContext(ctx).Create(ormCode).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create authorization code: %v", err)
+		return nil, err
+	}
+	return codeORMToModel(ormCode), nil
+}
+
+func (s *repo) ConsumeCode(ctx context.Context, codeHash string, at time.Time) (*model.OAuthCode, bool, error) {
+	var ormCode *orm.OAuthCode
+	err := s.db.WithContext(ctx).Where("code_hash = ?", codeHash).First(&ormCode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if ormCode.UsedAt != nil {
+		return codeORMToModel(ormCode), false, nil
+	}
+
+	// The condition on used_at settles concurrent exchanges of one code
+	result := s.db.WithContext(ctx).Model(&orm.OAuthCode{}).
+		Where("id = ? AND used_at IS NULL", ormCode.ID).
+		Update("used_at", at)
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to consume authorization code: %v", result.Error)
+		return nil, false, result.Error
+	}
+	ormCode.UsedAt = &at
+	return codeORMToModel(ormCode), result.RowsAffected == 1, nil
+}
+
+func (s *repo) CreateToken(ctx context.Context, token *model.OAuthToken, tokenHash string) (*model.OAuthToken, error) {
+	ormToken := tokenModelToORM(token)
+	ormToken.TokenHash = tokenHash
+
+	if err := s.db.WithContext(ctx).Create(ormToken).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create access token: %v", err)
+		return nil, err
+	}
+	return tokenORMToModel(ormToken), nil
+}
+
+func (s *repo) FindToken(ctx context.Context, tokenHash string) (*model.OAuthToken, error) {
+	var ormToken *orm.OAuthToken
+	err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&ormToken).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokenORMToModel(ormToken), nil
+}
+
+func (s *repo) RevokeToken(ctx context.Context, tokenHash, clientID string, at time.Time) error {
+	err := s.db.WithContext(ctx).Model(&orm.OAuthToken{}).
+		Where("token_hash = ? AND client_id = ? AND revoked_at IS NULL", tokenHash, clientID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to revoke access token: %v", err)
+	}
+	return err
+}
+
+func (s *repo) RevokeTokensByCode(ctx context.Context, codeID uint, at time.Time) error {
+	err := s.db.WithContext(ctx).Model(&orm.OAuthToken{}).
+		Where("code_id = ? AND revoked_at IS NULL", codeID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to revoke access tokens of a code: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package oauthserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Clients_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	storage := New(zap.NewNop().Sugar(), storagetest.OpenDB(t, &orm.OAuthClient{}, &orm.OAuthCode{}, &orm.OAuthToken{}))
+	ctx := context.Background()
+
+	_, err := storage.CreateClient(ctx, &model.OAuthClient{
+		ClientID:     "confidential",
+		Name:         "Reports",
+		RedirectURIs: []string{"https://reports.example/cb", "http://localhost:3000/cb"},
+		GrantTypes:   []string{model.GrantAuthorizationCode, model.GrantClientCredentials},
+		Scopes:       []string{model.OAuthScopeOpenID, "reports:read"},
+	}, "secret-hash")
+	require.NoError(t, err)
+	_, err = storage.CreateClient(ctx, &model.OAuthClient{ClientID: "public", Name: "SPA"}, "")
+	require.NoError(t, err)
+
+	client, secretHash, err := storage.FindClient(ctx, "confidential")
+	require.NoError(t, err)
+	require.NotNil(t, client)
+	assert.Equal(t, "secret-hash", secretHash)
+	assert.True(t, client.Confidential)
+	assert.Equal(t, []string{"https://reports.example/cb", "http://localhost:3000/cb"}, client.RedirectURIs)
+	assert.Equal(t, []string{model.OAuthScopeOpenID, "reports:read"}, client.Scopes)
+
+	client, _, err = storage.FindClient(ctx, "public")
+	require.NoError(t, err)
+	assert.False(t, client.Confidential)
+
+	client, _, err = storage.FindClient(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, client)
+
+	clients, err := storage.ListClients(ctx)
+	require.NoError(t, err)
+	assert.Len(t, clients, 2)
+
+	_, err = storage.CreateClient(ctx, &model.OAuthClient{ClientID: "public", Name: "dup"}, "")
+	assert.Error(t, err, "client IDs are unique")
+}
+
+func TestRepo_CodesAndTokens_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	storage := New(zap.NewNop().Sugar(), storagetest.OpenDB(t, &orm.OAuthClient{}, &orm.OAuthCode{}, &orm.OAuthToken{}))
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	code, err := storage.CreateCode(ctx, &model.OAuthCode{
+		ClientID:      "app",
+		UserID:        7,
+		RedirectURI:   "https://app.example/cb",
+		Scopes:        []string{model.OAuthScopeOpenID},
+		Nonce:         "n",
+		CodeChallenge: "challenge",
+		ExpiresAt:     now.Add(time.Minute),
+	}, "code-hash")
+	require.NoError(t, err)
+
+	t.Run("a code is consumed once", func(t *testing.T) {
+		consumed, firstUse, err := storage.ConsumeCode(ctx, "code-hash", now)
+		require.NoError(t, err)
+		assert.True(t, firstUse)
+		assert.Equal(t, code.ID, consumed.ID)
+		assert.Equal(t, "challenge", consumed.CodeChallenge)
+
+		consumed, firstUse, err = storage.ConsumeCode(ctx, "code-hash", now)
+		require.NoError(t, err)
+		assert.False(t, firstUse)
+		assert.NotNil(t, consumed.UsedAt)
+
+		consumed, _, err = storage.ConsumeCode(ctx, "unknown", now)
+		require.NoError(t, err)
+		assert.Nil(t, consumed)
+	})
+
+	_, err = storage.CreateToken(ctx, &model.OAuthToken{
+		ClientID: "app", UserID: 7, CodeID: code.ID, Scopes: []string{model.OAuthScopeOpenID}, ExpiresAt: now.Add(time.Hour),
+	}, "token-hash")
+	require.NoError(t, err)
+	_, err = storage.CreateToken(ctx, &model.OAuthToken{ClientID: "app", ExpiresAt: now.Add(time.Hour)}, "machine-hash")
+	require.NoError(t, err)
+
+	t.Run("find token", func(t *testing.T) {
+		token, err := storage.FindToken(ctx, "token-hash")
+		require.NoError(t, err)
+		require.NotNil(t, token)
+		assert.Equal(t, uint(7), token.UserID)
+		assert.True(t, token.Active(now))
+
+		token, err = storage.FindToken(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+	})
+
+	t.Run("only the owning client revokes", func(t *testing.T) {
+		require.NoError(t, storage.RevokeToken(ctx, "machine-hash", "other", now))
+		token, err := storage.FindToken(ctx, "machine-hash")
+		require.NoError(t, err)
+		assert.True(t, token.Active(now))
+
+		require.NoError(t, storage.RevokeToken(ctx, "machine-hash", "app", now))
+		token, err = storage.FindToken(ctx, "machine-hash")
+		require.NoError(t, err)
+		assert.False(t, token.Active(now))
+	})
+
+	t.Run("revoke the tokens of a code", func(t *testing.T) {
+		require.NoError(t, storage.RevokeTokensByCode(ctx, code.ID, now))
+		token, err := storage.FindToken(ctx, "token-hash")
+		require.NoError(t, err)
+		assert.False(t, token.Active(now))
+	})
+}
@@ -0,0 +1,106 @@
+package organization
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// orgORMToModel converts ORM Organization to domain Organization
+func orgORMToModel(o *orm.Organization) *model.Organization {
+	if o == nil {
+		return nil
+	}
+
+	return &model.Organization{
+		ID:        o.ID,
+		Name:      o.Name,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+// orgModelToORM converts domain Organization to ORM Organization
+func orgModelToORM(o *model.Organization) *orm.Organization {
+	if o == nil {
+		return nil
+	}
+
+	return &orm.Organization{
+		ID:        o.ID,
+		Name:      o.Name,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+// membershipORMToModel converts ORM Membership to domain Membership,
+// along with its preloaded Organization and User
+func membershipORMToModel(m *orm.Membership) *model.Membership {
+	if m == nil {
+		return nil
+	}
+
+	membership := &model.Membership{
+		ID:           m.ID,
+		OrgID:        m.OrgID,
+		UserID:       m.UserID,
+		Role:         model.OrgRole(m.Role),
+		CreatedAt:    m.CreatedAt,
+		Organization: orgORMToModel(m.Organization),
+	}
+	if m.User != nil {
+		membership.User = &model.User{
+			ID:        m.User.ID,
+			Username:  m.User.Username,
+			Email:     m.User.Email,
+			Locale:    m.User.Locale,
+			CreatedAt: m.User.CreatedAt,
+			UpdatedAt: m.User.UpdatedAt,
+		}
+	}
+	return membership
+}
+
+// membershipListToModel converts a list of ORM Memberships
+func membershipListToModel(memberships []*orm.Membership) []*model.Membership {
+	result := make([]*model.Membership, len(memberships))
+	for i, membership := range memberships {
+		result[i] = membershipORMToModel(membership)
+	}
+	return result
+}
+
+// invitationORMToModel converts ORM Invitation to domain Invitation
+func invitationORMToModel(i *orm.Invitation) *model.Invitation {
+	if i == nil {
+		return nil
+	}
+
+	return &model.Invitation{
+		ID:         i.ID,
+		OrgID:      i.OrgID,
+		Email:      i.Email,
+		Role:       model.OrgRole(i.Role),
+		InvitedBy:  i.InvitedBy,
+		ExpiresAt:  i.ExpiresAt,
+		CreatedAt:  i.CreatedAt,
+		AcceptedAt: i.AcceptedAt,
+	}
+}
+
+// invitationModelToORM converts domain Invitation to ORM Invitation,
+// without a token hash
+func invitationModelToORM(i *model.Invitation) *orm.Invitation {
+	if i == nil {
+		return nil
+	}
+
+	return &orm.Invitation{
+		ID:         i.ID,
+		OrgID:      i.OrgID,
+		Email:      i.Email,
+		Role:       string(i.Role),
+		InvitedBy:  i.InvitedBy,
+		ExpiresAt:  i.ExpiresAt,
+		CreatedAt:  i.CreatedAt,
+		AcceptedAt: i.AcceptedAt,
+	}
+}
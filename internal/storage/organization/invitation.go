@@ -0,0 +1,136 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage"
+	"golang-sample/internal/tenant"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/utils/identity"
+)
+
+func (s *repo) CreateInvitation(ctx context.Context, invitation *model.Invitation, tokenHash string) (*model.Invitation, error) {
+	ormInvitation := invitationModelToORM(invitation)
+	ormInvitation.TokenHash = tokenHash
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Scopes(tenant.Scope(ctx)).
+			Where("email_normalized = ? AND accepted_at IS NULL", identity.Normalize(invitation.Email)).
+			Delete(&orm.Invitation{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Scopes(tenant.Scope(ctx)).Create(ormInvitation).Error
+	})
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create invitation: %v", err)
+		return nil, err
+	}
+	return invitationORMToModel(ormInvitation), nil
+}
+
+func (s *repo) ListPendingInvitations(ctx context.Context, now time.Time) ([]*model.Invitation, error) {
+	var ormInvitations []*orm.Invitation
+	err := s.db.WithContext(ctx).
+		Scopes(tenant.Scope(ctx)).
+		Where("accepted_at IS NULL AND expires_at > ?", now).
+		Order("id DESC").
+		Find(&ormInvitations).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list invitations: %v", err)
+		return nil, err
+	}
+
+	invitations := make([]*model.Invitation, len(ormInvitations))
+	for i, invitation := range ormInvitations {
+		invitations[i] = invitationORMToModel(invitation)
+	}
+	return invitations, nil
+}
+
+func (s *repo) DeleteInvitation(ctx context.Context, id uint) error {
+	result := s.db.WithContext(ctx).
+		Scopes(tenant.Scope(ctx)).
+		Where("id = ? AND accepted_at IS NULL", id).
+		Delete(&orm.Invitation{})
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to delete invitation: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *repo) FindInvitationByToken(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	var ormInvitation *orm.Invitation
+	err := s.db.WithContext(ctx).
+		Scopes(tenant.AllTenants).
+		Where("token_hash = ?", tokenHash).
+		First(&ormInvitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invitationORMToModel(ormInvitation), nil
+}
+
+func (s *repo) AcceptInvitation(ctx context.Context, id, userID uint, at time.Time) (*model.Membership, error) {
+	var ormMembership *orm.Membership
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claiming the invitation first makes a concurrent accept of the
+		// same token affect no rows
+		result := tx.Model(&orm.Invitation{}).
+			Scopes(tenant.Scope(ctx)).
+			Where("id = ? AND accepted_at IS NULL", id).
+			Update("accepted_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var ormInvitation *orm.Invitation
+		if err := tx.Scopes(tenant.Scope(ctx)).First(&ormInvitation, id).Error; err != nil {
+			return err
+		}
+
+		var members int64
+		err := tx.Model(&orm.Membership{}).
+			Scopes(tenant.Scope(ctx)).
+			Where("user_id = ?", userID).
+			Count(&members).Error
+		if err != nil {
+			return err
+		}
+		if members > 0 {
+			return ErrAlreadyMember
+		}
+
+		ormMembership = &orm.Membership{UserID: userID, Role: ormInvitation.Role}
+		if err := tx.Scopes(tenant.Scope(ctx)).Create(ormMembership).Error; err != nil {
+			if storage.IsDuplicate(err) {
+				return ErrAlreadyMember
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, ErrAlreadyMember) {
+			logger.FromContext(ctx, s.log).Errorf("Failed to accept invitation: %v", err)
+		}
+		return nil, err
+	}
+	return membershipORMToModel(ormMembership), nil
+}
@@ -0,0 +1,77 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+var (
+	// ErrLastOwner is returned when a change would leave an organization
+	// without an owner
+	ErrLastOwner = errors.New("organization would have no owner")
+	// ErrAlreadyMember is returned when accepting an invitation for a user
+	// who is a member already
+	ErrAlreadyMember = errors.New("user is a member already")
+)
+
+// Storage holds organizations, memberships and invitations. Memberships
+// and invitations belong to an organization; unless documented otherwise,
+// methods act in the organization of ctx (see tenant.NewContext) and fail
+// without one.
+type Storage interface {
+	// Create stores org with ownerID as its first owner
+	Create(ctx context.Context, org *model.Organization, ownerID uint) (*model.Organization, error)
+	// FindByID finds any organization; org is nil when not found
+	FindByID(ctx context.Context, id uint) (org *model.Organization, err error)
+	// ListMembershipsByUser returns the memberships of userID across
+	// organizations, each with its Organization, oldest first
+	ListMembershipsByUser(ctx context.Context, userID uint) ([]*model.Membership, error)
+
+	// FindMembership finds the membership of userID; membership is nil when
+	// userID is not a member
+	FindMembership(ctx context.Context, userID uint) (membership *model.Membership, err error)
+	// ListMembers returns the memberships, each with its User, oldest first
+	ListMembers(ctx context.Context) ([]*model.Membership, error)
+	// UpdateRole changes the role of member userID; gorm.ErrRecordNotFound
+	// when there is no such member, ErrLastOwner when it is the only owner
+	// and role is not owner
+	UpdateRole(ctx context.Context, userID uint, role model.OrgRole) error
+	// DeleteMembership removes member userID; gorm.ErrRecordNotFound when
+	// there is no such member, ErrLastOwner when it is the only owner
+	DeleteMembership(ctx context.Context, userID uint) error
+
+	// CreateInvitation stores invitation with the hash of its token,
+	// replacing pending invitations to the same email
+	CreateInvitation(ctx context.Context, invitation *model.Invitation, tokenHash string) (*model.Invitation, error)
+	// ListPendingInvitations returns the invitations neither accepted nor
+	// expired at now, newest first
+	ListPendingInvitations(ctx context.Context, now time.Time) ([]*model.Invitation, error)
+	// DeleteInvitation removes pending invitation id; gorm.ErrRecordNotFound
+	// when there is none
+	DeleteInvitation(ctx context.Context, id uint) error
+	// FindInvitationByToken finds an invitation by token hash in any
+	// organization, accepted or not; invitation is nil when not found
+	FindInvitationByToken(ctx context.Context, tokenHash string) (invitation *model.Invitation, err error)
+	// AcceptInvitation marks invitation id accepted at at and makes userID
+	// a member with its role; gorm.ErrRecordNotFound when it was accepted
+	// already, ErrAlreadyMember when userID is a member already
+	AcceptInvitation(ctx context.Context, id, userID uint, at time.Time) (*model.Membership, error)
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,136 @@
+package organization
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/tenant"
+	"golang-sample/pkg/logger"
+)
+
+func (s *repo) Create(ctx context.Context, org *model.Organization, ownerID uint) (*model.Organization, error) {
+	ormOrg := orgModelToORM(org)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ormOrg).Error; err != nil {
+			return err
+		}
+		orgCtx := tenant.NewContext(ctx, ormOrg.ID)
+		return tx.Scopes(tenant.Scope(orgCtx)).Create(&orm.Membership{
+			UserID: ownerID,
+			Role:   string(model.OrgRoleOwner),
+		}).Error
+	})
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create organization: %v", err)
+		return nil, err
+	}
+	return orgORMToModel(ormOrg), nil
+}
+
+func (s *repo) FindByID(ctx context.Context, id uint) (*model.Organization, error) {
+	var ormOrg *orm.Organization
+	err := s.db.WithContext(ctx).First(&ormOrg, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return orgORMToModel(ormOrg), nil
+}
+
+func (s *repo) ListMembershipsByUser(ctx context.Context, userID uint) ([]*model.Membership, error) {
+	var ormMemberships []*orm.Membership
+	err := s.db.WithContext(ctx).
+		Scopes(tenant.AllTenants).
+		Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&ormMemberships).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list memberships: %v", err)
+		return nil, err
+	}
+	return membershipListToModel(ormMemberships), nil
+}
+
+func (s *repo) FindMembership(ctx context.Context, userID uint) (*model.Membership, error) {
+	var ormMembership *orm.Membership
+	err := s.db.WithContext(ctx).
+		Scopes(tenant.Scope(ctx)).
+		Where("user_id = ?", userID).
+		First(&ormMembership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return membershipORMToModel(ormMembership), nil
+}
+
+func (s *repo) ListMembers(ctx context.Context) ([]*model.Membership, error) {
+	var ormMemberships []*orm.Membership
+	err := s.db.WithContext(ctx).
+		Scopes(tenant.Scope(ctx)).
+		Preload("User").
+		Order("id ASC").
+		Find(&ormMemberships).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list members: %v", err)
+		return nil, err
+	}
+	return membershipListToModel(ormMemberships), nil
+}
+
+func (s *repo) UpdateRole(ctx context.Context, userID uint, role model.OrgRole) error {
+	return s.changeMember(ctx, userID, role != model.OrgRoleOwner, func(tx *gorm.DB, member *orm.Membership) error {
+		return tx.Model(member).Update("role", string(role)).Error
+	})
+}
+
+func (s *repo) DeleteMembership(ctx context.Context, userID uint) error {
+	return s.changeMember(ctx, userID, true, func(tx *gorm.DB, member *orm.Membership) error {
+		return tx.Delete(member).Error
+	})
+}
+
+// changeMember applies change to member userID in a transaction. When
+// demotes is set and the member is an owner, the change is refused with
+// ErrLastOwner unless another owner remains; the owners are locked
+// meanwhile, so concurrent demotions cannot both pass the check.
+func (s *repo) changeMember(ctx context.Context, userID uint, demotes bool, change func(tx *gorm.DB, member *orm.Membership) error) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scoped := func() *gorm.DB {
+			return tx.Scopes(tenant.Scope(ctx)).Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		var owners []*orm.Membership
+		err := scoped().
+			Where("role = ?", string(model.OrgRoleOwner)).
+			Order("id ASC").
+			Find(&owners).Error
+		if err != nil {
+			return err
+		}
+
+		var member *orm.Membership
+		if err := scoped().Where("user_id = ?", userID).First(&member).Error; err != nil {
+			return err
+		}
+		if demotes && member.Role == string(model.OrgRoleOwner) && len(owners) <= 1 {
+			return ErrLastOwner
+		}
+		return change(tx.Scopes(tenant.Scope(ctx)), member)
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, ErrLastOwner) {
+		logger.FromContext(ctx, s.log).Errorf("Failed to change member: %v", err)
+	}
+	return err
+}
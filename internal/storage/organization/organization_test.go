@@ -0,0 +1,171 @@
+package organization
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+	"golang-sample/internal/tenant"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	db := storagetest.OpenDB(t, &orm.User{}, &orm.Organization{}, &orm.Membership{}, &orm.Invitation{})
+	require.NoError(t, tenant.Register(db))
+	return db
+}
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := openTestDB(t)
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		require.NoError(t, db.Create(&orm.User{Username: name, Email: name + "@example.com", PasswordHash: "x"}).Error)
+	}
+	const alice, bob, carol = 1, 2, 3
+
+	acme, err := storage.Create(ctx, &model.Organization{Name: "Acme"}, alice)
+	require.NoError(t, err)
+	globex, err := storage.Create(ctx, &model.Organization{Name: "Globex"}, bob)
+	require.NoError(t, err)
+	acmeCtx := tenant.NewContext(ctx, acme.ID)
+	globexCtx := tenant.NewContext(ctx, globex.ID)
+
+	t.Run("makes the creator owner", func(t *testing.T) {
+		found, err := storage.FindByID(ctx, acme.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "Acme", found.Name)
+
+		member, err := storage.FindMembership(acmeCtx, alice)
+		require.NoError(t, err)
+		require.NotNil(t, member)
+		assert.Equal(t, model.OrgRoleOwner, member.Role)
+
+		missing, err := storage.FindByID(ctx, 999)
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("fails closed without a tenant", func(t *testing.T) {
+		_, err := storage.FindMembership(ctx, alice)
+		assert.ErrorIs(t, err, tenant.ErrMissing)
+		_, err = storage.ListMembers(ctx)
+		assert.ErrorIs(t, err, tenant.ErrMissing)
+	})
+
+	t.Run("keeps tenants apart", func(t *testing.T) {
+		member, err := storage.FindMembership(globexCtx, alice)
+		require.NoError(t, err)
+		assert.Nil(t, member, "alice is not a member of Globex")
+
+		assert.ErrorIs(t, storage.UpdateRole(globexCtx, alice, model.OrgRoleMember), gorm.ErrRecordNotFound)
+		assert.ErrorIs(t, storage.DeleteMembership(globexCtx, alice), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("accepts an invitation once", func(t *testing.T) {
+		invitation, err := storage.CreateInvitation(acmeCtx, &model.Invitation{
+			Email:     "Carol@Example.com",
+			Role:      model.OrgRoleAdmin,
+			InvitedBy: alice,
+			ExpiresAt: now.Add(time.Hour),
+		}, "hash-1")
+		require.NoError(t, err)
+		assert.Equal(t, acme.ID, invitation.OrgID)
+
+		// A new invitation to the same address replaces the pending one
+		invitation, err = storage.CreateInvitation(acmeCtx, &model.Invitation{
+			Email:     "carol@example.com",
+			Role:      model.OrgRoleMember,
+			InvitedBy: alice,
+			ExpiresAt: now.Add(time.Hour),
+		}, "hash-2")
+		require.NoError(t, err)
+
+		pending, err := storage.ListPendingInvitations(acmeCtx, now)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, invitation.ID, pending[0].ID)
+
+		replaced, err := storage.FindInvitationByToken(ctx, "hash-1")
+		require.NoError(t, err)
+		assert.Nil(t, replaced)
+
+		found, err := storage.FindInvitationByToken(ctx, "hash-2")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, acme.ID, found.OrgID)
+
+		member, err := storage.AcceptInvitation(acmeCtx, found.ID, carol, now)
+		require.NoError(t, err)
+		assert.Equal(t, model.OrgRoleMember, member.Role)
+		assert.Equal(t, acme.ID, member.OrgID)
+
+		_, err = storage.AcceptInvitation(acmeCtx, found.ID, carol, now)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		pending, err = storage.ListPendingInvitations(acmeCtx, now)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("refuses to add a member twice", func(t *testing.T) {
+		invitation, err := storage.CreateInvitation(acmeCtx, &model.Invitation{
+			Email:     "carol@example.com",
+			Role:      model.OrgRoleAdmin,
+			InvitedBy: alice,
+			ExpiresAt: now.Add(time.Hour),
+		}, "hash-3")
+		require.NoError(t, err)
+
+		_, err = storage.AcceptInvitation(acmeCtx, invitation.ID, carol, now)
+		assert.ErrorIs(t, err, ErrAlreadyMember)
+
+		pending, err := storage.ListPendingInvitations(acmeCtx, now)
+		require.NoError(t, err)
+		assert.Len(t, pending, 1, "the failed accept is rolled back")
+
+		require.NoError(t, storage.DeleteInvitation(acmeCtx, invitation.ID))
+		assert.ErrorIs(t, storage.DeleteInvitation(acmeCtx, invitation.ID), gorm.ErrRecordNotFound)
+	})
+
+	t.Run("lists members and memberships", func(t *testing.T) {
+		members, err := storage.ListMembers(acmeCtx)
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+		assert.Equal(t, "alice", members[0].User.Username)
+		assert.Equal(t, "carol", members[1].User.Username)
+
+		memberships, err := storage.ListMembershipsByUser(ctx, carol)
+		require.NoError(t, err)
+		require.Len(t, memberships, 1)
+		assert.Equal(t, "Acme", memberships[0].Organization.Name)
+	})
+
+	t.Run("keeps an owner", func(t *testing.T) {
+		assert.ErrorIs(t, storage.UpdateRole(acmeCtx, alice, model.OrgRoleAdmin), ErrLastOwner)
+		assert.ErrorIs(t, storage.DeleteMembership(acmeCtx, alice), ErrLastOwner)
+
+		require.NoError(t, storage.UpdateRole(acmeCtx, carol, model.OrgRoleOwner))
+		require.NoError(t, storage.UpdateRole(acmeCtx, alice, model.OrgRoleMember))
+		assert.ErrorIs(t, storage.DeleteMembership(acmeCtx, carol), ErrLastOwner)
+
+		require.NoError(t, storage.DeleteMembership(acmeCtx, alice))
+		member, err := storage.FindMembership(acmeCtx, alice)
+		require.NoError(t, err)
+		assert.Nil(t, member)
+	})
+}
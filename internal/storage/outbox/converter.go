@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// lastErrorSize is the size of orm.OutboxEvent.LastError
+const lastErrorSize = 512
+
+// ormToModel converts ORM OutboxEvent to domain OutboxEntry
+func ormToModel(e *orm.OutboxEvent) *model.OutboxEntry {
+	if e == nil {
+		return nil
+	}
+
+	return &model.OutboxEntry{
+		Event: model.Event{
+			ID:         e.EventID,
+			Type:       model.EventType(e.Type),
+			UserID:     e.UserID,
+			Payload:    []byte(e.Payload),
+			OccurredAt: e.OccurredAt,
+		},
+		Attempts: e.Attempts,
+	}
+}
+
+// modelToORM converts domain Event to ORM OutboxEvent, due as soon as it
+// occurred
+func modelToORM(e *model.Event) *orm.OutboxEvent {
+	if e == nil {
+		return nil
+	}
+
+	payload := string(e.Payload)
+	if payload == "" {
+		payload = "{}"
+	}
+
+	return &orm.OutboxEvent{
+		EventID:       e.ID,
+		Type:          string(e.Type),
+		UserID:        e.UserID,
+		Payload:       payload,
+		OccurredAt:    e.OccurredAt,
+		NextAttemptAt: e.OccurredAt,
+	}
+}
@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+// Storage holds domain events until a relay has published them. To write
+// events together with the change they describe, other storages call
+// Append inside their own transaction.
+type Storage interface {
+	// Add writes events on their own, for changes that are not stored in
+	// a transaction of their own
+	Add(ctx context.Context, events ...*model.Event) error
+	// Claim returns up to limit unpublished events that are due at now,
+	// oldest first, and holds them back until now+lease so that other
+	// relays skip them meanwhile
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.OutboxEntry, error)
+	// MarkPublished records that event id was delivered at at
+	MarkPublished(ctx context.Context, id string, at time.Time) error
+	// MarkFailed counts a failed delivery of event id and holds it back
+	// until retryAt
+	MarkFailed(ctx context.Context, id string, retryAt time.Time, reason string) error
+	// DeletePublishedBefore removes the events published before before and
+	// returns how many it removed
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/pkg/logger"
+)
+
+// Append writes events to the outbox through tx, the transaction of the
+// change they describe, so that both are stored or neither is
+func Append(tx *gorm.DB, events []*model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rows := make([]*orm.OutboxEvent, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, modelToORM(event))
+	}
+	return tx.Create(&rows).Error
+}
+
+func (s *repo) Add(ctx context.Context, events ...*model.Event) error {
+	if err := Append(s.db.WithContext(ctx), events); err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to add events to the outbox: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *repo) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.OutboxEntry, error) {
+	var rows []*orm.OutboxEvent
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Events another relay is claiming are skipped rather than waited for
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", now).
+			Order("id").
+			Limit(limit).
+			Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+
+		ids := make([]uint, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		return tx.Model(&orm.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to claim outbox events: %v", err)
+		return nil, err
+	}
+
+	entries := make([]*model.OutboxEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, ormToModel(row))
+	}
+	return entries, nil
+}
+
+func (s *repo) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	result := s.db.WithContext(ctx).Model(&orm.OutboxEvent{}).
+		Where("event_id = ?", id).
+		Updates(map[string]any{"published_at": at, "last_error": ""})
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to mark outbox event published: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *repo) MarkFailed(ctx context.Context, id string, retryAt time.Time, reason string) error {
+	if len(reason) > lastErrorSize {
+		reason = reason[:lastErrorSize]
+	}
+
+	result := s.db.WithContext(ctx).Model(&orm.OutboxEvent{}).
+		Where("event_id = ? AND published_at IS NULL", id).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": retryAt,
+			"last_error":      reason,
+		})
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to mark outbox event failed: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *repo) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("published_at < ?", before).Delete(&orm.OutboxEvent{})
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to delete published outbox events: %v", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	storage := New(zap.NewNop().Sugar(), storagetest.OpenDB(t, &orm.OutboxEvent{}))
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	require.NoError(t, storage.Add(ctx,
+		&model.Event{ID: "event-1", Type: model.EventUserRegistered, UserID: 7, Payload: []byte(`{"method":"password"}`), OccurredAt: now.Add(-time.Minute)},
+		&model.Event{ID: "event-2", Type: model.EventUserLoggedIn, UserID: 7, OccurredAt: now},
+		&model.Event{ID: "event-3", Type: model.EventPasswordChanged, UserID: 7, OccurredAt: now.Add(time.Minute)},
+	))
+
+	err := storage.Add(ctx, &model.Event{ID: "event-1", Type: model.EventUserRegistered, OccurredAt: now})
+	assert.Error(t, err, "event IDs are unique")
+
+	// Only due events are claimed, oldest first
+	claimed, err := storage.Claim(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, "event-1", claimed[0].ID)
+	assert.Equal(t, model.EventUserRegistered, claimed[0].Type)
+	assert.Equal(t, uint(7), claimed[0].UserID)
+	assert.JSONEq(t, `{"method":"password"}`, string(claimed[0].Payload))
+	assert.JSONEq(t, `{}`, string(claimed[1].Payload))
+
+	// Claimed events are held back for the lease
+	claimed, err = storage.Claim(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	require.NoError(t, storage.MarkPublished(ctx, "event-1", now))
+	require.NoError(t, storage.MarkFailed(ctx, "event-2", now.Add(2*time.Minute), "sink unavailable"))
+	assert.ErrorIs(t, storage.MarkPublished(ctx, "missing", now), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, storage.MarkFailed(ctx, "event-1", now, "late"), gorm.ErrRecordNotFound, "a published event stays published")
+
+	// After the lease, a failed event is due again at its retry time
+	claimed, err = storage.Claim(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, "event-2", claimed[0].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.Equal(t, "event-3", claimed[1].ID)
+
+	claimed, err = storage.Claim(ctx, now.Add(10*time.Minute), time.Minute, 1)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1, "at most limit events are claimed")
+
+	deleted, err := storage.DeletePublishedBefore(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestAppend_RollsBackWithTransaction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.OutboxEvent{})
+	now := time.Now().UTC()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := Append(tx, []*model.Event{{ID: "event-1", Type: model.EventUserRegistered, OccurredAt: now}}); err != nil {
+			return err
+		}
+		return gorm.ErrInvalidData
+	})
+	require.ErrorIs(t, err, gorm.ErrInvalidData)
+
+	var count int64
+	require.NoError(t, db.Model(&orm.OutboxEvent{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
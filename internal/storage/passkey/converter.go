@@ -0,0 +1,64 @@
+package passkey
+
+import (
+	"strings"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// ormToModel converts ORM Passkey to domain Passkey
+func ormToModel(p *orm.Passkey) *model.Passkey {
+	if p == nil {
+		return nil
+	}
+
+	return &model.Passkey{
+		ID:             p.ID,
+		UserID:         p.UserID,
+		Name:           p.Name,
+		CredentialID:   p.CredentialID,
+		PublicKey:      p.PublicKey,
+		SignCount:      uint32(p.SignCount),
+		Transports:     strings.Fields(p.Transports),
+		AAGUID:         p.AAGUID,
+		BackupEligible: p.BackupEligible,
+		CreatedAt:      p.CreatedAt,
+		LastUsedAt:     p.LastUsedAt,
+	}
+}
+
+// modelToORM converts domain Passkey to ORM Passkey
+func modelToORM(p *model.Passkey) *orm.Passkey {
+	if p == nil {
+		return nil
+	}
+
+	return &orm.Passkey{
+		ID:             p.ID,
+		UserID:         p.UserID,
+		Name:           p.Name,
+		CredentialID:   p.CredentialID,
+		PublicKey:      p.PublicKey,
+		SignCount:      int64(p.SignCount),
+		Transports:     strings.Join(p.Transports, " "),
+		AAGUID:         p.AAGUID,
+		BackupEligible: p.BackupEligible,
+		CreatedAt:      p.CreatedAt,
+		LastUsedAt:     p.LastUsedAt,
+	}
+}
+
+// challengeModelToORM converts domain WebAuthnChallenge to ORM WebAuthnChallenge, without its hash
+func challengeModelToORM(c *model.WebAuthnChallenge) *orm.WebAuthnChallenge {
+	if c == nil {
+		return nil
+	}
+
+	return &orm.WebAuthnChallenge{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		Ceremony:  c.Ceremony,
+		ExpiresAt: c.ExpiresAt,
+	}
+}
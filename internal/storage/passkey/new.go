@@ -0,0 +1,42 @@
+package passkey
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+type Storage interface {
+	// CreateChallenge records an issued challenge by its hash and deletes
+	// expired ones
+	CreateChallenge(ctx context.Context, challenge *model.WebAuthnChallenge, challengeHash string) error
+	// ConsumeChallenge deletes the challenge of ceremony for userID with
+	// challengeHash. It reports false when there is none or it expired by at.
+	ConsumeChallenge(ctx context.Context, ceremony string, userID uint, challengeHash string, at time.Time) (bool, error)
+
+	Create(ctx context.Context, passkey *model.Passkey) (*model.Passkey, error)
+	// FindByCredentialID finds a passkey by its credential ID; passkey is nil when not found
+	FindByCredentialID(ctx context.Context, credentialID []byte) (passkey *model.Passkey, err error)
+	// ListByUser lists the passkeys of userID, oldest first
+	ListByUser(ctx context.Context, userID uint) ([]*model.Passkey, error)
+	// RecordUse stores the signature counter of a login with passkey id
+	RecordUse(ctx context.Context, id uint, signCount uint32, at time.Time) error
+	// Delete deletes passkey id of userID; it reports false when userID has no such passkey
+	Delete(ctx context.Context, userID, id uint) (bool, error)
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
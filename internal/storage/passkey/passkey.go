@@ -0,0 +1,94 @@
+package passkey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/pkg/logger"
+)
+
+func (s *repo) CreateChallenge(ctx context.Context, challenge *model.WebAuthnChallenge, challengeHash string) error {
+	log := logger.FromContext(ctx, s.log)
+
+	if err := s.db.WithContext(ctx).Where("expires_at <= ?", time.Now().UTC()).Delete(&orm.WebAuthnChallenge{}).Error; err != nil {
+		log.Warnf("Failed to delete expired WebAuthn challenges: %v", err)
+	}
+
+	ormChallenge := challengeModelToORM(challenge)
+	ormChallenge.ChallengeHash = challengeHash
+	if err := s.db.WithContext(ctx).Create(ormChallenge).Error; err != nil {
+		log.Errorf("Failed to create WebAuthn challenge: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *repo) ConsumeChallenge(ctx context.Context, ceremony string, userID uint, challengeHash string, at time.Time) (bool, error) {
+	// Deleting settles concurrent uses of one challenge
+	result := s.db.WithContext(ctx).
+		Where("ceremony = ? AND user_id = ? AND challenge_hash = ? AND expires_at > ?", ceremony, userID, challengeHash, at).
+		Delete(&orm.WebAuthnChallenge{})
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to consume WebAuthn challenge: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (s *repo) Create(ctx context.Context, passkey *model.Passkey) (*model.Passkey, error) {
+	ormPasskey := modelToORM(passkey)
+
+	if err := s.db.WithContext(ctx).Create(ormPasskey).Error; err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create passkey: %v", err)
+		return nil, err
+	}
+	return ormToModel(ormPasskey), nil
+}
+
+func (s *repo) FindByCredentialID(ctx context.Context, credentialID []byte) (*model.Passkey, error) {
+	var ormPasskey *orm.Passkey
+	err := s.db.WithContext(ctx).Where("credential_id = ?", credentialID).First(&ormPasskey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ormToModel(ormPasskey), nil
+}
+
+func (s *repo) ListByUser(ctx context.Context, userID uint) ([]*model.Passkey, error) {
+	var ormPasskeys []*orm.Passkey
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&ormPasskeys).Error; err != nil {
+		return nil, err
+	}
+
+	passkeys := make([]*model.Passkey, len(ormPasskeys))
+	for i, p := range ormPasskeys {
+		passkeys[i] = ormToModel(p)
+	}
+	return passkeys, nil
+}
+
+func (s *repo) RecordUse(ctx context.Context, id uint, signCount uint32, at time.Time) error {
+	err := s.db.WithContext(ctx).Model(&orm.Passkey{}).Where("id = ?", id).
+		Updates(map[string]any{"sign_count": int64(signCount), "last_used_at": at}).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to record passkey use: %v", err)
+	}
+	return err
+}
+
+func (s *repo) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&orm.Passkey{})
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to delete passkey: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,107 @@
+package passkey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.Passkey{}, &orm.WebAuthnChallenge{})
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	t.Run("a challenge is consumed once by its ceremony and user", func(t *testing.T) {
+		require.NoError(t, storage.CreateChallenge(ctx, &model.WebAuthnChallenge{
+			UserID:    7,
+			Ceremony:  model.CeremonyRegistration,
+			ExpiresAt: now.Add(5 * time.Minute),
+		}, "hash-1"))
+
+		ok, err := storage.ConsumeChallenge(ctx, model.CeremonyLogin, 0, "hash-1", now)
+		require.NoError(t, err)
+		assert.False(t, ok, "another ceremony")
+
+		ok, err = storage.ConsumeChallenge(ctx, model.CeremonyRegistration, 7, "hash-1", now)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = storage.ConsumeChallenge(ctx, model.CeremonyRegistration, 7, "hash-1", now)
+		require.NoError(t, err)
+		assert.False(t, ok, "second use")
+	})
+
+	t.Run("expired challenges are not consumed and get deleted", func(t *testing.T) {
+		require.NoError(t, storage.CreateChallenge(ctx, &model.WebAuthnChallenge{
+			Ceremony:  model.CeremonyLogin,
+			ExpiresAt: now.Add(-time.Second),
+		}, "hash-2"))
+
+		ok, err := storage.ConsumeChallenge(ctx, model.CeremonyLogin, 0, "hash-2", now)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, storage.CreateChallenge(ctx, &model.WebAuthnChallenge{
+			Ceremony:  model.CeremonyLogin,
+			ExpiresAt: now.Add(time.Minute),
+		}, "hash-3"))
+		var count int64
+		require.NoError(t, db.Model(&orm.WebAuthnChallenge{}).Where("challenge_hash = ?", "hash-2").Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("stores, finds, updates and deletes passkeys", func(t *testing.T) {
+		created, err := storage.Create(ctx, &model.Passkey{
+			UserID:         7,
+			Name:           "Laptop",
+			CredentialID:   []byte{1, 2, 3},
+			PublicKey:      []byte{0xa5},
+			SignCount:      3,
+			Transports:     []string{"internal", "hybrid"},
+			BackupEligible: true,
+		})
+		require.NoError(t, err)
+
+		_, err = storage.Create(ctx, &model.Passkey{UserID: 8, Name: "Copy", CredentialID: []byte{1, 2, 3}, PublicKey: []byte{0xa5}})
+		assert.Error(t, err, "credential IDs are unique")
+
+		found, err := storage.FindByCredentialID(ctx, []byte{1, 2, 3})
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, created.ID, found.ID)
+		assert.Equal(t, []string{"internal", "hybrid"}, found.Transports)
+		assert.Equal(t, uint32(3), found.SignCount)
+
+		missing, err := storage.FindByCredentialID(ctx, []byte{9})
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+
+		require.NoError(t, storage.RecordUse(ctx, created.ID, 4, now))
+		list, err := storage.ListByUser(ctx, 7)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, uint32(4), list[0].SignCount)
+		assert.NotNil(t, list[0].LastUsedAt)
+
+		deleted, err := storage.Delete(ctx, 8, created.ID)
+		require.NoError(t, err)
+		assert.False(t, deleted, "another user's passkey")
+
+		deleted, err = storage.Delete(ctx, 7, created.ID)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+	})
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+)
+
+// ormToModel converts ORM Session to domain Session
+func ormToModel(s *orm.Session) *model.Session {
+	if s == nil {
+		return nil
+	}
+
+	return &model.Session{
+		ID:         s.ID,
+		UserID:     s.UserID,
+		Family:     s.Family,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		RevokedAt:  s.RevokedAt,
+	}
+}
+
+// modelToORM converts domain Session to ORM Session
+func modelToORM(s *model.Session) *orm.Session {
+	if s == nil {
+		return nil
+	}
+
+	return &orm.Session{
+		ID:         s.ID,
+		UserID:     s.UserID,
+		Family:     s.Family,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		RevokedAt:  s.RevokedAt,
+	}
+}
@@ -0,0 +1,42 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+)
+
+type Storage interface {
+	// Create records session and writes events to the outbox in the same
+	// transaction
+	Create(ctx context.Context, session *model.Session, events ...*model.Event) (*model.Session, error)
+	// FindByFamily finds the session of a token family, revoked or not;
+	// session is nil when not found
+	FindByFamily(ctx context.Context, family string) (session *model.Session, err error)
+	// ListActiveByUser returns the sessions of userID that are neither
+	// revoked nor expired at now, most recently seen first
+	ListActiveByUser(ctx context.Context, userID uint, now time.Time) ([]*model.Session, error)
+	// SeenDevice reports whether userID has any session, and any from userAgent
+	SeenDevice(ctx context.Context, userID uint, userAgent string) (anySession, sameUserAgent bool, err error)
+	// Touch records that session id was seen at at
+	Touch(ctx context.Context, id uint, at time.Time) error
+	// Revoke marks session id of userID revoked; gorm.ErrRecordNotFound when
+	// userID has no such active session
+	Revoke(ctx context.Context, userID, id uint, at time.Time) error
+}
+
+type repo struct {
+	log *zap.SugaredLogger
+	db  *gorm.DB
+}
+
+func New(log *zap.SugaredLogger, db *gorm.DB) Storage {
+	return &repo{
+		log: log,
+		db:  db,
+	}
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/outbox"
+	"golang-sample/pkg/logger"
+)
+
+func (s *repo) Create(ctx context.Context, session *model.Session, events ...*model.Event) (*model.Session, error) {
+	ormSession := modelToORM(session)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ormSession).Error; err != nil {
+			return err
+		}
+		return outbox.Append(tx, events)
+	})
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create session: %v", err)
+		return nil, err
+	}
+	return ormToModel(ormSession), nil
+}
+
+func (s *repo) FindByFamily(ctx context.Context, family string) (*model.Session, error) {
+	var ormSession *orm.Session
+	err := s.db.WithContext(ctx).Where("family = ?", family).First(&ormSession).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ormToModel(ormSession), nil
+}
+
+func (s *repo) ListActiveByUser(ctx context.Context, userID uint, now time.Time) ([]*model.Session, error) {
+	var ormSessions []*orm.Session
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC, id DESC").
+		Find(&ormSessions).Error
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to list sessions: %v", err)
+		return nil, err
+	}
+
+	sessions := make([]*model.Session, len(ormSessions))
+	for i, session := range ormSessions {
+		sessions[i] = ormToModel(session)
+	}
+	return sessions, nil
+}
+
+func (s *repo) SeenDevice(ctx context.Context, userID uint, userAgent string) (bool, bool, error) {
+	var counts struct {
+		Total int64
+		Same  int64
+	}
+	err := s.db.WithContext(ctx).Model(&orm.Session{}).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN user_agent = ? THEN 1 ELSE 0 END), 0) AS same", userAgent).
+		Where("user_id = ?", userID).
+		Scan(&counts).Error
+	if err != nil {
+		return false, false, err
+	}
+	return counts.Total > 0, counts.Same > 0, nil
+}
+
+func (s *repo) Touch(ctx context.Context, id uint, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&orm.Session{}).
+		Where("id = ?", id).
+		UpdateColumn("last_seen_at", at).Error
+}
+
+func (s *repo) Revoke(ctx context.Context, userID, id uint, at time.Time) error {
+	result := s.db.WithContext(ctx).Model(&orm.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to revoke session: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"golang-sample/internal/model"
+	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
+)
+
+func TestRepo_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.Session{}, &orm.OutboxEvent{})
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	create := func(userID uint, family, userAgent string, expiresAt time.Time) *model.Session {
+		t.Helper()
+		created, err := storage.Create(ctx, &model.Session{
+			UserID:     userID,
+			Family:     family,
+			UserAgent:  userAgent,
+			IP:         "203.0.113.7",
+			LastSeenAt: now,
+			ExpiresAt:  expiresAt,
+		})
+		require.NoError(t, err)
+		return created
+	}
+
+	anySession, same, err := storage.SeenDevice(ctx, 7, "Firefox")
+	require.NoError(t, err)
+	assert.False(t, anySession)
+	assert.False(t, same)
+
+	laptop := create(7, "family-1", "Firefox", now.Add(time.Hour))
+	create(7, "family-2", "Safari", now.Add(-time.Minute))
+	create(8, "family-3", "Chrome", now.Add(time.Hour))
+
+	t.Run("finds a session by its family", func(t *testing.T) {
+		found, err := storage.FindByFamily(ctx, "family-1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, laptop.ID, found.ID)
+		assert.Equal(t, "203.0.113.7", found.IP)
+
+		missing, err := storage.FindByFamily(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("tells known devices apart", func(t *testing.T) {
+		anySession, same, err := storage.SeenDevice(ctx, 7, "Firefox")
+		require.NoError(t, err)
+		assert.True(t, anySession)
+		assert.True(t, same)
+
+		anySession, same, err = storage.SeenDevice(ctx, 7, "Chrome")
+		require.NoError(t, err)
+		assert.True(t, anySession)
+		assert.False(t, same, "only another user has a session from Chrome")
+	})
+
+	t.Run("lists active sessions of the user", func(t *testing.T) {
+		require.NoError(t, storage.Touch(ctx, laptop.ID, now.Add(time.Minute)))
+
+		sessions, err := storage.ListActiveByUser(ctx, 7, now)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1, "the expired session is not listed")
+		assert.Equal(t, laptop.ID, sessions[0].ID)
+		assert.WithinDuration(t, now.Add(time.Minute), sessions[0].LastSeenAt, time.Second)
+	})
+
+	t.Run("revokes only own active sessions", func(t *testing.T) {
+		assert.ErrorIs(t, storage.Revoke(ctx, 8, laptop.ID, now), gorm.ErrRecordNotFound)
+		require.NoError(t, storage.Revoke(ctx, 7, laptop.ID, now))
+		assert.ErrorIs(t, storage.Revoke(ctx, 7, laptop.ID, now), gorm.ErrRecordNotFound)
+
+		found, err := storage.FindByFamily(ctx, "family-1")
+		require.NoError(t, err)
+		assert.NotNil(t, found.RevokedAt)
+
+		sessions, err := storage.ListActiveByUser(ctx, 7, now)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("stores events with the session", func(t *testing.T) {
+		session := &model.Session{UserID: 9, Family: "family-4", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+		_, err := storage.Create(ctx, session,
+			&model.Event{ID: "event-1", Type: model.EventUserLoggedIn, UserID: 9, OccurredAt: now})
+		require.NoError(t, err)
+
+		// A session that is not stored stores no event
+		_, err = storage.Create(ctx, session,
+			&model.Event{ID: "event-2", Type: model.EventUserLoggedIn, UserID: 9, OccurredAt: now})
+		require.Error(t, err)
+
+		var stored []orm.OutboxEvent
+		require.NoError(t, db.Find(&stored).Error)
+		require.Len(t, stored, 1)
+		assert.Equal(t, "event-1", stored[0].EventID)
+	})
+}
@@ -0,0 +1,22 @@
+// Package storage holds what the storage packages below it share
+package storage
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// IsDuplicate reports whether err is a unique constraint violation:
+// gorm.ErrDuplicatedKey when the dialector translates errors, otherwise
+// PostgreSQL's 23505 or SQLite's failed UNIQUE constraint
+func IsDuplicate(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey) ||
+		strings.Contains(err.Error(), "duplicate key value violates unique constraint") ||
+		strings.Contains(err.Error(), "SQLSTATE 23505") ||
+		strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
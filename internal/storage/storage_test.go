@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestIsDuplicate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "translated", err: fmt.Errorf("create: %w", gorm.ErrDuplicatedKey), want: true},
+		{name: "postgres", err: errors.New(`ERROR: duplicate key value violates unique constraint "idx_users_email" (SQLSTATE 23505)`), want: true},
+		{name: "sqlite", err: errors.New("UNIQUE constraint failed: users.username"), want: true},
+		{name: "other", err: errors.New("NOT NULL constraint failed: users.email"), want: false},
+		{name: "not found", err: gorm.ErrRecordNotFound, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsDuplicate(tt.err))
+		})
+	}
+}
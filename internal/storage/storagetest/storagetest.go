@@ -0,0 +1,33 @@
+// Package storagetest provides databases for the tests of the storage
+// packages.
+package storagetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// OpenDB opens an in-memory SQLite database private to t, migrated for
+// models, and closes it when t finishes. Its single connection keeps the
+// shared-cache database alive for the whole test.
+func OpenDB(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if len(models) > 0 {
+		require.NoError(t, db.AutoMigrate(models...))
+	}
+	return db
+}
@@ -15,6 +15,7 @@ func ormToModel(u *orm.User) *model.User {
 		ID:        u.ID,
 		Username:  u.Username,
 		Email:     u.Email,
+		Locale:    u.Locale,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
@@ -30,6 +31,7 @@ func modelToORM(u *model.User) *orm.User {
 		ID:        u.ID,
 		Username:  u.Username,
 		Email:     u.Email,
+		Locale:    u.Locale,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
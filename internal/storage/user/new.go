@@ -11,14 +11,27 @@ import (
 
 type Storage interface {
 	IsExistBy(ctx context.Context, field string, condition string) (bool, error)
-	// CheckUniqueness checks both username and email uniqueness in a single query
+	// CheckUniqueness checks both username and email uniqueness in a single query; a username
+	// equal to another account's email is taken, and the other way around
 	// Returns (usernameExists, emailExists, error)
 	CheckUniqueness(ctx context.Context, username, email string) (bool, bool, error)
 	// CreateUserWithPassword creates a user with password hash (returns domain model without password)
-	CreateUserWithPassword(ctx context.Context, user *model.User, passwordHash string) (*model.User, error)
+	// and writes events to the outbox in the same transaction; events without a UserID get the new user's
+	CreateUserWithPassword(ctx context.Context, user *model.User, passwordHash string, events ...*model.Event) (*model.User, error)
+	// FindUserByUsername matches the normalized username; user is nil when not found
 	FindUserByUsername(ctx context.Context, username string) (user *model.User, err error)
-	// FindUserByUsernameWithPassword finds user and returns with password hash for authentication
-	FindUserByUsernameWithPassword(ctx context.Context, username string) (user *model.User, passwordHash string, err error)
+	// FindUserByEmail matches the normalized email; user is nil when not found
+	FindUserByEmail(ctx context.Context, email string) (user *model.User, err error)
+	// FindUserByLoginWithPassword finds the user whose normalized username or email is login
+	// and returns it with the password hash for authentication; user is nil when not found
+	FindUserByLoginWithPassword(ctx context.Context, login string) (user *model.User, passwordHash string, err error)
+	// FindUserByID finds user by ID; user is nil when not found
+	FindUserByID(ctx context.Context, id uint) (user *model.User, err error)
+	// FindUserByIDWithPassword finds user by ID and returns with password hash; user is nil when not found
+	FindUserByIDWithPassword(ctx context.Context, id uint) (user *model.User, passwordHash string, err error)
+	// UpdatePassword replaces the password hash of user id and writes events to the outbox
+	// in the same transaction
+	UpdatePassword(ctx context.Context, id uint, passwordHash string, events ...*model.Event) error
 }
 
 type repo struct {
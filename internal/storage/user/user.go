@@ -5,31 +5,39 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
-	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"golang-sample/internal/model"
 	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/outbox"
+	"golang-sample/pkg/logger"
+	"golang-sample/pkg/utils/identity"
 )
 
 func (s *repo) IsExistBy(ctx context.Context, field string, condition string) (bool, error) {
-	// Whitelist of allowed columns to prevent SQL injection
-	allowedColumns := map[string]bool{
-		"username": true,
-		"email":    true,
-		"id":       true,
+	// Whitelist of allowed columns to prevent SQL injection; usernames and
+	// emails are matched by their normalized form
+	allowedColumns := map[string]string{
+		"username": "username_normalized",
+		"email":    "email_normalized",
+		"id":       "id",
 	}
 
-	if !allowedColumns[field] {
-		s.log.Errorf("Invalid field name for existence check: %s", field)
+	column, ok := allowedColumns[field]
+	if !ok {
+		logger.FromContext(ctx, s.log).Errorf("Invalid field name for existence check: %s", field)
 		return false, fmt.Errorf("invalid field name: %s", field)
 	}
+	if column != "id" {
+		condition = identity.Normalize(condition)
+	}
 
 	// Check if the field exists in the database
 	var count int64
-	query := fmt.Sprintf("%s = ?", field)
+	query := fmt.Sprintf("%s = ?", column)
 	if err := s.db.WithContext(ctx).Model(&orm.User{}).Where(query, condition).Count(&count).Error; err != nil {
-		s.log.Errorf("Failed to check if %s exists, err: %#v", field, zap.Error(err))
+		logger.FromContext(ctx, s.log).Errorf("Failed to check if %s exists: %v", field, err)
 		return false, err
 	}
 	return count > 0, nil
@@ -37,34 +45,50 @@ func (s *repo) IsExistBy(ctx context.Context, field string, condition string) (b
 
 // CheckUniqueness checks both username and email uniqueness in a single optimized query.
 // Uses CASE WHEN conditional aggregation to check both fields in one database roundtrip.
-// Returns (usernameExists, emailExists, error)
+// Both are compared by their normalized form, so "Alice" is taken when "alice" exists.
+// Logins match either column, so each also counts as taken when it equals the other
+// column of an existing account. Returns (usernameExists, emailExists, error)
 func (s *repo) CheckUniqueness(ctx context.Context, username, email string) (bool, bool, error) {
 	type UniquenessResult struct {
 		UsernameCount int64
 		EmailCount    int64
 	}
 
+	normalizedUsername := identity.Normalize(username)
+	normalizedEmail := identity.Normalize(email)
+
 	var result UniquenessResult
 	err := s.db.WithContext(ctx).Model(&orm.User{}).Select(`
-		COUNT(CASE WHEN username = ? THEN 1 END) as username_count,
-		COUNT(CASE WHEN email = ? THEN 1 END) as email_count
-	`, username, email).Scan(&result).Error
+		COUNT(CASE WHEN username_normalized = ? OR email_normalized = ? THEN 1 END) as username_count,
+		COUNT(CASE WHEN email_normalized = ? OR username_normalized = ? THEN 1 END) as email_count
+	`, normalizedUsername, normalizedUsername, normalizedEmail, normalizedEmail).Scan(&result).Error
 
 	if err != nil {
-		s.log.Errorf("Failed to check uniqueness, err: %#v", zap.Error(err))
+		logger.FromContext(ctx, s.log).Errorf("Failed to check uniqueness: %v", err)
 		return false, false, err
 	}
 
 	return result.UsernameCount > 0, result.EmailCount > 0, nil
 }
 
-func (s *repo) CreateUserWithPassword(ctx context.Context, user *model.User, passwordHash string) (*model.User, error) {
+func (s *repo) CreateUserWithPassword(ctx context.Context, user *model.User, passwordHash string, events ...*model.Event) (*model.User, error) {
 	// Convert domain model to ORM
 	ormUser := modelToORM(user)
 	ormUser.PasswordHash = passwordHash
 
-	if err := s.db.WithContext(ctx).Create(&ormUser).Error; err != nil {
-		s.log.Errorf("Failed to create user, err: %#v", zap.Error(err))
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ormUser).Error; err != nil {
+			return err
+		}
+		for _, event := range events {
+			if event.UserID == 0 {
+				event.UserID = ormUser.ID
+			}
+		}
+		return outbox.Append(tx, events)
+	})
+	if err != nil {
+		logger.FromContext(ctx, s.log).Errorf("Failed to create user: %v", err)
 		return nil, err
 	}
 
@@ -74,7 +98,7 @@ func (s *repo) CreateUserWithPassword(ctx context.Context, user *model.User, pas
 
 func (s *repo) FindUserByUsername(ctx context.Context, username string) (user *model.User, err error) {
 	var ormUser *orm.User
-	err = s.db.WithContext(ctx).Where("username = ?", username).First(&ormUser).Error
+	err = s.db.WithContext(ctx).Where("username_normalized = ?", identity.Normalize(username)).First(&ormUser).Error
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
@@ -86,9 +110,32 @@ func (s *repo) FindUserByUsername(ctx context.Context, username string) (user *m
 	return ormToModel(ormUser), nil
 }
 
-func (s *repo) FindUserByUsernameWithPassword(ctx context.Context, username string) (user *model.User, passwordHash string, err error) {
+func (s *repo) FindUserByEmail(ctx context.Context, email string) (user *model.User, err error) {
+	var ormUser *orm.User
+	err = s.db.WithContext(ctx).Where("email_normalized = ?", identity.Normalize(email)).First(&ormUser).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ormToModel(ormUser), nil
+}
+
+func (s *repo) FindUserByLoginWithPassword(ctx context.Context, login string) (user *model.User, passwordHash string, err error) {
+	normalized := identity.Normalize(login)
+
 	var ormUser *orm.User
-	err = s.db.WithContext(ctx).Where("username = ?", username).First(&ormUser).Error
+	err = s.db.WithContext(ctx).
+		Where("username_normalized = ? OR email_normalized = ?", normalized, normalized).
+		// A username equal to another account's email wins. Take rather than
+		// First, which would replace this ordering with the primary key.
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN username_normalized = ? THEN 0 ELSE 1 END, id",
+			Vars: []any{normalized},
+		}}).
+		Take(&ormUser).Error
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, "", nil
 	}
@@ -99,3 +146,46 @@ func (s *repo) FindUserByUsernameWithPassword(ctx context.Context, username stri
 	// Convert ORM to domain model
 	return ormToModel(ormUser), ormUser.PasswordHash, nil
 }
+
+func (s *repo) FindUserByID(ctx context.Context, id uint) (user *model.User, err error) {
+	var ormUser *orm.User
+	err = s.db.WithContext(ctx).Where("id = ?", id).First(&ormUser).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ormToModel(ormUser), nil
+}
+
+func (s *repo) FindUserByIDWithPassword(ctx context.Context, id uint) (user *model.User, passwordHash string, err error) {
+	var ormUser *orm.User
+	err = s.db.WithContext(ctx).Where("id = ?", id).First(&ormUser).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ormToModel(ormUser), ormUser.PasswordHash, nil
+}
+
+func (s *repo) UpdatePassword(ctx context.Context, id uint, passwordHash string, events ...*model.Event) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&orm.User{}).Where("id = ?", id).Update("password_hash", passwordHash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return outbox.Append(tx, events)
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.FromContext(ctx, s.log).Errorf("Failed to update password: %v", err)
+	}
+	return err
+}
@@ -12,12 +12,12 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	storageMocks "golang-sample/internal/mocks/storage"
 	"golang-sample/internal/model"
 	"golang-sample/internal/orm"
+	"golang-sample/internal/storage/storagetest"
 )
 
 // TestStorage_InterfaceCompliance verifies the repo implements Storage interface
@@ -566,38 +566,11 @@ func TestStorage_ConcurrentOperations_WithMock(t *testing.T) {
 	}
 }
 
-// openTestDB creates a test database with proper cleanup
-func openTestDB(t *testing.T) *gorm.DB {
-	t.Helper()
-
-	// Use unique DSN per test to avoid conflicts
-	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
-
-	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
-	require.NoError(t, err, "Failed to open test database")
-
-	dbSQL, err := db.DB()
-	require.NoError(t, err, "Failed to get underlying sql.DB")
-
-	// Limit connections to 1 for shared cache
-	dbSQL.SetMaxOpenConns(1)
-	dbSQL.SetMaxIdleConns(1)
-
-	// Register cleanup to close the database
-	t.Cleanup(func() {
-		if err := dbSQL.Close(); err != nil {
-			t.Errorf("Failed to close test database: %v", err)
-		}
-	})
-
-	return db
-}
-
 // TestNew verifies the constructor
 func TestNew(t *testing.T) {
 	t.Run("creates new storage instance", func(t *testing.T) {
 		log := zap.NewNop().Sugar()
-		db := openTestDB(t)
+		db := storagetest.OpenDB(t)
 
 		storage := New(log, db)
 
@@ -610,7 +583,7 @@ func TestNew(t *testing.T) {
 func TestRepo(t *testing.T) {
 	t.Run("repo struct stores dependencies", func(t *testing.T) {
 		log := zap.NewNop().Sugar()
-		db := openTestDB(t)
+		db := storagetest.OpenDB(t)
 
 		r := &repo{log: log, db: db}
 
@@ -710,11 +683,7 @@ func TestRepo_IsExistBy_Integration(t *testing.T) {
 		t.Skip("skipping integration test in short mode")
 	}
 
-	db := openTestDB(t)
-
-	if err := db.AutoMigrate(&orm.User{}); err != nil {
-		t.Fatalf("failed to migrate: %v", err)
-	}
+	db := storagetest.OpenDB(t, &orm.User{})
 
 	log := zap.NewNop().Sugar()
 	storage := New(log, db).(*repo)
@@ -744,10 +713,14 @@ func TestRepo_IsExistBy_Integration(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, exists)
 
-	// Test case sensitivity
+	// Usernames and emails are case-insensitive
 	exists, err = storage.IsExistBy(ctx, "username", "TestUser")
 	assert.NoError(t, err)
-	assert.False(t, exists)
+	assert.True(t, exists)
+
+	exists, err = storage.IsExistBy(ctx, "email", "Test@Example.com")
+	assert.NoError(t, err)
+	assert.True(t, exists)
 }
 
 // TestRepo_CreateUser_Integration tests CreateUser with real database
@@ -756,11 +729,7 @@ func TestRepo_CreateUser_Integration(t *testing.T) {
 		t.Skip("skipping integration test in short mode")
 	}
 
-	db := openTestDB(t)
-
-	if err := db.AutoMigrate(&orm.User{}); err != nil {
-		t.Fatalf("failed to migrate: %v", err)
-	}
+	db := storagetest.OpenDB(t, &orm.User{})
 
 	log := zap.NewNop().Sugar()
 	storage := New(log, db).(*repo)
@@ -797,6 +766,12 @@ func TestRepo_CreateUser_Integration(t *testing.T) {
 	}
 	_, err = storage.CreateUserWithPassword(ctx, duplicate2, "anotherhash")
 	assert.Error(t, err)
+
+	// Test duplicates differing only by case or Unicode form
+	_, err = storage.CreateUserWithPassword(ctx, &model.User{Username: "NewUser", Email: "other@example.com"}, "anotherhash")
+	assert.Error(t, err)
+	_, err = storage.CreateUserWithPassword(ctx, &model.User{Username: "other", Email: "ＮＥＷＵＳＥＲ@example.com"}, "anotherhash")
+	assert.Error(t, err)
 }
 
 // TestRepo_FindUserByUsername_Integration tests FindUserByUsername with real database
@@ -805,11 +780,7 @@ func TestRepo_FindUserByUsername_Integration(t *testing.T) {
 		t.Skip("skipping integration test in short mode")
 	}
 
-	db := openTestDB(t)
-
-	if err := db.AutoMigrate(&orm.User{}); err != nil {
-		t.Fatalf("failed to migrate: %v", err)
-	}
+	db := storagetest.OpenDB(t, &orm.User{})
 
 	log := zap.NewNop().Sugar()
 	storage := New(log, db).(*repo)
@@ -843,11 +814,7 @@ func TestRepo_CompleteWorkflow_Integration(t *testing.T) {
 		t.Skip("skipping integration test in short mode")
 	}
 
-	db := openTestDB(t)
-
-	if err := db.AutoMigrate(&orm.User{}); err != nil {
-		t.Fatalf("failed to migrate: %v", err)
-	}
+	db := storagetest.OpenDB(t, &orm.User{})
 
 	log := zap.NewNop().Sugar()
 	storage := New(log, db).(*repo)
@@ -881,3 +848,172 @@ func TestRepo_CompleteWorkflow_Integration(t *testing.T) {
 	assert.Equal(t, created.ID, found.ID)
 	assert.Equal(t, "workflowuser", found.Username)
 }
+
+// TestRepo_UpdatePassword_Integration tests password lookup by ID and update with real database
+func TestRepo_UpdatePassword_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.User{})
+
+	log := zap.NewNop().Sugar()
+	storage := New(log, db).(*repo)
+
+	ctx := context.Background()
+
+	created, err := storage.CreateUserWithPassword(ctx, &model.User{
+		Username: "pwuser",
+		Email:    "pw@example.com",
+	}, "oldhash")
+	require.NoError(t, err)
+
+	require.NoError(t, storage.UpdatePassword(ctx, created.ID, "newhash"))
+
+	found, hash, err := storage.FindUserByIDWithPassword(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "pwuser", found.Username)
+	assert.Equal(t, "newhash", hash)
+
+	found, err = storage.FindUserByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "pw@example.com", found.Email)
+
+	// Unknown users
+	found, err = storage.FindUserByID(ctx, created.ID+1)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+	found, _, err = storage.FindUserByIDWithPassword(ctx, created.ID+1)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+	assert.ErrorIs(t, storage.UpdatePassword(ctx, created.ID+1, "hash"), gorm.ErrRecordNotFound)
+}
+
+// TestRepo_Events_Integration tests that events are stored with the change they describe
+func TestRepo_Events_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.User{}, &orm.OutboxEvent{})
+
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+	now := time.Now()
+
+	registered := &model.Event{ID: "event-1", Type: model.EventUserRegistered, OccurredAt: now}
+	created, err := storage.CreateUserWithPassword(ctx, &model.User{Username: "eventuser", Email: "event@example.com"}, "hash", registered)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, registered.UserID, "the event gets the new user's ID")
+
+	// A failed change stores no event
+	_, err = storage.CreateUserWithPassword(ctx, &model.User{Username: "eventuser", Email: "other@example.com"}, "hash",
+		&model.Event{ID: "event-2", Type: model.EventUserRegistered, OccurredAt: now})
+	require.Error(t, err)
+	err = storage.UpdatePassword(ctx, created.ID+1, "hash", &model.Event{ID: "event-3", Type: model.EventPasswordChanged, OccurredAt: now})
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, storage.UpdatePassword(ctx, created.ID, "newhash",
+		&model.Event{ID: "event-4", Type: model.EventPasswordChanged, UserID: created.ID, OccurredAt: now}))
+
+	var stored []orm.OutboxEvent
+	require.NoError(t, db.Order("id").Find(&stored).Error)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "event-1", stored[0].EventID)
+	assert.Equal(t, created.ID, stored[0].UserID)
+	assert.Equal(t, "event-4", stored[1].EventID)
+}
+
+// TestRepo_CheckUniqueness_Integration tests that uniqueness respects normalization
+func TestRepo_CheckUniqueness_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.User{})
+
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+
+	_, err := storage.CreateUserWithPassword(ctx, &model.User{Username: "Alice", Email: "Alice@Example.com"}, "hash")
+	require.NoError(t, err)
+
+	usernameExists, emailExists, err := storage.CheckUniqueness(ctx, "alice", "alice@example.com")
+	require.NoError(t, err)
+	assert.True(t, usernameExists)
+	assert.True(t, emailExists)
+
+	usernameExists, emailExists, err = storage.CheckUniqueness(ctx, "bob", "bob@example.com")
+	require.NoError(t, err)
+	assert.False(t, usernameExists)
+	assert.False(t, emailExists)
+
+	// A username may not take another account's email, nor the other way around
+	usernameExists, emailExists, err = storage.CheckUniqueness(ctx, "alice@example.com", "bob@example.com")
+	require.NoError(t, err)
+	assert.True(t, usernameExists)
+	assert.False(t, emailExists)
+	usernameExists, emailExists, err = storage.CheckUniqueness(ctx, "bob", "ALICE")
+	require.NoError(t, err)
+	assert.False(t, usernameExists)
+	assert.True(t, emailExists)
+
+	found, err := storage.FindUserByEmail(ctx, "ALICE@example.COM")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "Alice", found.Username)
+
+	found, err = storage.FindUserByEmail(ctx, "alice")
+	require.NoError(t, err)
+	assert.Nil(t, found, "usernames do not match")
+}
+
+// TestRepo_FindUserByLoginWithPassword_Integration tests login by username or email
+func TestRepo_FindUserByLoginWithPassword_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := storagetest.OpenDB(t, &orm.User{})
+
+	storage := New(zap.NewNop().Sugar(), db)
+	ctx := context.Background()
+
+	alice, err := storage.CreateUserWithPassword(ctx, &model.User{Username: "Alice", Email: "alice@example.com"}, "alicehash")
+	require.NoError(t, err)
+	_, err = storage.CreateUserWithPassword(ctx, &model.User{Username: "carol", Email: "Carol@Example.com"}, "carolhash")
+	require.NoError(t, err)
+	// A legacy username equal to carol's email
+	legacy, err := storage.CreateUserWithPassword(ctx, &model.User{Username: "carol@example.com", Email: "legacy@example.com"}, "legacyhash")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		login    string
+		wantID   uint
+		wantHash string
+	}{
+		{name: "username", login: "Alice", wantID: alice.ID, wantHash: "alicehash"},
+		{name: "username in another case", login: "ALICE", wantID: alice.ID, wantHash: "alicehash"},
+		{name: "email", login: "Alice@Example.com", wantID: alice.ID, wantHash: "alicehash"},
+		{name: "username wins over email", login: "CAROL@example.com", wantID: legacy.ID, wantHash: "legacyhash"},
+		{name: "email of the legacy account", login: "legacy@example.com", wantID: legacy.ID, wantHash: "legacyhash"},
+		{name: "unknown", login: "dave"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, hash, err := storage.FindUserByLoginWithPassword(ctx, tt.login)
+			require.NoError(t, err)
+			if tt.wantID == 0 {
+				assert.Nil(t, found)
+				return
+			}
+			require.NotNil(t, found)
+			assert.Equal(t, tt.wantID, found.ID)
+			assert.Equal(t, tt.wantHash, hash)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package tenant carries the organization a request acts in and enforces
+// it on the rows of tenant-owned tables.
+//
+// Repositories select the tenant with Scope(ctx). Callbacks installed by
+// Register reject any query, update, delete or insert on a tenant-owned
+// model that lacks it, so a forgotten filter fails closed instead of
+// reading every organization's rows. The few queries that must cross
+// tenants, such as listing a user's organizations, say so with AllTenants.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Column holds the owning organization in tenant-owned tables
+const Column = "org_id"
+
+var (
+	// ErrMissing is returned by queries scoped to a context without a tenant
+	ErrMissing = errors.New("tenant: no organization in context")
+	// ErrUnscoped is returned by queries on tenant-owned models without Scope
+	// or AllTenants
+	ErrUnscoped = errors.New("tenant: query on a tenant-owned table without a tenant scope")
+)
+
+// Owned is implemented by ORM models whose rows belong to one organization,
+// kept in Column
+type Owned interface {
+	TenantOwned()
+}
+
+type ctxKey struct{}
+
+const (
+	scopeKey = "tenant:org_id"
+	allKey   = "tenant:all"
+)
+
+// NewContext returns a copy of ctx acting in organization orgID
+func NewContext(ctx context.Context, orgID uint) context.Context {
+	return context.WithValue(ctx, ctxKey{}, orgID)
+}
+
+// FromContext returns the organization ctx acts in
+func FromContext(ctx context.Context) (uint, bool) {
+	orgID, ok := ctx.Value(ctxKey{}).(uint)
+	return orgID, ok && orgID != 0
+}
+
+// Scope restricts a statement to the organization of ctx; inserts are
+// assigned to it. Without one in ctx the statement fails with ErrMissing.
+func Scope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		orgID, ok := FromContext(ctx)
+		if !ok {
+			_ = db.AddError(ErrMissing)
+			return db
+		}
+		// Qualified with the table, so the filter stays unambiguous in joins
+		column := clause.Column{Table: clause.CurrentTable, Name: Column}
+		return db.InstanceSet(scopeKey, orgID).Where(clause.Eq{Column: column, Value: orgID})
+	}
+}
+
+// AllTenants marks a statement as deliberately reading or writing across
+// organizations
+func AllTenants(db *gorm.DB) *gorm.DB {
+	return db.InstanceSet(allKey, true)
+}
+
+// Register installs the callbacks that enforce Scope on tenant-owned models
+func Register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Query().Before("gorm:query").Register("tenant:query", check),
+		callbacks.Row().Before("gorm:row").Register("tenant:row", check),
+		callbacks.Update().Before("gorm:update").Register("tenant:update", check),
+		callbacks.Delete().Before("gorm:delete").Register("tenant:delete", check),
+		callbacks.Create().Before("gorm:create").Register("tenant:create", checkCreate),
+	)
+}
+
+func check(db *gorm.DB) {
+	if !owned(db) {
+		return
+	}
+	if _, ok := db.InstanceGet(allKey); ok {
+		return
+	}
+	if _, ok := db.InstanceGet(scopeKey); !ok {
+		_ = db.AddError(ErrUnscoped)
+	}
+}
+
+func checkCreate(db *gorm.DB) {
+	if !owned(db) {
+		return
+	}
+	if _, ok := db.InstanceGet(allKey); ok {
+		return
+	}
+	orgID, ok := db.InstanceGet(scopeKey)
+	if !ok {
+		_ = db.AddError(ErrUnscoped)
+		return
+	}
+	db.Statement.SetColumn(Column, orgID)
+}
+
+// owned reports whether the statement's model is tenant-owned. Statements
+// without a parsed model, such as raw SQL, are not checked.
+func owned(db *gorm.DB) bool {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return false
+	}
+	_, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(Owned)
+	return ok
+}
@@ -0,0 +1,112 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type note struct {
+	ID    uint `gorm:"primaryKey"`
+	OrgID uint `gorm:"not null;index"`
+	Text  string
+}
+
+func (note) TenantOwned() {}
+
+type global struct {
+	ID   uint `gorm:"primaryKey"`
+	Text string
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&note{}, &global{}))
+	require.NoError(t, Register(db))
+	return db
+}
+
+func TestContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	orgID, ok := FromContext(NewContext(context.Background(), 3))
+	assert.True(t, ok)
+	assert.Equal(t, uint(3), orgID)
+
+	_, ok = FromContext(NewContext(context.Background(), 0))
+	assert.False(t, ok, "organization 0 is no organization")
+}
+
+func TestScope(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	db := openTestDB(t)
+	org1 := NewContext(context.Background(), 1)
+	org2 := NewContext(context.Background(), 2)
+
+	require.NoError(t, db.WithContext(org1).Scopes(Scope(org1)).Create(&note{Text: "one"}).Error)
+	require.NoError(t, db.WithContext(org2).Scopes(Scope(org2)).Create(&note{OrgID: 1, Text: "two"}).Error)
+
+	t.Run("inserts into the scoped organization", func(t *testing.T) {
+		var notes []note
+		require.NoError(t, db.Scopes(AllTenants).Order("id").Find(&notes).Error)
+		require.Len(t, notes, 2)
+		assert.Equal(t, uint(1), notes[0].OrgID)
+		assert.Equal(t, uint(2), notes[1].OrgID, "the scope overrides the row's organization")
+	})
+
+	t.Run("reads only the scoped organization", func(t *testing.T) {
+		var notes []note
+		require.NoError(t, db.Scopes(Scope(org1)).Find(&notes).Error)
+		require.Len(t, notes, 1)
+		assert.Equal(t, "one", notes[0].Text)
+
+		var count int64
+		require.NoError(t, db.Model(&note{}).Scopes(Scope(org2)).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("writes only the scoped organization", func(t *testing.T) {
+		result := db.Model(&note{}).Scopes(Scope(org2)).Where("text = ?", "one").Update("text", "changed")
+		require.NoError(t, result.Error)
+		assert.Zero(t, result.RowsAffected)
+
+		result = db.Scopes(Scope(org2)).Where("text = ?", "one").Delete(&note{})
+		require.NoError(t, result.Error)
+		assert.Zero(t, result.RowsAffected)
+	})
+
+	t.Run("fails closed", func(t *testing.T) {
+		var notes []note
+		assert.ErrorIs(t, db.Find(&notes).Error, ErrUnscoped)
+		assert.ErrorIs(t, db.Create(&note{OrgID: 1}).Error, ErrUnscoped)
+		assert.ErrorIs(t, db.Model(&note{}).Where("id = ?", 1).Update("text", "x").Error, ErrUnscoped)
+		assert.ErrorIs(t, db.Where("id = ?", 1).Delete(&note{}).Error, ErrUnscoped)
+		assert.ErrorIs(t, db.Scopes(Scope(context.Background())).Find(&notes).Error, ErrMissing)
+	})
+
+	t.Run("leaves other tables alone", func(t *testing.T) {
+		require.NoError(t, db.Create(&global{Text: "shared"}).Error)
+		var rows []global
+		require.NoError(t, db.Find(&rows).Error)
+		assert.Len(t, rows, 1)
+	})
+}
@@ -1,38 +1,50 @@
 package validator
 
 import (
+	"errors"
 	"reflect"
-	"regexp"
 	"strings"
 
 	validatePkg "github.com/go-playground/validator/v10"
 	governerrors "github.com/haipham22/govern/errors"
 
+	"golang-sample/internal/errcode"
+	"golang-sample/internal/i18n"
 	"golang-sample/internal/schemas"
 )
 
-// Precompiled regex for array index notation
-var arrayIndexRe = regexp.MustCompile(`\[\d+]`)
+// Keys of schemas.ErrorDetail.MsgValues
+const (
+	MsgValueTag   = "tag"
+	MsgValueParam = "param"
+	MsgValueKind  = "kind"
+)
+
+// english builds the default messages of validation errors
+var english = i18n.Default().Localizer(i18n.DefaultLocale)
 
 type CustomValidator struct {
 	validator *validatePkg.Validate
 }
 
+// Validate checks i and returns every failed field, wrapped with CodeInvalid
 func (cv *CustomValidator) Validate(i interface{}) error {
-	if err := cv.validator.Struct(i); err != nil {
-		for _, fieldErr := range err.(validatePkg.ValidationErrors) {
-			// Create detailed validation error with property information
-			property := FormatStructField(fieldErr)
-			detail := schemas.ErrorDetail{
-				Property: property,
-				Msg:      "Validation failed for field: " + property,
-			}
-			// Wrap the validation error with govern code and include detail in message
-			return governerrors.WrapCode(governerrors.CodeInvalid,
-				&ValidationError{Detail: detail})
-		}
+	err := cv.validator.Struct(i)
+	if err == nil {
+		return nil
 	}
-	return nil
+
+	var fieldErrs validatePkg.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		// Not a field failure, e.g. i is not a struct: a programming error
+		return err
+	}
+
+	details := make([]*schemas.ErrorDetail, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		details = append(details, newErrorDetail(fieldErr))
+	}
+	return Invalid(details...)
 }
 
 func NewCustomValidator() *CustomValidator {
@@ -51,23 +63,78 @@ func NewCustomValidator() *CustomValidator {
 	}
 }
 
+// FormatStructField returns the path of the failed field relative to the
+// validated struct, keeping nested fields and slice indices, e.g. "items[0].name"
 func FormatStructField(fieldError validatePkg.FieldError) string {
-	field := fieldError.Field()
+	namespace := fieldError.Namespace()
 
-	// Replace array index notation with an empty string
-	return arrayIndexRe.ReplaceAllString(field, "")
+	// Drop the name of the validated struct itself
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
 }
 
-// ValidationError represents a validation error with detailed information
+func newErrorDetail(fieldErr validatePkg.FieldError) *schemas.ErrorDetail {
+	return NewErrorDetail(FormatStructField(fieldErr), fieldErr.Tag(), fieldErr.Param(), fieldErr.Kind().String())
+}
+
+// NewErrorDetail describes a field that failed tag with an English message;
+// the error handler re-localizes it from MsgValues. The rejected value itself
+// is never included since it may be a password or other secret; only its kind is.
+func NewErrorDetail(property, tag, param, kind string) *schemas.ErrorDetail {
+	return &schemas.ErrorDetail{
+		Property: property,
+		Code:     string(errcode.ForValidationTag(tag)),
+		Msg:      english.FieldMessage(property, tag, param, kind),
+		MsgValues: map[string]interface{}{
+			MsgValueTag:   tag,
+			MsgValueParam: param,
+			MsgValueKind:  kind,
+		},
+	}
+}
+
+// Invalid reports field failures found outside struct validation, e.g. by a
+// service, the same way Validate does
+func Invalid(details ...*schemas.ErrorDetail) error {
+	return governerrors.WrapCode(governerrors.CodeInvalid, &ValidationError{Errors: details})
+}
+
+// LocalizeErrors returns copies of details with messages in the locale of loc
+func LocalizeErrors(loc i18n.Localizer, details []*schemas.ErrorDetail) []*schemas.ErrorDetail {
+	localized := make([]*schemas.ErrorDetail, 0, len(details))
+	for _, detail := range details {
+		d := *detail
+		tag, _ := d.MsgValues[MsgValueTag].(string)
+		if tag != "" {
+			param, _ := d.MsgValues[MsgValueParam].(string)
+			kind, _ := d.MsgValues[MsgValueKind].(string)
+			d.Msg = loc.FieldMessage(d.Property, tag, param, kind)
+		}
+		localized = append(localized, &d)
+	}
+	return localized
+}
+
+// ValidationError lists every field that failed validation
 type ValidationError struct {
-	Detail schemas.ErrorDetail
+	Errors []*schemas.ErrorDetail
 }
 
 func (e *ValidationError) Error() string {
-	return e.Detail.Msg
+	msgs := make([]string, 0, len(e.Errors))
+	for _, detail := range e.Errors {
+		msgs = append(msgs, detail.Msg)
+	}
+	return strings.Join(msgs, "; ")
 }
 
-// GetProperty returns the property that failed validation
-func (e *ValidationError) GetProperty() string {
-	return e.Detail.Property
+// Properties returns the paths of the fields that failed validation
+func (e *ValidationError) Properties() []string {
+	properties := make([]string, 0, len(e.Errors))
+	for _, detail := range e.Errors {
+		properties = append(properties, detail.Property)
+	}
+	return properties
 }
@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"testing"
+
+	governerrors "github.com/haipham22/govern/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/internal/schemas"
+)
+
+type testItem struct {
+	Name     string `json:"name" validate:"required"`
+	Quantity int    `json:"quantity" validate:"min=1,max=10"`
+}
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testOrder struct {
+	Email    string      `json:"email" validate:"required,email"`
+	Note     string      `json:"note" validate:"max=5"`
+	Coupon   string      `json:"coupon" validate:"min=3"`
+	Status   string      `json:"status" validate:"oneof=draft paid"`
+	Items    []testItem  `json:"items" validate:"min=1,dive"`
+	Tags     []string    `json:"tags" validate:"max=2"`
+	Address  testAddress `json:"address"`
+	Internal string      `json:"-" validate:"required"`
+}
+
+func validationErrors(t *testing.T, err error) []*schemas.ErrorDetail {
+	t.Helper()
+
+	require.Error(t, err)
+	assert.True(t, governerrors.IsCode(err, governerrors.CodeInvalid))
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	return validationErr.Errors
+}
+
+func TestValidate_ReportsEveryField(t *testing.T) {
+	v := NewCustomValidator()
+
+	err := v.Validate(&testOrder{
+		Email:    "not-an-email",
+		Note:     "too long",
+		Coupon:   "ab",
+		Status:   "shipped",
+		Items:    []testItem{{Name: "ok", Quantity: 1}, {Quantity: 11}},
+		Tags:     []string{"a", "b", "c"},
+		Internal: "set",
+	})
+
+	details := validationErrors(t, err)
+
+	got := map[string]string{}
+	for _, d := range details {
+		got[d.Property] = d.Msg
+	}
+	assert.Equal(t, map[string]string{
+		"email":             "email must be a valid email address",
+		"note":              "note must be at most 5 characters",
+		"coupon":            "coupon must be at least 3 characters",
+		"status":            "status must be one of: draft, paid",
+		"items[1].name":     "items[1].name is required",
+		"items[1].quantity": "items[1].quantity must be at most 10",
+		"tags":              "tags must be at most 2 items",
+		"address.city":      "address.city is required",
+	}, got)
+}
+
+func TestValidate_MsgValues(t *testing.T) {
+	v := NewCustomValidator()
+
+	details := validationErrors(t, v.Validate(&testItem{Name: "ok", Quantity: 0}))
+
+	require.Len(t, details, 1)
+	assert.Equal(t, "quantity", details[0].Property)
+	assert.Equal(t, map[string]interface{}{
+		MsgValueTag:   "min",
+		MsgValueParam: "1",
+		MsgValueKind:  "int",
+	}, details[0].MsgValues)
+}
+
+func TestValidate_Valid(t *testing.T) {
+	v := NewCustomValidator()
+
+	assert.NoError(t, v.Validate(&schemas.LoginRequest{Username: "alice", Password: "secret"}))
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Errors: []*schemas.ErrorDetail{
+		{Property: "username", Msg: "username is required"},
+		{Property: "email", Msg: "email must be a valid email address"},
+	}}
+
+	assert.Equal(t, "username is required; email must be a valid email address", err.Error())
+	assert.Equal(t, []string{"username", "email"}, err.Properties())
+}
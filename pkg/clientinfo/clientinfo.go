@@ -0,0 +1,26 @@
+// Package clientinfo carries the client a request comes from through
+// context.Context, so that services can record where a user signs in and
+// which request did what.
+package clientinfo
+
+import "context"
+
+// Info describes the client of a request
+type Info struct {
+	UserAgent string
+	IP        string
+	RequestID string
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx that carries info.
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// FromContext returns the client carried by ctx; it is zero when ctx has none.
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(ctxKey{}).(Info)
+	return info
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/haipham22/govern/config"
@@ -24,6 +26,186 @@ type EnvConfigMap struct {
 	API struct {
 		Secret string `mapstructure:"secret"`
 	} `mapstructure:"api"`
+	Log struct {
+		// Level is the minimum enabled level; defaults to debug when app.debug is set, info otherwise
+		Level string `mapstructure:"level" validate:"omitempty,oneof=debug info warn error dpanic panic fatal"`
+		// Format is json or console; defaults to json in production, console otherwise
+		Format      string   `mapstructure:"format" validate:"omitempty,oneof=json console"`
+		OutputPaths []string `mapstructure:"output_paths"`
+		Sampling    struct {
+			Enabled    bool `mapstructure:"enabled"`
+			Initial    int  `mapstructure:"initial" validate:"gte=0"`
+			Thereafter int  `mapstructure:"thereafter" validate:"gte=0"`
+		} `mapstructure:"sampling"`
+		Rotation struct {
+			MaxSizeMB  int  `mapstructure:"max_size_mb" validate:"gte=0"`
+			MaxBackups int  `mapstructure:"max_backups" validate:"gte=0"`
+			MaxAgeDays int  `mapstructure:"max_age_days" validate:"gte=0"`
+			Compress   bool `mapstructure:"compress"`
+		} `mapstructure:"rotation"`
+	} `mapstructure:"log"`
+	Tracing struct {
+		// Exporter is otlp, stdout or none (default: none)
+		Exporter string `mapstructure:"exporter" validate:"omitempty,oneof=otlp stdout none"`
+		// Endpoint is the OTLP/HTTP collector URL; defaults to OTEL_EXPORTER_OTLP_ENDPOINT
+		Endpoint string `mapstructure:"endpoint" validate:"omitempty,url"`
+		// SampleRatio is the fraction of new traces sampled; defaults to 1
+		SampleRatio float64 `mapstructure:"sample_ratio" validate:"gte=0,lte=1"`
+	} `mapstructure:"tracing"`
+	Sentry struct {
+		// DSN enables error reporting; events are dropped when empty
+		DSN string `mapstructure:"dsn" validate:"omitempty,url"`
+		// Environment defaults to app.env
+		Environment string `mapstructure:"environment"`
+		// Release defaults to the build version
+		Release string `mapstructure:"release"`
+		// SampleRate is the fraction of error events sent; 0 sends all
+		SampleRate float64 `mapstructure:"sample_rate" validate:"gte=0,lte=1"`
+	} `mapstructure:"sentry"`
+	Errors struct {
+		// LegacyFormat renders errors as {msg, error, path} instead of application/problem+json
+		LegacyFormat bool `mapstructure:"legacy_format"`
+		// TypeBaseURI prefixes problem type slugs (default: /problems/)
+		TypeBaseURI string `mapstructure:"type_base_uri" validate:"omitempty,uri"`
+	} `mapstructure:"errors"`
+	Health struct {
+		// DiskPath is the filesystem checked for free space (default: working directory)
+		DiskPath string `mapstructure:"disk_path"`
+		// DiskMinFree is the minimum fraction of free disk space; defaults to 0.05
+		DiskMinFree float64 `mapstructure:"disk_min_free" validate:"gte=0,lte=1"`
+	} `mapstructure:"health"`
+	Password struct {
+		// MinLength and MaxLength bound new passwords in characters; default 8 and 64
+		MinLength int `mapstructure:"min_length" validate:"gte=0"`
+		MaxLength int `mapstructure:"max_length" validate:"gte=0"`
+		// Character classes a new password must contain; none are required by default
+		RequireLower  bool `mapstructure:"require_lower"`
+		RequireUpper  bool `mapstructure:"require_upper"`
+		RequireDigit  bool `mapstructure:"require_digit"`
+		RequireSymbol bool `mapstructure:"require_symbol"`
+		// MinStrength is the minimum estimated strength, 1-4; defaults to 2
+		MinStrength int `mapstructure:"min_strength" validate:"gte=0,lte=4"`
+		// BreachList is a SHA-1 breached-password file or range directory; not checked when empty
+		BreachList string `mapstructure:"breach_list"`
+		// Algorithm hashes new passwords: argon2id (default) or bcrypt. Existing
+		// hashes of either kind keep working and are upgraded on login.
+		Algorithm string `mapstructure:"algorithm" validate:"omitempty,oneof=argon2id bcrypt"`
+		// BcryptCost defaults to 10
+		BcryptCost int `mapstructure:"bcrypt_cost" validate:"omitempty,gte=4,lte=31"`
+		// Argon2id parameters; default 19456 KiB, 2 iterations and parallelism 1
+		Argon2MemoryKiB   uint32 `mapstructure:"argon2_memory_kib" validate:"omitempty,gte=8"`
+		Argon2Iterations  uint32 `mapstructure:"argon2_iterations"`
+		Argon2Parallelism uint8  `mapstructure:"argon2_parallelism"`
+		// HashConcurrency bounds concurrent hashing operations; defaults to GOMAXPROCS
+		HashConcurrency int `mapstructure:"hash_concurrency" validate:"gte=0"`
+		// HashQueueTimeout is how long an operation waits for a slot before a 503; defaults to 2s
+		HashQueueTimeout time.Duration `mapstructure:"hash_queue_timeout" validate:"gte=0"`
+	} `mapstructure:"password"`
+	Auth struct {
+		// NonEnumeratingRegistration answers every registration with 202 and
+		// emails the outcome of a conflict, so registration does not reveal
+		// which accounts exist
+		NonEnumeratingRegistration bool `mapstructure:"non_enumerating_registration"`
+		// NotifyNewDevice emails users when they log in from a device they
+		// have not used before
+		NotifyNewDevice bool `mapstructure:"notify_new_device"`
+		// SessionlessTokenCutoff is an RFC 3339 time. Access tokens without a
+		// session issued before it are rejected, so those from before sessions
+		// existed stop working; they are accepted until they expire when empty.
+		SessionlessTokenCutoff string `mapstructure:"sessionless_token_cutoff"`
+	} `mapstructure:"auth"`
+	Mail struct {
+		// From is the sender address, e.g. "Example <no-reply@example.com>"
+		From string `mapstructure:"from"`
+		// SMTPHost relays mail. Without it mail is written to the log, which
+		// is only allowed in development.
+		SMTPHost string `mapstructure:"smtp_host"`
+		// SMTPPort defaults to 587
+		SMTPPort     int    `mapstructure:"smtp_port" validate:"gte=0,lte=65535"`
+		SMTPUsername string `mapstructure:"smtp_username"`
+		SMTPPassword string `mapstructure:"smtp_password"`
+	} `mapstructure:"mail"`
+	OAuth struct {
+		// RedirectBaseURL is the public URL of the API; providers redirect to
+		// <base>/api/oauth/<provider>/callback. Social login is off when empty.
+		RedirectBaseURL string      `mapstructure:"redirect_base_url" validate:"omitempty,url"`
+		Google          OAuthClient `mapstructure:"google"`
+		GitHub          OAuthClient `mapstructure:"github"`
+		// OIDC is any other OpenID Connect provider, found by discovery at Issuer
+		OIDC struct {
+			// Name is the provider's path segment; defaults to oidc
+			Name         string `mapstructure:"name" validate:"omitempty,alphanum"`
+			Issuer       string `mapstructure:"issuer" validate:"omitempty,url"`
+			ClientID     string `mapstructure:"client_id"`
+			ClientSecret string `mapstructure:"client_secret"`
+		} `mapstructure:"oidc"`
+	} `mapstructure:"oauth"`
+	MagicLink struct {
+		// BaseURL is the public URL of the API; emailed links open
+		// <base>/api/login/magic-link/verify. Magic link login is off when empty.
+		BaseURL string `mapstructure:"base_url" validate:"omitempty,url"`
+		// TTL is the lifetime of a link; defaults to 15m
+		TTL time.Duration `mapstructure:"ttl" validate:"gte=0"`
+		// MaxPerHour bounds the links sent to one email per hour; defaults to 3
+		MaxPerHour int `mapstructure:"max_per_hour" validate:"gte=0"`
+	} `mapstructure:"magic_link"`
+	IdP struct {
+		// Issuer is the public base URL of this service as an OAuth2 and
+		// OpenID Connect provider for other apps; the provider is off when empty
+		Issuer string `mapstructure:"issuer" validate:"omitempty,url"`
+		// SigningKeyFile is a PEM RSA key that signs ID tokens. An ephemeral
+		// key is generated when empty, which invalidates ID tokens on restart.
+		SigningKeyFile string `mapstructure:"signing_key_file"`
+		// LoginURL is the frontend page that signs the user in and asks for
+		// consent; required with an issuer
+		LoginURL string `mapstructure:"login_url" validate:"omitempty,url"`
+		// AccessTokenTTL defaults to 1h
+		AccessTokenTTL time.Duration `mapstructure:"access_token_ttl" validate:"gte=0"`
+	} `mapstructure:"idp"`
+	Passkey struct {
+		// RPID is the domain passkeys are scoped to, e.g. example.com; the
+		// frontend must be served from it or a subdomain. Passkeys are off when empty.
+		RPID string `mapstructure:"rp_id" validate:"omitempty,hostname"`
+		// RPName is shown by the authenticator; defaults to RPID
+		RPName string `mapstructure:"rp_name"`
+		// Origins are the frontend origins allowed to use passkeys, e.g.
+		// https://app.example.com; required with an RP ID
+		Origins []string `mapstructure:"origins" validate:"dive,url"`
+	} `mapstructure:"passkey"`
+	Orgs struct {
+		// InvitationURL is the frontend page that accepts invitations; the
+		// token is added as its token query parameter. Invitation emails
+		// carry the bare token when empty.
+		InvitationURL string `mapstructure:"invitation_url" validate:"omitempty,url"`
+		// InvitationTTL is the lifetime of an invitation; defaults to 7 days
+		InvitationTTL time.Duration `mapstructure:"invitation_ttl" validate:"gte=0"`
+	} `mapstructure:"orgs"`
+	Events struct {
+		// Sinks receive domain events from the outbox: log, webhook and bus
+		// (in-process subscribers); defaults to log
+		Sinks []string `mapstructure:"sinks" validate:"dive,oneof=log webhook bus"`
+		// WebhookURL receives each event as a JSON POST; required with the webhook sink
+		WebhookURL string `mapstructure:"webhook_url" validate:"omitempty,url"`
+		// WebhookSecret signs webhook requests in X-Signature when set
+		WebhookSecret string `mapstructure:"webhook_secret"`
+		// PollInterval is how often the relay checks the outbox; defaults to 1s
+		PollInterval time.Duration `mapstructure:"poll_interval" validate:"gte=0"`
+		// BatchSize is the number of events relayed at once; defaults to 100
+		BatchSize int `mapstructure:"batch_size" validate:"gte=0"`
+		// Retention is how long published events are kept; defaults to 7 days
+		Retention time.Duration `mapstructure:"retention" validate:"gte=0"`
+	} `mapstructure:"events"`
+	Admin struct {
+		// Token guards the /admin endpoints; they are not registered when empty
+		Token string `mapstructure:"token"`
+	} `mapstructure:"admin"`
+}
+
+// OAuthClient is an application registered with an identity provider; the
+// provider is enabled when ClientID is set
+type OAuthClient struct {
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
 }
 
 // ENV is global variable for using config in other places
@@ -92,5 +274,39 @@ func (c *EnvConfigMap) Validate() error {
 		return fmt.Errorf("APP_API_SECRET must be at least 32 characters (got %d)", len(c.API.Secret))
 	}
 
+	if cutoff := c.Auth.SessionlessTokenCutoff; cutoff != "" {
+		if _, err := time.Parse(time.RFC3339, cutoff); err != nil {
+			return fmt.Errorf("APP_AUTH_SESSIONLESS_TOKEN_CUTOFF must be an RFC 3339 time: %w", err)
+		}
+	}
+
+	if c.Mail.SMTPHost != "" && c.Mail.From == "" {
+		return fmt.Errorf("APP_MAIL_FROM is required when APP_MAIL_SMTP_HOST is set")
+	}
+
+	if c.Auth.NonEnumeratingRegistration && c.Mail.SMTPHost == "" && c.App.Env != EnvDevelopment {
+		return fmt.Errorf("APP_MAIL_SMTP_HOST is required for non-enumerating registration outside development")
+	}
+
+	if c.MagicLink.BaseURL != "" && c.Mail.SMTPHost == "" && c.App.Env != EnvDevelopment {
+		return fmt.Errorf("APP_MAIL_SMTP_HOST is required for magic links outside development")
+	}
+
+	if c.IdP.Issuer != "" && c.IdP.LoginURL == "" {
+		return fmt.Errorf("APP_IDP_LOGIN_URL is required when APP_IDP_ISSUER is set")
+	}
+
+	if c.Passkey.RPID != "" && len(c.Passkey.Origins) == 0 {
+		return fmt.Errorf("APP_PASSKEY_ORIGINS is required when APP_PASSKEY_RP_ID is set")
+	}
+
+	if slices.Contains(c.Events.Sinks, "webhook") && c.Events.WebhookURL == "" {
+		return fmt.Errorf("APP_EVENTS_WEBHOOK_URL is required with the webhook sink")
+	}
+
+	if c.Admin.Token != "" && len(c.Admin.Token) < 32 {
+		return fmt.Errorf("APP_ADMIN_TOKEN must be at least 32 characters (got %d)", len(c.Admin.Token))
+	}
+
 	return nil
 }
@@ -235,3 +235,109 @@ func TestEnvConfigMapValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvConfigMapValidateLogAndAdmin(t *testing.T) {
+	newConfig := func() *EnvConfigMap {
+		cfg := &EnvConfigMap{}
+		cfg.App.Env = EnvProduction
+		cfg.Postgres.DSN = "host=localhost user=postgres password=password dbname=golang_sample port=5432 sslmode=disable"
+		return cfg
+	}
+
+	t.Run("valid log settings", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Log.Level = "warn"
+		cfg.Log.Format = "json"
+		cfg.Log.OutputPaths = []string{"stdout", "/var/log/golang-sample/app.log"}
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("invalid log level", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Log.Level = "verbose"
+
+		assert.ErrorContains(t, cfg.Validate(), "Level")
+	})
+
+	t.Run("invalid log format", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Log.Format = "xml"
+
+		assert.ErrorContains(t, cfg.Validate(), "Format")
+	})
+
+	t.Run("admin token too short", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Admin.Token = "short"
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_ADMIN_TOKEN must be at least 32 characters")
+	})
+
+	t.Run("sessionless token cutoff", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Auth.SessionlessTokenCutoff = "2026-10-01"
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_AUTH_SESSIONLESS_TOKEN_CUTOFF must be an RFC 3339 time")
+
+		cfg.Auth.SessionlessTokenCutoff = "2026-10-01T00:00:00Z"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("non-enumerating registration without SMTP outside development", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Auth.NonEnumeratingRegistration = true
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_MAIL_SMTP_HOST is required")
+
+		cfg.App.Env = EnvDevelopment
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("magic links without SMTP outside development", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.MagicLink.BaseURL = "https://api.example.com"
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_MAIL_SMTP_HOST is required")
+
+		cfg.App.Env = EnvDevelopment
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("SMTP without a sender", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Mail.SMTPHost = "smtp.example.com"
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_MAIL_FROM is required")
+	})
+
+	t.Run("identity provider without a login page", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.IdP.Issuer = "https://api.example.com"
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_IDP_LOGIN_URL is required")
+	})
+
+	t.Run("passkeys without origins", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Passkey.RPID = "example.com"
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_PASSKEY_ORIGINS is required")
+
+		cfg.Passkey.Origins = []string{"https://app.example.com"}
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("webhook sink without a URL", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Events.Sinks = []string{"log", "webhook"}
+
+		assert.ErrorContains(t, cfg.Validate(), "APP_EVENTS_WEBHOOK_URL is required")
+
+		cfg.Events.WebhookURL = "https://hooks.example.com/events"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Events.Sinks = []string{"kafka"}
+		assert.Error(t, cfg.Validate(), "unknown sinks are rejected")
+	})
+}
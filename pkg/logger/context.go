@@ -0,0 +1,38 @@
+// Package logger builds the application zap logger and carries request-scoped
+// loggers through context.Context so that services and repositories log with
+// the request's correlation fields.
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx that carries log.
+func NewContext(ctx context.Context, log *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, log)
+}
+
+// FromContext returns the logger carried by ctx, or fallback when ctx has none.
+// A nil fallback resolves to the global zap.S() logger.
+func FromContext(ctx context.Context, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	if ctx != nil {
+		if log, ok := ctx.Value(ctxKey{}).(*zap.SugaredLogger); ok && log != nil {
+			return log
+		}
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return zap.S()
+}
+
+// With returns a copy of ctx whose logger is enriched with keysAndValues.
+// Use it to attach fields that become known mid-request, such as the user ID
+// after authentication.
+func With(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	return NewContext(ctx, FromContext(ctx, nil).With(keysAndValues...))
+}
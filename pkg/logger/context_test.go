@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger() (*zap.SugaredLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(zap.DebugLevel)
+	return zap.New(core).Sugar(), logs
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("returns logger stored in context", func(t *testing.T) {
+		log, _ := newObservedLogger()
+		ctx := NewContext(context.Background(), log)
+
+		assert.Same(t, log, FromContext(ctx, zap.NewNop().Sugar()))
+	})
+
+	t.Run("returns fallback when context has no logger", func(t *testing.T) {
+		fallback := zap.NewNop().Sugar()
+
+		assert.Same(t, fallback, FromContext(context.Background(), fallback))
+	})
+
+	t.Run("returns global logger when fallback is nil", func(t *testing.T) {
+		assert.Same(t, zap.S(), FromContext(context.Background(), nil))
+	})
+
+	t.Run("handles nil context", func(t *testing.T) {
+		fallback := zap.NewNop().Sugar()
+
+		//nolint:staticcheck // nil context is tested on purpose
+		assert.Same(t, fallback, FromContext(nil, fallback))
+	})
+}
+
+func TestWith(t *testing.T) {
+	log, logs := newObservedLogger()
+	ctx := NewContext(context.Background(), log.With("request_id", "req-1"))
+
+	ctx = With(ctx, "user_id", "42")
+	FromContext(ctx, nil).Info("hello")
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, "42", fields["user_id"])
+}
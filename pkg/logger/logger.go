@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// FormatJSON encodes log entries as JSON objects, one per line
+	FormatJSON = "json"
+	// FormatConsole encodes log entries in a human-friendly format
+	FormatConsole = "console"
+)
+
+// Config describes how the application logger is built
+type Config struct {
+	// Level is the initial minimum level; it can be changed at runtime through the returned AtomicLevel
+	Level string
+	// Format is FormatJSON or FormatConsole
+	Format string
+	// OutputPaths lists sinks: "stdout", "stderr" or file paths. Defaults to stderr.
+	OutputPaths []string
+	// Sampling caps repeated entries per second; nil disables sampling
+	Sampling *SamplingConfig
+	// Rotation applies to file output paths
+	Rotation RotationConfig
+	// Development enables stack traces on warnings and colored console levels
+	Development bool
+}
+
+// SamplingConfig logs the first Initial entries with the same level and message
+// each second, then every Thereafter-th entry
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+}
+
+// RotationConfig controls file rotation. Zero values fall back to lumberjack defaults.
+type RotationConfig struct {
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+// New builds a zap logger from cfg. The returned AtomicLevel changes the
+// level at runtime, and cleanup flushes buffered entries and closes log files.
+func New(cfg Config) (*zap.Logger, zap.AtomicLevel, func(), error) {
+	level := zap.NewAtomicLevel()
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, level, nil, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+		}
+	}
+
+	encoder, err := newEncoder(cfg)
+	if err != nil {
+		return nil, level, nil, err
+	}
+
+	sink, closeSinks := newSink(cfg)
+
+	var core zapcore.Core = zapcore.NewCore(encoder, sink, level)
+	if cfg.Sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+
+	opts := []zap.Option{zap.AddCaller(), zap.ErrorOutput(zapcore.Lock(os.Stderr))}
+	if cfg.Development {
+		opts = append(opts, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
+	} else {
+		opts = append(opts, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+
+	log := zap.New(core, opts...)
+	cleanup := func() {
+		// Sync on a terminal returns EINVAL/ENOTTY, which is not actionable
+		_ = log.Sync()
+		closeSinks()
+	}
+
+	return log, level, cleanup, nil
+}
+
+func newEncoder(cfg Config) (zapcore.Encoder, error) {
+	switch cfg.Format {
+	case FormatJSON:
+		encCfg := zap.NewProductionEncoderConfig()
+		encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewJSONEncoder(encCfg), nil
+	case FormatConsole, "":
+		encCfg := zap.NewDevelopmentEncoderConfig()
+		if cfg.Development {
+			encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(encCfg), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be %s or %s", cfg.Format, FormatJSON, FormatConsole)
+	}
+}
+
+// newSink combines the configured output paths into one WriteSyncer.
+// File paths are written through lumberjack so they rotate.
+func newSink(cfg Config) (zapcore.WriteSyncer, func()) {
+	paths := cfg.OutputPaths
+	if len(paths) == 0 {
+		paths = []string{"stderr"}
+	}
+
+	syncers := make([]zapcore.WriteSyncer, 0, len(paths))
+	files := make([]*lumberjack.Logger, 0, len(paths))
+	for _, path := range paths {
+		switch path {
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		default:
+			file := &lumberjack.Logger{
+				Filename:   path,
+				MaxSize:    cfg.Rotation.MaxSizeMB,
+				MaxBackups: cfg.Rotation.MaxBackups,
+				MaxAge:     cfg.Rotation.MaxAgeDays,
+				Compress:   cfg.Rotation.Compress,
+			}
+			files = append(files, file)
+			syncers = append(syncers, zapcore.AddSync(file))
+		}
+	}
+
+	closeFiles := func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}
+
+	return zapcore.NewMultiWriteSyncer(syncers...), closeFiles
+}
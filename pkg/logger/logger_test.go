@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// readLines returns the JSON log entries written to path
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestNew_JSONFileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	log, _, cleanup, err := New(Config{
+		Level:       "info",
+		Format:      FormatJSON,
+		OutputPaths: []string{path},
+	})
+	require.NoError(t, err)
+
+	log.With(zap.String("app.env", "production")).Info("hello")
+	log.Debug("filtered by level")
+	cleanup()
+
+	entries := readLines(t, path)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "hello", entries[0]["msg"])
+	assert.Equal(t, "info", entries[0]["level"])
+	assert.Equal(t, "production", entries[0]["app.env"])
+}
+
+func TestNew_AtomicLevelChangesAtRuntime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	log, level, cleanup, err := New(Config{
+		Level:       "warn",
+		Format:      FormatJSON,
+		OutputPaths: []string{path},
+	})
+	require.NoError(t, err)
+
+	log.Info("before")
+	level.SetLevel(zap.DebugLevel)
+	log.Debug("after")
+	cleanup()
+
+	entries := readLines(t, path)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "after", entries[0]["msg"])
+}
+
+func TestNew_Sampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	log, _, cleanup, err := New(Config{
+		Format:      FormatJSON,
+		OutputPaths: []string{path},
+		Sampling:    &SamplingConfig{Initial: 2, Thereafter: 100},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		log.Info("repeated")
+	}
+	cleanup()
+
+	assert.Len(t, readLines(t, path), 2, "only the first entries within the tick are kept")
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	t.Run("invalid level", func(t *testing.T) {
+		_, _, _, err := New(Config{Level: "verbose"})
+		assert.ErrorContains(t, err, "invalid log level")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, _, _, err := New(Config{Format: "xml"})
+		assert.ErrorContains(t, err, "invalid log format")
+	})
+}
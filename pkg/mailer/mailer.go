@@ -0,0 +1,128 @@
+// Package mailer sends transactional email.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPConfig configures an SMTP relay
+type SMTPConfig struct {
+	Host string
+	// Port defaults to 587; the connection is upgraded with STARTTLS when
+	// the server offers it
+	Port int
+	// Username and Password authenticate with PLAIN when Username is set
+	Username string
+	Password string
+	// From is the sender address, e.g. "Example <no-reply@example.com>"
+	From string
+}
+
+type smtpMailer struct {
+	cfg  SMTPConfig
+	addr string
+	from *mail.Address
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTP returns a Mailer that relays through an SMTP server
+func NewSMTP(cfg SMTPConfig) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("mailer: invalid from address: %w", err)
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+
+	return &smtpMailer{
+		cfg:  cfg,
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		from: from,
+		send: smtp.SendMail,
+	}, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("mailer: invalid recipient: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	data, err := compose(m.from, to, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := m.send(m.addr, auth, m.from.Address, []string{to.Address}, data); err != nil {
+		return fmt.Errorf("mailer: send: %w", err)
+	}
+	return nil
+}
+
+// compose renders msg as an RFC 5322 message. Header values are checked
+// for line breaks, which would let them inject headers.
+func compose(from, to *mail.Address, msg Message, date time.Time) ([]byte, error) {
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, fmt.Errorf("mailer: subject contains a line break")
+	}
+
+	var b bytes.Buffer
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + to.String() + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+type logMailer struct {
+	log *zap.SugaredLogger
+}
+
+// NewLog returns a Mailer for development that writes messages to the log
+// instead of sending them. The log then holds whatever the messages
+// carry, such as sign-in links, so it must not be used in production.
+func NewLog(log *zap.SugaredLogger) Mailer {
+	return &logMailer{log: log}
+}
+
+func (m *logMailer) Send(_ context.Context, msg Message) error {
+	m.log.Infof("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
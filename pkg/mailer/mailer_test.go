@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"context"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	m, err := NewSMTP(SMTPConfig{
+		Host:     "smtp.example.com",
+		Username: "user",
+		Password: "secret",
+		From:     "Example <no-reply@example.com>",
+	})
+	require.NoError(t, err)
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	m.(*smtpMailer).send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		assert.NotNil(t, a)
+		return nil
+	}
+
+	require.NoError(t, m.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "Sign in",
+		Body:    "Line one\nLine two",
+	}))
+
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, "no-reply@example.com", gotFrom)
+	assert.Equal(t, []string{"alice@example.com"}, gotTo)
+	assert.Contains(t, string(gotMsg), "Subject: Sign in\r\n")
+	assert.True(t, strings.HasSuffix(string(gotMsg), "\r\n\r\nLine one\r\nLine two"))
+}
+
+func TestSMTPMailer_RejectsHeaderInjection(t *testing.T) {
+	m, err := NewSMTP(SMTPConfig{Host: "smtp.example.com", From: "no-reply@example.com"})
+	require.NoError(t, err)
+	m.(*smtpMailer).send = func(string, smtp.Auth, string, []string, []byte) error {
+		t.Fatal("message must not be sent")
+		return nil
+	}
+
+	assert.Error(t, m.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hi\r\nBcc: eve@example.com"}))
+	assert.Error(t, m.Send(context.Background(), Message{To: "alice@example.com\r\nBcc: eve@example.com", Subject: "Hi"}))
+}
+
+func TestCompose_EncodesSubject(t *testing.T) {
+	from := &mail.Address{Address: "no-reply@example.com"}
+	to := &mail.Address{Name: "Alice", Address: "alice@example.com"}
+
+	data, err := compose(from, to, Message{Subject: "Đăng nhập", Body: "x"}, time.Unix(0, 0).UTC())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), "Subject: =?utf-8?q?")
+	assert.Contains(t, string(data), "To: \"Alice\" <alice@example.com>\r\n")
+}
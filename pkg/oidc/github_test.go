@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeGitHub(t *testing.T, emails []map[string]any) Provider {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "good-code" {
+			// GitHub reports errors with 200
+			writeJSON(w, http.StatusOK, map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"access_token": "gho_token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gho_token", r.Header.Get("Authorization"))
+		writeJSON(w, http.StatusOK, map[string]any{"id": 583231, "login": "octocat", "name": "The Octocat", "email": "public@example.com"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, emails)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &githubProvider{
+		cfg:      Config{ClientID: "gh-client", ClientSecret: "secret", RedirectURL: "http://app.test/callback", HTTPClient: server.Client()},
+		authURL:  server.URL + "/login/oauth/authorize",
+		tokenURL: server.URL + "/login/oauth/access_token",
+		apiURL:   server.URL,
+	}
+}
+
+func TestGitHub_Exchange(t *testing.T) {
+	t.Run("primary verified email", func(t *testing.T) {
+		provider := newFakeGitHub(t, []map[string]any{
+			{"email": "secondary@example.com", "primary": false, "verified": true},
+			{"email": "octocat@example.com", "primary": true, "verified": true},
+		})
+
+		identity, err := provider.Exchange(context.Background(), "good-code", "verifier", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, &Identity{
+			Provider:      "github",
+			Subject:       "583231",
+			Email:         "octocat@example.com",
+			EmailVerified: true,
+			Name:          "The Octocat",
+		}, identity)
+	})
+
+	t.Run("unverified primary email", func(t *testing.T) {
+		provider := newFakeGitHub(t, []map[string]any{
+			{"email": "octocat@example.com", "primary": true, "verified": false},
+		})
+
+		identity, err := provider.Exchange(context.Background(), "good-code", "verifier", "")
+
+		require.NoError(t, err)
+		assert.False(t, identity.EmailVerified)
+	})
+
+	t.Run("bad code", func(t *testing.T) {
+		provider := newFakeGitHub(t, nil)
+
+		_, err := provider.Exchange(context.Background(), "bad-code", "verifier", "")
+
+		assert.ErrorIs(t, err, ErrVerification)
+	})
+}
+
+func TestGitHub_AuthCodeURL(t *testing.T) {
+	authURL, err := GitHub(Config{ClientID: "gh-client", RedirectURL: "http://app.test/callback"}).
+		AuthCodeURL(context.Background(), "the-state", "ignored", "the-challenge")
+	require.NoError(t, err)
+
+	assert.Contains(t, authURL, "https://github.com/login/oauth/authorize?")
+	assert.Contains(t, authURL, "state=the-state")
+	assert.Contains(t, authURL, "code_challenge=the-challenge")
+	assert.Contains(t, authURL, "scope=read%3Auser+user%3Aemail")
+	assert.NotContains(t, authURL, "nonce")
+}
@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GoogleIssuer is the OpenID Connect issuer of Google accounts
+const GoogleIssuer = "https://accounts.google.com"
+
+// idTokenLeeway tolerates clock skew with the provider
+const idTokenLeeway = time.Minute
+
+// signingMethods are the ID token algorithms accepted; "none" and HMAC with
+// the client secret never are
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "PS256"}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider is an OpenID Connect provider configured by discovery
+type oidcProvider struct {
+	name   string
+	issuer string
+	cfg    Config
+	parser *jwt.Parser
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      *keySet
+}
+
+// NewOIDC returns an OpenID Connect provider named name. Its endpoints and
+// keys are discovered from issuer on first use, so an unreachable provider
+// does not prevent startup.
+func NewOIDC(name, issuer string, cfg Config) Provider {
+	return &oidcProvider{
+		name:   name,
+		issuer: strings.TrimSuffix(issuer, "/"),
+		cfg:    cfg,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(signingMethods),
+			jwt.WithIssuer(strings.TrimSuffix(issuer, "/")),
+			jwt.WithAudience(cfg.ClientID),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithLeeway(idTokenLeeway),
+		),
+	}
+}
+
+// Google returns the provider of Google accounts
+func Google(cfg Config) Provider {
+	return NewOIDC("google", GoogleIssuer, cfg)
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return authCodeURL(doc.AuthorizationEndpoint, p.cfg, p.cfg.scopes("openid", "email", "profile"), url.Values{
+		"state":          {state},
+		"nonce":          {nonce},
+		"code_challenge": {codeChallenge},
+	})
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	doc, keys, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := exchangeCode(ctx, p.cfg, doc.TokenEndpoint, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrVerification)
+	}
+	return p.verifyIDToken(ctx, keys, token.IDToken, nonce)
+}
+
+// idTokenClaims are the ID token claims used to build an Identity
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string   `json:"nonce"`
+	AuthorizedParty string   `json:"azp"`
+	Email           string   `json:"email"`
+	EmailVerified   flexBool `json:"email_verified"`
+	Name            string   `json:"name"`
+}
+
+// verifyIDToken checks the signature, issuer, audience, lifetime and nonce
+// of an ID token (OpenID Connect Core 3.1.3.7)
+func (p *oidcProvider) verifyIDToken(ctx context.Context, keys *keySet, raw, nonce string) (*Identity, error) {
+	claims := &idTokenClaims{}
+	_, err := p.parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ID token: %w", ErrVerification, err)
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: ID token azp %q is not the client", ErrVerification, claims.AuthorizedParty)
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: ID token nonce mismatch", ErrVerification)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: ID token has no subject", ErrVerification)
+	}
+
+	return &Identity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// discover fetches the discovery document once; a failed fetch is retried
+// by the next call
+func (p *oidcProvider) discover(ctx context.Context) (*discoveryDocument, *keySet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, p.keys, nil
+	}
+
+	var doc discoveryDocument
+	if err := getJSON(ctx, p.cfg.httpClient(), p.issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+		return nil, nil, fmt.Errorf("oidc: discovery of %s failed: %w", p.issuer, err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.issuer {
+		return nil, nil, fmt.Errorf("%w: discovery issuer %q does not match %q", ErrVerification, doc.Issuer, p.issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, nil, fmt.Errorf("oidc: discovery document of %s is incomplete", p.issuer)
+	}
+
+	p.discovery = &doc
+	p.keys = newKeySet(doc.JWKSURI, p.cfg.httpClient())
+	return p.discovery, p.keys, nil
+}
+
+// flexBool accepts booleans and the "true"/"false" strings some providers
+// send for email_verified
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		*b = flexBool(v == "true")
+	default:
+		*b = false
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdP is a local OpenID Connect provider. authorize stands in for the
+// user consenting on the provider's page.
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu sync.Mutex
+	// grants maps issued codes to the authorization request
+	grants map[string]url.Values
+	// claims adjusts the ID token claims of the next token response
+	claims func(jwt.MapClaims)
+	// sign overrides how the ID token is signed
+	sign func(jwt.MapClaims) string
+	// issuer overrides the issuer published by discovery
+	issuer string
+}
+
+const testClientID = "client-123"
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	f := &fakeIdP{t: t, key: newRSAKey(t), kid: "key-1", grants: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := f.server.URL
+		if f.issuer != "" {
+			issuer = f.issuer
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": f.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", f.token)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeIdP) provider() Provider {
+	return NewOIDC("fake", f.server.URL, Config{
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.test/callback",
+		HTTPClient:   f.server.Client(),
+	})
+}
+
+// authorize consents to the authorization request at authURL and returns the code
+func (f *fakeIdP) authorize(authURL string) string {
+	f.t.Helper()
+	u, err := url.Parse(authURL)
+	require.NoError(f.t, err)
+	code, err := RandomString()
+	require.NoError(f.t, err)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.grants[code] = u.Query()
+	return code
+}
+
+func (f *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	require.NoError(f.t, r.ParseForm())
+
+	f.mu.Lock()
+	grant, ok := f.grants[r.PostForm.Get("code")]
+	delete(f.grants, r.PostForm.Get("code"))
+	f.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case S256Challenge(r.PostForm.Get("code_verifier")) != grant.Get("code_challenge"):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	case r.PostForm.Get("client_id") != testClientID || r.PostForm.Get("redirect_uri") != grant.Get("redirect_uri"):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            f.server.URL,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          grant.Get("nonce"),
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"name":           "Alice",
+	}
+	if f.claims != nil {
+		f.claims(claims)
+	}
+	idToken := f.signed(claims)
+	if f.sign != nil {
+		idToken = f.sign(claims)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": idToken})
+}
+
+func (f *fakeIdP) signed(claims jwt.MapClaims) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = f.kid
+	signed, err := token.SignedString(f.key)
+	require.NoError(f.t, err)
+	return signed
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// signIn runs the flow against provider, consenting with idp
+func signIn(t *testing.T, idp *fakeIdP, provider Provider, exchangeVerifier func(string) string) (*Identity, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	state, err := RandomString()
+	require.NoError(t, err)
+	nonce, err := RandomString()
+	require.NoError(t, err)
+	verifier, challenge, err := NewPKCE()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+	require.NoError(t, err)
+	code := idp.authorize(authURL)
+
+	if exchangeVerifier != nil {
+		verifier = exchangeVerifier(verifier)
+	}
+	return provider.Exchange(ctx, code, verifier, nonce)
+}
+
+func TestOIDC_AuthCodeURL(t *testing.T) {
+	idp := newFakeIdP(t)
+
+	authURL, err := idp.provider().AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-challenge")
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, idp.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	q := u.Query()
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, testClientID, q.Get("client_id"))
+	assert.Equal(t, "http://app.test/callback", q.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "the-state", q.Get("state"))
+	assert.Equal(t, "the-nonce", q.Get("nonce"))
+	assert.Equal(t, "the-challenge", q.Get("code_challenge"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+}
+
+func TestOIDC_Exchange(t *testing.T) {
+	t.Run("verified identity", func(t *testing.T) {
+		idp := newFakeIdP(t)
+
+		identity, err := signIn(t, idp, idp.provider(), nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, &Identity{
+			Provider:      "fake",
+			Subject:       "user-1",
+			Email:         "alice@example.com",
+			EmailVerified: true,
+			Name:          "Alice",
+		}, identity)
+	})
+
+	t.Run("email_verified as a string", func(t *testing.T) {
+		idp := newFakeIdP(t)
+		idp.claims = func(c jwt.MapClaims) { c["email_verified"] = "true" }
+
+		identity, err := signIn(t, idp, idp.provider(), nil)
+
+		require.NoError(t, err)
+		assert.True(t, identity.EmailVerified)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		idp := newFakeIdP(t)
+		idp.claims = func(c jwt.MapClaims) { c["email_verified"] = false }
+
+		identity, err := signIn(t, idp, idp.provider(), nil)
+
+		require.NoError(t, err)
+		assert.False(t, identity.EmailVerified)
+	})
+
+	t.Run("wrong PKCE verifier", func(t *testing.T) {
+		idp := newFakeIdP(t)
+
+		_, err := signIn(t, idp, idp.provider(), func(string) string { return "another-verifier" })
+
+		assert.ErrorIs(t, err, ErrVerification)
+	})
+}
+
+func TestOIDC_Exchange_RejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(jwt.MapClaims)
+		sign   func(idp *fakeIdP, c jwt.MapClaims) string
+	}{
+		{name: "nonce mismatch", claims: func(c jwt.MapClaims) { c["nonce"] = "replayed" }},
+		{name: "missing nonce", claims: func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{name: "other audience", claims: func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{name: "several audiences without azp", claims: func(c jwt.MapClaims) { c["aud"] = []string{testClientID, "other-client"} }},
+		{name: "other issuer", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }},
+		{name: "expired", claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "missing subject", claims: func(c jwt.MapClaims) { delete(c, "sub") }},
+		{
+			name: "signed by another key",
+			sign: func(idp *fakeIdP, c jwt.MapClaims) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+				token.Header["kid"] = idp.kid
+				signed, err := token.SignedString(newRSAKey(idp.t))
+				require.NoError(idp.t, err)
+				return signed
+			},
+		},
+		{
+			name: "HMAC with the client secret",
+			sign: func(idp *fakeIdP, c jwt.MapClaims) string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte("secret"))
+				require.NoError(idp.t, err)
+				return signed
+			},
+		},
+		{
+			name: "unsigned",
+			sign: func(idp *fakeIdP, c jwt.MapClaims) string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, c).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				require.NoError(idp.t, err)
+				return signed
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			idp.claims = tt.claims
+			if tt.sign != nil {
+				idp.sign = func(c jwt.MapClaims) string { return tt.sign(idp, c) }
+			}
+
+			identity, err := signIn(t, idp, idp.provider(), nil)
+
+			assert.ErrorIs(t, err, ErrVerification)
+			assert.Nil(t, identity)
+		})
+	}
+}
+
+func TestOIDC_KeyRotation(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := idp.provider()
+
+	_, err := signIn(t, idp, provider, nil)
+	require.NoError(t, err)
+
+	idp.mu.Lock()
+	idp.key, idp.kid = newRSAKey(t), "key-2"
+	idp.mu.Unlock()
+
+	_, err = signIn(t, idp, provider, nil)
+	assert.ErrorIs(t, err, ErrVerification, "the key set is not refetched within the refresh interval")
+
+	keys := provider.(*oidcProvider).keys
+	keys.mu.Lock()
+	keys.fetchedAt = time.Now().Add(-jwksRefreshInterval)
+	keys.mu.Unlock()
+
+	_, err = signIn(t, idp, provider, nil)
+	assert.NoError(t, err, "an unknown key ID refetches a stale key set")
+}
+
+func TestOIDC_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.issuer = "https://evil.example"
+
+	_, err := idp.provider().AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+
+	assert.ErrorIs(t, err, ErrVerification)
+}
+
+func TestNewPKCE(t *testing.T) {
+	verifier, challenge, err := NewPKCE()
+	require.NoError(t, err)
+
+	assert.Len(t, verifier, 43)
+	assert.Equal(t, S256Challenge(verifier), challenge)
+}
@@ -0,0 +1,204 @@
+// Package oidc signs users in with external identity providers: the OAuth
+// 2.0 authorization-code flow with PKCE and, for OpenID Connect providers,
+// ID token verification through discovery and JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrVerification is wrapped by every error about an untrustworthy response
+// of the provider, as opposed to a failure to reach it
+var ErrVerification = errors.New("oidc: verification failed")
+
+// Identity is a user as asserted by a provider
+type Identity struct {
+	// Provider is the Name of the provider
+	Provider string
+	// Subject identifies the user at the provider; it never changes
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an identity provider supporting the authorization-code flow
+type Provider interface {
+	// Name identifies the provider in URLs and stored identities
+	Name() string
+	// AuthCodeURL returns the URL of the provider's consent page. state,
+	// nonce and the S256 codeChallenge are echoed back or bound to the code.
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems the code returned to the redirect URL and returns the
+	// verified identity. nonce is the one passed to AuthCodeURL.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+// Config is the client registration at a provider
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered at the provider
+	RedirectURL string
+	// Scopes default to each provider's scopes for an email identity
+	Scopes []string
+	// HTTPClient defaults to a client with a 10s timeout
+	HTTPClient *http.Client
+}
+
+func (c Config) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return defaultHTTPClient
+}
+
+func (c Config) scopes(defaults ...string) string {
+	if len(c.Scopes) > 0 {
+		return strings.Join(c.Scopes, " ")
+	}
+	return strings.Join(defaults, " ")
+}
+
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// RandomString returns a URL-safe random string for state and nonce values
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewPKCE returns a PKCE code verifier and its S256 challenge (RFC 7636)
+func NewPKCE() (verifier, challenge string, err error) {
+	verifier, err = RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	return verifier, S256Challenge(verifier), nil
+}
+
+// S256Challenge returns the S256 code challenge of verifier
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authCodeURL builds the authorization request of the code flow with PKCE
+func authCodeURL(endpoint string, cfg Config, scope string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: invalid authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg.ClientID)
+	q.Set("redirect_uri", cfg.RedirectURL)
+	q.Set("scope", scope)
+	q.Set("code_challenge_method", "S256")
+	for key, values := range params {
+		for _, v := range values {
+			q.Add(key, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode redeems code at the token endpoint, authenticating with the
+// client secret in the body
+func exchangeCode(ctx context.Context, cfg Config, tokenURL, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	status, err := doJSON(cfg.httpClient(), req, &token)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	// GitHub reports errors with 200
+	if token.Error != "" {
+		return nil, fmt.Errorf("%w: token endpoint returned %s: %s", ErrVerification, token.Error, token.ErrorDescription)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint returned status %d", status)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: token response has no access_token", ErrVerification)
+	}
+	return &token, nil
+}
+
+// maxResponseSize bounds provider responses
+const maxResponseSize = 1 << 20
+
+// doJSON sends req and decodes a JSON body into v, whatever the status
+func doJSON(client *http.Client, req *http.Request, v any) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// getJSON fetches url, sending token as a bearer token when set
+func getJSON(ctx context.Context, client *http.Client, url, token string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	status, err := doJSON(client, req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", url, status)
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 
 	governpostgres "github.com/haipham22/govern/database/postgres"
 	"gorm.io/gorm"
+
+	"golang-sample/pkg/tracing"
 )
 
 // Config holds database configuration
@@ -59,5 +61,11 @@ func New(cfg Config) (*gorm.DB, func(), error) {
 		return nil, nil, err
 	}
 
+	// Trace every query as a child of the request span
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
 	return db, cleanup, nil
 }
@@ -0,0 +1,169 @@
+// Package reporting configures Sentry error reporting and scrubs sensitive
+// data from events before they leave the process.
+package reporting
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// filtered replaces the value of sensitive fields
+const filtered = "[Filtered]"
+
+// sensitiveKeys are matched case-insensitively as substrings of header,
+// query, body and extra keys, so "new_password" and "X-Admin-Token" match too.
+var sensitiveKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"api_key",
+	"apikey",
+	"session",
+	"credential",
+}
+
+// Config describes how errors are reported to Sentry
+type Config struct {
+	// DSN is the Sentry project DSN; reporting is disabled when empty
+	DSN         string
+	Environment string
+	Release     string
+	// SampleRate is the fraction of error events sent; 0 sends all
+	SampleRate float64
+	Debug      bool
+	// Transport overrides how events are sent; tests inject a fake to inspect events
+	Transport sentry.Transport
+}
+
+// Init initializes the global Sentry client. With an empty DSN the client is
+// still installed but drops every event, so capture calls are always safe.
+func Init(cfg Config) error {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		Release:          cfg.Release,
+		SampleRate:       cfg.SampleRate,
+		Debug:            cfg.Debug,
+		AttachStacktrace: true,
+		SendDefaultPII:   false,
+		BeforeSend:       Scrub,
+		Transport:        cfg.Transport,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize sentry: %w", err)
+	}
+	return nil
+}
+
+// Scrub is a sentry.ClientOptions.BeforeSend hook that filters sensitive
+// headers, cookies, query parameters, JSON body fields and extra data.
+func Scrub(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	if event == nil {
+		return nil
+	}
+
+	if req := event.Request; req != nil {
+		for key := range req.Headers {
+			if isSensitive(key) {
+				req.Headers[key] = filtered
+			}
+		}
+		if req.Cookies != "" {
+			req.Cookies = filtered
+		}
+		req.QueryString = scrubQuery(req.QueryString)
+		req.Data = scrubBody(req.Data)
+	}
+
+	for key, value := range event.Extra {
+		if isSensitive(key) {
+			event.Extra[key] = filtered
+		} else {
+			event.Extra[key] = scrubValue(value)
+		}
+	}
+
+	return event
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func scrubQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return filtered
+	}
+	for key := range values {
+		if isSensitive(key) {
+			values[key] = []string{filtered}
+		}
+	}
+	return values.Encode()
+}
+
+// scrubBody filters sensitive fields of a JSON body. Bodies that are not
+// JSON cannot be inspected and are dropped entirely.
+func scrubBody(data string) string {
+	if data == "" {
+		return data
+	}
+
+	var body interface{}
+	if err := json.Unmarshal([]byte(data), &body); err != nil {
+		return filtered
+	}
+
+	scrubbed, err := json.Marshal(scrubValue(body))
+	if err != nil {
+		return filtered
+	}
+	return string(scrubbed)
+}
+
+// scrubValue walks decoded JSON-like values and filters sensitive map keys
+func scrubValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if isSensitive(key) {
+				v[key] = filtered
+			} else {
+				v[key] = scrubValue(inner)
+			}
+		}
+		return v
+	case map[string]string:
+		for key := range v {
+			if isSensitive(key) {
+				v[key] = filtered
+			}
+		}
+		return v
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = scrubValue(inner)
+		}
+		return v
+	default:
+		return v
+	}
+}
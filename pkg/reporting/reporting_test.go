@@ -0,0 +1,86 @@
+package reporting
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/pkg/reporting/reportingtest"
+)
+
+// testDSN is a syntactically valid DSN; events never leave the fake transport
+const testDSN = "https://public@sentry.example.com/1"
+
+func TestScrub(t *testing.T) {
+	event := &sentry.Event{
+		Request: &sentry.Request{
+			Headers: map[string]string{
+				"X-Admin-Token": "admin-secret",
+				"Authorization": "Bearer abc",
+				"Content-Type":  "application/json",
+			},
+			Cookies:     "session=abc",
+			QueryString: "page=2&access_token=abc",
+			Data:        `{"username":"alice","password":"hunter2","nested":{"new_password":"x"},"items":[{"api_key":"k"}]}`,
+		},
+		Extra: map[string]interface{}{
+			"client_secret": "s",
+			"payload":       map[string]interface{}{"token": "t", "id": 1},
+		},
+	}
+
+	scrubbed := Scrub(event, nil)
+
+	req := scrubbed.Request
+	assert.Equal(t, filtered, req.Headers["X-Admin-Token"])
+	assert.Equal(t, filtered, req.Headers["Authorization"])
+	assert.Equal(t, "application/json", req.Headers["Content-Type"])
+	assert.Equal(t, filtered, req.Cookies)
+	assert.Equal(t, "access_token=%5BFiltered%5D&page=2", req.QueryString)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(req.Data), &body))
+	assert.Equal(t, "alice", body["username"])
+	assert.Equal(t, filtered, body["password"])
+	assert.Equal(t, filtered, body["nested"].(map[string]interface{})["new_password"])
+	assert.Equal(t, filtered, body["items"].([]interface{})[0].(map[string]interface{})["api_key"])
+
+	assert.Equal(t, filtered, scrubbed.Extra["client_secret"])
+	assert.Equal(t, filtered, scrubbed.Extra["payload"].(map[string]interface{})["token"])
+	assert.Equal(t, 1, scrubbed.Extra["payload"].(map[string]interface{})["id"])
+}
+
+func TestScrub_NonJSONBodyIsDropped(t *testing.T) {
+	event := &sentry.Event{Request: &sentry.Request{Data: "password=hunter2"}}
+
+	assert.Equal(t, filtered, Scrub(event, nil).Request.Data)
+}
+
+func TestInit_SendsScrubbedEvents(t *testing.T) {
+	transport := &reportingtest.Transport{}
+	require.NoError(t, Init(Config{
+		DSN:         testDSN,
+		Environment: "staging",
+		Release:     "v1.2.3",
+		Transport:   transport,
+	}))
+
+	req := httptest.NewRequest("POST", "/api/login?token=abc", nil)
+	req.Header.Set("X-Admin-Token", "admin-secret")
+
+	hub := sentry.CurrentHub().Clone()
+	hub.Scope().SetRequest(req)
+	hub.CaptureException(errors.New("boom"))
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "staging", events[0].Environment)
+	assert.Equal(t, "v1.2.3", events[0].Release)
+	assert.Equal(t, filtered, events[0].Request.Headers["X-Admin-Token"])
+	assert.Equal(t, "token=%5BFiltered%5D", events[0].Request.QueryString)
+}
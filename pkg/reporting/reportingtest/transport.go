@@ -0,0 +1,42 @@
+// Package reportingtest provides a fake Sentry transport for tests.
+package reportingtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Transport records events instead of sending them
+type Transport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+// Events returns the events sent so far
+func (t *Transport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// SendEvent implements sentry.Transport
+func (t *Transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+// Configure implements sentry.Transport
+func (t *Transport) Configure(sentry.ClientOptions) {}
+
+// Flush implements sentry.Transport
+func (t *Transport) Flush(time.Duration) bool { return true }
+
+// FlushWithContext implements sentry.Transport
+func (t *Transport) FlushWithContext(context.Context) bool { return true }
+
+// Close implements sentry.Transport
+func (t *Transport) Close() {}
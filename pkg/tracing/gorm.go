@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	gormTracerName = "golang-sample/pkg/tracing/gorm"
+	gormSpanKey    = "tracing:span"
+)
+
+// GormPlugin creates a client span for every GORM operation. Spans carry the
+// parameterized SQL only, so bound values (passwords, emails) are never exported.
+type GormPlugin struct {
+	tracer trace.Tracer
+}
+
+// NewGormPlugin creates a GORM plugin using the global tracer provider
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{tracer: otel.Tracer(gormTracerName)}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin by registering callbacks around each operation
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, h := range hooks {
+		if err := h.before("tracing:before_"+h.operation, p.before(h.operation)); err != nil {
+			return err
+		}
+		if err := h.after("tracing:after_"+h.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+
+		ctx, span := p.tracer.Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(semconv.DBQueryText(sql))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
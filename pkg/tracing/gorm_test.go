@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func newTracedDB(t *testing.T) (*gorm.DB, *tracetest.SpanRecorder, context.Context) {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&widget{}))
+	require.NoError(t, db.Use(&GormPlugin{tracer: tracer}))
+
+	ctx, root := tracer.Start(context.Background(), "request")
+	t.Cleanup(func() { root.End() })
+	return db, recorder, ctx
+}
+
+func attrValue(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestGormPlugin_TracesQueries(t *testing.T) {
+	db, recorder, ctx := newTracedDB(t)
+
+	require.NoError(t, db.WithContext(ctx).Create(&widget{Name: "secret-value"}).Error)
+	var found widget
+	require.NoError(t, db.WithContext(ctx).Where("name = ?", "secret-value").First(&found).Error)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "gorm.create", spans[0].Name())
+	assert.Equal(t, "gorm.query", spans[1].Name())
+
+	query := spans[1]
+	table, ok := attrValue(query, "db.collection.name")
+	require.True(t, ok)
+	assert.Equal(t, "widgets", table.AsString())
+
+	system, ok := attrValue(query, "db.system.name")
+	require.True(t, ok)
+	assert.Equal(t, "sqlite", system.AsString())
+
+	text, ok := attrValue(query, "db.query.text")
+	require.True(t, ok)
+	assert.Contains(t, text.AsString(), "SELECT")
+	assert.NotContains(t, text.AsString(), "secret-value", "bound values must not be exported")
+
+	for _, span := range spans {
+		assert.True(t, span.Parent().IsValid(), "%s should be a child of the request span", span.Name())
+	}
+}
+
+func TestGormPlugin_RecordsErrors(t *testing.T) {
+	db, recorder, ctx := newTracedDB(t)
+
+	var found widget
+	err := db.WithContext(ctx).First(&found, 42).Error
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = db.WithContext(ctx).Exec("SELECT * FROM missing_table").Error
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code, "record not found is not a failure")
+	assert.Equal(t, "gorm.raw", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
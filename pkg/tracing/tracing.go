@@ -0,0 +1,116 @@
+// Package tracing configures OpenTelemetry tracing and provides helpers
+// shared by the HTTP, service and database layers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterOTLP sends spans to an OTLP/HTTP collector
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans as JSON to stdout, useful in development
+	ExporterStdout = "stdout"
+	// ExporterNone disables span export; incoming trace context is still propagated
+	ExporterNone = "none"
+)
+
+// Config describes how spans are sampled and exported
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+	// Exporter is ExporterOTLP, ExporterStdout or ExporterNone
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318
+	Endpoint string
+	// SampleRatio is the fraction of new root traces sampled; 0 uses 1
+	SampleRatio float64
+}
+
+// Setup installs the global W3C trace context propagator and, unless the
+// exporter is none, a global tracer provider. The returned shutdown flushes
+// pending spans and must be called before exit.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter %q: must be %s, %s or %s",
+			cfg.Exporter, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+		semconv.DeploymentEnvironmentName(cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// TraceID returns the hex trace ID of the span in ctx, or empty string when
+// ctx carries no valid span context.
+func TraceID(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+	return spanCtx.TraceID().String()
+}
+
+// End records err on span, if any, and ends it. Use it with a named error
+// return: defer func() { tracing.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("none exporter installs no provider", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("invalid exporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+		assert.ErrorContains(t, err, "invalid tracing exporter")
+	})
+}
+
+func TestTraceID(t *testing.T) {
+	t.Run("empty without span", func(t *testing.T) {
+		assert.Empty(t, TraceID(context.Background()))
+	})
+
+	t.Run("returns trace ID of span in context", func(t *testing.T) {
+		traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		require.NoError(t, err)
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  trace.SpanID{1},
+		}))
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", TraceID(ctx))
+	})
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	End(ok, nil)
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("boom"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "boom", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1, "error should be recorded as an event")
+}
@@ -0,0 +1,20 @@
+// Package identity normalizes user identifiers (usernames and emails) so
+// that visually identical spellings compare equal.
+package identity
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize returns the canonical form of a username or email: trimmed,
+// NFKC-normalized and case-folded, so "Alice", "alice" and "ａｌｉｃｅ"
+// (fullwidth) are the same identifier. Store and compare this form; keep
+// the original for display.
+func Normalize(s string) string {
+	// Folding can leave a string that is no longer NFKC, so normalize again
+	folded := cases.Fold().String(norm.NFKC.String(strings.TrimSpace(s)))
+	return norm.NFKC.String(folded)
+}
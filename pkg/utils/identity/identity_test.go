@@ -0,0 +1,30 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "lowercase is unchanged", in: "alice", want: "alice"},
+		{name: "case folded", in: "Alice", want: "alice"},
+		{name: "email", in: "Alice@Example.COM", want: "alice@example.com"},
+		{name: "surrounding space trimmed", in: "  alice ", want: "alice"},
+		{name: "fullwidth compatibility form", in: "ＡＬＩＣＥ", want: "alice"},
+		{name: "decomposed accent composed", in: "Jose\u0301", want: "jos\u00e9"},
+		{name: "sharp s folds to ss", in: "Straße", want: "strasse"},
+		{name: "empty", in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.in))
+		})
+	}
+}
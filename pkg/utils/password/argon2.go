@@ -0,0 +1,122 @@
+package password
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idParams are the cost parameters of Argon2id
+type Argon2idParams struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams are the OWASP recommended minimum: 19 MiB of
+// memory, 2 iterations and a parallelism of 1
+func DefaultArgon2idParams() Argon2idParams {
+	return Argon2idParams{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Argon2idHasher hashes with Argon2id into PHC strings such as
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2id creates an Argon2id hasher with params
+func NewArgon2id(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(_ context.Context, password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return encodeArgon2id(p, salt, key), nil
+}
+
+func (h *Argon2idHasher) Verify(_ context.Context, password, encoded string) (bool, error) {
+	return Verify(password, encoded)
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	return err != nil || params != h.params
+}
+
+func encodeArgon2id(p Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+// decodeArgon2id parses a PHC string; the salt and key lengths are taken
+// from the decoded values
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var p Argon2idParams
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, fmt.Errorf("password: argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("password: unsupported argon2id version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("password: argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("password: argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("password: argon2id key: %w", err)
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
+
+func verifyArgon2id(password, encoded string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
@@ -0,0 +1,42 @@
+package password
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher hashes with bcrypt. bcrypt rejects passwords over 72 bytes,
+// so prefer Argon2idHasher for new deployments.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcrypt creates a bcrypt hasher with the given cost
+func NewBcrypt(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(_ context.Context, password string) (string, error) {
+	return HashPasswordWithCost(password, h.cost)
+}
+
+func (h *BcryptHasher) Verify(_ context.Context, password, encoded string) (bool, error) {
+	return Verify(password, encoded)
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcrypt(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost
+}
+
+// isBcrypt reports whether encoded is in the $2a$, $2b$ or $2y$ bcrypt format
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
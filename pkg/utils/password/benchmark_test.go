@@ -2,8 +2,12 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // BenchmarkHashPassword benchmarks password hashing performance
@@ -115,3 +119,67 @@ func BenchmarkOperation(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkHasher_Hash compares the default hashers at production parameters
+// Following Uber: "Benchmark alternatives side by side"
+func BenchmarkHasher_Hash(b *testing.B) {
+	hashers := map[string]Hasher{
+		"argon2id": DefaultHasher(),
+		"bcrypt":   NewBcrypt(bcrypt.DefaultCost),
+	}
+
+	for name, hasher := range hashers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := hasher.Hash(context.Background(), "TestPassword123!"); err != nil {
+					b.Fatalf("Hash failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPool_Overhead measures the cost of acquiring a free slot
+// Following Uber: "Benchmark the fast path separately"
+func BenchmarkPool_Overhead(b *testing.B) {
+	pool := NewPool(1, time.Second)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := pool.Do(ctx, func() {}); err != nil {
+			b.Fatalf("Do failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkCheckPasswordHash_Pool compares parallel verification with and
+// without the pool; the pooled run keeps CPUs free for other work at the
+// cost of queueing, so its per-op time reflects the wait
+func BenchmarkCheckPasswordHash_Pool(b *testing.B) {
+	hash, err := HashPasswordWithCost("TestPassword123!", bcrypt.DefaultCost)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	hashers := map[string]Hasher{
+		"unbounded": NewBcrypt(bcrypt.DefaultCost),
+		"pool-2":    NewPool(2, time.Minute).Limit(NewBcrypt(bcrypt.DefaultCost)),
+	}
+
+	for name, hasher := range hashers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				ctx := context.Background()
+				for pb.Next() {
+					ok, err := hasher.Verify(ctx, "TestPassword123!", hash)
+					if err != nil || !ok {
+						b.Fatalf("Verify failed: %v", err)
+					}
+				}
+			})
+		})
+	}
+}
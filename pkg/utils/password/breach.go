@@ -0,0 +1,157 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" //nolint:gosec // SHA-1 is the hash of the Pwned Passwords range format
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// prefixLength is the number of hex characters of the SHA-1 hash used as the
+// k-anonymity range key, as in the Pwned Passwords range API
+const prefixLength = 5
+
+// BreachChecker reports whether a password appears in a known breach
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// RangeSource returns the uppercase SHA-1 suffixes of breached passwords
+// whose hash starts with prefix, in the Pwned Passwords range format
+type RangeSource interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// BreachList checks passwords against a RangeSource. Only the 5-character
+// hash prefix is passed to the source, so a remote range API can replace the
+// local files without exposing the password hash.
+type BreachList struct {
+	source RangeSource
+}
+
+// NewBreachList creates a BreachList backed by source
+func NewBreachList(source RangeSource) *BreachList {
+	return &BreachList{source: source}
+}
+
+// OpenBreachList loads a local breach list. path is either a directory of
+// range files named after their prefix (e.g. "5BAA6" or "5BAA6.txt") holding
+// "SUFFIX:COUNT" lines, read on demand, or a single file of "HASH:COUNT"
+// lines, loaded into memory. Counts are optional.
+func OpenBreachList(path string) (*BreachList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breach list: %w", err)
+	}
+	if info.IsDir() {
+		return NewBreachList(rangeDir(path)), nil
+	}
+
+	source, err := loadHashFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load breach list: %w", err)
+	}
+	return NewBreachList(source), nil
+}
+
+// Breached hashes password with SHA-1 and looks the suffix up in its range
+func (b *BreachList) Breached(ctx context.Context, password string) (bool, error) {
+	prefix, suffix := hashRange(password)
+
+	suffixes, err := b.source.Range(ctx, prefix)
+	if err != nil {
+		return false, err
+	}
+
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix, nil
+}
+
+// hashRange splits the uppercase SHA-1 hex of password into its range
+// prefix and suffix
+func hashRange(password string) (string, string) {
+	sum := sha1.Sum([]byte(password)) //nolint:gosec // see import
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:prefixLength], hash[prefixLength:]
+}
+
+// rangeDir reads range files from a directory
+type rangeDir string
+
+func (d rangeDir) Range(_ context.Context, prefix string) ([]string, error) {
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		f, err := os.Open(filepath.Join(string(d), name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var suffixes []string
+		err = scanHashes(f, func(hash string) {
+			suffixes = append(suffixes, hash)
+		})
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read range %s: %w", prefix, err)
+		}
+		sort.Strings(suffixes)
+		return suffixes, nil
+	}
+	return nil, nil
+}
+
+// hashIndex is an in-memory breach list keyed by range prefix
+type hashIndex map[string][]string
+
+func (idx hashIndex) Range(_ context.Context, prefix string) ([]string, error) {
+	return idx[prefix], nil
+}
+
+func loadHashFile(path string) (hashIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	idx := hashIndex{}
+	err = scanHashes(f, func(hash string) {
+		if len(hash) <= prefixLength {
+			return
+		}
+		prefix := hash[:prefixLength]
+		idx[prefix] = append(idx[prefix], hash[prefixLength:])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, suffixes := range idx {
+		sort.Strings(suffixes)
+	}
+	return idx, nil
+}
+
+// scanHashes calls fn with the uppercase hash of every non-empty line,
+// dropping the optional ":COUNT"
+func scanHashes(f *os.File, fn func(hash string)) error {
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		fn(strings.ToUpper(hash))
+	}
+	return scanner.Err()
+}
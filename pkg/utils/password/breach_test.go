@@ -0,0 +1,54 @@
+package password
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+const passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+func TestOpenBreachList_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# top breached passwords\n" +
+		"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:9545824\n" +
+		"7C4A8D09CA3762AF61E59520943DC26494F8941B\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	list, err := OpenBreachList(path)
+	require.NoError(t, err)
+
+	assertBreached(t, list, "password", true)
+	assertBreached(t, list, "123456", true)
+	assertBreached(t, list, "Gx7#pLm2qR!", false)
+}
+
+func TestOpenBreachList_RangeDir(t *testing.T) {
+	dir := t.TempDir()
+	content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + passwordSuffix + ":9545824\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(content), 0o600))
+
+	list, err := OpenBreachList(dir)
+	require.NoError(t, err)
+
+	assertBreached(t, list, "password", true)
+	// The range file of this prefix is absent
+	assertBreached(t, list, "Gx7#pLm2qR!", false)
+}
+
+func TestOpenBreachList_Missing(t *testing.T) {
+	_, err := OpenBreachList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func assertBreached(t *testing.T, list *BreachList, password string, want bool) {
+	t.Helper()
+	breached, err := list.Breached(context.Background(), password)
+	require.NoError(t, err)
+	assert.Equal(t, want, breached, password)
+}
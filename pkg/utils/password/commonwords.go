@@ -0,0 +1,24 @@
+package password
+
+// commonWords are frequent passwords and the words they are built from,
+// lowercase. Strength treats them as a single guess from this list.
+var commonWords = []string{
+	"password", "passw0rd", "p@ssword", "p@ssw0rd", "pass", "secret", "letmein", "welcome",
+	"admin", "administrator", "root", "login", "user", "guest", "test", "master",
+	"qwerty", "azerty", "abc", "iloveyou", "love", "trustno1", "changeme", "default",
+	"monkey", "dragon", "shadow", "sunshine", "princess", "football", "baseball", "soccer",
+	"hockey", "basketball", "superman", "batman", "starwars", "pokemon", "ninja", "mustang",
+	"michael", "jordan", "jennifer", "hunter", "ranger", "buster", "thomas", "robert",
+	"charlie", "daniel", "andrew", "jessica", "ashley", "michelle", "nicole", "maggie",
+	"summer", "winter", "spring", "autumn", "monday", "friday", "sunday", "january",
+	"december", "hello", "freedom", "whatever", "computer", "internet", "google", "apple",
+	"samsung", "facebook", "linkedin", "company", "office", "access", "secure", "security",
+	"flower", "cookie", "cheese", "chocolate", "coffee", "orange", "banana", "purple",
+	"silver", "golden", "diamond", "matrix", "killer", "pepper", "ginger", "tigger",
+	"angel", "baby", "family", "forever", "friend", "happy", "lucky", "money",
+	"music", "heaven", "house", "home", "world", "player", "gamer", "monster",
+	"blink", "zaq1", "qazwsx", "asdf", "zxcv", "abcd", "aaaa", "1234",
+	"12345", "123456", "1234567", "12345678", "123456789", "1234567890", "111111", "000000",
+	"654321", "121212", "123123", "696969", "666666", "888888", "112233", "7777777",
+	"matkhau", "anhyeuem", "emyeuanh", "yeuem", "vietnam", "hanoi", "saigon", "nguyen",
+}
@@ -1,7 +1,53 @@
 package password
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"context"
+	"errors"
+	"strings"
 
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHashFormat is returned when verifying a hash of no supported algorithm
+var ErrUnknownHashFormat = errors.New("password: unknown hash format")
+
+// Hasher hashes new passwords into self-describing strings that encode the
+// algorithm and its parameters, and verifies hashes of every supported format
+type Hasher interface {
+	// Hash returns the encoded hash of password
+	Hash(ctx context.Context, password string) (string, error)
+	// Verify reports whether password matches encoded, whatever its format
+	Verify(ctx context.Context, password, encoded string) (bool, error)
+	// NeedsRehash reports whether encoded uses another algorithm or other
+	// parameters than Hash, so it should be replaced after a successful Verify
+	NeedsRehash(encoded string) bool
+}
+
+// DefaultHasher returns the hasher for new passwords: Argon2id with
+// DefaultArgon2idParams
+func DefaultHasher() Hasher {
+	return NewArgon2id(DefaultArgon2idParams())
+}
+
+// Verify reports whether password matches encoded, which may be an Argon2id
+// PHC string or a bcrypt hash
+func Verify(password, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return verifyArgon2id(password, encoded)
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+// HashPassword hashes password with bcrypt at bcrypt.DefaultCost.
+// New code should use a Hasher.
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
@@ -15,7 +61,9 @@ func HashPasswordWithCost(password string, cost int) (string, error) {
 	return string(bytes), err
 }
 
+// CheckPasswordHash reports whether password matches hash in any supported
+// format; malformed hashes never match
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	ok, _ := Verify(password, hash)
+	return ok
 }
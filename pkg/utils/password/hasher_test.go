@@ -0,0 +1,102 @@
+package password
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ctx = context.Background()
+
+// testArgon2idParams keep tests fast
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashers_RoundTrip(t *testing.T) {
+	hashers := map[string]Hasher{
+		"argon2id": NewArgon2id(testArgon2idParams),
+		"bcrypt":   NewBcrypt(bcrypt.MinCost),
+	}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := hasher.Hash(ctx, "TestPassword123!")
+			require.NoError(t, err)
+			assert.False(t, hasher.NeedsRehash(encoded))
+
+			ok, err := hasher.Verify(ctx, "TestPassword123!", encoded)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = hasher.Verify(ctx, "WrongPassword123!", encoded)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestArgon2idHasher_Format(t *testing.T) {
+	encoded, err := NewArgon2id(testArgon2idParams).Hash(ctx, "secret")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"), encoded)
+
+	// Same password, fresh salt
+	other, err := NewArgon2id(testArgon2idParams).Hash(ctx, "secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other)
+}
+
+func TestArgon2idHasher_LongPasswords(t *testing.T) {
+	// bcrypt would reject or truncate these; Argon2id tells them apart
+	hasher := NewArgon2id(testArgon2idParams)
+	long := strings.Repeat("a", 100)
+
+	encoded, err := hasher.Hash(ctx, long)
+	require.NoError(t, err)
+
+	ok, err := hasher.Verify(ctx, long+"b", encoded)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVerify_AcrossFormats(t *testing.T) {
+	bcryptHash, err := HashPasswordWithCost("secret", bcrypt.MinCost)
+	require.NoError(t, err)
+	argonHash, err := NewArgon2id(testArgon2idParams).Hash(ctx, "secret")
+	require.NoError(t, err)
+
+	// Either hasher verifies hashes of the other
+	for _, hasher := range []Hasher{NewArgon2id(testArgon2idParams), NewBcrypt(bcrypt.MinCost)} {
+		for _, encoded := range []string{bcryptHash, argonHash} {
+			ok, err := hasher.Verify(ctx, "secret", encoded)
+			require.NoError(t, err)
+			assert.True(t, ok, encoded)
+		}
+	}
+
+	_, err = Verify("secret", "$md5$abc")
+	assert.ErrorIs(t, err, ErrUnknownHashFormat)
+
+	_, err = Verify("secret", "$argon2id$v=19$m=x$salt$key")
+	assert.Error(t, err)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash, err := HashPasswordWithCost("secret", bcrypt.MinCost)
+	require.NoError(t, err)
+	argonHash, err := NewArgon2id(testArgon2idParams).Hash(ctx, "secret")
+	require.NoError(t, err)
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+
+	assert.True(t, NewArgon2id(testArgon2idParams).NeedsRehash(bcryptHash), "algorithm changed")
+	assert.True(t, NewArgon2id(stronger).NeedsRehash(argonHash), "parameters changed")
+	assert.True(t, NewBcrypt(bcrypt.MinCost+1).NeedsRehash(bcryptHash), "cost changed")
+	assert.True(t, NewBcrypt(bcrypt.MinCost).NeedsRehash(argonHash), "algorithm changed")
+	assert.True(t, NewArgon2id(testArgon2idParams).NeedsRehash("garbage"))
+}
@@ -0,0 +1,166 @@
+package password
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule names a password policy requirement
+type Rule string
+
+// Rules reported in PolicyError
+const (
+	RuleMinLength Rule = "min_length"
+	RuleMaxLength Rule = "max_length"
+	RuleLower     Rule = "lower"
+	RuleUpper     Rule = "upper"
+	RuleDigit     Rule = "digit"
+	RuleSymbol    Rule = "symbol"
+	// RuleIdentity rejects passwords containing the username or email
+	RuleIdentity Rule = "identity"
+	// RuleStrength rejects passwords whose estimated Strength is too low
+	RuleStrength Rule = "strength"
+	// RuleBreached rejects passwords found in a breach list
+	RuleBreached Rule = "breached"
+)
+
+// minIdentityLength skips identities too short to be meaningful in a password
+const minIdentityLength = 3
+
+// Policy describes the requirements a new password must meet. The zero
+// value accepts every password; use DefaultPolicy for a sensible baseline.
+type Policy struct {
+	// MinLength and MaxLength count characters; 0 disables the bound
+	MinLength int
+	MaxLength int
+
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// MinStrength is the minimum Strength score, 0 (any) to 4
+	MinStrength int
+}
+
+// DefaultPolicy follows NIST SP 800-63B: a length range and a strength
+// estimate rather than mandatory character classes
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:   8,
+		MaxLength:   64,
+		MinStrength: 2,
+	}
+}
+
+// Violation is a requirement the password failed. Param holds the bound of
+// length and strength rules.
+type Violation struct {
+	Rule  Rule
+	Param string
+}
+
+// PolicyError lists every requirement a password failed
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, string(v.Rule))
+	}
+	return "password does not meet policy: " + strings.Join(rules, ", ")
+}
+
+// Check returns a *PolicyError listing every requirement password fails, or
+// nil. identities are the username, email and similar values the password
+// must not contain; the local part of an email is checked on its own too.
+func (p Policy) Check(password string, identities ...string) error {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, Violation{Rule: RuleMinLength, Param: strconv.Itoa(p.MinLength)})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Param: strconv.Itoa(p.MaxLength)})
+	}
+
+	classes := characterClasses(password)
+	if p.RequireLower && !classes.lower {
+		violations = append(violations, Violation{Rule: RuleLower})
+	}
+	if p.RequireUpper && !classes.upper {
+		violations = append(violations, Violation{Rule: RuleUpper})
+	}
+	if p.RequireDigit && !classes.digit {
+		violations = append(violations, Violation{Rule: RuleDigit})
+	}
+	if p.RequireSymbol && !classes.symbol {
+		violations = append(violations, Violation{Rule: RuleSymbol})
+	}
+
+	identities = expandIdentities(identities)
+	if containsIdentity(password, identities) {
+		violations = append(violations, Violation{Rule: RuleIdentity})
+	}
+
+	if p.MinStrength > 0 && Strength(password, identities...) < p.MinStrength {
+		violations = append(violations, Violation{Rule: RuleStrength, Param: strconv.Itoa(p.MinStrength)})
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+type classSet struct {
+	lower, upper, digit, symbol bool
+}
+
+func characterClasses(password string) classSet {
+	var set classSet
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			set.lower = true
+		case unicode.IsUpper(r):
+			set.upper = true
+		case unicode.IsDigit(r):
+			set.digit = true
+		default:
+			set.symbol = true
+		}
+	}
+	return set
+}
+
+// expandIdentities lowercases identities, adds the local part of emails and
+// drops values too short to check
+func expandIdentities(identities []string) []string {
+	expanded := make([]string, 0, len(identities)+1)
+	for _, identity := range identities {
+		identity = strings.ToLower(strings.TrimSpace(identity))
+		if local, _, isEmail := strings.Cut(identity, "@"); isEmail && len(local) >= minIdentityLength {
+			expanded = append(expanded, local)
+		}
+		if len(identity) >= minIdentityLength {
+			expanded = append(expanded, identity)
+		}
+	}
+	return expanded
+}
+
+func containsIdentity(password string, identities []string) bool {
+	lower := strings.ToLower(password)
+	for _, identity := range identities {
+		if strings.Contains(lower, identity) {
+			return true
+		}
+	}
+	return false
+}
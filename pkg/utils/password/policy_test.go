@@ -0,0 +1,105 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	strict := Policy{
+		MinLength:     10,
+		MaxLength:     20,
+		RequireLower:  true,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		password   string
+		identities []string
+		want       []Violation
+	}{
+		{
+			name:     "zero policy accepts anything",
+			policy:   Policy{},
+			password: "a",
+		},
+		{
+			name:     "strict policy accepts a complex password",
+			policy:   strict,
+			password: "Gx7#pLm2qR!",
+		},
+		{
+			name:     "too short and missing classes",
+			policy:   strict,
+			password: "abc",
+			want: []Violation{
+				{Rule: RuleMinLength, Param: "10"},
+				{Rule: RuleUpper},
+				{Rule: RuleDigit},
+				{Rule: RuleSymbol},
+			},
+		},
+		{
+			name:     "too long",
+			policy:   Policy{MaxLength: 5},
+			password: "abcdefg",
+			want:     []Violation{{Rule: RuleMaxLength, Param: "5"}},
+		},
+		{
+			name:     "length counts characters, not bytes",
+			policy:   Policy{MinLength: 4, MaxLength: 4},
+			password: "mật!",
+		},
+		{
+			name:       "contains username case-insensitively",
+			policy:     Policy{},
+			password:   "xxAliceSmith99",
+			identities: []string{"alicesmith"},
+			want:       []Violation{{Rule: RuleIdentity}},
+		},
+		{
+			name:       "contains email local part",
+			policy:     Policy{},
+			password:   "bob.jones-2024!",
+			identities: []string{"Bob.Jones@example.com"},
+			want:       []Violation{{Rule: RuleIdentity}},
+		},
+		{
+			name:       "short identities are ignored",
+			policy:     Policy{},
+			password:   "Gx7#pLm2qR!",
+			identities: []string{"gx", ""},
+		},
+		{
+			name:     "too weak",
+			policy:   DefaultPolicy(),
+			password: "password1",
+			want:     []Violation{{Rule: RuleStrength, Param: "2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.password, tt.identities...)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, tt.want, policyErr.Violations)
+		})
+	}
+}
+
+func TestPolicyError_Error(t *testing.T) {
+	err := &PolicyError{Violations: []Violation{{Rule: RuleMinLength, Param: "8"}, {Rule: RuleStrength}}}
+	assert.Equal(t, "password does not meet policy: min_length, strength", err.Error())
+}
@@ -0,0 +1,128 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBusy is returned when a hashing slot doesn't free up within the queue timeout
+var ErrBusy = errors.New("password: too many concurrent hashing operations")
+
+// Pool bounds the number of concurrent hashing operations, so a burst of
+// logins queues up instead of starving every other request of CPU. Callers
+// wait at most the queue timeout for a slot.
+type Pool struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+
+	waiting  atomic.Int64
+	inFlight atomic.Int64
+	timeouts atomic.Uint64
+}
+
+// NewPool creates a pool of size slots; size <= 0 uses GOMAXPROCS
+func NewPool(size int, queueTimeout time.Duration) *Pool {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+	return &Pool{
+		slots:        make(chan struct{}, size),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Do runs fn once a slot is free. It returns ErrBusy after waiting the queue
+// timeout, or the context error if ctx is done first; fn isn't run then.
+func (p *Pool) Do(ctx context.Context, fn func()) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	p.inFlight.Add(1)
+	defer func() {
+		p.inFlight.Add(-1)
+		<-p.slots
+	}()
+
+	fn()
+	return nil
+}
+
+func (p *Pool) acquire(ctx context.Context) error {
+	// Fast path without a timer
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+
+	timer := time.NewTimer(p.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		p.timeouts.Add(1)
+		return ErrBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Size is the number of concurrent operations allowed
+func (p *Pool) Size() int {
+	return cap(p.slots)
+}
+
+// Waiting is the number of callers queued for a slot
+func (p *Pool) Waiting() int64 {
+	return p.waiting.Load()
+}
+
+// InFlight is the number of operations running
+func (p *Pool) InFlight() int64 {
+	return p.inFlight.Load()
+}
+
+// Timeouts is the number of callers rejected with ErrBusy
+func (p *Pool) Timeouts() uint64 {
+	return p.timeouts.Load()
+}
+
+// Limit returns a Hasher that runs the hashing and verification of h in the pool
+func (p *Pool) Limit(h Hasher) Hasher {
+	return &pooledHasher{pool: p, hasher: h}
+}
+
+type pooledHasher struct {
+	pool   *Pool
+	hasher Hasher
+}
+
+func (h *pooledHasher) Hash(ctx context.Context, password string) (encoded string, err error) {
+	if poolErr := h.pool.Do(ctx, func() {
+		encoded, err = h.hasher.Hash(ctx, password)
+	}); poolErr != nil {
+		return "", poolErr
+	}
+	return encoded, err
+}
+
+func (h *pooledHasher) Verify(ctx context.Context, password, encoded string) (ok bool, err error) {
+	if poolErr := h.pool.Do(ctx, func() {
+		ok, err = h.hasher.Verify(ctx, password, encoded)
+	}); poolErr != nil {
+		return false, poolErr
+	}
+	return ok, err
+}
+
+func (h *pooledHasher) NeedsRehash(encoded string) bool {
+	return h.hasher.NeedsRehash(encoded)
+}
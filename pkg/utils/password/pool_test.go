@@ -0,0 +1,107 @@
+package password
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// occupy fills every slot of pool until the returned func is called
+func occupy(t *testing.T, pool *Pool) (release func()) {
+	t.Helper()
+	done := make(chan struct{})
+	var started sync.WaitGroup
+	for i := 0; i < pool.Size(); i++ {
+		started.Add(1)
+		go func() {
+			_ = pool.Do(context.Background(), func() {
+				started.Done()
+				<-done
+			})
+		}()
+	}
+	started.Wait()
+	return func() { close(done) }
+}
+
+func TestPool_Do(t *testing.T) {
+	pool := NewPool(2, time.Second)
+
+	ran := false
+	require.NoError(t, pool.Do(context.Background(), func() { ran = true }))
+	assert.True(t, ran)
+	assert.Zero(t, pool.InFlight())
+}
+
+func TestPool_QueueTimeout(t *testing.T) {
+	pool := NewPool(1, 20*time.Millisecond)
+	release := occupy(t, pool)
+	defer release()
+
+	assert.Equal(t, int64(1), pool.InFlight())
+
+	err := pool.Do(context.Background(), func() { t.Error("fn must not run") })
+
+	assert.ErrorIs(t, err, ErrBusy)
+	assert.Equal(t, uint64(1), pool.Timeouts())
+	assert.Zero(t, pool.Waiting())
+}
+
+func TestPool_ContextDone(t *testing.T) {
+	pool := NewPool(1, time.Minute)
+	release := occupy(t, pool)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- pool.Do(ctx, func() { t.Error("fn must not run") })
+	}()
+
+	require.Eventually(t, func() bool { return pool.Waiting() == 1 }, time.Second, time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-errc, context.Canceled)
+	assert.Zero(t, pool.Timeouts())
+}
+
+func TestPool_WaitsForSlot(t *testing.T) {
+	pool := NewPool(1, time.Minute)
+	release := occupy(t, pool)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- pool.Do(context.Background(), func() {})
+	}()
+
+	require.Eventually(t, func() bool { return pool.Waiting() == 1 }, time.Second, time.Millisecond)
+	release()
+
+	assert.NoError(t, <-errc)
+}
+
+func TestPool_Limit(t *testing.T) {
+	pool := NewPool(1, 20*time.Millisecond)
+	hasher := pool.Limit(NewArgon2id(testArgon2idParams))
+
+	encoded, err := hasher.Hash(context.Background(), "secret")
+	require.NoError(t, err)
+	ok, err := hasher.Verify(context.Background(), "secret", encoded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, hasher.NeedsRehash(encoded))
+
+	release := occupy(t, pool)
+	defer release()
+
+	_, err = hasher.Verify(context.Background(), "secret", encoded)
+	assert.ErrorIs(t, err, ErrBusy)
+}
+
+func TestNewPool_DefaultSize(t *testing.T) {
+	assert.Positive(t, NewPool(0, time.Second).Size())
+}
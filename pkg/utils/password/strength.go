@@ -0,0 +1,184 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strength estimates how hard password is to guess, in the style of zxcvbn:
+// it estimates the number of guesses an attacker needs, discounting common
+// passwords, words, years, repeats, sequences and keyboard walks, and maps
+// that to a score from 0 (too guessable) to 4 (very unguessable). inputs are
+// user-specific words such as the username, treated as known to the attacker.
+func Strength(password string, inputs ...string) int {
+	guesses := log10Guesses(password, inputs)
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Guesses per matched pattern, as log10
+var (
+	log10DictionaryWord = math.Log10(float64(len(commonWords)))
+	log10InputWord      = 1.0
+	log10Year           = math.Log10(200)
+	log10Capitalization = math.Log10(2)
+)
+
+// minPatternLength is the shortest repeat, sequence or keyboard walk matched
+const minPatternLength = 3
+
+// log10Guesses finds the cheapest way to cover password with patterns,
+// falling back to brute force over the character pool, and returns the
+// log10 of the total guesses
+func log10Guesses(password string, inputs []string) float64 {
+	runes := []rune(password)
+	lower := []rune(strings.ToLower(password))
+	if len(runes) != len(lower) {
+		// Case mapping changed the length; compare as-is
+		lower = runes
+	}
+	log10Pool := math.Log10(float64(poolSize(password)))
+
+	// best[j] is the cheapest cover of runes[:j]
+	best := make([]float64, len(runes)+1)
+	for j := 1; j <= len(runes); j++ {
+		best[j] = math.Inf(1)
+	}
+	relax := func(i, n int, guesses float64) {
+		if cost := best[i] + guesses; cost < best[i+n] {
+			best[i+n] = cost
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		relax(i, 1, log10Pool)
+
+		for _, n := range matchWords(lower, i, inputs) {
+			relax(i, n, log10InputWord+capitalization(runes[i:i+n]))
+		}
+		for _, n := range matchWords(lower, i, commonWords) {
+			relax(i, n, log10DictionaryWord+capitalization(runes[i:i+n]))
+		}
+		if matchYear(runes, i) {
+			relax(i, 4, log10Year)
+		}
+		// The first character plus the length of the run
+		for n := matchPattern(lower, i); n >= minPatternLength; n-- {
+			relax(i, n, log10Pool+math.Log10(float64(n)))
+		}
+	}
+	return best[len(runes)]
+}
+
+// poolSize is the size of the alphabet a brute-force attacker would try
+func poolSize(password string) int {
+	classes := characterClasses(password)
+	size := 0
+	if classes.lower {
+		size += 26
+	}
+	if classes.upper {
+		size += 26
+	}
+	if classes.digit {
+		size += 10
+	}
+	if classes.symbol {
+		size += 33
+	}
+	if size == 0 {
+		size = 1
+	}
+	return size
+}
+
+// matchWords returns the lengths of the words starting at runes[i]
+func matchWords(runes []rune, i int, words []string) []int {
+	rest := string(runes[i:])
+	var lengths []int
+	for _, word := range words {
+		if len(word) >= minPatternLength && strings.HasPrefix(rest, word) {
+			lengths = append(lengths, utf8.RuneCountInString(word))
+		}
+	}
+	return lengths
+}
+
+func capitalization(runes []rune) float64 {
+	if characterClasses(string(runes)).upper {
+		return log10Capitalization
+	}
+	return 0
+}
+
+// matchYear reports whether a year between 1900 and 2099 starts at runes[i]
+func matchYear(runes []rune, i int) bool {
+	if i+4 > len(runes) {
+		return false
+	}
+	for _, r := range runes[i : i+4] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	century := string(runes[i : i+2])
+	return century == "19" || century == "20"
+}
+
+// keyboardRows are the adjacent-key rows of a QWERTY keyboard
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+// matchPattern returns the length of the repeat, sequence or keyboard walk
+// starting at runes[i]
+func matchPattern(runes []rune, i int) int {
+	if i+1 >= len(runes) {
+		return 1
+	}
+
+	n := 2
+	switch {
+	case runes[i+1] == runes[i]:
+		for i+n < len(runes) && runes[i+n] == runes[i] {
+			n++
+		}
+	case runes[i+1]-runes[i] == 1 || runes[i+1]-runes[i] == -1:
+		step := runes[i+1] - runes[i]
+		for i+n < len(runes) && runes[i+n]-runes[i+n-1] == step {
+			n++
+		}
+	case keyboardAdjacent(runes[i], runes[i+1]):
+		for i+n < len(runes) && keyboardAdjacent(runes[i+n-1], runes[i+n]) {
+			n++
+		}
+	default:
+		return 1
+	}
+	return n
+}
+
+// keyboardAdjacent reports whether b follows or precedes a on a keyboard row
+func keyboardAdjacent(a, b rune) bool {
+	for _, row := range keyboardRows {
+		ia, ib := strings.IndexRune(row, a), strings.IndexRune(row, b)
+		if ia >= 0 && ib >= 0 && (ib-ia == 1 || ia-ib == 1) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		inputs   []string
+		want     int
+	}{
+		{password: "", want: 0},
+		{password: "password", want: 0},
+		{password: "Password1", want: 1},
+		{password: "12345678", want: 0},
+		{password: "aaaaaaaaaaaa", want: 0},
+		{password: "qwertyuiop", want: 0},
+		{password: "abcdefghij", want: 0},
+		{password: "Summer2024", want: 1},
+		{password: "alice2024", inputs: []string{"alice"}, want: 1},
+		{password: "kx8vq2", want: 3},
+		{password: "correcthorsebatterystaple", want: 4},
+		{password: "Gx7#pLm2qR!", want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			assert.Equal(t, tt.want, Strength(tt.password, tt.inputs...))
+		})
+	}
+}
@@ -0,0 +1,6 @@
+// Package version exposes build metadata injected at link time.
+package version
+
+// Version is the application version, set at build time with
+// -ldflags "-X golang-sample/pkg/version.Version=v1.2.3"
+var Version = "dev"
@@ -0,0 +1,150 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The authenticator encodes attestation objects and public keys in CTAP2
+// canonical CBOR (RFC 8949). This decoder covers what those use: integers,
+// byte and text strings, arrays, maps and simple values, all of definite
+// length.
+
+// maxCBORDepth bounds the nesting of decoded values
+const maxCBORDepth = 16
+
+var errCBOR = errors.New("webauthn: malformed CBOR")
+
+// decodeCBOR decodes the first value of data and returns the bytes after
+// it. Integers decode to int64, byte strings to []byte, text to string,
+// arrays to []any and maps to map[any]any.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORValue(data, 0)
+}
+
+func decodeCBORValue(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, fmt.Errorf("%w: nested too deep", errCBOR)
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: unexpected end", errCBOR)
+	}
+
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		default:
+			return nil, nil, fmt.Errorf("%w: unsupported simple value %d", errCBOR, info)
+		}
+	}
+
+	arg, data, err := cborArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: string longer than input", errCBOR)
+		}
+		if major == 2 {
+			return append([]byte{}, data[:arg]...), data[arg:], nil
+		}
+		return string(data[:arg]), data[arg:], nil
+	case 4:
+		// Every item takes at least one byte
+		if arg > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: array longer than input", errCBOR)
+		}
+		items := make([]any, 0, arg)
+		for range arg {
+			var item any
+			if item, data, err = decodeCBORValue(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data))/2 {
+			return nil, nil, fmt.Errorf("%w: map longer than input", errCBOR)
+		}
+		entries := make(map[any]any, arg)
+		for range arg {
+			var key, value any
+			if key, data, err = decodeCBORValue(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("%w: unsupported map key", errCBOR)
+			}
+			if _, dup := entries[key]; dup {
+				return nil, nil, fmt.Errorf("%w: duplicate map key", errCBOR)
+			}
+			if value, data, err = decodeCBORValue(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			entries[key] = value
+		}
+		return entries, data, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported major type %d", errCBOR, major)
+	}
+}
+
+// cborArgument reads the argument of an item head
+func cborArgument(info byte, data []byte) (uint64, []byte, error) {
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, nil, fmt.Errorf("%w: indefinite or reserved length", errCBOR)
+	}
+	if len(data) < size {
+		return 0, nil, fmt.Errorf("%w: unexpected end", errCBOR)
+	}
+
+	var arg uint64
+	switch size {
+	case 1:
+		arg = uint64(data[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(data))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(data))
+	case 8:
+		arg = binary.BigEndian.Uint64(data)
+	}
+	return arg, data[size:], nil
+}
@@ -0,0 +1,84 @@
+package webauthn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want any
+	}{
+		{name: "small integer", data: []byte{0x17}, want: int64(23)},
+		{name: "two-byte integer", data: []byte{0x19, 0x01, 0x00}, want: int64(256)},
+		{name: "negative integer", data: []byte{0x26}, want: int64(-7)},
+		{name: "wide negative integer", data: []byte{0x39, 0x01, 0x00}, want: int64(-257)},
+		{name: "byte string", data: []byte{0x42, 0x01, 0x02}, want: []byte{1, 2}},
+		{name: "text", data: []byte{0x63, 'f', 'm', 't'}, want: "fmt"},
+		{name: "array", data: []byte{0x82, 0x01, 0xf5}, want: []any{int64(1), true}},
+		{name: "map", data: []byte{0xa2, 0x01, 0x02, 0x20, 0x40}, want: map[any]any{int64(1): int64(2), int64(-1): []byte{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := decodeCBOR(append(tt.data, 0xff))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, []byte{0xff}, rest)
+		})
+	}
+}
+
+func TestDecodeCBOR_Malformed(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":              {},
+		"truncated argument": {0x19, 0x01},
+		"truncated string":   {0x45, 0x01},
+		"indefinite length":  {0x5f},
+		"huge array":         {0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"duplicate key":      {0xa2, 0x01, 0x01, 0x01, 0x02},
+		"array key":          {0xa1, 0x80, 0x01},
+		"float":              {0xf9, 0x3c, 0x00},
+		"tag":                {0xc1, 0x01},
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := decodeCBOR(data)
+			assert.ErrorIs(t, err, errCBOR)
+		})
+	}
+
+	t.Run("deep nesting", func(t *testing.T) {
+		data := make([]byte, 100)
+		for i := range data {
+			data[i] = 0x81
+		}
+		_, _, err := decodeCBOR(data)
+		assert.ErrorIs(t, err, errCBOR)
+	})
+}
+
+func FuzzDecodeCBOR(f *testing.F) {
+	f.Add([]byte{0x17})
+	f.Add([]byte{0x39, 0x01, 0x00})
+	f.Add([]byte{0x82, 0x01, 0xf5})
+	f.Add([]byte{0xa2, 0x01, 0x02, 0x20, 0x40})
+	f.Add([]byte{0xa1, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e'})
+	f.Add([]byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	f.Add([]byte{0x5b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, rest, err := decodeCBOR(data)
+		if err != nil {
+			return
+		}
+		// A decoded item is never empty and leaves what follows it
+		if len(rest) >= len(data) {
+			t.Fatalf("decoded %d bytes into a rest of %d", len(data), len(rest))
+		}
+		assert.Equal(t, data[len(data)-len(rest):], rest)
+	})
+}
@@ -0,0 +1,128 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithms (RFC 9053) offered to authenticators, in order of preference
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// COSE key parameters (RFC 9052 section 7, RFC 9053 section 7)
+const (
+	coseKeyType  = 1
+	coseKeyAlg   = 3
+	coseCurve    = -1
+	coseX        = -2
+	coseY        = -3
+	coseRSAN     = -1
+	coseRSAE     = -2
+	coseKtyOKP   = 1
+	coseKtyEC2   = 2
+	coseKtyRSA   = 3
+	coseCrvP256  = 1
+	coseCrvEd255 = 6
+)
+
+// minRSABits rejects RSA keys too short to trust
+const minRSABits = 2048
+
+var errSignature = errors.New("webauthn: invalid signature")
+
+// publicKey is a credential public key with its algorithm
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey parses a COSE_Key of a supported algorithm
+func parsePublicKey(cose []byte) (*publicKey, error) {
+	value, rest, err := decodeCBOR(cose)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data after key", errCBOR)
+	}
+	params, ok := value.(map[any]any)
+	if !ok {
+		return nil, errors.New("webauthn: public key is not a map")
+	}
+
+	kty, _ := params[int64(coseKeyType)].(int64)
+	alg, _ := params[int64(coseKeyAlg)].(int64)
+
+	switch {
+	case alg == AlgES256 && kty == coseKtyEC2:
+		crv, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		y, _ := params[int64(coseY)].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("webauthn: invalid P-256 key")
+		}
+		// Checks that the point is on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("webauthn: invalid P-256 key: %w", err)
+		}
+		return &publicKey{alg: alg, key: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}}, nil
+	case alg == AlgEdDSA && kty == coseKtyOKP:
+		crv, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		if crv != coseCrvEd255 || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("webauthn: invalid Ed25519 key")
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+	case alg == AlgRS256 && kty == coseKtyRSA:
+		n, _ := params[int64(coseRSAN)].([]byte)
+		e, _ := params[int64(coseRSAE)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("webauthn: invalid RSA key")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits || key.E < 3 || key.E%2 == 0 {
+			return nil, errors.New("webauthn: invalid RSA key")
+		}
+		return &publicKey{alg: alg, key: key}, nil
+	default:
+		return nil, fmt.Errorf("webauthn: unsupported key type %d with algorithm %d", kty, alg)
+	}
+}
+
+// verify checks sig over data
+func (k *publicKey) verify(data, sig []byte) error {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return errSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, sig) {
+			return errSignature
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+			return errSignature
+		}
+	default:
+		return errSignature
+	}
+	return nil
+}
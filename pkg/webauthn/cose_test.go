@@ -0,0 +1,72 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// coseKeySeeds encodes a valid COSE_Key of each supported algorithm
+func coseKeySeeds(tb testing.TB) [][]byte {
+	tb.Helper()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(tb, err)
+	x, y := make([]byte, 32), make([]byte, 32)
+	ecKey.X.FillBytes(x)
+	ecKey.Y.FillBytes(y)
+	es256 := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	es256 = append(es256, x...)
+	es256 = append(es256, 0x22, 0x58, 0x20)
+	es256 = append(es256, y...)
+
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(tb, err)
+	eddsa := append([]byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21, 0x58, 0x20}, edKey...)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(tb, err)
+	rs256 := append([]byte{0xa4, 0x01, 0x03, 0x03, 0x39, 0x01, 0x00, 0x20, 0x59, 0x01, 0x00}, rsaKey.N.Bytes()...)
+	rs256 = append(rs256, 0x21, 0x43, 0x01, 0x00, 0x01)
+
+	return [][]byte{es256, eddsa, rs256}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	seeds := coseKeySeeds(t)
+	for i, alg := range []int64{AlgES256, AlgEdDSA, AlgRS256} {
+		key, err := parsePublicKey(seeds[i])
+		require.NoError(t, err)
+		assert.Equal(t, alg, key.alg)
+	}
+}
+
+func FuzzParsePublicKey(f *testing.F) {
+	for _, seed := range coseKeySeeds(f) {
+		f.Add(seed)
+	}
+	f.Add([]byte{0xa0})
+	f.Add([]byte{0xa2, 0x01, 0x02, 0x03, 0x26})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		key, err := parsePublicKey(data)
+		if err != nil {
+			return
+		}
+		switch key.alg {
+		case AlgES256, AlgEdDSA, AlgRS256:
+		default:
+			t.Fatalf("parsed a key of unsupported algorithm %d", key.alg)
+		}
+		// A parsed key only ever rejects a forged signature
+		if key.verify([]byte("data"), data) == nil {
+			t.Fatal("verified a signature that was not made by the key")
+		}
+	})
+}
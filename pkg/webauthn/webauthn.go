@@ -0,0 +1,484 @@
+// Package webauthn is a relying party for passkeys (Web Authentication
+// Level 3), written against the standard library. It verifies
+// registrations without attestation and assertions signed with ES256,
+// EdDSA or RS256 keys.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+	flagExtensionData  = 0x80
+)
+
+// maxCredentialIDLength is the longest credential ID the specification allows
+const maxCredentialIDLength = 1023
+
+var (
+	// ErrVerification is returned for a response that must not be trusted
+	ErrVerification = errors.New("webauthn: verification failed")
+	// ErrSignCountRegression is returned by VerifyAssertion when the
+	// authenticator's signature counter did not increase, a sign that the
+	// credential may have been cloned
+	ErrSignCountRegression = errors.New("webauthn: signature counter did not increase")
+)
+
+// Config describes the relying party
+type Config struct {
+	// RPID is the domain credentials are scoped to, e.g. example.com
+	RPID string
+	// RPName is shown by the authenticator
+	RPName string
+	// Origins are the origins of the pages allowed to run ceremonies, e.g.
+	// https://app.example.com
+	Origins []string
+	// Timeout is the hint given to the browser; defaults to 5 minutes
+	Timeout time.Duration
+}
+
+// RelyingParty builds ceremony options and verifies their responses
+type RelyingParty struct {
+	cfg    Config
+	rpHash [32]byte
+}
+
+// New returns the relying party of cfg
+func New(cfg Config) (*RelyingParty, error) {
+	if cfg.RPID == "" || len(cfg.Origins) == 0 {
+		return nil, errors.New("webauthn: RPID and origins are required")
+	}
+	if cfg.RPName == "" {
+		cfg.RPName = cfg.RPID
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	return &RelyingParty{cfg: cfg, rpHash: sha256.Sum256([]byte(cfg.RPID))}, nil
+}
+
+// Timeout is the lifetime of a ceremony
+func (rp *RelyingParty) Timeout() time.Duration {
+	return rp.cfg.Timeout
+}
+
+// User is the account a credential is registered for
+type User struct {
+	// ID is the user handle; it must not carry personal information
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// CreationOptions are the options of navigator.credentials.create, in the
+// JSON form of PublicKeyCredential.parseCreationOptionsFromJSON
+type CreationOptions struct {
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              string                 `json:"challenge"`
+	PubKeyCredParams       []CredentialParameters `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options of navigator.credentials.get, in the
+// JSON form of PublicKeyCredential.parseRequestOptionsFromJSON
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type RPEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameters struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// CreationOptions returns the options of a registration. Passkeys are
+// discoverable and verify the user, so login needs neither a username
+// nor a password. exclude lists the user's credentials, which the
+// authenticator must not register twice.
+func (rp *RelyingParty) CreationOptions(challenge []byte, user User, exclude []CredentialDescriptor) *CreationOptions {
+	return &CreationOptions{
+		RP: RPEntity{ID: rp.cfg.RPID, Name: rp.cfg.RPName},
+		User: UserEntity{
+			ID:          Encode(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		Challenge: Encode(challenge),
+		PubKeyCredParams: []CredentialParameters{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            rp.cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: append([]CredentialDescriptor{}, exclude...),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions returns the options of a login with any discoverable
+// credential of the relying party
+func (rp *RelyingParty) RequestOptions(challenge []byte) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        Encode(challenge),
+		Timeout:          rp.cfg.Timeout.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: []CredentialDescriptor{},
+		UserVerification: "required",
+	}
+}
+
+// RegistrationResponse is the JSON form of the PublicKeyCredential
+// navigator.credentials.create resolves to
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the PublicKeyCredential
+// navigator.credentials.get resolves to
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// ParseRegistration parses a registration response. Its challenge tells
+// which ceremony it answers; nothing in it is verified yet.
+func ParseRegistration(data []byte) (*RegistrationResponse, []byte, error) {
+	var resp RegistrationResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	challenge, err := clientChallenge(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, challenge, nil
+}
+
+// ParseAssertion parses an assertion response like ParseRegistration
+func ParseAssertion(data []byte) (*AssertionResponse, []byte, error) {
+	var resp AssertionResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	challenge, err := clientChallenge(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, challenge, nil
+}
+
+// CredentialID returns the decoded ID of the credential that signed
+func (r *AssertionResponse) CredentialID() ([]byte, error) {
+	return decode(r.RawID)
+}
+
+// UserHandle returns the decoded user handle, nil when the authenticator
+// sent none
+func (r *AssertionResponse) UserHandle() ([]byte, error) {
+	if r.Response.UserHandle == "" {
+		return nil, nil
+	}
+	return decode(r.Response.UserHandle)
+}
+
+// Credential is a verified public key credential
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE_Key of the credential
+	PublicKey  []byte
+	SignCount  uint32
+	AAGUID     []byte
+	Transports []string
+	// BackupEligible credentials may be synced between devices, as passkeys usually are
+	BackupEligible bool
+}
+
+// VerifyRegistration verifies a registration response to the ceremony
+// of challenge
+func (rp *RelyingParty) VerifyRegistration(resp *RegistrationResponse, challenge []byte) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("%w: unexpected credential type", ErrVerification)
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawObject, err := decode(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	value, rest, err := decodeCBOR(rawObject)
+	if err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: malformed attestation object", ErrVerification)
+	}
+	object, _ := value.(map[any]any)
+	format, _ := object["fmt"].(string)
+	statement, _ := object["attStmt"].(map[any]any)
+	rawAuthData, _ := object["authData"].([]byte)
+	// Options ask for no attestation, which browsers then strip
+	if format != "none" || len(statement) != 0 {
+		return nil, fmt.Errorf("%w: unsupported attestation format %q", ErrVerification, format)
+	}
+
+	authData, err := rp.parseAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedData == 0 {
+		return nil, fmt.Errorf("%w: no attested credential", ErrVerification)
+	}
+
+	rawID, err := decode(resp.RawID)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(rawID, authData.credentialID) {
+		return nil, fmt.Errorf("%w: credential ID mismatch", ErrVerification)
+	}
+	if _, err := parsePublicKey(authData.publicKey); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+
+	return &Credential{
+		ID:             authData.credentialID,
+		PublicKey:      authData.publicKey,
+		SignCount:      authData.signCount,
+		AAGUID:         authData.aaguid,
+		Transports:     resp.Response.Transports,
+		BackupEligible: authData.flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// VerifyAssertion verifies an assertion response to the ceremony of
+// challenge, signed by a credential with publicKey and the stored
+// signCount. It returns the new signature counter, also along with
+// ErrSignCountRegression.
+func (rp *RelyingParty) VerifyAssertion(resp *AssertionResponse, challenge, publicKey []byte, signCount uint32) (uint32, error) {
+	if resp.Type != "public-key" {
+		return 0, fmt.Errorf("%w: unexpected credential type", ErrVerification)
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := decode(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	authData, err := rp.parseAuthData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	clientData, err := decode(resp.Response.ClientDataJSON)
+	if err != nil {
+		return 0, err
+	}
+	signature, err := decode(resp.Response.Signature)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	if err := key.verify(append(rawAuthData, clientDataHash[:]...), signature); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+
+	// Authenticators that do not count always send zero
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return authData.signCount, ErrSignCountRegression
+	}
+	return authData.signCount, nil
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func clientChallenge(encoded string) ([]byte, error) {
+	raw, err := decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("%w: malformed client data", ErrVerification)
+	}
+	return decode(data.Challenge)
+}
+
+func (rp *RelyingParty) verifyClientData(encoded, ceremony string, challenge []byte) error {
+	raw, err := decode(encoded)
+	if err != nil {
+		return err
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("%w: malformed client data", ErrVerification)
+	}
+
+	got, err := decode(data.Challenge)
+	if err != nil {
+		return err
+	}
+	switch {
+	case data.Type != ceremony:
+		return fmt.Errorf("%w: client data of %q, not %q", ErrVerification, data.Type, ceremony)
+	case subtle.ConstantTimeCompare(got, challenge) != 1:
+		return fmt.Errorf("%w: challenge mismatch", ErrVerification)
+	case !slices.Contains(rp.cfg.Origins, data.Origin):
+		return fmt.Errorf("%w: origin %q is not allowed", ErrVerification, data.Origin)
+	case data.CrossOrigin:
+		return fmt.Errorf("%w: cross-origin ceremony", ErrVerification)
+	}
+	return nil
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+// parseAuthData parses authenticator data and checks that it belongs to
+// the relying party and that the user was present and verified
+func (rp *RelyingParty) parseAuthData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrVerification)
+	}
+
+	result := &authenticatorData{
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	switch {
+	case subtle.ConstantTimeCompare(data[:32], rp.rpHash[:]) != 1:
+		return nil, fmt.Errorf("%w: credential of another relying party", ErrVerification)
+	case result.flags&flagUserPresent == 0:
+		return nil, fmt.Errorf("%w: user not present", ErrVerification)
+	case result.flags&flagUserVerified == 0:
+		return nil, fmt.Errorf("%w: user not verified", ErrVerification)
+	case result.flags&flagBackupState != 0 && result.flags&flagBackupEligible == 0:
+		return nil, fmt.Errorf("%w: backed up credential that is not backup eligible", ErrVerification)
+	}
+
+	rest := data[37:]
+	if result.flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data too short", ErrVerification)
+		}
+		result.aaguid = append([]byte(nil), rest[:16]...)
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength > maxCredentialIDLength || idLength > len(rest) {
+			return nil, fmt.Errorf("%w: invalid credential ID length", ErrVerification)
+		}
+		result.credentialID = append([]byte(nil), rest[:idLength]...)
+		rest = rest[idLength:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+		}
+		result.publicKey = append([]byte(nil), rest[:len(rest)-len(after)]...)
+		rest = after
+	}
+	if result.flags&flagExtensionData != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing authenticator data", ErrVerification)
+	}
+	return result, nil
+}
+
+// Encode is the base64url encoding WebAuthn JSON uses
+func Encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decode accepts base64url with or without padding
+func decode(s string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base64url", ErrVerification)
+	}
+	return data, nil
+}
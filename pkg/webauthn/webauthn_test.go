@@ -0,0 +1,173 @@
+package webauthn_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang-sample/pkg/webauthn"
+	"golang-sample/pkg/webauthn/webauthntest"
+)
+
+const origin = "https://app.example.com"
+
+func newRelyingParty(t *testing.T) *webauthn.RelyingParty {
+	t.Helper()
+	rp, err := webauthn.New(webauthn.Config{RPID: "example.com", RPName: "Example", Origins: []string{origin}})
+	require.NoError(t, err)
+	return rp
+}
+
+// register creates a passkey on authenticator and returns the verified credential
+func register(t *testing.T, rp *webauthn.RelyingParty, authenticator *webauthntest.Authenticator) *webauthn.Credential {
+	t.Helper()
+	challenge := []byte("registration-challenge-0123456789")
+	opts := rp.CreationOptions(challenge, webauthn.User{ID: []byte{0, 0, 0, 7}, Name: "alice"}, nil)
+
+	resp, got, err := webauthn.ParseRegistration(authenticator.Create(opts))
+	require.NoError(t, err)
+	require.Equal(t, challenge, got)
+
+	credential, err := rp.VerifyRegistration(resp, challenge)
+	require.NoError(t, err)
+	return credential
+}
+
+func TestRelyingParty_Registration(t *testing.T) {
+	rp := newRelyingParty(t)
+
+	t.Run("verifies a passkey", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+
+		credential := register(t, rp, authenticator)
+
+		assert.Equal(t, authenticator.CredentialID(), credential.ID)
+		assert.NotEmpty(t, credential.PublicKey)
+		assert.Equal(t, []string{"internal", "hybrid"}, credential.Transports)
+		assert.True(t, credential.BackupEligible)
+	})
+
+	t.Run("options ask for a discoverable, verified passkey", func(t *testing.T) {
+		opts := rp.CreationOptions([]byte("c"), webauthn.User{ID: []byte{1}, Name: "alice"}, nil)
+
+		data, err := json.Marshal(opts)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"residentKey":"required"`)
+		assert.Contains(t, string(data), `"userVerification":"required"`)
+		assert.Contains(t, string(data), `"excludeCredentials":[]`)
+	})
+
+	tests := []struct {
+		name      string
+		modify    func(a *webauthntest.Authenticator)
+		challenge []byte
+	}{
+		{name: "another origin", modify: func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example" }},
+		{name: "another relying party", modify: func(a *webauthntest.Authenticator) { a.RPID = "evil.example" }},
+		{name: "user not verified", modify: func(a *webauthntest.Authenticator) { a.UserVerified = false }},
+		{name: "another challenge", challenge: []byte("other")},
+	}
+	for _, tt := range tests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			authenticator := webauthntest.New(origin)
+			if tt.modify != nil {
+				tt.modify(authenticator)
+			}
+			challenge := []byte("registration-challenge")
+			opts := rp.CreationOptions(challenge, webauthn.User{ID: []byte{7}, Name: "alice"}, nil)
+			resp, _, err := webauthn.ParseRegistration(authenticator.Create(opts))
+			require.NoError(t, err)
+
+			expected := challenge
+			if tt.challenge != nil {
+				expected = tt.challenge
+			}
+			_, err = rp.VerifyRegistration(resp, expected)
+			assert.ErrorIs(t, err, webauthn.ErrVerification)
+		})
+	}
+}
+
+func TestRelyingParty_Assertion(t *testing.T) {
+	rp := newRelyingParty(t)
+
+	login := func(authenticator *webauthntest.Authenticator, challenge []byte) *webauthn.AssertionResponse {
+		resp, got, err := webauthn.ParseAssertion(authenticator.Get(rp.RequestOptions(challenge)))
+		require.NoError(t, err)
+		require.Equal(t, challenge, got)
+		return resp
+	}
+
+	t.Run("verifies a signature and returns the counter", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+
+		resp := login(authenticator, []byte("login-1"))
+		id, err := resp.CredentialID()
+		require.NoError(t, err)
+		assert.Equal(t, credential.ID, id)
+		handle, err := resp.UserHandle()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 7}, handle)
+
+		signCount, err := rp.VerifyAssertion(resp, []byte("login-1"), credential.PublicKey, credential.SignCount)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), signCount)
+	})
+
+	t.Run("rejects a counter that did not increase", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+		authenticator.SignCount = 4
+		authenticator.FixedSignCount = true
+
+		_, err := rp.VerifyAssertion(login(authenticator, []byte("c")), []byte("c"), credential.PublicKey, 4)
+		assert.ErrorIs(t, err, webauthn.ErrSignCountRegression)
+	})
+
+	t.Run("accepts authenticators that do not count", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+		authenticator.FixedSignCount = true
+
+		signCount, err := rp.VerifyAssertion(login(authenticator, []byte("c")), []byte("c"), credential.PublicKey, 0)
+		require.NoError(t, err)
+		assert.Zero(t, signCount)
+	})
+
+	t.Run("rejects a signature of another key", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		other := register(t, rp, webauthntest.New(origin))
+		register(t, rp, authenticator)
+
+		_, err := rp.VerifyAssertion(login(authenticator, []byte("c")), []byte("c"), other.PublicKey, 0)
+		assert.ErrorIs(t, err, webauthn.ErrVerification)
+	})
+
+	t.Run("rejects tampered authenticator data", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+		resp := login(authenticator, []byte("c"))
+		// Raise the counter without re-signing
+		other := login(authenticator, []byte("c"))
+		resp.Response.AuthenticatorData = other.Response.AuthenticatorData
+
+		_, err := rp.VerifyAssertion(resp, []byte("c"), credential.PublicKey, 0)
+		assert.ErrorIs(t, err, webauthn.ErrVerification)
+	})
+
+	t.Run("rejects a registration response", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+		resp := login(authenticator, []byte("c"))
+		registration, _, err := webauthn.ParseRegistration(authenticator.Create(rp.CreationOptions([]byte("c"), webauthn.User{ID: []byte{7}}, nil)))
+		require.NoError(t, err)
+		resp.Response.ClientDataJSON = registration.Response.ClientDataJSON
+
+		_, err = rp.VerifyAssertion(resp, []byte("c"), credential.PublicKey, 0)
+		assert.True(t, errors.Is(err, webauthn.ErrVerification))
+	})
+}
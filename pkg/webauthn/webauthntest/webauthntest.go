@@ -0,0 +1,219 @@
+// Package webauthntest provides a software authenticator that creates
+// and signs passkeys for tests of WebAuthn relying parties.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+
+	"golang-sample/pkg/webauthn"
+)
+
+// Authenticator holds a single P-256 passkey. Fields may be changed
+// between ceremonies to produce invalid responses.
+type Authenticator struct {
+	// Origin is reported in client data
+	Origin string
+	// RPID overrides the relying party the authenticator signs for
+	RPID string
+	// SignCount is the signature counter; Get increments it unless
+	// FixedSignCount is set
+	SignCount      uint32
+	FixedSignCount bool
+	// UserVerified is set in the flags; true by default
+	UserVerified bool
+
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+}
+
+// New returns an authenticator with a fresh key that answers for origin
+func New(origin string) *Authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return &Authenticator{Origin: origin, UserVerified: true, key: key, credentialID: id}
+}
+
+// CredentialID returns the ID of the passkey
+func (a *Authenticator) CredentialID() []byte {
+	return a.credentialID
+}
+
+// Create answers a registration the way navigator.credentials.create does
+func (a *Authenticator) Create(opts *webauthn.CreationOptions) []byte {
+	a.userHandle = mustDecode(opts.User.ID)
+	rpID := a.rpID(opts.RP.ID)
+
+	x, y := make([]byte, 32), make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	coseKey := encodeCBOR(map[int64]any{1: int64(2), 3: webauthn.AlgES256, -1: int64(1), -2: x, -3: y})
+
+	authData := a.authData(rpID, 0x40)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, coseKey...)
+
+	attestation := encodeCBOR(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+
+	var resp webauthn.RegistrationResponse
+	resp.ID = webauthn.Encode(a.credentialID)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = webauthn.Encode(a.clientData("webauthn.create", opts.Challenge))
+	resp.Response.AttestationObject = webauthn.Encode(attestation)
+	resp.Response.Transports = []string{"internal", "hybrid"}
+	return mustMarshal(resp)
+}
+
+// Get answers a login the way navigator.credentials.get does
+func (a *Authenticator) Get(opts *webauthn.RequestOptions) []byte {
+	if !a.FixedSignCount {
+		a.SignCount++
+	}
+	authData := a.authData(a.rpID(opts.RPID), 0)
+	clientData := a.clientData("webauthn.get", opts.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	var resp webauthn.AssertionResponse
+	resp.ID = webauthn.Encode(a.credentialID)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = webauthn.Encode(clientData)
+	resp.Response.AuthenticatorData = webauthn.Encode(authData)
+	resp.Response.Signature = webauthn.Encode(signature)
+	resp.Response.UserHandle = webauthn.Encode(a.userHandle)
+	return mustMarshal(resp)
+}
+
+func (a *Authenticator) rpID(requested string) string {
+	if a.RPID != "" {
+		return a.RPID
+	}
+	return requested
+}
+
+func (a *Authenticator) authData(rpID string, flags byte) []byte {
+	rpHash := sha256.Sum256([]byte(rpID))
+	flags |= 0x01 | 0x08 | 0x10 // user present, backup eligible and backed up
+	if a.UserVerified {
+		flags |= 0x04
+	}
+	data := append(rpHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+func (a *Authenticator) clientData(ceremony, challenge string) []byte {
+	return mustMarshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+}
+
+// encodeCBOR encodes the values Create needs: maps with integer or text
+// keys, byte strings, text and integers. Map keys are sorted as CTAP2
+// canonical encoding requires.
+func encodeCBOR(value any) []byte {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case map[int64]any:
+		keys := make([][]byte, 0, len(v))
+		values := map[string][]byte{}
+		for key, item := range v {
+			encoded := encodeCBOR(key)
+			keys = append(keys, encoded)
+			values[string(encoded)] = encodeCBOR(item)
+		}
+		return cborMap(keys, values)
+	case map[string]any:
+		keys := make([][]byte, 0, len(v))
+		values := map[string][]byte{}
+		for key, item := range v {
+			encoded := encodeCBOR(key)
+			keys = append(keys, encoded)
+			values[string(encoded)] = encodeCBOR(item)
+		}
+		return cborMap(keys, values)
+	default:
+		panic("webauthntest: cannot encode value")
+	}
+}
+
+func cborMap(keys [][]byte, values map[string][]byte) []byte {
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return string(keys[i]) < string(keys[j])
+	})
+	out := cborHead(5, uint64(len(keys)))
+	for _, key := range keys {
+		out = append(out, key...)
+		out = append(out, values[string(key)]...)
+	}
+	return out
+}
+
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+	}
+}
+
+func mustDecode(s string) []byte {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func mustMarshal(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}